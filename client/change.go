@@ -40,6 +40,9 @@ type Change struct {
 	SpawnTime time.Time `json:"spawn-time,omitempty"`
 	ReadyTime time.Time `json:"ready-time,omitempty"`
 
+	// QueuedAfter lists the changes in progress this change waits for.
+	QueuedAfter []string `json:"queued-after,omitempty"`
+
 	data map[string]*json.RawMessage
 }
 
@@ -162,3 +165,34 @@ func (client *Client) Changes(opts *ChangesOptions) ([]*Change, error) {
 
 	return chgs, err
 }
+
+// A TimingSpan is the timing of a step of the work of a task, made
+// possibly of nested spans.
+type TimingSpan struct {
+	Label    string        `json:"label"`
+	Summary  string        `json:"summary,omitempty"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Spans    []*TimingSpan `json:"spans,omitempty"`
+}
+
+// TaskTimings holds the timings of the runs of the do and undo
+// handlers of a task.
+type TaskTimings struct {
+	ID      string        `json:"id"`
+	Kind    string        `json:"kind"`
+	Summary string        `json:"summary"`
+	Status  string        `json:"status"`
+	Timings []*TimingSpan `json:"timings,omitempty"`
+}
+
+// ChangeTimings fetches the timings of the tasks of a Change given its ID.
+func (client *Client) ChangeTimings(id string) ([]*TaskTimings, error) {
+	var timings []*TaskTimings
+	_, err := client.doSync("GET", "/v2/changes/"+id+"/timings", nil, nil, nil, &timings)
+	if err != nil {
+		return nil, err
+	}
+
+	return timings, nil
+}
@@ -60,6 +60,21 @@ func (cs *clientSuite) TestClientChange(c *check.C) {
 	})
 }
 
+func (cs *clientSuite) TestClientChangeQueuedAfter(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": {
+  "id":   "uno",
+  "kind": "remove-snap",
+  "summary": "...",
+  "status": "Do",
+  "ready": false,
+  "queued-after": ["42"]
+}}`
+
+	chg, err := cs.cli.Change("uno")
+	c.Assert(err, check.IsNil)
+	c.Check(chg.QueuedAfter, check.DeepEquals, []string{"42"})
+}
+
 func (cs *clientSuite) TestClientChangeData(c *check.C) {
 	cs.rsp = `{"type": "sync", "result": {
   "id":   "uno",
@@ -213,3 +228,36 @@ func (cs *clientSuite) TestClientAbort(c *check.C) {
 
 	c.Assert(string(body), check.Equals, "{\"action\":\"abort\"}\n")
 }
+
+func (cs *clientSuite) TestClientChangeTimings(c *check.C) {
+	cs.rsp = `{"type": "sync", "result": [{
+  "id": "1",
+  "kind": "download-snap",
+  "summary": "...",
+  "status": "Done",
+  "timings": [{"label": "do", "start": "2016-04-21T01:02:03Z", "duration": 2000000000,
+               "spans": [{"label": "download", "summary": "Download snap \"foo\"", "start": "2016-04-21T01:02:03Z", "duration": 1000000000}]}]
+}]}`
+
+	timings, err := cs.cli.ChangeTimings("uno")
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/changes/uno/timings")
+	c.Check(timings, check.DeepEquals, []*client.TaskTimings{{
+		ID:      "1",
+		Kind:    "download-snap",
+		Summary: "...",
+		Status:  "Done",
+		Timings: []*client.TimingSpan{{
+			Label:    "do",
+			Start:    time.Date(2016, 04, 21, 1, 2, 3, 0, time.UTC),
+			Duration: 2 * time.Second,
+			Spans: []*client.TimingSpan{{
+				Label:    "download",
+				Summary:  `Download snap "foo"`,
+				Start:    time.Date(2016, 04, 21, 1, 2, 3, 0, time.UTC),
+				Duration: time.Second,
+			}},
+		}},
+	}})
+}
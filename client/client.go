@@ -30,6 +30,7 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"strconv"
 	"time"
 
 	"github.com/snapcore/snapd/dirs"
@@ -75,6 +76,9 @@ type Client struct {
 
 	disableAuth bool
 	interactive bool
+
+	warningCount     int
+	warningTimestamp time.Time
 }
 
 // New returns a new instance of Client
@@ -162,6 +166,14 @@ func (e ConnectionError) Error() string {
 // that the client is willing to allow interaction.
 const AllowInteractionHeader = "X-Allow-Interaction"
 
+// WarningCountHeader and WarningTimestampHeader are the HTTP response
+// headers snapd uses to report the number of pending warnings and the
+// time the most recent of them was last added.
+const (
+	WarningCountHeader     = "X-Snapd-Warning-Count"
+	WarningTimestampHeader = "X-Snapd-Warning-Timestamp"
+)
+
 // raw performs a request and returns the resulting http.Response and
 // error you usually only need to call this directly if you expect the
 // response to not be JSON, otherwise you'd call Do(...) instead.
@@ -195,10 +207,34 @@ func (client *Client) raw(method, urlpath string, query url.Values, headers map[
 	if err != nil {
 		return nil, ConnectionError{err}
 	}
+	client.checkWarnings(rsp)
 
 	return rsp, nil
 }
 
+func (client *Client) checkWarnings(rsp *http.Response) {
+	client.warningCount = 0
+	client.warningTimestamp = time.Time{}
+
+	count, err := strconv.Atoi(rsp.Header.Get(WarningCountHeader))
+	if err != nil || count <= 0 {
+		return
+	}
+	stamp, err := time.Parse(time.RFC3339Nano, rsp.Header.Get(WarningTimestampHeader))
+	if err != nil {
+		return
+	}
+	client.warningCount = count
+	client.warningTimestamp = stamp
+}
+
+// WarningsSummary returns the number of pending warnings and the time
+// the most recent of them was last added, as reported by the last
+// response from snapd.
+func (client *Client) WarningsSummary() (count int, timestamp time.Time) {
+	return client.warningCount, client.warningTimestamp
+}
+
 var (
 	doRetry   = 250 * time.Millisecond
 	doTimeout = 5 * time.Second
@@ -0,0 +1,71 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"net/url"
+)
+
+// Connection describes a connection between a plug and a slot.
+type Connection struct {
+	Slot      SlotRef `json:"slot"`
+	Plug      PlugRef `json:"plug"`
+	Interface string  `json:"interface"`
+	// Origin is how the connection was made, one of "auto", "gadget"
+	// or "manual".
+	Origin string `json:"origin"`
+	// Undesired is set for connections that were explicitly
+	// disconnected and that are not made again automatically.
+	Undesired bool                   `json:"undesired,omitempty"`
+	SlotAttrs map[string]interface{} `json:"slot-attrs,omitempty"`
+	PlugAttrs map[string]interface{} `json:"plug-attrs,omitempty"`
+}
+
+// ConnectionOptions contains the criteria for selecting connections.
+type ConnectionOptions struct {
+	// Snap restricts the connections to the ones with a plug or a
+	// slot of the given snap.
+	Snap string
+	// Interface restricts the connections to the ones of the given
+	// interface.
+	Interface string
+	// All includes the undesired connections.
+	All bool
+}
+
+// ListConnections returns the connections matching the given options.
+func (client *Client) ListConnections(opts *ConnectionOptions) ([]Connection, error) {
+	query := url.Values{}
+	if opts != nil {
+		if opts.Snap != "" {
+			query.Set("snap", opts.Snap)
+		}
+		if opts.Interface != "" {
+			query.Set("interface", opts.Interface)
+		}
+		if opts.All {
+			query.Set("select", "all")
+		}
+	}
+
+	var conns []Connection
+	_, err := client.doSync("GET", "/v2/connections", query, nil, nil, &conns)
+	return conns, err
+}
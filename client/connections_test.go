@@ -0,0 +1,77 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"net/url"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestListConnections(c *C) {
+	cs.rsp = `{"type": "sync", "result": [{
+		"slot": {"snap": "core", "slot": "network"},
+		"plug": {"snap": "foo", "plug": "network"},
+		"interface": "network",
+		"origin": "auto"
+	}, {
+		"slot": {"snap": "bar", "slot": "content"},
+		"plug": {"snap": "foo", "plug": "content"},
+		"interface": "content",
+		"origin": "manual",
+		"undesired": true,
+		"slot-attrs": {"read": ["/"]},
+		"plug-attrs": {"target": "/x"}
+	}]}`
+
+	conns, err := cs.cli.ListConnections(nil)
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Method, Equals, "GET")
+	c.Check(cs.req.URL.Path, Equals, "/v2/connections")
+	c.Check(cs.req.URL.RawQuery, Equals, "")
+	c.Check(conns, DeepEquals, []client.Connection{{
+		Slot:      client.SlotRef{Snap: "core", Name: "network"},
+		Plug:      client.PlugRef{Snap: "foo", Name: "network"},
+		Interface: "network",
+		Origin:    "auto",
+	}, {
+		Slot:      client.SlotRef{Snap: "bar", Name: "content"},
+		Plug:      client.PlugRef{Snap: "foo", Name: "content"},
+		Interface: "content",
+		Origin:    "manual",
+		Undesired: true,
+		SlotAttrs: map[string]interface{}{"read": []interface{}{"/"}},
+		PlugAttrs: map[string]interface{}{"target": "/x"},
+	}})
+}
+
+func (cs *clientSuite) TestListConnectionsOptions(c *C) {
+	cs.rsp = `{"type": "sync", "result": []}`
+
+	_, err := cs.cli.ListConnections(&client.ConnectionOptions{Snap: "foo", Interface: "network", All: true})
+	c.Assert(err, IsNil)
+	c.Check(cs.req.URL.Query(), DeepEquals, url.Values{
+		"snap":      []string{"foo"},
+		"interface": []string{"network"},
+		"select":    []string{"all"},
+	})
+}
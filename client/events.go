@@ -0,0 +1,109 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// An Event is a transition of a change or of one of its tasks.
+type Event struct {
+	// Type is one of "change-added", "change-status", "task-status"
+	// or "task-progress".
+	Type string `json:"type"`
+	// Change holds the change details, without its tasks.
+	Change *Change `json:"change"`
+	// Task is set for task events.
+	Task *Task `json:"task,omitempty"`
+}
+
+// EventsOptions represent the options of the Events call.
+type EventsOptions struct {
+	// ChangeID restricts the events to those of the given change;
+	// the stream then starts with the current status of the change
+	// and ends once the change is ready.
+	ChangeID string
+}
+
+// Events streams change and task events as they happen. The returned
+// channel is closed when the stream ends, which happens also if the
+// client doesn't keep up with the events; stop ends it early.
+func (client *Client) Events(opts *EventsOptions) (events <-chan Event, stop func(), err error) {
+	query := url.Values{}
+	if opts != nil && opts.ChangeID != "" {
+		query.Set("change", opts.ChangeID)
+	}
+
+	rsp, err := client.raw("GET", "/v2/events", query, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rsp.Header.Get("Content-Type") != "application/json-seq" {
+		defer rsp.Body.Close()
+		var r response
+		if err := json.NewDecoder(rsp.Body).Decode(&r); err != nil {
+			return nil, nil, fmt.Errorf("cannot decode events response: %v", err)
+		}
+		if err := r.err(); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("expected an events stream, got %q", r.Type)
+	}
+
+	ch := make(chan Event, 20)
+	done := make(chan struct{})
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			close(done)
+			rsp.Body.Close()
+		})
+	}
+	go func() {
+		defer close(ch)
+		defer stop()
+		// events come in application/json-seq, see Logs
+		scanner := bufio.NewScanner(rsp.Body)
+		for scanner.Scan() {
+			buf := scanner.Bytes()
+			idx := bytes.IndexByte(buf, 0x1E)
+			if idx < 0 {
+				continue
+			}
+			var ev Event
+			if err := json.Unmarshal(buf[idx+1:], &ev); err != nil || ev.Change == nil {
+				// truncated/corrupted record, or an error one
+				continue
+			}
+			select {
+			case ch <- ev:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return ch, stop, nil
+}
@@ -0,0 +1,85 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestClientEvents(c *check.C) {
+	cs.header = http.Header{"Content-Type": []string{"application/json-seq"}}
+	cs.rsp = "\x1e" + `{"type": "change-status", "change": {"id": "42", "status": "Do"}}` + "\n" +
+		"junk\n" +
+		"\x1e" + `{"type": "task-progress", "change": {"id": "42", "status": "Doing"}, "task": {"id": "1", "status": "Doing", "progress": {"label": "foo", "done": 1, "total": 2}}}` + "\n" +
+		"\x1e" + `{"error": "too many pending events"}` + "\n"
+
+	events, stop, err := cs.cli.Events(&client.EventsOptions{ChangeID: "42"})
+	c.Assert(err, check.IsNil)
+	defer stop()
+	c.Check(cs.req.Method, check.Equals, "GET")
+	c.Check(cs.req.URL.Path, check.Equals, "/v2/events")
+	c.Check(cs.req.URL.Query().Get("change"), check.Equals, "42")
+
+	var got []client.Event
+	for ev := range events {
+		got = append(got, ev)
+	}
+	c.Check(got, check.DeepEquals, []client.Event{{
+		Type:   "change-status",
+		Change: &client.Change{ID: "42", Status: "Do"},
+	}, {
+		Type:   "task-progress",
+		Change: &client.Change{ID: "42", Status: "Doing"},
+		Task: &client.Task{
+			ID:       "1",
+			Status:   "Doing",
+			Progress: client.TaskProgress{Label: "foo", Done: 1, Total: 2},
+		},
+	}})
+}
+
+func (cs *clientSuite) TestClientEventsStop(c *check.C) {
+	cs.header = http.Header{"Content-Type": []string{"application/json-seq"}}
+	cs.rsp = "\x1e" + `{"type": "change-added", "change": {"id": "1"}}` + "\n" +
+		"\x1e" + `{"type": "change-added", "change": {"id": "2"}}` + "\n"
+
+	events, stop, err := cs.cli.Events(nil)
+	c.Assert(err, check.IsNil)
+	c.Check(cs.req.URL.RawQuery, check.Equals, "")
+	ev := <-events
+	c.Check(ev.Change.ID, check.Equals, "1")
+	stop()
+	stop()
+	// the channel gets closed
+	for range events {
+	}
+}
+
+func (cs *clientSuite) TestClientEventsError(c *check.C) {
+	cs.status = 404
+	cs.rsp = `{"type": "error", "status-code": 404, "result": {"message": "cannot find change with id \"42\""}}`
+
+	_, _, err := cs.cli.Events(&client.EventsOptions{ChangeID: "42"})
+	c.Check(err, check.ErrorMatches, `cannot find change with id "42"`)
+}
@@ -146,6 +146,26 @@ func (cs *clientSuite) TestClientOpSnap(c *check.C) {
 	}
 }
 
+func (cs *clientSuite) TestClientOpSnapQueued(c *check.C) {
+	cs.rsp = `{
+		"change": "d728",
+		"status-code": 202,
+		"type": "async"
+	}`
+	_, err := cs.cli.Remove(pkgName, &client.SnapOptions{Queue: true, After: "42"})
+	c.Assert(err, check.IsNil)
+
+	body, err := ioutil.ReadAll(cs.req.Body)
+	c.Assert(err, check.IsNil)
+	jsonBody := make(map[string]interface{})
+	c.Assert(json.Unmarshal(body, &jsonBody), check.IsNil)
+	c.Check(jsonBody, check.DeepEquals, map[string]interface{}{
+		"action": "remove",
+		"queue":  true,
+		"after":  "42",
+	})
+}
+
 func (cs *clientSuite) TestClientMultiOpSnap(c *check.C) {
 	cs.rsp = `{
 		"change": "d728",
@@ -0,0 +1,89 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
+// Warning holds a short message that's meant to alert about a system
+// condition that needs the attention of the user.
+type Warning struct {
+	Message     string        `json:"message"`
+	FirstAdded  time.Time     `json:"first-added"`
+	LastAdded   time.Time     `json:"last-added"`
+	LastShown   time.Time     `json:"last-shown,omitempty"`
+	ExpireAfter time.Duration `json:"-"`
+	RepeatAfter time.Duration `json:"-"`
+}
+
+type jsonWarning struct {
+	Warning
+	ExpireAfter string `json:"expire-after,omitempty"`
+	RepeatAfter string `json:"repeat-after,omitempty"`
+}
+
+// WarningsOptions contains options for querying snapd for warnings
+// supported options:
+// - All: return all warnings, instead of only the un-okayed ones.
+type WarningsOptions struct {
+	All bool
+}
+
+// Warnings returns the list of un-okayed warnings, or all of them with
+// opts.All.
+func (client *Client) Warnings(opts WarningsOptions) ([]*Warning, error) {
+	var jws []*jsonWarning
+	q := make(url.Values)
+	if opts.All {
+		q.Add("select", "all")
+	}
+	if _, err := client.doSync("GET", "/v2/warnings", q, nil, nil, &jws); err != nil {
+		return nil, err
+	}
+
+	ws := make([]*Warning, len(jws))
+	for i, jw := range jws {
+		ws[i] = &jw.Warning
+		ws[i].ExpireAfter, _ = time.ParseDuration(jw.ExpireAfter)
+		ws[i].RepeatAfter, _ = time.ParseDuration(jw.RepeatAfter)
+	}
+
+	return ws, nil
+}
+
+type warningsAction struct {
+	Action    string    `json:"action"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Okay asks snapd to chill about the warnings that would have been
+// returned by Warnings at the given time.
+func (client *Client) Okay(t time.Time) error {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(warningsAction{Action: "okay", Timestamp: t}); err != nil {
+		return err
+	}
+	_, err := client.doSync("POST", "/v2/warnings", nil, nil, &body, nil)
+	return err
+}
@@ -0,0 +1,93 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package client_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+)
+
+func (cs *clientSuite) TestWarningsSummaryFromHeaders(c *C) {
+	cs.rsp = `{"type": "sync", "result": {}}`
+	cs.header = http.Header{}
+	cs.header.Set(client.WarningCountHeader, "2")
+	cs.header.Set(client.WarningTimestampHeader, "2017-11-01T10:00:00.5Z")
+
+	_, err := cs.cli.SysInfo()
+	c.Assert(err, IsNil)
+	n, t := cs.cli.WarningsSummary()
+	c.Check(n, Equals, 2)
+	c.Check(t, DeepEquals, time.Date(2017, 11, 1, 10, 0, 0, 5e8, time.UTC))
+
+	cs.header = nil
+	_, err = cs.cli.SysInfo()
+	c.Assert(err, IsNil)
+	n, t = cs.cli.WarningsSummary()
+	c.Check(n, Equals, 0)
+	c.Check(t.IsZero(), Equals, true)
+}
+
+func (cs *clientSuite) TestWarnings(c *C) {
+	cs.rsp = `{"type": "sync", "result": [{
+		"message": "hello",
+		"first-added": "2017-11-01T10:00:00Z",
+		"last-added": "2017-11-01T11:00:00Z",
+		"expire-after": "672h0m0s",
+		"repeat-after": "24h0m0s"
+	}]}`
+
+	ws, err := cs.cli.Warnings(client.WarningsOptions{})
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Method, Equals, "GET")
+	c.Check(cs.req.URL.Path, Equals, "/v2/warnings")
+	c.Check(cs.req.URL.RawQuery, Equals, "")
+	c.Check(ws, DeepEquals, []*client.Warning{{
+		Message:     "hello",
+		FirstAdded:  time.Date(2017, 11, 1, 10, 0, 0, 0, time.UTC),
+		LastAdded:   time.Date(2017, 11, 1, 11, 0, 0, 0, time.UTC),
+		ExpireAfter: 28 * 24 * time.Hour,
+		RepeatAfter: 24 * time.Hour,
+	}})
+
+	_, err = cs.cli.Warnings(client.WarningsOptions{All: true})
+	c.Assert(err, IsNil)
+	c.Check(cs.req.URL.RawQuery, Equals, "select=all")
+}
+
+func (cs *clientSuite) TestOkay(c *C) {
+	cs.rsp = `{"type": "sync", "result": 1}`
+	t := time.Date(2017, 11, 1, 10, 0, 0, 0, time.UTC)
+
+	err := cs.cli.Okay(t)
+	c.Assert(err, IsNil)
+	c.Check(cs.req.Method, Equals, "POST")
+	c.Check(cs.req.URL.Path, Equals, "/v2/warnings")
+	var body map[string]interface{}
+	c.Assert(json.NewDecoder(cs.req.Body).Decode(&body), IsNil)
+	c.Check(body, DeepEquals, map[string]interface{}{
+		"action":    "okay",
+		"timestamp": "2017-11-01T10:00:00Z",
+	})
+}
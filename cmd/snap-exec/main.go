@@ -34,12 +34,17 @@ import (
 )
 
 // for the tests
-var syscallExec = syscall.Exec
+var (
+	syscallExec = syscall.Exec
+	syscallKill = syscall.Kill
+)
 
 // commandline args
 var opts struct {
 	Command string `long:"command" description:"use a different command like {stop,post-stop} from the app"`
 	Hook    string `long:"hook" description:"hook to run" hidden:"yes"`
+
+	StopBeforeExec bool `long:"stop-before-exec" description:"stop right before running the command, for a debugger to attach" hidden:"yes"`
 }
 
 func main() {
@@ -167,6 +172,9 @@ func snapExecApp(snapApp, revision, command string, args []string) error {
 	fullCmdArgs := []string{fullCmd}
 	fullCmdArgs = append(fullCmdArgs, cmdArgs...)
 	fullCmdArgs = append(fullCmdArgs, args...)
+	if err := maybeStopBeforeExec(); err != nil {
+		return err
+	}
 	if err := syscallExec(fullCmd, fullCmdArgs, env); err != nil {
 		return fmt.Errorf("cannot exec %q: %s", fullCmd, err)
 	}
@@ -197,5 +205,20 @@ func snapExecHook(snapName, revision, hookName string) error {
 
 	// run the hook
 	hookPath := filepath.Join(hook.Snap.HooksDir(), hook.Name)
+	if err := maybeStopBeforeExec(); err != nil {
+		return err
+	}
 	return syscallExec(hookPath, []string{hookPath}, env)
 }
+
+// maybeStopBeforeExec stops snap-exec when asked to by `snap run --gdb`,
+// which then attaches gdbserver right before the command is run.
+func maybeStopBeforeExec() error {
+	if !opts.StopBeforeExec {
+		return nil
+	}
+	if err := syscallKill(os.Getpid(), syscall.SIGSTOP); err != nil {
+		return fmt.Errorf("cannot stop before exec: %s", err)
+	}
+	return nil
+}
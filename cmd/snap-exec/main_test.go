@@ -47,10 +47,12 @@ func (s *snapExecSuite) SetUpTest(c *C) {
 	// clean previous parse runs
 	opts.Command = ""
 	opts.Hook = ""
+	opts.StopBeforeExec = false
 }
 
 func (s *snapExecSuite) TearDown(c *C) {
 	syscallExec = syscall.Exec
+	syscallKill = syscall.Kill
 	dirs.SetRootDir("/")
 }
 
@@ -156,6 +158,33 @@ func (s *snapExecSuite) TestSnapExecAppIntegration(c *C) {
 	c.Check(execEnv, testutil.Contains, fmt.Sprintf("MY_PATH=%s", os.Getenv("PATH")))
 }
 
+func (s *snapExecSuite) TestSnapExecAppStopBeforeExec(c *C) {
+	dirs.SetRootDir(c.MkDir())
+	snaptest.MockSnap(c, string(mockYaml), string(mockContents), &snap.SideInfo{
+		Revision: snap.R("42"),
+	})
+
+	var calls []string
+	syscallKill = func(pid int, sig syscall.Signal) error {
+		c.Check(pid, Equals, os.Getpid())
+		c.Check(sig, Equals, syscall.SIGSTOP)
+		calls = append(calls, "kill")
+		return nil
+	}
+	defer func() { syscallKill = syscall.Kill }()
+	syscallExec = func(argv0 string, argv []string, env []string) error {
+		calls = append(calls, "exec")
+		return nil
+	}
+
+	_, _, err := parseArgs([]string{"--stop-before-exec", "snapname.app"})
+	c.Assert(err, IsNil)
+	c.Check(opts.StopBeforeExec, Equals, true)
+	err = snapExecApp("snapname.app", "42", "", nil)
+	c.Assert(err, IsNil)
+	c.Check(calls, DeepEquals, []string{"kill", "exec"})
+}
+
 func (s *snapExecSuite) TestSnapExecHookIntegration(c *C) {
 	dirs.SetRootDir(c.MkDir())
 	snaptest.MockSnap(c, string(mockHookYaml), string(mockContents), &snap.SideInfo{
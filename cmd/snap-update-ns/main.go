@@ -125,7 +125,11 @@ func run() error {
 			changesMade = append(changesMade, change)
 			continue
 		}
-		if err := change.Perform(); err != nil {
+		synthesised, err := change.Perform()
+		// Synthetic changes, made to create writable mimics, are recorded
+		// even if the change itself failed.
+		changesMade = append(changesMade, synthesised...)
+		if err != nil {
 			logger.Noticef("cannot change mount namespace of snap %q according to change %s: %s", snapName, change, err)
 			continue
 		}
@@ -42,13 +42,14 @@ type cmdChanges struct {
 	Positional struct {
 		Snap string `positional-arg-name:"<snap>"`
 	} `positional-args:"yes"`
+	formatMixin
 }
 
 type cmdTasks struct{ changeIDMixin }
 
 func init() {
 	addCommand("changes", shortChangesHelp, longChangesHelp,
-		func() flags.Commander { return &cmdChanges{} }, nil, nil)
+		func() flags.Commander { return &cmdChanges{} }, formatDescs, nil)
 	addCommand("tasks", shortTasksHelp, longTasksHelp,
 		func() flags.Commander { return &cmdTasks{} },
 		changeIDMixinOptDesc,
@@ -90,11 +91,18 @@ func (c *cmdChanges) Execute(args []string) error {
 	}
 
 	if len(changes) == 0 {
+		if c.structured() {
+			return c.printStructured([]*client.Change{})
+		}
 		return fmt.Errorf(i18n.G("no changes found"))
 	}
 
 	sort.Sort(changesByTime(changes))
 
+	if c.structured() {
+		return c.printStructured(changes)
+	}
+
 	w := tabWriter()
 
 	fmt.Fprintf(w, i18n.G("ID\tStatus\tSpawn\tReady\tSummary\n"))
@@ -104,7 +112,12 @@ func (c *cmdChanges) Execute(args []string) error {
 		if chg.ReadyTime.IsZero() {
 			readyTime = "-"
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", chg.ID, chg.Status, spawnTime, readyTime, chg.Summary)
+		status := chg.Status
+		if len(chg.QueuedAfter) > 0 {
+			// waiting for other changes to finish
+			status = "Queued"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", chg.ID, status, spawnTime, readyTime, chg.Summary)
 	}
 
 	w.Flush()
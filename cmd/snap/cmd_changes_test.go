@@ -24,6 +24,7 @@ import (
 	"net/http"
 
 	"gopkg.in/check.v1"
+	"gopkg.in/yaml.v2"
 
 	snap "github.com/snapcore/snapd/cmd/snap"
 )
@@ -111,6 +112,29 @@ var mockChangesJSON = `{"type": "sync", "result": [
   }
 ]}`
 
+func (s *SnapSuite) TestChangesFormatYAML(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/changes")
+		fmt.Fprintln(w, mockChangesJSON)
+	})
+	rest, err := snap.Parser().ParseArgs([]string{"changes", "--format=yaml"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+
+	var changes []map[string]interface{}
+	c.Assert(yaml.Unmarshal(s.stdout.Bytes(), &changes), check.IsNil)
+	c.Assert(changes, check.HasLen, 4)
+	// sorted by spawn time, like the table
+	ids := make([]interface{}, len(changes))
+	for i, chg := range changes {
+		ids[i] = chg["id"]
+	}
+	c.Check(ids, check.DeepEquals, []interface{}{"four", "three", "one", "two"})
+	c.Check(changes[0]["kind"], check.Equals, "install-snap")
+	c.Check(changes[0]["spawn-time"], check.Equals, "2015-02-21T01:02:03Z")
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
 func (s *SnapSuite) TestTasksLast(c *check.C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
 		c.Check(r.Method, check.Equals, "GET")
@@ -178,3 +202,21 @@ Doing +2016-04-21T01:02:03Z +2016-04-21T01:02:04Z +some summary \(50.00%\)
 `)
 	c.Check(s.Stderr(), check.Equals, "")
 }
+
+func (s *SnapSuite) TestChangesQueued(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/changes")
+		fmt.Fprintln(w, `{"type": "sync", "result": [
+  {"id": "42", "kind": "refresh-snap", "summary": "Refresh \"foo\" snap", "status": "Doing", "spawn-time": "2016-04-21T01:02:03Z"},
+  {"id": "43", "kind": "remove-snap", "summary": "Remove \"foo\" snap after change 42", "status": "Do", "spawn-time": "2016-04-21T01:02:04Z", "queued-after": ["42"]}
+]}`)
+	})
+
+	_, err := snap.Parser().ParseArgs([]string{"changes"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, `ID   Status  Spawn                 Ready  Summary
+42   Doing   2016-04-21T01:02:03Z  -      Refresh "foo" snap
+43   Queued  2016-04-21T01:02:04Z  -      Remove "foo" snap after change 42
+
+`)
+}
@@ -0,0 +1,118 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/i18n"
+)
+
+var shortConnectionsHelp = i18n.G("List interface connections")
+var longConnectionsHelp = i18n.G(`
+The connections command lists the connections between plugs and slots
+in the system, or only the ones involving the given snap.
+
+The notes column tells connections made manually or as requested by the
+gadget apart from automatic ones. With --all, connections that were
+explicitly disconnected, and are not made again automatically, are
+listed as well.
+`)
+
+type cmdConnections struct {
+	All         bool   `long:"all"`
+	Interface   string `short:"i"`
+	Positionals struct {
+		Snap installedSnapName `positional-arg-name:"<snap>"`
+	} `positional-args:"true"`
+	formatMixin
+}
+
+func init() {
+	addCommand("connections", shortConnectionsHelp, longConnectionsHelp, func() flags.Commander {
+		return &cmdConnections{}
+	}, formatDescs.also(map[string]string{
+		"all": i18n.G("Include connections that were explicitly disconnected"),
+		"i":   i18n.G("Constrain listing to the given interface"),
+	}), []argDesc{{
+		name: i18n.G("<snap>"),
+		desc: i18n.G("Constrain listing to the given snap"),
+	}})
+}
+
+// connectionEnd formats a plug or slot reference, abbreviating the ones
+// of the OS snap as in 'snap interfaces'.
+func connectionEnd(snapName, name string) string {
+	if snapName == "core" || snapName == "ubuntu-core" {
+		return ":" + name
+	}
+	return snapName + ":" + name
+}
+
+func connectionNotes(conn *client.Connection) string {
+	switch {
+	case conn.Undesired:
+		return "disconnected"
+	case conn.Origin == "manual" || conn.Origin == "gadget":
+		return conn.Origin
+	default:
+		return "-"
+	}
+}
+
+func (x *cmdConnections) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	conns, err := Client().ListConnections(&client.ConnectionOptions{
+		Snap:      string(x.Positionals.Snap),
+		Interface: x.Interface,
+		All:       x.All,
+	})
+	if err != nil {
+		return err
+	}
+	if x.structured() {
+		if conns == nil {
+			conns = []client.Connection{}
+		}
+		return x.printStructured(conns)
+	}
+	if len(conns) == 0 {
+		fmt.Fprintln(Stderr, i18n.G("No connections."))
+		return nil
+	}
+
+	w := tabWriter()
+	defer w.Flush()
+	fmt.Fprintln(w, i18n.G("Interface\tPlug\tSlot\tNotes"))
+	for i := range conns {
+		conn := &conns[i]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", conn.Interface,
+			connectionEnd(conn.Plug.Snap, conn.Plug.Name),
+			connectionEnd(conn.Slot.Snap, conn.Slot.Name),
+			connectionNotes(conn))
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+const mockConnectionsJSON = `{"type": "sync", "result": [{
+  "slot": {"snap": "core", "slot": "network"},
+  "plug": {"snap": "foo", "plug": "network"},
+  "interface": "network",
+  "origin": "auto"
+}, {
+  "slot": {"snap": "bar", "slot": "content"},
+  "plug": {"snap": "foo", "plug": "content"},
+  "interface": "content",
+  "origin": "manual",
+  "plug-attrs": {"target": "/x"}
+}, {
+  "slot": {"snap": "core", "slot": "home"},
+  "plug": {"snap": "foo", "plug": "home"},
+  "interface": "home",
+  "origin": "auto",
+  "undesired": true
+}]}`
+
+func (s *SnapSuite) TestConnectionsCmd(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		c.Check(r.URL.Path, Equals, "/v2/connections")
+		c.Check(r.URL.RawQuery, Equals, "select=all&snap=foo")
+		fmt.Fprintln(w, mockConnectionsJSON)
+	})
+
+	rest, err := snap.Parser().ParseArgs([]string{"connections", "--all", "foo"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	c.Check(s.Stdout(), Equals, ""+
+		"Interface  Plug         Slot         Notes\n"+
+		"network    foo:network  :network     -\n"+
+		"content    foo:content  bar:content  manual\n"+
+		"home       foo:home     :home        disconnected\n")
+	c.Check(s.Stderr(), Equals, "")
+}
+
+func (s *SnapSuite) TestConnectionsCmdInterface(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.RawQuery, Equals, "interface=network")
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+
+	_, err := snap.Parser().ParseArgs([]string{"connections", "-i", "network"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, "")
+	c.Check(s.Stderr(), Equals, "No connections.\n")
+}
+
+func (s *SnapSuite) TestConnectionsCmdFormatJSON(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": [{
+  "slot": {"snap": "bar", "slot": "content"},
+  "plug": {"snap": "foo", "plug": "content"},
+  "interface": "content",
+  "origin": "manual",
+  "plug-attrs": {"target": "/x"}
+}]}`)
+	})
+
+	_, err := snap.Parser().ParseArgs([]string{"connections", "--format=json"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, `[
+  {
+    "slot": {
+      "snap": "bar",
+      "slot": "content"
+    },
+    "plug": {
+      "snap": "foo",
+      "plug": "content"
+    },
+    "interface": "content",
+    "origin": "manual",
+    "plug-attrs": {
+      "target": "/x"
+    }
+  }
+]
+`)
+}
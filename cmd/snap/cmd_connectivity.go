@@ -0,0 +1,68 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+type cmdConnectivity struct{}
+
+var shortConnectivityHelp = i18n.G("Check the connectivity to the store")
+var longConnectivityHelp = i18n.G(`
+The connectivity command checks that snapd can reach the store API,
+the assertions service and the download servers, going through the
+proxy store if one is configured.
+`)
+
+func init() {
+	addDebugCommand("connectivity", shortConnectivityHelp, longConnectivityHelp, func() flags.Commander {
+		return &cmdConnectivity{}
+	})
+}
+
+func (x *cmdConnectivity) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	var status struct {
+		Connectivity bool
+		Unreachable  []string
+	}
+	if err := Client().Debug("connectivity", nil, &status); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(Stdout, i18n.G("Connectivity status:"))
+	if status.Connectivity {
+		fmt.Fprintln(Stdout, " * PASS")
+		return nil
+	}
+
+	for _, host := range status.Unreachable {
+		fmt.Fprintf(Stdout, i18n.G(" * %s: unreachable\n"), host)
+	}
+	return fmt.Errorf(i18n.G("%d snap store hosts are unreachable"), len(status.Unreachable))
+}
@@ -0,0 +1,97 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+type cmdConnectCheck struct {
+	Positionals struct {
+		PlugSpec connectPlugSpec `required:"yes"`
+		SlotSpec connectSlotSpec `required:"yes"`
+	} `positional-args:"true"`
+}
+
+var shortConnectCheckHelp = i18n.G("Explain the connection policy for a plug and a slot")
+var longConnectCheckHelp = i18n.G(`
+The connect-check command checks whether the given plug and slot can be
+connected, manually and automatically, according to the base declaration
+and the snap declarations of their snaps, and lists the rules and
+constraints that led to the outcome.
+
+As with the connect command, the snap name of the slot can be omitted to
+refer to the core snap, as in :<slot>.
+`)
+
+func init() {
+	addDebugCommand("connect-check", shortConnectCheckHelp, longConnectCheckHelp, func() flags.Commander {
+		return &cmdConnectCheck{}
+	})
+}
+
+type policyCheck struct {
+	Allowed bool     `json:"allowed"`
+	Error   string   `json:"error"`
+	Trace   []string `json:"trace"`
+}
+
+func printPolicyCheck(what string, check *policyCheck) {
+	if check.Allowed {
+		fmt.Fprintf(Stdout, i18n.G("%s: allowed\n"), what)
+	} else {
+		fmt.Fprintf(Stdout, i18n.G("%s: not allowed (%s)\n"), what, check.Error)
+	}
+	for _, step := range check.Trace {
+		fmt.Fprintf(Stdout, " * %s\n", step)
+	}
+}
+
+func (x *cmdConnectCheck) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+	plug := x.Positionals.PlugSpec
+	slot := x.Positionals.SlotSpec
+	// the snap of the slot defaults to the core snap, as for connect
+	if plug.Snap == "" || plug.Name == "" || slot.Name == "" {
+		return fmt.Errorf(i18n.G("need a plug and a slot, as <snap>:<plug> [<snap>]:<slot>"))
+	}
+
+	params := map[string]interface{}{
+		"plug": map[string]string{"snap": plug.Snap, "plug": plug.Name},
+		"slot": map[string]string{"snap": slot.Snap, "slot": slot.Name},
+	}
+	var result struct {
+		Connection     policyCheck `json:"connection"`
+		AutoConnection policyCheck `json:"auto-connection"`
+	}
+	if err := Client().Debug("connect-check", params, &result); err != nil {
+		return err
+	}
+
+	printPolicyCheck(i18n.G("Connection"), &result.Connection)
+	printPolicyCheck(i18n.G("Auto-connection"), &result.AutoConnection)
+	return nil
+}
@@ -0,0 +1,85 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) TestConnectCheck(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "POST")
+			c.Check(r.URL.Path, check.Equals, "/v2/debug")
+			data, err := ioutil.ReadAll(r.Body)
+			c.Check(err, check.IsNil)
+			c.Check(string(data), check.Equals, `{"action":"connect-check","params":{"plug":{"plug":"plug","snap":"consumer"},"slot":{"slot":"slot","snap":"producer"}}}`)
+			fmt.Fprintln(w, `{"type": "sync", "result": {
+"connection": {"allowed": true, "trace": ["using slot rule of interface \"test\" from base-declaration", "allow-connection constraints match"]},
+"auto-connection": {"allowed": false, "error": "auto-connection denied by slot rule of interface \"test\"", "trace": ["using slot rule of interface \"test\" from base-declaration", "deny-auto-connection constraints match"]}
+}}`)
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+
+		n++
+	})
+	rest, err := snap.Parser().ParseArgs([]string{"debug", "connect-check", "consumer:plug", "producer:slot"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `Connection: allowed
+ * using slot rule of interface "test" from base-declaration
+ * allow-connection constraints match
+Auto-connection: not allowed (auto-connection denied by slot rule of interface "test")
+ * using slot rule of interface "test" from base-declaration
+ * deny-auto-connection constraints match
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestConnectCheckNeedsPlugAndSlot(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request")
+	})
+	_, err := snap.Parser().ParseArgs([]string{"debug", "connect-check", "consumer:plug", "producer"})
+	c.Assert(err, check.ErrorMatches, `need a plug and a slot, as <snap>:<plug> \[<snap>\]:<slot>`)
+	_, err = snap.Parser().ParseArgs([]string{"debug", "connect-check", "plug", ":slot"})
+	c.Assert(err, check.ErrorMatches, `need a plug and a slot, as <snap>:<plug> \[<snap>\]:<slot>`)
+}
+
+func (s *SnapSuite) TestConnectCheckCoreSlot(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadAll(r.Body)
+		c.Check(err, check.IsNil)
+		c.Check(string(data), check.Equals, `{"action":"connect-check","params":{"plug":{"plug":"plug","snap":"consumer"},"slot":{"slot":"network","snap":""}}}`)
+		fmt.Fprintln(w, `{"type": "sync", "result": {"connection": {"allowed": true}, "auto-connection": {"allowed": true}}}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"debug", "connect-check", "consumer:plug", ":network"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "Connection: allowed\nAuto-connection: allowed\n")
+}
@@ -0,0 +1,279 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type cmdDebugState struct {
+	Changes  bool     `long:"changes" description:"List all changes"`
+	ChangeID string   `long:"change" description:"List the tasks of the given change, with their lanes and what they wait for"`
+	Dot      bool     `long:"dot" description:"Output the tasks of the change given with --change as a dot graph of their dependencies"`
+	TaskID   string   `long:"task" description:"Show the details and logs of the given task"`
+	Keys     []string `long:"key" description:"Dump the data stored in the state under the given key, like snaps or conns"`
+
+	Positional struct {
+		StateFilePath flags.Filename `positional-arg-name:"<state-file>" required:"yes"`
+	} `positional-args:"yes"`
+}
+
+var shortDebugStateHelp = i18n.G("Inspect a snapd state file")
+var longDebugStateHelp = i18n.G(`
+The state command reads the given snapd state file, like a copy of
+/var/lib/snapd/state.json, and shows its changes, their tasks and
+the data stored in it. Any state journal next to the file, like
+state.json.journal, is read as well. It doesn't need snapd to be running.
+`)
+
+func init() {
+	addDebugCommand("state", shortDebugStateHelp, longDebugStateHelp, func() flags.Commander {
+		return &cmdDebugState{}
+	})
+}
+
+func readStateFile(path string) (*state.State, error) {
+	var r io.Reader
+	if journalPath := state.JournalPath(path); osutil.FileExists(journalPath) {
+		// include the checkpoints not compacted into the state file yet
+		data, err := state.ReadJournaled(path, journalPath)
+		if err != nil {
+			return nil, fmt.Errorf(i18n.G("cannot read state file: %v"), err)
+		}
+		r = bytes.NewReader(data)
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf(i18n.G("cannot open state file: %v"), err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	st, err := state.ReadState(nil, r)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.G("cannot read state file: %v"), err)
+	}
+	return st, nil
+}
+
+func formatStateTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+type byChangeID []*state.Change
+
+func (s byChangeID) Len() int      { return len(s) }
+func (s byChangeID) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byChangeID) Less(i, j int) bool {
+	return idLess(s[i].ID(), s[j].ID())
+}
+
+type byTaskID []*state.Task
+
+func (s byTaskID) Len() int      { return len(s) }
+func (s byTaskID) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byTaskID) Less(i, j int) bool {
+	return idLess(s[i].ID(), s[j].ID())
+}
+
+// idLess compares change and task ids, which are increasing numbers.
+func idLess(a, b string) bool {
+	ai, erra := strconv.Atoi(a)
+	bi, errb := strconv.Atoi(b)
+	if erra != nil || errb != nil {
+		return a < b
+	}
+	return ai < bi
+}
+
+func formatTaskIDs(tasks []*state.Task) string {
+	if len(tasks) == 0 {
+		return "-"
+	}
+	sort.Sort(byTaskID(tasks))
+	ids := make([]string, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID()
+	}
+	return strings.Join(ids, ",")
+}
+
+func formatLanes(t *state.Task) string {
+	lanes := t.Lanes()
+	if len(lanes) == 0 {
+		return "-"
+	}
+	strs := make([]string, len(lanes))
+	for i, l := range lanes {
+		strs[i] = strconv.Itoa(l)
+	}
+	return strings.Join(strs, ",")
+}
+
+func (x *cmdDebugState) showChanges(st *state.State) error {
+	changes := st.Changes()
+	sort.Sort(byChangeID(changes))
+
+	w := tabWriter()
+	fmt.Fprintf(w, i18n.G("ID\tStatus\tSpawn\tReady\tKind\tSummary\n"))
+	for _, chg := range changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", chg.ID(), chg.Status(), formatStateTime(chg.SpawnTime()), formatStateTime(chg.ReadyTime()), chg.Kind(), chg.Summary())
+	}
+	w.Flush()
+	return nil
+}
+
+func (x *cmdDebugState) showTasks(st *state.State, chg *state.Change) error {
+	tasks := chg.Tasks()
+	sort.Sort(byTaskID(tasks))
+
+	w := tabWriter()
+	fmt.Fprintf(w, i18n.G("Lanes\tID\tStatus\tSpawn\tReady\tWaits for\tKind\tSummary\n"))
+	for _, t := range tasks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", formatLanes(t), t.ID(), t.Status(), formatStateTime(t.SpawnTime()), formatStateTime(t.ReadyTime()), formatTaskIDs(t.WaitTasks()), t.Kind(), t.Summary())
+	}
+	w.Flush()
+	return nil
+}
+
+func (x *cmdDebugState) writeDot(st *state.State, chg *state.Change) error {
+	tasks := chg.Tasks()
+	sort.Sort(byTaskID(tasks))
+
+	fmt.Fprintf(Stdout, "digraph %q {\n", chg.Kind())
+	for _, t := range tasks {
+		fmt.Fprintf(Stdout, "\t%q [label=%q];\n", t.ID(), fmt.Sprintf("%s:%s", t.ID(), t.Kind()))
+	}
+	for _, t := range tasks {
+		wait := t.WaitTasks()
+		sort.Sort(byTaskID(wait))
+		for _, wt := range wait {
+			fmt.Fprintf(Stdout, "\t%q -> %q;\n", t.ID(), wt.ID())
+		}
+	}
+	fmt.Fprintf(Stdout, "}\n")
+	return nil
+}
+
+func (x *cmdDebugState) showTask(st *state.State, t *state.Task) error {
+	fmt.Fprintf(Stdout, "id: %s\n", t.ID())
+	fmt.Fprintf(Stdout, "kind: %s\n", t.Kind())
+	fmt.Fprintf(Stdout, "summary: %s\n", t.Summary())
+	fmt.Fprintf(Stdout, "status: %s\n", t.Status())
+	if chg := t.Change(); chg != nil {
+		fmt.Fprintf(Stdout, "change: %s\n", chg.ID())
+	}
+	fmt.Fprintf(Stdout, "lanes: %s\n", formatLanes(t))
+	fmt.Fprintf(Stdout, "waits-for: %s\n", formatTaskIDs(t.WaitTasks()))
+	fmt.Fprintf(Stdout, "halts: %s\n", formatTaskIDs(t.HaltTasks()))
+	log := t.Log()
+	if len(log) == 0 {
+		return nil
+	}
+	fmt.Fprintf(Stdout, "log:\n")
+	for _, line := range log {
+		fmt.Fprintf(Stdout, "  %s\n", line)
+	}
+	return nil
+}
+
+func (x *cmdDebugState) showData(st *state.State, keys []string) error {
+	for _, key := range keys {
+		var raw json.RawMessage
+		if err := st.Get(key, &raw); err != nil {
+			if err == state.ErrNoState {
+				return fmt.Errorf(i18n.G("no data for key %q in state"), key)
+			}
+			return err
+		}
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, raw, "", "  "); err != nil {
+			return err
+		}
+		fmt.Fprintf(Stdout, "%s: %s\n", key, buf.String())
+	}
+	return nil
+}
+
+func (x *cmdDebugState) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	modes := 0
+	for _, set := range []bool{x.Changes, x.ChangeID != "", x.TaskID != "", len(x.Keys) > 0} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return fmt.Errorf(i18n.G("cannot use --changes, --change, --task and --key together"))
+	}
+	if x.Dot && x.ChangeID == "" {
+		return fmt.Errorf(i18n.G("--dot can only be used with --change"))
+	}
+
+	st, err := readStateFile(string(x.Positional.StateFilePath))
+	if err != nil {
+		return err
+	}
+	st.Lock()
+	defer st.Unlock()
+
+	switch {
+	case x.ChangeID != "":
+		chg := st.Change(x.ChangeID)
+		if chg == nil {
+			return fmt.Errorf(i18n.G("no change with id %q in state"), x.ChangeID)
+		}
+		if x.Dot {
+			return x.writeDot(st, chg)
+		}
+		return x.showTasks(st, chg)
+	case x.TaskID != "":
+		t := st.Task(x.TaskID)
+		if t == nil {
+			return fmt.Errorf(i18n.G("no task with id %q in state"), x.TaskID)
+		}
+		return x.showTask(st, t)
+	case len(x.Keys) > 0:
+		return x.showData(st, x.Keys)
+	default:
+		return x.showChanges(st)
+	}
+}
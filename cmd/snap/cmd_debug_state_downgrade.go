@@ -0,0 +1,103 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/patch"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type cmdDebugStateDowngrade struct {
+	ToLevel int `long:"to-level" required:"yes" description:"The patch level to take the state back to"`
+
+	Positional struct {
+		StateFilePath flags.Filename `positional-arg-name:"<state-file>" required:"yes"`
+	} `positional-args:"yes"`
+}
+
+var shortDebugStateDowngradeHelp = i18n.G("Take a snapd state file back to an older patch level")
+var longDebugStateDowngradeHelp = i18n.G(`
+The state-downgrade command reverts the patches applied to the given
+snapd state file above the given patch level, so that it can be used
+by an older snapd. A state journal next to the file is merged into it
+and removed first. snapd must not be running while this is done.
+`)
+
+func init() {
+	addDebugCommand("state-downgrade", shortDebugStateDowngradeHelp, longDebugStateDowngradeHelp, func() flags.Commander {
+		return &cmdDebugStateDowngrade{}
+	})
+}
+
+func (x *cmdDebugStateDowngrade) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	path := string(x.Positional.StateFilePath)
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot open state file: %v"), err)
+	}
+	if journalPath := state.JournalPath(path); osutil.FileExists(journalPath) {
+		// fold the journal into the state file first, its entries
+		// would otherwise be applied on top of the downgraded state
+		j, _, err := state.OpenJournal(path, journalPath)
+		if err != nil {
+			return fmt.Errorf(i18n.G("cannot read state journal: %v"), err)
+		}
+		if err := j.Remove(); err != nil {
+			return fmt.Errorf(i18n.G("cannot remove state journal: %v"), err)
+		}
+	}
+	st, err := readStateFile(path)
+	if err != nil {
+		return err
+	}
+
+	var fromLevel int
+	st.Lock()
+	st.Get("patch-level", &fromLevel)
+	st.Unlock()
+
+	if err := patch.Downgrade(st, x.ToLevel); err != nil {
+		return err
+	}
+
+	st.Lock()
+	data, err := st.MarshalJSON()
+	st.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := osutil.AtomicWriteFile(path, data, fi.Mode().Perm(), 0); err != nil {
+		return fmt.Errorf(i18n.G("cannot write state file: %v"), err)
+	}
+
+	fmt.Fprintf(Stdout, i18n.G("State patch level reverted from %d to %d.\n"), fromLevel, x.ToLevel)
+	return nil
+}
@@ -0,0 +1,115 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+var statePatch6JSON = []byte(`
+{
+	"data": {
+		"patch-level": 6,
+		"snaps": {"foo": {"sequence": [{"name": "foo", "revision": "1"}], "current": "1", "devmode": true}}
+	},
+	"changes": {},
+	"tasks": {},
+	"last-change-id": 0,
+	"last-task-id": 0,
+	"last-lane-id": 0
+}`)
+
+func (s *SnapSuite) TestDebugStateDowngrade(c *check.C) {
+	path := filepath.Join(c.MkDir(), "state.json")
+	c.Assert(ioutil.WriteFile(path, statePatch6JSON, 0600), check.IsNil)
+
+	rest, err := snap.Parser().ParseArgs([]string{"debug", "state-downgrade", "--to-level=5", path})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, "State patch level reverted from 6 to 5.\n")
+
+	content, err := ioutil.ReadFile(path)
+	c.Assert(err, check.IsNil)
+	var st struct {
+		Data struct {
+			PatchLevel int `json:"patch-level"`
+			Snaps      map[string]struct {
+				Flags   int  `json:"flags"`
+				DevMode bool `json:"devmode"`
+			} `json:"snaps"`
+		} `json:"data"`
+	}
+	c.Assert(json.Unmarshal(content, &st), check.IsNil)
+	c.Check(st.Data.PatchLevel, check.Equals, 5)
+	c.Check(st.Data.Snaps["foo"].Flags, check.Equals, 1)
+	c.Check(st.Data.Snaps["foo"].DevMode, check.Equals, false)
+}
+
+func (s *SnapSuite) TestDebugStateDowngradeMergesJournal(c *check.C) {
+	path := filepath.Join(c.MkDir(), "state.json")
+	c.Assert(ioutil.WriteFile(path, statePatch6JSON, 0600), check.IsNil)
+	journal := `{"records":[{"s":"data","k":"snaps","v":{"foo":{"sequence":[{"name":"foo","revision":"2"}],"current":"2","jailmode":true}}}],"last-change-id":0,"last-task-id":0,"last-lane-id":0}` + "\n"
+	c.Assert(ioutil.WriteFile(path+".journal", []byte(journal), 0600), check.IsNil)
+
+	_, err := snap.Parser().ParseArgs([]string{"debug", "state-downgrade", "--to-level=5", path})
+	c.Assert(err, check.IsNil)
+
+	content, err := ioutil.ReadFile(path)
+	c.Assert(err, check.IsNil)
+	var st struct {
+		Data struct {
+			PatchLevel int `json:"patch-level"`
+			Snaps      map[string]struct {
+				Current string `json:"current"`
+				Flags   int    `json:"flags"`
+			} `json:"snaps"`
+		} `json:"data"`
+	}
+	c.Assert(json.Unmarshal(content, &st), check.IsNil)
+	c.Check(st.Data.PatchLevel, check.Equals, 5)
+	// the journaled snap state was downgraded
+	c.Check(st.Data.Snaps["foo"].Current, check.Equals, "2")
+	c.Check(st.Data.Snaps["foo"].Flags, check.Equals, 4)
+
+	_, err = os.Stat(path + ".journal")
+	c.Check(os.IsNotExist(err), check.Equals, true)
+}
+
+func (s *SnapSuite) TestDebugStateDowngradeErrors(c *check.C) {
+	path := filepath.Join(c.MkDir(), "state.json")
+	c.Assert(ioutil.WriteFile(path, statePatch6JSON, 0600), check.IsNil)
+
+	_, err := snap.Parser().ParseArgs([]string{"debug", "state-downgrade", path})
+	c.Check(err, check.ErrorMatches, `the required flag .*--to-level' was not specified`)
+
+	_, err = snap.Parser().ParseArgs([]string{"debug", "state-downgrade", "--to-level=7", path})
+	c.Check(err, check.ErrorMatches, `cannot downgrade system state at patch level 6 to higher level 7`)
+
+	content, err := ioutil.ReadFile(path)
+	c.Assert(err, check.IsNil)
+	c.Check(content, check.DeepEquals, statePatch6JSON)
+}
@@ -0,0 +1,213 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+var stateJSON = []byte(`
+{
+	"data": {
+		"snaps": {"foo": {"active": true, "sequence": [{"name": "foo", "revision": "1"}], "current": "1"}}
+	},
+	"changes": {
+		"1": {
+			"id": "1",
+			"kind": "install-snap",
+			"summary": "install a snap",
+			"status": 0,
+			"task-ids": ["11", "12"],
+			"spawn-time": "2017-11-03T12:00:00Z"
+		},
+		"2": {
+			"id": "2",
+			"kind": "remove-snap",
+			"summary": "remove a snap",
+			"status": 4,
+			"spawn-time": "2017-11-03T13:00:00Z",
+			"ready-time": "2017-11-03T13:00:05Z"
+		}
+	},
+	"tasks": {
+		"11": {
+			"id": "11",
+			"change": "1",
+			"kind": "download-snap",
+			"summary": "Download snap \"foo\"",
+			"status": 4,
+			"lanes": [1],
+			"halt-tasks": ["12"],
+			"log": ["2017-11-03T12:00:01Z INFO downloading"],
+			"spawn-time": "2017-11-03T12:00:00Z",
+			"ready-time": "2017-11-03T12:00:03Z"
+		},
+		"12": {
+			"id": "12",
+			"change": "1",
+			"kind": "mount-snap",
+			"summary": "Mount snap \"foo\"",
+			"status": 9,
+			"lanes": [1],
+			"wait-tasks": ["11"],
+			"spawn-time": "2017-11-03T12:00:00Z"
+		}
+	},
+	"last-change-id": 2,
+	"last-task-id": 12,
+	"last-lane-id": 1
+}`)
+
+func (s *SnapSuite) writeStateFile(c *check.C) string {
+	path := filepath.Join(c.MkDir(), "state.json")
+	c.Assert(ioutil.WriteFile(path, stateJSON, 0644), check.IsNil)
+	return path
+}
+
+func (s *SnapSuite) TestDebugStateChanges(c *check.C) {
+	path := s.writeStateFile(c)
+
+	rest, err := snap.Parser().ParseArgs([]string{"debug", "state", "--changes", path})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `ID   Status  Spawn                 Ready                 Kind          Summary
+1    Error   2017-11-03T12:00:00Z  -                     install-snap  install a snap
+2    Done    2017-11-03T13:00:00Z  2017-11-03T13:00:05Z  remove-snap   remove a snap
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestDebugStateChangesIsDefault(c *check.C) {
+	path := s.writeStateFile(c)
+
+	_, err := snap.Parser().ParseArgs([]string{"debug", "state", path})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Matches, `(?s)ID .*install a snap.*remove a snap\n`)
+}
+
+func (s *SnapSuite) TestDebugStateTasks(c *check.C) {
+	path := s.writeStateFile(c)
+
+	_, err := snap.Parser().ParseArgs([]string{"debug", "state", "--change=1", path})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, `Lanes  ID   Status  Spawn                 Ready                 Waits for  Kind           Summary
+1      11   Done    2017-11-03T12:00:00Z  2017-11-03T12:00:03Z  -          download-snap  Download snap "foo"
+1      12   Error   2017-11-03T12:00:00Z  -                     11         mount-snap     Mount snap "foo"
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestDebugStateDot(c *check.C) {
+	path := s.writeStateFile(c)
+
+	_, err := snap.Parser().ParseArgs([]string{"debug", "state", "--change=1", "--dot", path})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, `digraph "install-snap" {
+	"11" [label="11:download-snap"];
+	"12" [label="12:mount-snap"];
+	"12" -> "11";
+}
+`)
+}
+
+func (s *SnapSuite) TestDebugStateTask(c *check.C) {
+	path := s.writeStateFile(c)
+
+	_, err := snap.Parser().ParseArgs([]string{"debug", "state", "--task=11", path})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, `id: 11
+kind: download-snap
+summary: Download snap "foo"
+status: Done
+change: 1
+lanes: 1
+waits-for: -
+halts: 12
+log:
+  2017-11-03T12:00:01Z INFO downloading
+`)
+}
+
+func (s *SnapSuite) TestDebugStateData(c *check.C) {
+	path := s.writeStateFile(c)
+
+	_, err := snap.Parser().ParseArgs([]string{"debug", "state", "--key=snaps", path})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, `snaps: {
+  "foo": {
+    "active": true,
+    "sequence": [
+      {
+        "name": "foo",
+        "revision": "1"
+      }
+    ],
+    "current": "1"
+  }
+}
+`)
+
+	_, err = snap.Parser().ParseArgs([]string{"debug", "state", "--key=conns", path})
+	c.Check(err, check.ErrorMatches, `no data for key "conns" in state`)
+}
+
+func (s *SnapSuite) TestDebugStateErrors(c *check.C) {
+	path := s.writeStateFile(c)
+
+	for _, args := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--change=1", "--task=11"}, `cannot use --changes, --change, --task and --key together`},
+		{[]string{"--dot"}, `--dot can only be used with --change`},
+		{[]string{"--change=99"}, `no change with id "99" in state`},
+		{[]string{"--task=99"}, `no task with id "99" in state`},
+	} {
+		_, err := snap.Parser().ParseArgs(append(append([]string{"debug", "state"}, args.args...), path))
+		c.Check(err, check.ErrorMatches, args.err)
+	}
+
+	_, err := snap.Parser().ParseArgs([]string{"debug", "state", filepath.Join(c.MkDir(), "missing.json")})
+	c.Check(err, check.ErrorMatches, `cannot open state file: .*`)
+}
+
+func (s *SnapSuite) TestDebugStateReadsJournal(c *check.C) {
+	path := s.writeStateFile(c)
+	journal := `{"records":[{"s":"changes","k":"3","v":{"id":"3","kind":"refresh-snap","summary":"refresh a snap","status":2,"spawn-time":"2017-11-03T14:00:00Z"}}],"last-change-id":3,"last-task-id":12,"last-lane-id":1}` + "\n"
+	c.Assert(ioutil.WriteFile(path+".journal", []byte(journal), 0600), check.IsNil)
+
+	_, err := snap.Parser().ParseArgs([]string{"debug", "state", "--changes", path})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, `ID   Status  Spawn                 Ready                 Kind          Summary
+1    Error   2017-11-03T12:00:00Z  -                     install-snap  install a snap
+2    Done    2017-11-03T13:00:00Z  2017-11-03T13:00:05Z  remove-snap   remove a snap
+3    Do      2017-11-03T14:00:00Z  -                     refresh-snap  refresh a snap
+`)
+
+	// the journal is left alone
+	data, err := ioutil.ReadFile(path + ".journal")
+	c.Assert(err, check.IsNil)
+	c.Check(string(data), check.Equals, journal)
+}
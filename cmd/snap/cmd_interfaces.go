@@ -22,6 +22,7 @@ package main
 import (
 	"fmt"
 
+	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/i18n"
 
 	"github.com/jessevdk/go-flags"
@@ -32,6 +33,7 @@ type cmdInterfaces struct {
 	Positionals struct {
 		Query interfacesSlotOrPlugSpec `skip-help:"true"`
 	} `positional-args:"true"`
+	formatMixin
 }
 
 var shortInterfacesHelp = i18n.G("Lists interfaces in the system")
@@ -51,14 +53,16 @@ Lists the slots offered and plugs used by the specified snap.
 $ snap interfaces -i=<interface> [<snap>]
 
 Filters the complete output so only plugs and/or slots matching the provided details are listed.
+
+Connections made manually, or as requested by the gadget, are marked as such.
 `)
 
 func init() {
 	addCommand("interfaces", shortInterfacesHelp, longInterfacesHelp, func() flags.Commander {
 		return &cmdInterfaces{}
-	}, map[string]string{
+	}, formatDescs.also(map[string]string{
 		"i": i18n.G("Constrain listing to specific interfaces"),
-	}, []argDesc{{
+	}), []argDesc{{
 		name: i18n.G("<snap>:<slot or plug>"),
 		desc: i18n.G("Constrain listing to a specific snap or snap:name"),
 	}})
@@ -73,26 +77,18 @@ func (x *cmdInterfaces) Execute(args []string) error {
 	if err != nil {
 		return err
 	}
+	if x.structured() {
+		return x.printStructured(x.filter(ifaces))
+	}
 	if len(ifaces.Plugs) == 0 && len(ifaces.Slots) == 0 {
 		return fmt.Errorf(i18n.G("no interfaces found"))
 	}
+	origins := x.connectionOrigins(ifaces)
 	w := tabWriter()
 	defer w.Flush()
 	fmt.Fprintln(w, i18n.G("Slot\tPlug"))
 	for _, slot := range ifaces.Slots {
-		if wanted := x.Positionals.Query.Snap; wanted != "" {
-			ok := wanted == slot.Snap
-			for i := 0; i < len(slot.Connections) && !ok; i++ {
-				ok = wanted == slot.Connections[i].Snap
-			}
-			if !ok {
-				continue
-			}
-		}
-		if x.Positionals.Query.Name != "" && x.Positionals.Query.Name != slot.Name {
-			continue
-		}
-		if x.Interface != "" && slot.Interface != x.Interface {
+		if !x.wantSlot(&slot) {
 			continue
 		}
 		// The OS snap is special and enable abbreviated
@@ -111,6 +107,11 @@ func (x *cmdInterfaces) Execute(args []string) error {
 			} else {
 				fmt.Fprintf(w, "%s", slot.Connections[i].Snap)
 			}
+			// Automatic connections are the norm, tell the others apart.
+			origin := origins[connectionKey(slot.Connections[i].Snap, slot.Connections[i].Name, slot.Snap, slot.Name)]
+			if origin == "manual" || origin == "gadget" {
+				fmt.Fprintf(w, " (%s)", origin)
+			}
 		}
 		// Display visual indicator for disconnected slots
 		if len(slot.Connections) == 0 {
@@ -121,13 +122,7 @@ func (x *cmdInterfaces) Execute(args []string) error {
 	// Plugs are treated differently. Since the loop above already printed each connected
 	// plug, the loop below focuses on printing just the disconnected plugs.
 	for _, plug := range ifaces.Plugs {
-		if x.Positionals.Query.Snap != "" && x.Positionals.Query.Snap != plug.Snap {
-			continue
-		}
-		if x.Positionals.Query.Name != "" && x.Positionals.Query.Name != plug.Name {
-			continue
-		}
-		if x.Interface != "" && plug.Interface != x.Interface {
+		if !x.wantPlug(&plug) {
 			continue
 		}
 		// Display visual indicator for disconnected plugs.
@@ -137,3 +132,88 @@ func (x *cmdInterfaces) Execute(args []string) error {
 	}
 	return nil
 }
+
+func connectionKey(plugSnap, plugName, slotSnap, slotName string) string {
+	return fmt.Sprintf("%s:%s %s:%s", plugSnap, plugName, slotSnap, slotName)
+}
+
+// connectionOrigins returns how the listed connections were made, keyed
+// by connectionKey.
+func (x *cmdInterfaces) connectionOrigins(ifaces client.Connections) map[string]string {
+	connected := false
+	for _, slot := range ifaces.Slots {
+		if len(slot.Connections) > 0 {
+			connected = true
+			break
+		}
+	}
+	if !connected {
+		return nil
+	}
+	conns, err := Client().ListConnections(nil)
+	if err != nil {
+		// an older snapd does not know where connections come from,
+		// list them without it
+		return nil
+	}
+	origins := make(map[string]string, len(conns))
+	for _, conn := range conns {
+		origins[connectionKey(conn.Plug.Snap, conn.Plug.Name, conn.Slot.Snap, conn.Slot.Name)] = conn.Origin
+	}
+	return origins
+}
+
+// wantSlot returns whether the slot matches the query and interface given
+// on the command line.
+func (x *cmdInterfaces) wantSlot(slot *client.Slot) bool {
+	if wanted := x.Positionals.Query.Snap; wanted != "" {
+		ok := wanted == slot.Snap
+		for i := 0; i < len(slot.Connections) && !ok; i++ {
+			ok = wanted == slot.Connections[i].Snap
+		}
+		if !ok {
+			return false
+		}
+	}
+	if x.Positionals.Query.Name != "" && x.Positionals.Query.Name != slot.Name {
+		return false
+	}
+	if x.Interface != "" && slot.Interface != x.Interface {
+		return false
+	}
+	return true
+}
+
+// wantPlug returns whether the plug matches the query and interface given
+// on the command line.
+func (x *cmdInterfaces) wantPlug(plug *client.Plug) bool {
+	if x.Positionals.Query.Snap != "" && x.Positionals.Query.Snap != plug.Snap {
+		return false
+	}
+	if x.Positionals.Query.Name != "" && x.Positionals.Query.Name != plug.Name {
+		return false
+	}
+	if x.Interface != "" && plug.Interface != x.Interface {
+		return false
+	}
+	return true
+}
+
+// filter returns the slots and plugs of conns wanted by the command line.
+func (x *cmdInterfaces) filter(conns client.Connections) client.Connections {
+	filtered := client.Connections{
+		Plugs: []client.Plug{},
+		Slots: []client.Slot{},
+	}
+	for _, slot := range conns.Slots {
+		if x.wantSlot(&slot) {
+			filtered.Slots = append(filtered.Slots, slot)
+		}
+	}
+	for _, plug := range conns.Plugs {
+		if x.wantPlug(&plug) {
+			filtered.Plugs = append(filtered.Plugs, plug)
+		}
+	}
+	return filtered
+}
@@ -20,6 +20,7 @@
 package main_test
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -93,6 +94,13 @@ func (s *SnapSuite) TestConnectionsZeroPlugsOneSlot(c *C) {
 
 func (s *SnapSuite) TestConnectionsOneSlotOnePlug(c *C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/connections" {
+			EncodeResponseBody(c, w, map[string]interface{}{
+				"type":   "sync",
+				"result": []client.Connection{},
+			})
+			return
+		}
 		c.Check(r.Method, Equals, "GET")
 		c.Check(r.URL.Path, Equals, "/v2/interfaces")
 		body, err := ioutil.ReadAll(r.Body)
@@ -158,8 +166,59 @@ func (s *SnapSuite) TestConnectionsOneSlotOnePlug(c *C) {
 	c.Assert(s.Stderr(), Equals, "")
 }
 
+func (s *SnapSuite) TestConnectionsFormatJSON(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		EncodeResponseBody(c, w, map[string]interface{}{
+			"type": "sync",
+			"result": client.Connections{
+				Slots: []client.Slot{
+					{
+						Snap:      "canonical-pi2",
+						Name:      "pin-13",
+						Interface: "bool-file",
+					},
+					{
+						Snap:      "core",
+						Name:      "network",
+						Interface: "network",
+					},
+				},
+				Plugs: []client.Plug{
+					{
+						Snap:      "keyboard-lights",
+						Name:      "capslock-led",
+						Interface: "bool-file",
+					},
+				},
+			},
+		})
+	})
+	rest, err := Parser().ParseArgs([]string{"interfaces", "--format=json", "-i", "bool-file"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	var conns client.Connections
+	c.Assert(json.Unmarshal(s.stdout.Bytes(), &conns), IsNil)
+	c.Check(conns, DeepEquals, client.Connections{
+		Slots: []client.Slot{{Snap: "canonical-pi2", Name: "pin-13", Interface: "bool-file"}},
+		Plugs: []client.Plug{{Snap: "keyboard-lights", Name: "capslock-led", Interface: "bool-file"}},
+	})
+	c.Assert(s.Stderr(), Equals, "")
+
+	s.SetUpTest(c)
+	_, err = Parser().ParseArgs([]string{"interfaces", "--format=json", "-i", "foo"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, "{\n  \"plugs\": [],\n  \"slots\": []\n}\n")
+}
+
 func (s *SnapSuite) TestConnectionsTwoPlugs(c *C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/connections" {
+			EncodeResponseBody(c, w, map[string]interface{}{
+				"type":   "sync",
+				"result": []client.Connection{},
+			})
+			return
+		}
 		c.Check(r.Method, Equals, "GET")
 		c.Check(r.URL.Path, Equals, "/v2/interfaces")
 		body, err := ioutil.ReadAll(r.Body)
@@ -201,6 +260,13 @@ func (s *SnapSuite) TestConnectionsTwoPlugs(c *C) {
 
 func (s *SnapSuite) TestConnectionsPlugsWithCommonName(c *C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/connections" {
+			EncodeResponseBody(c, w, map[string]interface{}{
+				"type":   "sync",
+				"result": []client.Connection{},
+			})
+			return
+		}
 		c.Check(r.Method, Equals, "GET")
 		c.Check(r.URL.Path, Equals, "/v2/interfaces")
 		body, err := ioutil.ReadAll(r.Body)
@@ -268,6 +334,13 @@ func (s *SnapSuite) TestConnectionsPlugsWithCommonName(c *C) {
 
 func (s *SnapSuite) TestConnectionsOsSnapSlots(c *C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/connections" {
+			EncodeResponseBody(c, w, map[string]interface{}{
+				"type":   "sync",
+				"result": []client.Connection{},
+			})
+			return
+		}
 		c.Check(r.Method, Equals, "GET")
 		c.Check(r.URL.Path, Equals, "/v2/interfaces")
 		body, err := ioutil.ReadAll(r.Body)
@@ -335,6 +408,13 @@ func (s *SnapSuite) TestConnectionsOsSnapSlots(c *C) {
 
 func (s *SnapSuite) TestConnectionsTwoSlotsAndFiltering(c *C) {
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/connections" {
+			EncodeResponseBody(c, w, map[string]interface{}{
+				"type":   "sync",
+				"result": []client.Connection{},
+			})
+			return
+		}
 		c.Check(r.Method, Equals, "GET")
 		c.Check(r.URL.Path, Equals, "/v2/interfaces")
 		body, err := ioutil.ReadAll(r.Body)
@@ -572,3 +652,69 @@ func (s *SnapSuite) TestConnectionsCompletion(c *C) {
 	c.Assert(s.Stdout(), Equals, "")
 	c.Assert(s.Stderr(), Equals, "")
 }
+
+func (s *SnapSuite) TestConnectionsShowsOrigin(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		switch r.URL.Path {
+		case "/v2/interfaces":
+			EncodeResponseBody(c, w, map[string]interface{}{
+				"type": "sync",
+				"result": client.Connections{
+					Slots: []client.Slot{
+						{
+							Snap:      "core",
+							Name:      "network",
+							Interface: "network",
+							Connections: []client.PlugRef{
+								{Snap: "foo", Name: "network"},
+								{Snap: "bar", Name: "net"},
+							},
+						},
+						{
+							Snap:      "canonical-pi2",
+							Name:      "pin-13",
+							Interface: "bool-file",
+							Connections: []client.PlugRef{
+								{Snap: "keyboard-lights", Name: "capslock-led"},
+							},
+						},
+					},
+				},
+			})
+		case "/v2/connections":
+			c.Check(r.URL.RawQuery, Equals, "")
+			EncodeResponseBody(c, w, map[string]interface{}{
+				"type": "sync",
+				"result": []client.Connection{
+					{
+						Plug:   client.PlugRef{Snap: "foo", Name: "network"},
+						Slot:   client.SlotRef{Snap: "core", Name: "network"},
+						Origin: "auto",
+					},
+					{
+						Plug:   client.PlugRef{Snap: "bar", Name: "net"},
+						Slot:   client.SlotRef{Snap: "core", Name: "network"},
+						Origin: "manual",
+					},
+					{
+						Plug:   client.PlugRef{Snap: "keyboard-lights", Name: "capslock-led"},
+						Slot:   client.SlotRef{Snap: "canonical-pi2", Name: "pin-13"},
+						Origin: "gadget",
+					},
+				},
+			})
+		default:
+			c.Fatalf("unexpected path %q", r.URL.Path)
+		}
+	})
+	rest, err := Parser().ParseArgs([]string{"interfaces"})
+	c.Assert(err, IsNil)
+	c.Assert(rest, DeepEquals, []string{})
+	expectedStdout := "" +
+		"Slot                  Plug\n" +
+		":network              foo,bar:net (manual)\n" +
+		"canonical-pi2:pin-13  keyboard-lights:capslock-led (gadget)\n"
+	c.Assert(s.Stdout(), Equals, expectedStdout)
+	c.Assert(s.Stderr(), Equals, "")
+}
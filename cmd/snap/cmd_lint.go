@@ -0,0 +1,73 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/snap/lint"
+)
+
+var shortLintHelp = i18n.G("Look for packaging problems in a snap")
+var longLintHelp = i18n.G(`
+The lint command looks for common packaging problems in the given snap
+file or snap directory: invalid snap.yaml content, app commands and hooks
+that are missing or not executable, unknown interfaces, plugs and slots
+that will need manual review in the store, desktop files with bad Exec
+lines, oversized icons and invalid SPDX license expressions.
+
+The command fails if any problem is found.
+`)
+
+type cmdLint struct {
+	Positional struct {
+		Snap string `positional-arg-name:"<snap>" required:"yes"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	addCommand("lint", shortLintHelp, longLintHelp, func() flags.Commander { return &cmdLint{} }, nil, []argDesc{{
+		name: i18n.G("<snap>"),
+		desc: i18n.G("Snap file or directory to check"),
+	}})
+}
+
+func (x *cmdLint) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	problems, err := lint.Snap(x.Positional.Snap)
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot lint %q: %v"), x.Positional.Snap, err)
+	}
+	if len(problems) == 0 {
+		fmt.Fprintln(Stderr, i18n.G("No problems found."))
+		return nil
+	}
+
+	for _, p := range problems {
+		fmt.Fprintln(Stdout, p)
+	}
+	return fmt.Errorf(i18n.NG("found %d problem", "found %d problems", uint32(len(problems))), len(problems))
+}
@@ -0,0 +1,50 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) TestLintNoProblems(c *check.C) {
+	snapDir := makeSnapDirForPack(c, "name: hello\nversion: 1.0\n")
+
+	_, err := snap.Parser().ParseArgs([]string{"lint", snapDir})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "")
+	c.Check(s.Stderr(), check.Equals, "No problems found.\n")
+}
+
+func (s *SnapSuite) TestLintProblems(c *check.C) {
+	snapDir := makeSnapDirForPack(c, "name: hello\nversion: 1.0\nlicense: foo\napps:\n hello:\n  command: bin/hello\n")
+
+	_, err := snap.Parser().ParseArgs([]string{"lint", snapDir})
+	c.Assert(err, check.ErrorMatches, "found 2 problems")
+	c.Check(s.Stdout(), check.Equals, `license: invalid SPDX license expression "foo": unknown license: foo
+apps: app "hello": bin/hello does not exist
+`)
+}
+
+func (s *SnapSuite) TestLintCannotRead(c *check.C) {
+	_, err := snap.Parser().ParseArgs([]string{"lint", c.MkDir()})
+	c.Assert(err, check.ErrorMatches, `cannot lint ".*": open .*/meta/snap.yaml: no such file or directory`)
+}
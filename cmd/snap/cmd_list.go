@@ -41,11 +41,12 @@ type cmdList struct {
 	} `positional-args:"yes"`
 
 	All bool `long:"all"`
+	formatMixin
 }
 
 func init() {
 	addCommand("list", shortListHelp, longListHelp, func() flags.Commander { return &cmdList{} },
-		map[string]string{"all": i18n.G("Show all revisions")}, nil)
+		formatDescs.also(map[string]string{"all": i18n.G("Show all revisions")}), nil)
 }
 
 type snapsByName []*client.Snap
@@ -64,17 +65,20 @@ func (x *cmdList) Execute(args []string) error {
 		names[i] = string(name)
 	}
 
-	return listSnaps(names, x.All)
+	return listSnaps(names, x.All, x.formatMixin)
 }
 
 var ErrNoMatchingSnaps = errors.New(i18n.G("no matching snaps installed"))
 
-func listSnaps(names []string, all bool) error {
+func listSnaps(names []string, all bool, format formatMixin) error {
 	cli := Client()
 	snaps, err := cli.List(names, &client.ListOptions{All: all})
 	if err != nil {
 		if err == client.ErrNoSnapsInstalled {
 			if len(names) == 0 {
+				if format.structured() {
+					return format.printStructured([]*client.Snap{})
+				}
 				fmt.Fprintln(Stderr, i18n.G("No snaps are installed yet. Try \"snap install hello-world\"."))
 				return nil
 			} else {
@@ -87,6 +91,10 @@ func listSnaps(names []string, all bool) error {
 	}
 	sort.Sort(snapsByName(snaps))
 
+	if format.structured() {
+		return format.printStructured(snaps)
+	}
+
 	w := tabWriter()
 	defer w.Flush()
 
@@ -20,10 +20,12 @@
 package main_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"gopkg.in/check.v1"
+	"gopkg.in/yaml.v2"
 
 	snap "github.com/snapcore/snapd/cmd/snap"
 )
@@ -35,13 +37,15 @@ func (s *SnapSuite) TestListHelp(c *check.C) {
 The list command displays a summary of snaps installed in the current system.
 
 Application Options:
-      --version     Print the version and exit
+      --version                     Print the version and exit
 
 Help Options:
-  -h, --help        Show this help message
+  -h, --help                        Show this help message
 
 [list command options]
-          --all     Show all revisions
+          --all                     Show all revisions
+          --format=[text|json|yaml] Print the output in the given format
+                                    (default: text)
 `
 	rest, err := snap.Parser().ParseArgs([]string{"list", "--help"})
 	c.Assert(err.Error(), check.Equals, msg)
@@ -72,6 +76,55 @@ foo +4.2 +17 +bar +-
 	c.Check(s.Stderr(), check.Equals, "")
 }
 
+func (s *SnapSuite) TestListFormatJSON(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": [{"name": "foo", "status": "active", "version": "4.2", "developer": "bar", "revision":17}]}`)
+	})
+	rest, err := snap.Parser().ParseArgs([]string{"list", "--format=json"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+
+	var snaps []map[string]interface{}
+	c.Assert(json.Unmarshal(s.stdout.Bytes(), &snaps), check.IsNil)
+	c.Assert(snaps, check.HasLen, 1)
+	c.Check(snaps[0]["name"], check.Equals, "foo")
+	c.Check(snaps[0]["version"], check.Equals, "4.2")
+	c.Check(snaps[0]["revision"], check.Equals, "17")
+	c.Check(snaps[0]["developer"], check.Equals, "bar")
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestListFormatYAML(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": [{"name": "foo", "status": "active", "version": "4.2", "developer": "bar", "revision":17, "installed-size": 12345678}]}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"list", "--format=yaml"})
+	c.Assert(err, check.IsNil)
+
+	var snaps []map[string]interface{}
+	c.Assert(yaml.Unmarshal(s.stdout.Bytes(), &snaps), check.IsNil)
+	c.Assert(snaps, check.HasLen, 1)
+	c.Check(snaps[0]["name"], check.Equals, "foo")
+	c.Check(snaps[0]["revision"], check.Equals, "17")
+	c.Check(snaps[0]["installed-size"], check.Equals, 12345678)
+	c.Check(s.Stdout(), check.Matches, `(?ms).*^  installed-size: 12345678$.*`)
+}
+
+func (s *SnapSuite) TestListFormatJSONNoSnaps(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+	_, err := snap.Parser().ParseArgs([]string{"list", "--format=json"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Equals, "[]\n")
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestListFormatInvalid(c *check.C) {
+	_, err := snap.Parser().ParseArgs([]string{"list", "--format=xml"})
+	c.Assert(err, check.ErrorMatches, "Invalid value `xml' for option `--format'.*")
+}
+
 func (s *SnapSuite) TestListAll(c *check.C) {
 	n := 0
 	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,130 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/pack"
+)
+
+var shortPackHelp = i18n.G("Pack the given directory as a snap")
+var longPackHelp = i18n.G(`
+The pack command checks the snap in the given directory, including that
+the commands of its apps and its hooks are executable, and packs it into
+a name_version_architecture.snap file in the target directory, or the
+current directory if no target directory is given.
+
+Packing the same directory twice results in identical snap files.
+`)
+
+var shortUnpackHelp = i18n.G("Unpack the given snap file")
+var longUnpackHelp = i18n.G(`
+The unpack command extracts the content of the given snap file into the
+given directory, which must not exist yet. By default it is named after
+the snap file, without the .snap extension.
+`)
+
+type cmdPack struct {
+	Positional struct {
+		SnapDir   string `positional-arg-name:"<snap-dir>" required:"yes"`
+		TargetDir string `positional-arg-name:"<target-dir>"`
+	} `positional-args:"yes"`
+}
+
+type cmdUnpack struct {
+	Positional struct {
+		SnapFile string `positional-arg-name:"<snap-file>" required:"yes"`
+		DestDir  string `positional-arg-name:"<dest-dir>"`
+	} `positional-args:"yes"`
+}
+
+func init() {
+	addCommand("pack", shortPackHelp, longPackHelp, func() flags.Commander { return &cmdPack{} }, nil, []argDesc{{
+		name: i18n.G("<snap-dir>"),
+		desc: i18n.G("Directory with the snap to pack"),
+	}, {
+		name: i18n.G("<target-dir>"),
+		desc: i18n.G("Directory to write the snap file to"),
+	}})
+	addCommand("unpack", shortUnpackHelp, longUnpackHelp, func() flags.Commander { return &cmdUnpack{} }, nil, []argDesc{{
+		name: i18n.G("<snap-file>"),
+		desc: i18n.G("Snap file to unpack"),
+	}, {
+		name: i18n.G("<dest-dir>"),
+		desc: i18n.G("Directory to unpack the snap into"),
+	}})
+}
+
+func (x *cmdPack) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	if _, err := pack.CheckSnap(x.Positional.SnapDir); err != nil {
+		return fmt.Errorf(i18n.G("cannot pack %q: %v"), x.Positional.SnapDir, err)
+	}
+
+	snapPath, err := pack.Snap(x.Positional.SnapDir, x.Positional.TargetDir)
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot pack %q: %v"), x.Positional.SnapDir, err)
+	}
+
+	// TRANSLATORS: %s is the path to the built snap file
+	fmt.Fprintf(Stdout, i18n.G("built: %s\n"), snapPath)
+	return nil
+}
+
+func (x *cmdUnpack) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	snapFile := x.Positional.SnapFile
+	destDir := x.Positional.DestDir
+	if destDir == "" {
+		destDir = strings.TrimSuffix(filepath.Base(snapFile), ".snap")
+	}
+	if osutil.FileExists(destDir) {
+		return fmt.Errorf(i18n.G("cannot unpack %q: %q already exists"), snapFile, destDir)
+	}
+
+	if osutil.IsDirectory(snapFile) {
+		return fmt.Errorf(i18n.G("cannot unpack %q: not a snap file"), snapFile)
+	}
+	snapf, err := snap.Open(snapFile)
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot unpack %q: %v"), snapFile, err)
+	}
+	if err := snapf.Unpack("*", destDir); err != nil {
+		return fmt.Errorf(i18n.G("cannot unpack %q: %v"), snapFile, err)
+	}
+
+	// TRANSLATORS: %s is the directory the snap was unpacked into
+	fmt.Fprintf(Stdout, i18n.G("unpacked into: %s\n"), destDir)
+	return nil
+}
@@ -0,0 +1,80 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func makeSnapDirForPack(c *check.C, snapYaml string) string {
+	snapDir := c.MkDir()
+	c.Assert(os.Mkdir(filepath.Join(snapDir, "meta"), 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(snapDir, "meta", "snap.yaml"), []byte(snapYaml), 0644), check.IsNil)
+	return snapDir
+}
+
+func (s *SnapSuite) TestPackChecksSnapYaml(c *check.C) {
+	snapDir := makeSnapDirForPack(c, "name: hello\nversion: 1.0\nconfinement: whatever\n")
+
+	_, err := snap.Parser().ParseArgs([]string{"pack", snapDir})
+	c.Assert(err, check.ErrorMatches, `cannot pack ".*": .*confinement.*`)
+	c.Check(s.Stdout(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestPackChecksApps(c *check.C) {
+	snapDir := makeSnapDirForPack(c, "name: hello\nversion: 1.0\napps:\n hello:\n  command: bin/hello\n")
+
+	_, err := snap.Parser().ParseArgs([]string{"pack", snapDir})
+	c.Assert(err, check.ErrorMatches, `cannot pack ".*": snap is unusable due to bad app "hello": bin/hello does not exist`)
+	c.Check(s.Stdout(), check.Equals, "")
+}
+
+func (s *SnapSuite) TestUnpackNotASnap(c *check.C) {
+	tmp := c.MkDir()
+	notASnap := filepath.Join(tmp, "foo.snap")
+	c.Assert(ioutil.WriteFile(notASnap, []byte("not a squashfs image at all"), 0644), check.IsNil)
+
+	_, err := snap.Parser().ParseArgs([]string{"unpack", notASnap, filepath.Join(tmp, "dest")})
+	c.Assert(err, check.ErrorMatches, `cannot unpack ".*/foo.snap": cannot open snap: unknown header: .*`)
+
+	_, err = snap.Parser().ParseArgs([]string{"unpack", tmp, filepath.Join(tmp, "dest")})
+	c.Assert(err, check.ErrorMatches, `cannot unpack ".*": not a snap file`)
+}
+
+func (s *SnapSuite) TestUnpackDestinationExists(c *check.C) {
+	tmp := c.MkDir()
+	snapFile := filepath.Join(tmp, "foo_1.0_all.snap")
+	c.Assert(ioutil.WriteFile(snapFile, []byte("hsqs"), 0644), check.IsNil)
+	c.Assert(os.Mkdir(filepath.Join(tmp, "foo_1.0_all"), 0755), check.IsNil)
+
+	pwd, err := os.Getwd()
+	c.Assert(err, check.IsNil)
+	defer os.Chdir(pwd)
+	c.Assert(os.Chdir(tmp), check.IsNil)
+
+	_, err = snap.Parser().ParseArgs([]string{"unpack", snapFile})
+	c.Assert(err, check.ErrorMatches, `cannot unpack ".*/foo_1.0_all.snap": "foo_1.0_all" already exists`)
+}
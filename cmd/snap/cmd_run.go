@@ -20,11 +20,16 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"os/user"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 
@@ -50,6 +55,8 @@ type cmdRun struct {
 	Hook     string `long:"hook" hidden:"yes"`
 	Revision string `short:"r" default:"unset" hidden:"yes"`
 	Shell    bool   `long:"shell" `
+	Strace   string `long:"strace" optional:"true" optional-value:"with-strace" default:"no-strace" default-mask:"-"`
+	Gdb      bool   `long:"gdb"`
 }
 
 func init() {
@@ -63,6 +70,8 @@ func init() {
 			"hook":    i18n.G("Hook to run"),
 			"r":       i18n.G("Use a specific snap revision when running hook"),
 			"shell":   i18n.G("Run a shell instead of the command (useful for debugging)"),
+			"strace":  i18n.G("Run the command under strace (useful for debugging). Extra strace options can be specified as well here."),
+			"gdb":     i18n.G("Run the command with gdbserver attached (useful for debugging)"),
 		}, nil)
 }
 
@@ -84,10 +93,13 @@ func (x *cmdRun) Execute(args []string) error {
 		// TRANSLATORS: %q is the hook name; %s a space-separated list of extra arguments
 		return fmt.Errorf(i18n.G("too many arguments for hook %q: %s"), x.Hook, strings.Join(args, " "))
 	}
+	if x.useStrace() && x.Gdb {
+		return fmt.Errorf(i18n.G("cannot use --strace and --gdb together"))
+	}
 
 	// Now actually handle the dispatching
 	if x.Hook != "" {
-		return snapRunHook(snapApp, x.Revision, x.Hook)
+		return x.snapRunHook(snapApp, x.Revision, x.Hook)
 	}
 
 	// pass shell as a special command to snap-exec
@@ -95,7 +107,7 @@ func (x *cmdRun) Execute(args []string) error {
 		x.Command = "shell"
 	}
 
-	return snapRunApp(snapApp, x.Command, args)
+	return x.snapRunApp(snapApp, x.Command, args)
 }
 
 func getSnapInfo(snapName string, revision snap.Revision) (*snap.Info, error) {
@@ -185,7 +197,7 @@ func createUserDataDirs(info *snap.Info) error {
 	return createOrUpdateUserDataSymlink(info, usr)
 }
 
-func snapRunApp(snapApp, command string, args []string) error {
+func (x *cmdRun) snapRunApp(snapApp, command string, args []string) error {
 	snapName, appName := snap.SplitSnapApp(snapApp)
 	info, err := getSnapInfo(snapName, snap.R(0))
 	if err != nil {
@@ -197,10 +209,10 @@ func snapRunApp(snapApp, command string, args []string) error {
 		return fmt.Errorf(i18n.G("cannot find app %q in %q"), appName, snapName)
 	}
 
-	return runSnapConfine(info, app.SecurityTag(), snapApp, command, "", args)
+	return x.runSnapConfine(info, app.SecurityTag(), snapApp, command, "", args)
 }
 
-func snapRunHook(snapName, snapRevision, hookName string) error {
+func (x *cmdRun) snapRunHook(snapName, snapRevision, hookName string) error {
 	revision, err := snap.ParseRevision(snapRevision)
 	if err != nil {
 		return err
@@ -216,7 +228,7 @@ func snapRunHook(snapName, snapRevision, hookName string) error {
 		return fmt.Errorf(i18n.G("cannot find hook %q in %q"), hookName, snapName)
 	}
 
-	return runSnapConfine(info, hook.SecurityTag(), snapName, "", hook.Name, nil)
+	return x.runSnapConfine(info, hook.SecurityTag(), snapName, "", hook.Name, nil)
 }
 
 var osReadlink = os.Readlink
@@ -363,7 +375,7 @@ func migrateXauthority(info *snap.Info) (string, error) {
 	return targetPath, nil
 }
 
-func runSnapConfine(info *snap.Info, securityTag, snapApp, command, hook string, args []string) error {
+func (x *cmdRun) runSnapConfine(info *snap.Info, securityTag, snapApp, command, hook string, args []string) error {
 	snapConfine := filepath.Join(dirs.DistroLibExecDir, "snap-confine")
 	// if we re-exec, we must run the snap-confine from the core snap
 	// as well, if they get out of sync, havoc will happen
@@ -409,6 +421,10 @@ func runSnapConfine(info *snap.Info, securityTag, snapApp, command, hook string,
 		cmd = append(cmd, "--hook="+hook)
 	}
 
+	if x.Gdb {
+		cmd = append(cmd, "--stop-before-exec")
+	}
+
 	// snap-exec is POSIXly-- options must come before positionals.
 	cmd = append(cmd, snapApp)
 	cmd = append(cmd, args...)
@@ -419,5 +435,185 @@ func runSnapConfine(info *snap.Info, securityTag, snapApp, command, hook string,
 	}
 	env := snapenv.ExecEnv(info, extraEnv)
 
+	if x.useStrace() {
+		return x.runCmdUnderStrace(cmd, env)
+	}
+	if x.Gdb {
+		return runCmdWithGdbserver(cmd, env)
+	}
+
 	return syscallExec(cmd[0], cmd, env)
 }
+
+func (x *cmdRun) useStrace() bool {
+	// Strace is empty when cmdRun was not filled in by the parser
+	return x.Strace != "" && x.Strace != "no-strace"
+}
+
+// straceCmd returns the command that runs the given command under strace
+// as the current user. strace itself is run as root through sudo so that
+// it can follow the setuid snap-confine.
+func straceCmd(extraStraceOpts string) ([]string, error) {
+	current, err := userCurrent()
+	if err != nil {
+		return nil, err
+	}
+	sudoPath, err := exec.LookPath("sudo")
+	if err != nil {
+		return nil, fmt.Errorf(i18n.G("cannot use strace without sudo: %s"), err)
+	}
+	stracePath, err := exec.LookPath("strace")
+	if err != nil {
+		return nil, fmt.Errorf(i18n.G("cannot find an installed strace: %s"), err)
+	}
+
+	cmd := []string{
+		sudoPath, "-E",
+		stracePath,
+		"-u", current.Username,
+		"-f",
+		// these syscalls are excluded because they make strace hang
+		// on all or some architectures (gdb too)
+		"-e", "!select,pselect6,_newselect,clock_gettime",
+	}
+	if extraStraceOpts != "with-strace" {
+		cmd = append(cmd, strings.Fields(extraStraceOpts)...)
+	}
+	return cmd, nil
+}
+
+func (x *cmdRun) runCmdUnderStrace(origCmd, env []string) error {
+	cmd, err := straceCmd(x.Strace)
+	if err != nil {
+		return err
+	}
+	cmd = append(cmd, origCmd...)
+
+	gcmd := exec.Command(cmd[0], cmd[1:]...)
+	gcmd.Env = env
+	gcmd.Stdin = Stdin
+	gcmd.Stdout = Stdout
+	stderr, err := gcmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := gcmd.Start(); err != nil {
+		return err
+	}
+
+	denied := filterStraceOutput(stderr, Stderr)
+	err = gcmd.Wait()
+	printDeniedSyscalls(denied)
+	return err
+}
+
+// deniedSyscall matches the strace lines of syscalls that failed with
+// EPERM, which is what seccomp makes denied syscalls return.
+var deniedSyscall = regexp.MustCompile(`^(?:\[pid\s+\d+\]\s+)?(?:<\.\.\. (\w+) resumed>|(\w+)\().*= -1 EPERM\b`)
+
+// filterStraceOutput copies the strace output from r to w, leaving out
+// the setup done by snap-confine and snap-exec, and returns how many
+// times each syscall failed with EPERM once the snap command runs.
+func filterStraceOutput(r io.Reader, w io.Writer) map[string]int {
+	denied := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+
+	// The first thing strace prints when things work is the execve()
+	// of snap-confine; show everything before it so that errors from
+	// strace itself are not swallowed.
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, "execve(") {
+			break
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	// The last thing snap-exec does is to execve() something in the
+	// snap mount dir, from then on the output is the snap's.
+	needle := fmt.Sprintf(`execve("%s`, dirs.SnapMountDir)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, needle) {
+			fmt.Fprintln(w, line)
+			break
+		}
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		fmt.Fprintln(w, line)
+		if m := deniedSyscall.FindStringSubmatch(line); m != nil {
+			denied[m[1]+m[2]]++
+		}
+	}
+
+	return denied
+}
+
+func printDeniedSyscalls(denied map[string]int) {
+	if len(denied) == 0 {
+		return
+	}
+	names := make([]string, 0, len(denied))
+	for name := range denied {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(Stderr, i18n.G("Syscalls that failed with EPERM, probably denied by seccomp:"))
+	for _, name := range names {
+		fmt.Fprintf(Stderr, "  %s: %d\n", name, denied[name])
+	}
+}
+
+// runCmdWithGdbserver runs the given command, which makes snap-exec stop
+// itself right before running the snap command, and attaches gdbserver
+// to it at that point.
+func runCmdWithGdbserver(origCmd, env []string) error {
+	sudoPath, err := exec.LookPath("sudo")
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot use gdbserver without sudo: %s"), err)
+	}
+	gdbserverPath, err := exec.LookPath("gdbserver")
+	if err != nil {
+		return fmt.Errorf(i18n.G("cannot find an installed gdbserver: %s"), err)
+	}
+
+	fmt.Fprintf(Stderr, i18n.G(`The snap command will stop right before it runs. Connect gdb with
+"target remote :<port>" using the port gdbserver reports below.
+`))
+
+	gcmd := exec.Command(origCmd[0], origCmd[1:]...)
+	gcmd.Env = env
+	gcmd.Stdin = Stdin
+	gcmd.Stdout = Stdout
+	gcmd.Stderr = Stderr
+	if err := gcmd.Start(); err != nil {
+		return err
+	}
+	pid := gcmd.Process.Pid
+
+	var ws syscall.WaitStatus
+	if _, err := syscall.Wait4(pid, &ws, syscall.WUNTRACED, nil); err != nil {
+		gcmd.Process.Kill()
+		gcmd.Wait()
+		return fmt.Errorf(i18n.G("cannot wait for the command to start: %s"), err)
+	}
+	if !ws.Stopped() {
+		// the process was reaped already, gcmd.Wait() would fail
+		return fmt.Errorf(i18n.G("cannot attach gdbserver: the command exited before it could be debugged"))
+	}
+
+	gdbserver := exec.Command(sudoPath, gdbserverPath, "--attach", ":0", strconv.Itoa(pid))
+	gdbserver.Stdin = Stdin
+	gdbserver.Stdout = Stdout
+	gdbserver.Stderr = Stderr
+	if err := gdbserver.Run(); err != nil {
+		gcmd.Process.Kill()
+		gcmd.Wait()
+		return fmt.Errorf(i18n.G("cannot run gdbserver: %s"), err)
+	}
+
+	return gcmd.Wait()
+}
@@ -20,10 +20,13 @@
 package main_test
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/user"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/check.v1"
 
@@ -538,3 +541,114 @@ func (s *SnapSuite) TestSnapRunXauthorityMigration(c *check.C) {
 	err = x11.ValidateXauthorityFile(expectedXauthPath)
 	c.Assert(err, check.IsNil)
 }
+
+func (s *SnapSuite) TestSnapRunStraceAndGdbTogether(c *check.C) {
+	_, err := snaprun.Parser().ParseArgs([]string{"run", "--strace", "--gdb", "snapname.app"})
+	c.Check(err, check.ErrorMatches, "cannot use --strace and --gdb together")
+}
+
+func (s *SnapSuite) TestSnapRunAppWithStraceIntegration(c *check.C) {
+	defer mockSnapConfine(dirs.DistroLibExecDir)()
+
+	// mock installed snap
+	si := snaptest.MockSnap(c, string(mockYaml), string(mockContents), &snap.SideInfo{
+		Revision: snap.R("x2"),
+	})
+	err := os.Symlink(si.MountDir(), filepath.Join(si.MountDir(), "../current"))
+	c.Assert(err, check.IsNil)
+
+	restorer := snaprun.MockUserCurrent(func() (*user.User, error) {
+		return &user.User{Username: "some-user", HomeDir: c.MkDir()}, nil
+	})
+	defer restorer()
+
+	// pretend to be strace, run through sudo
+	sudoCmd := testutil.MockCommand(c, "sudo", fmt.Sprintf(`
+echo 'strace: some message from strace' >&2
+echo 'execve("%[1]s", ["snap-confine"]) = 0' >&2
+echo 'mount("none", "/", NULL, MS_REC|MS_SLAVE, NULL) = 0' >&2
+echo '[pid  1234] execve("%[2]s/snapname/x2/run-app", ["run-app"]) = 0' >&2
+echo '[pid  1234] mount("a", "b", NULL, 0, NULL) = -1 EPERM (Operation not permitted)' >&2
+echo '[pid  1235] socket(AF_NETLINK, SOCK_RAW, 0 <unfinished ...>' >&2
+echo '[pid  1235] <... socket resumed> ) = -1 EPERM (Operation not permitted)' >&2
+echo 'mount("c", "d", NULL, 0, NULL) = -1 EPERM (Operation not permitted)' >&2
+echo 'open("/foo", O_RDONLY) = -1 ENOENT (No such file or directory)' >&2
+`, filepath.Join(dirs.DistroLibExecDir, "snap-confine"), dirs.SnapMountDir))
+	defer sudoCmd.Restore()
+	straceCmd := testutil.MockCommand(c, "strace", "")
+	defer straceCmd.Restore()
+
+	rest, err := snaprun.Parser().ParseArgs([]string{"run", "--strace=-tt -s 64", "snapname.app", "--arg1"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{"snapname.app", "--arg1"})
+	c.Check(sudoCmd.Calls(), check.DeepEquals, [][]string{{
+		"sudo", "-E",
+		filepath.Join(straceCmd.BinDir(), "strace"),
+		"-u", "some-user",
+		"-f",
+		"-e", "!select,pselect6,_newselect,clock_gettime",
+		"-tt", "-s", "64",
+		filepath.Join(dirs.DistroLibExecDir, "snap-confine"),
+		"snap.snapname.app",
+		filepath.Join(dirs.CoreLibExecDir, "snap-exec"),
+		"snapname.app", "--arg1",
+	}})
+	c.Check(s.Stderr(), check.Equals, fmt.Sprintf(`strace: some message from strace
+[pid  1234] execve("%s/snapname/x2/run-app", ["run-app"]) = 0
+[pid  1234] mount("a", "b", NULL, 0, NULL) = -1 EPERM (Operation not permitted)
+[pid  1235] socket(AF_NETLINK, SOCK_RAW, 0 <unfinished ...>
+[pid  1235] <... socket resumed> ) = -1 EPERM (Operation not permitted)
+mount("c", "d", NULL, 0, NULL) = -1 EPERM (Operation not permitted)
+open("/foo", O_RDONLY) = -1 ENOENT (No such file or directory)
+Syscalls that failed with EPERM, probably denied by seccomp:
+  mount: 2
+  socket: 1
+`, dirs.SnapMountDir))
+}
+
+func (s *SnapSuite) TestSnapRunAppWithGdbIntegration(c *check.C) {
+	// snap-confine (and snap-exec) stop right before running the app
+	c.Assert(os.MkdirAll(dirs.DistroLibExecDir, 0755), check.IsNil)
+	snapConfine := filepath.Join(dirs.DistroLibExecDir, "snap-confine")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %[1]s.log\nkill -STOP $$\necho running app >> %[1]s.log\n", snapConfine)
+	c.Assert(ioutil.WriteFile(snapConfine, []byte(script), 0755), check.IsNil)
+	defer os.Remove(snapConfine)
+
+	// mock installed snap
+	si := snaptest.MockSnap(c, string(mockYaml), string(mockContents), &snap.SideInfo{
+		Revision: snap.R("x2"),
+	})
+	err := os.Symlink(si.MountDir(), filepath.Join(si.MountDir(), "../current"))
+	c.Assert(err, check.IsNil)
+
+	restorer := snaprun.MockUserCurrent(func() (*user.User, error) {
+		return &user.User{Username: "some-user", HomeDir: c.MkDir()}, nil
+	})
+	defer restorer()
+
+	// pretend to be gdbserver, run through sudo, by letting the app go
+	sudoCmd := testutil.MockCommand(c, "sudo", `kill -CONT "$4"`)
+	defer sudoCmd.Restore()
+	gdbserverCmd := testutil.MockCommand(c, "gdbserver", "")
+	defer gdbserverCmd.Restore()
+
+	_, err = snaprun.Parser().ParseArgs([]string{"run", "--gdb", "snapname.app", "--arg1"})
+	c.Assert(err, check.IsNil)
+	c.Assert(sudoCmd.Calls(), check.HasLen, 1)
+	c.Check(sudoCmd.Calls()[0][:4], check.DeepEquals, []string{
+		"sudo", filepath.Join(gdbserverCmd.BinDir(), "gdbserver"), "--attach", ":0",
+	})
+	c.Check(s.Stderr(), check.Matches, `(?s).*target remote :<port>.*`)
+
+	// the app ran once gdbserver let it go
+	log, err := ioutil.ReadFile(snapConfine + ".log")
+	c.Assert(err, check.IsNil)
+	c.Check(string(log), check.Equals, fmt.Sprintf("snap.snapname.app %s --stop-before-exec snapname.app --arg1\nrunning app\n", filepath.Join(dirs.CoreLibExecDir, "snap-exec")))
+}
+
+func (s *SnapSuite) TestFilterStraceOutputShowsStraceErrors(c *check.C) {
+	var out bytes.Buffer
+	denied := snaprun.FilterStraceOutput(strings.NewReader("strace: cannot run\nsecond line\n"), &out)
+	c.Check(denied, check.HasLen, 0)
+	c.Check(out.String(), check.Equals, "strace: cannot run\nsecond line\n")
+}
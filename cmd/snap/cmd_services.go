@@ -33,6 +33,7 @@ type svcStatus struct {
 	Positional struct {
 		ServiceNames []serviceName `positional-arg-name:"<service>"`
 	} `positional-args:"yes"`
+	formatMixin
 }
 
 type svcLogs struct {
@@ -52,7 +53,7 @@ var (
 )
 
 func init() {
-	addCommand("services", shortServicesHelp, "", func() flags.Commander { return &svcStatus{} }, nil, nil)
+	addCommand("services", shortServicesHelp, "", func() flags.Commander { return &svcStatus{} }, formatDescs, nil)
 	addCommand("logs", shortLogsHelp, "", func() flags.Commander { return &svcLogs{} }, nil, nil)
 
 	addCommand("start", shortStartHelp, "", func() flags.Commander { return &svcStart{} }, nil, nil)
@@ -78,6 +79,13 @@ func (s *svcStatus) Execute(args []string) error {
 		return err
 	}
 
+	if s.structured() {
+		if services == nil {
+			services = []*client.AppInfo{}
+		}
+		return s.printStructured(services)
+	}
+
 	w := tabWriter()
 	defer w.Flush()
 
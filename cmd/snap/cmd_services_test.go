@@ -20,6 +20,7 @@
 package main_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
@@ -151,6 +152,25 @@ func (s *appOpSuite) testOp(c *check.C, op, summary string, names []string, extr
 	c.Check(n, check.Equals, expectedN)
 }
 
+func (s *appOpSuite) TestServicesFormatJSON(c *check.C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, check.Equals, "GET")
+		c.Check(r.URL.Path, check.Equals, "/v2/apps")
+		c.Check(r.URL.Query().Get("select"), check.Equals, "service")
+		fmt.Fprintln(w, `{"type": "sync", "result": [{"snap": "foo", "name": "bar", "daemon": "simple", "enabled": true, "active": false}]}`)
+	})
+	rest, err := snap.Parser().ParseArgs([]string{"services", "--format=json"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.HasLen, 0)
+
+	var apps []*client.AppInfo
+	c.Assert(json.Unmarshal(s.stdout.Bytes(), &apps), check.IsNil)
+	c.Check(apps, check.DeepEquals, []*client.AppInfo{
+		{Snap: "foo", Name: "bar", Daemon: "simple", Enabled: true},
+	})
+	c.Check(s.Stderr(), check.Equals, "")
+}
+
 func (s *appOpSuite) TestAppOps(c *check.C) {
 	extras := []string{"enable", "disable", "reload"}
 	summaries := []string{"Started.", "Stopped.", "Restarted."}
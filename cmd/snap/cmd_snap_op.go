@@ -48,8 +48,39 @@ func lastLogStr(logs []string) string {
 var (
 	maxGoneTime = 5 * time.Second
 	pollTime    = 100 * time.Millisecond
+	// when following the events of a change, it is still checked
+	// every eventWaitTime, as not everything (e.g. task logs) is an event
+	eventWaitTime = 5 * time.Second
+
+	useEventStream = true
 )
 
+// waitForEvent waits for the next events of the change, or for
+// eventWaitTime to pass anyway, calling spin while at it. It returns
+// nil once the stream of events is over.
+func waitForEvent(events <-chan client.Event, spin func()) <-chan client.Event {
+	timeout := time.After(eventWaitTime)
+	ticker := time.NewTicker(pollTime)
+	defer ticker.Stop()
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return nil
+			}
+			// skip what piled up meanwhile
+			for len(events) > 0 {
+				<-events
+			}
+			return events
+		case <-ticker.C:
+			spin()
+		case <-timeout:
+			return events
+		}
+	}
+}
+
 type waitMixin struct {
 	NoWait bool `long:"no-wait" hidden:"true"`
 }
@@ -62,6 +93,27 @@ var waitDescs = mixinDescs{
 
 var noWait = errors.New("no wait for op")
 
+type queueMixin struct {
+	Queue bool   `long:"queue"`
+	After string `long:"after"`
+}
+
+var queueDescs = mixinDescs{
+	"queue": i18n.G("Wait for the changes in progress that affect the snap, instead of failing"),
+	"after": i18n.G("Wait for the change with the given id to finish before starting"),
+}
+
+func (qmx queueMixin) asksToQueue() bool {
+	return qmx.Queue || qmx.After != ""
+}
+
+func (qmx queueMixin) setQueue(opts *client.SnapOptions) {
+	opts.Queue = qmx.Queue
+	opts.After = qmx.After
+}
+
+var errQueueMany = errors.New(i18n.G("a single snap name is needed to queue the operation"))
+
 func (wmx *waitMixin) wait(cli *client.Client, id string) (*client.Change, error) {
 	if wmx.NoWait {
 		fmt.Fprintf(Stdout, "%s\n", id)
@@ -78,9 +130,19 @@ func wait(cli *client.Client, id string) (*client.Change, error) {
 
 	tMax := time.Time{}
 
+	// follow the events of the change instead of polling when possible
+	var events <-chan client.Event
+	if useEventStream {
+		if evs, stop, err := cli.Events(&client.EventsOptions{ChangeID: id}); err == nil {
+			events = evs
+			defer stop()
+		}
+	}
+
 	var lastID string
 	lastLog := map[string]string{}
 	for {
+		spinning := ""
 		chg, err := cli.Change(id)
 		if err != nil {
 			// a client.Error means we were able to communicate with
@@ -114,6 +176,7 @@ func wait(cli *client.Client, id string) (*client.Change, error) {
 				continue
 			case t.Progress.Total == 1:
 				pb.Spin(t.Summary)
+				spinning = t.Summary
 				nowLog := lastLogStr(t.Log)
 				if lastLog[t.ID] != nowLog {
 					pb.Notify(nowLog)
@@ -140,6 +203,15 @@ func wait(cli *client.Client, id string) (*client.Change, error) {
 			return nil, fmt.Errorf(i18n.G("change finished in status %q with no error message"), chg.Status)
 		}
 
+		if events != nil {
+			events = waitForEvent(events, func() {
+				if spinning != "" {
+					pb.Spin(spinning)
+				}
+			})
+			continue
+		}
+
 		// note this very purposely is not a ticker; we want
 		// to sleep 100ms between calls, not call once every
 		// 100ms.
@@ -195,6 +267,7 @@ and the snap can easily be enabled again.
 
 type cmdRemove struct {
 	waitMixin
+	queueMixin
 
 	Revision   string `long:"revision"`
 	Positional struct {
@@ -272,6 +345,7 @@ func (x *cmdRemove) removeMany(opts *client.SnapOptions) error {
 
 func (x *cmdRemove) Execute([]string) error {
 	opts := &client.SnapOptions{Revision: x.Revision}
+	x.setQueue(opts)
 	if len(x.Positional.Snaps) == 1 {
 		return x.removeOne(opts)
 	}
@@ -279,6 +353,9 @@ func (x *cmdRemove) Execute([]string) error {
 	if x.Revision != "" {
 		return errors.New(i18n.G("a single snap name is needed to specify the revision"))
 	}
+	if x.asksToQueue() {
+		return errQueueMany
+	}
 	return x.removeMany(nil)
 }
 
@@ -411,6 +488,7 @@ func (mx modeMixin) setModes(opts *client.SnapOptions) {
 
 type cmdInstall struct {
 	waitMixin
+	queueMixin
 
 	channelMixin
 	modeMixin
@@ -449,6 +527,9 @@ func (x *cmdInstall) installOne(name string, opts *client.SnapOptions) error {
 
 	cli := Client()
 	if strings.Contains(name, "/") || strings.HasSuffix(name, ".snap") || strings.Contains(name, ".snap.") {
+		if x.asksToQueue() {
+			return errors.New(i18n.G("cannot queue the installation of a snap file"))
+		}
 		installFromFile = true
 		changeID, err = cli.InstallPath(name, opts)
 	} else {
@@ -553,6 +634,7 @@ func (x *cmdInstall) Execute([]string) error {
 		Unaliased: x.Unaliased,
 	}
 	x.setModes(opts)
+	x.setQueue(opts)
 
 	names := make([]string, len(x.Positional.Snaps))
 	for i, name := range x.Positional.Snaps {
@@ -566,12 +648,16 @@ func (x *cmdInstall) Execute([]string) error {
 	if x.asksForMode() || x.asksForChannel() {
 		return errors.New(i18n.G("a single snap name is needed to specify mode or channel flags"))
 	}
+	if x.asksToQueue() {
+		return errQueueMany
+	}
 
 	return x.installMany(names, nil)
 }
 
 type cmdRefresh struct {
 	waitMixin
+	queueMixin
 
 	channelMixin
 	modeMixin
@@ -724,12 +810,16 @@ func (x *cmdRefresh) Execute([]string) error {
 			Revision:         x.Revision,
 		}
 		x.setModes(opts)
+		x.setQueue(opts)
 		return x.refreshOne(names[0], opts)
 	}
 
 	if x.asksForMode() || x.asksForChannel() {
 		return errors.New(i18n.G("a single snap name is needed to specify mode or channel flags"))
 	}
+	if x.asksToQueue() {
+		return errQueueMany
+	}
 
 	if x.IgnoreValidation {
 		return errors.New(i18n.G("a single snap name must be specified when ignoring validation"))
@@ -832,6 +922,7 @@ Try "snapcraft prime" in your project directory, then "snap try" again.`), path)
 
 type cmdEnable struct {
 	waitMixin
+	queueMixin
 
 	Positional struct {
 		Snap installedSnapName `positional-arg-name:"<snap>"`
@@ -842,6 +933,7 @@ func (x *cmdEnable) Execute([]string) error {
 	cli := Client()
 	name := string(x.Positional.Snap)
 	opts := &client.SnapOptions{}
+	x.setQueue(opts)
 	changeID, err := cli.Enable(name, opts)
 	if err != nil {
 		return err
@@ -861,6 +953,7 @@ func (x *cmdEnable) Execute([]string) error {
 
 type cmdDisable struct {
 	waitMixin
+	queueMixin
 
 	Positional struct {
 		Snap installedSnapName `positional-arg-name:"<snap>"`
@@ -871,6 +964,7 @@ func (x *cmdDisable) Execute([]string) error {
 	cli := Client()
 	name := string(x.Positional.Snap)
 	opts := &client.SnapOptions{}
+	x.setQueue(opts)
 	changeID, err := cli.Disable(name, opts)
 	if err != nil {
 		return err
@@ -890,6 +984,7 @@ func (x *cmdDisable) Execute([]string) error {
 
 type cmdRevert struct {
 	waitMixin
+	queueMixin
 
 	modeMixin
 	Revision   string `long:"revision"`
@@ -921,6 +1016,7 @@ func (x *cmdRevert) Execute(args []string) error {
 	name := string(x.Positional.Snap)
 	opts := &client.SnapOptions{Revision: x.Revision}
 	x.setModes(opts)
+	x.setQueue(opts)
 	changeID, err := cli.Revert(name, opts)
 	if err != nil {
 		return err
@@ -991,25 +1087,25 @@ func (x cmdSwitch) Execute(args []string) error {
 
 func init() {
 	addCommand("remove", shortRemoveHelp, longRemoveHelp, func() flags.Commander { return &cmdRemove{} },
-		waitDescs.also(map[string]string{"revision": i18n.G("Remove only the given revision")}), nil)
+		waitDescs.also(queueDescs).also(map[string]string{"revision": i18n.G("Remove only the given revision")}), nil)
 	addCommand("install", shortInstallHelp, longInstallHelp, func() flags.Commander { return &cmdInstall{} },
-		waitDescs.also(channelDescs).also(modeDescs).also(map[string]string{
+		waitDescs.also(queueDescs).also(channelDescs).also(modeDescs).also(map[string]string{
 			"revision":        i18n.G("Install the given revision of a snap, to which you must have developer access"),
 			"dangerous":       i18n.G("Install the given snap file even if there are no pre-acknowledged signatures for it, meaning it was not verified and could be dangerous (--devmode implies this)"),
 			"force-dangerous": i18n.G("Alias for --dangerous (DEPRECATED)"),
 			"unaliased":       i18n.G("Install the given snap without enabling its automatic aliases"),
 		}), nil)
 	addCommand("refresh", shortRefreshHelp, longRefreshHelp, func() flags.Commander { return &cmdRefresh{} },
-		waitDescs.also(channelDescs).also(modeDescs).also(map[string]string{
+		waitDescs.also(queueDescs).also(channelDescs).also(modeDescs).also(map[string]string{
 			"revision":          i18n.G("Refresh to the given revision"),
 			"list":              i18n.G("Show available snaps for refresh but do not perform a refresh"),
 			"time":              i18n.G("Show auto refresh information but do not perform a refresh"),
 			"ignore-validation": i18n.G("Ignore validation by other snaps blocking the refresh"),
 		}), nil)
 	addCommand("try", shortTryHelp, longTryHelp, func() flags.Commander { return &cmdTry{} }, waitDescs.also(modeDescs), nil)
-	addCommand("enable", shortEnableHelp, longEnableHelp, func() flags.Commander { return &cmdEnable{} }, waitDescs, nil)
-	addCommand("disable", shortDisableHelp, longDisableHelp, func() flags.Commander { return &cmdDisable{} }, waitDescs, nil)
-	addCommand("revert", shortRevertHelp, longRevertHelp, func() flags.Commander { return &cmdRevert{} }, waitDescs.also(modeDescs).also(map[string]string{
+	addCommand("enable", shortEnableHelp, longEnableHelp, func() flags.Commander { return &cmdEnable{} }, waitDescs.also(queueDescs), nil)
+	addCommand("disable", shortDisableHelp, longDisableHelp, func() flags.Commander { return &cmdDisable{} }, waitDescs.also(queueDescs), nil)
+	addCommand("revert", shortRevertHelp, longRevertHelp, func() flags.Commander { return &cmdRevert{} }, waitDescs.also(queueDescs).also(modeDescs).also(map[string]string{
 		"revision": "Revert to the given revision",
 	}), nil)
 	addCommand("switch", shortSwitchHelp, longSwitchHelp, func() flags.Commander { return &cmdSwitch{} }, nil, nil)
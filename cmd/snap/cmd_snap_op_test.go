@@ -876,6 +876,32 @@ func (s *SnapOpSuite) TestRemove(c *check.C) {
 	c.Check(s.srv.n, check.Equals, s.srv.total)
 }
 
+func (s *SnapOpSuite) TestRemoveQueued(c *check.C) {
+	s.srv.total = 3
+	s.srv.checker = func(r *http.Request) {
+		c.Check(r.URL.Path, check.Equals, "/v2/snaps/foo")
+		c.Check(DecodedRequestBody(c, r), check.DeepEquals, map[string]interface{}{
+			"action": "remove",
+			"queue":  true,
+			"after":  "12",
+		})
+	}
+
+	s.RedirectClientToTestServer(s.srv.handle)
+	_, err := snap.Parser().ParseArgs([]string{"remove", "--queue", "--after=12", "foo"})
+	c.Assert(err, check.IsNil)
+	c.Check(s.Stdout(), check.Matches, `(?sm).*foo removed`)
+	c.Check(s.srv.n, check.Equals, s.srv.total)
+}
+
+func (s *SnapOpSuite) TestQueueMany(c *check.C) {
+	s.RedirectClientToTestServer(nil)
+	for _, cmd := range []string{"remove", "install", "refresh"} {
+		_, err := snap.Parser().ParseArgs([]string{cmd, "--queue", "one", "two"})
+		c.Check(err, check.ErrorMatches, `a single snap name is needed to queue the operation`, check.Commentf(cmd))
+	}
+}
+
 func (s *SnapOpSuite) TestRemoveManyRevision(c *check.C) {
 	s.RedirectClientToTestServer(nil)
 	_, err := snap.Parser().ParseArgs([]string{"remove", "--revision=17", "one", "two"})
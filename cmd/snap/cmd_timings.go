@@ -0,0 +1,97 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/i18n"
+)
+
+type cmdDebugTimings struct{ changeIDMixin }
+
+var shortDebugTimingsHelp = i18n.G("Show the timings of the tasks of a change")
+var longDebugTimingsHelp = i18n.G(`
+The timings command shows how long each run of the tasks of the given
+change took, broken down into the steps recorded by the task handlers.
+`)
+
+func init() {
+	addDebugCommand("timings", shortDebugTimingsHelp, longDebugTimingsHelp, func() flags.Commander {
+		return &cmdDebugTimings{}
+	})
+}
+
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%dms", d/time.Millisecond)
+}
+
+func printSpans(w io.Writer, spans []*client.TimingSpan, depth int) {
+	for _, span := range spans {
+		label := strings.Repeat("  ", depth) + span.Label
+		if span.Summary != "" {
+			label += ": " + span.Summary
+		}
+		fmt.Fprintf(w, "\t\t\t%s\t%s\n", formatDuration(span.Duration), label)
+		printSpans(w, span.Spans, depth+1)
+	}
+}
+
+func (x *cmdDebugTimings) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	cli := Client()
+	id, err := x.GetChangeID(cli)
+	if err != nil {
+		return err
+	}
+	timings, err := cli.ChangeTimings(id)
+	if err != nil {
+		return err
+	}
+
+	w := tabWriter()
+	fmt.Fprintf(w, i18n.G("ID\tStatus\tRun\tDuration\tSummary\n"))
+	for _, t := range timings {
+		if len(t.Timings) == 0 {
+			fmt.Fprintf(w, "%s\t%s\t-\t-\t%s\n", t.ID, t.Status, t.Summary)
+			continue
+		}
+		for i, run := range t.Timings {
+			id, status := t.ID, t.Status
+			if i > 0 {
+				id, status = "", ""
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", id, status, run.Label, formatDuration(run.Duration), t.Summary)
+			printSpans(w, run.Spans, 1)
+		}
+	}
+	w.Flush()
+
+	return nil
+}
@@ -0,0 +1,64 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+
+	"gopkg.in/check.v1"
+
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+func (s *SnapSuite) TestDebugTimings(c *check.C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		switch n {
+		case 0:
+			c.Check(r.Method, check.Equals, "GET")
+			c.Check(r.URL.Path, check.Equals, "/v2/changes/42/timings")
+			fmt.Fprintln(w, `{"type": "sync", "result": [
+{"id": "1", "kind": "download-snap", "summary": "Download snap \"foo\"", "status": "Done",
+ "timings": [
+  {"label": "do", "start": "2017-04-21T01:02:03Z", "duration": 5000000},
+  {"label": "do", "start": "2017-04-21T01:02:04Z", "duration": 2000000000,
+   "spans": [{"label": "download", "summary": "Download snap \"foo\"", "start": "2017-04-21T01:02:04Z", "duration": 1500000000,
+              "spans": [{"label": "verify", "start": "2017-04-21T01:02:05Z", "duration": 300000000}]}]}]},
+{"id": "2", "kind": "mount-snap", "summary": "Mount snap \"foo\"", "status": "Do"}
+]}`)
+		default:
+			c.Fatalf("expected to get 1 requests, now on %d", n+1)
+		}
+
+		n++
+	})
+	rest, err := snap.Parser().ParseArgs([]string{"debug", "timings", "42"})
+	c.Assert(err, check.IsNil)
+	c.Assert(rest, check.DeepEquals, []string{})
+	c.Check(s.Stdout(), check.Equals, `ID   Status  Run  Duration  Summary
+1    Done    do   5ms       Download snap "foo"
+             do   2000ms    Download snap "foo"
+                  1500ms      download: Download snap "foo"
+                  300ms         verify
+2    Do      -    -         Mount snap "foo"
+`)
+	c.Check(s.Stderr(), check.Equals, "")
+}
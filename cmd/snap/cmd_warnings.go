@@ -0,0 +1,214 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+
+	"github.com/snapcore/snapd/client"
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/osutil"
+)
+
+var shortWarningsHelp = i18n.G("List warnings")
+var longWarningsHelp = i18n.G(`
+The warnings command lists the warnings that have been reported to the
+system.
+
+Once warnings have been listed with 'snap warnings', 'snap okay' may be
+used to silence them. A warning that's been silenced in this way will not
+be listed again unless it happens again, _and_ a cooldown time has passed.
+
+Warnings expire automatically, and once expired they are forgotten.
+`)
+
+var shortOkayHelp = i18n.G("Acknowledge warnings")
+var longOkayHelp = i18n.G(`
+The okay command acknowledges the warnings listed with 'snap warnings'.
+
+Once acknowledged a warning won't appear again unless it re-occurrs and
+sufficient time has passed.
+`)
+
+type cmdWarnings struct {
+	All     bool `long:"all"`
+	Verbose bool `long:"verbose"`
+}
+
+type cmdOkay struct{}
+
+func init() {
+	addCommand("warnings", shortWarningsHelp, longWarningsHelp, func() flags.Commander { return &cmdWarnings{} },
+		map[string]string{
+			"all":     i18n.G("Show all warnings"),
+			"verbose": i18n.G("Show more information"),
+		}, nil)
+	addCommand("okay", shortOkayHelp, longOkayHelp, func() flags.Commander { return &cmdOkay{} }, nil, nil)
+}
+
+func (cmd *cmdWarnings) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	cli := Client()
+	warnings, err := cli.Warnings(client.WarningsOptions{All: cmd.All})
+	if err != nil {
+		return err
+	}
+	if len(warnings) == 0 {
+		fmt.Fprintln(Stderr, i18n.G("No warnings."))
+		return nil
+	}
+
+	var last time.Time
+	for i, w := range warnings {
+		if i > 0 {
+			fmt.Fprintln(Stdout, "---")
+		}
+		if cmd.Verbose {
+			fmt.Fprintf(Stdout, "first-occurrence:  %s\n", fmtWarningTime(w.FirstAdded))
+		}
+		fmt.Fprintf(Stdout, "last-occurrence:   %s\n", fmtWarningTime(w.LastAdded))
+		if cmd.Verbose {
+			fmt.Fprintf(Stdout, "acknowledged:      %s\n", fmtWarningTime(w.LastShown))
+			fmt.Fprintf(Stdout, "repeats-after:     %s\n", w.RepeatAfter)
+			fmt.Fprintf(Stdout, "expires-after:     %s\n", w.ExpireAfter)
+		}
+		fmt.Fprintln(Stdout, "warning: |")
+		for _, line := range strings.Split(w.Message, "\n") {
+			fmt.Fprintf(Stdout, "  %s\n", line)
+		}
+		if w.LastAdded.After(last) {
+			last = w.LastAdded
+		}
+	}
+
+	return writeWarningTimestamp(last)
+}
+
+func (cmd *cmdOkay) Execute(args []string) error {
+	if len(args) > 0 {
+		return ErrExtraArgs
+	}
+
+	last, err := lastWarningTimestamp()
+	if err != nil {
+		return err
+	}
+	if last.IsZero() {
+		return fmt.Errorf(i18n.G("you must have looked at the warnings before acknowledging them. Try 'snap warnings'."))
+	}
+
+	return Client().Okay(last)
+}
+
+func fmtWarningTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// maybePresentWarnings tells the user about pending warnings they have
+// not listed yet, as reported by the last response from snapd.
+func maybePresentWarnings(cli *client.Client) {
+	if cli == nil {
+		return
+	}
+	count, timestamp := cli.WarningsSummary()
+	if count == 0 {
+		return
+	}
+	if last, _ := lastWarningTimestamp(); !timestamp.After(last) {
+		return
+	}
+
+	fmt.Fprintf(Stderr, i18n.NG("WARNING: there is %d new warning. See 'snap warnings'.\n",
+		"WARNING: there are %d new warnings. See 'snap warnings'.\n", uint32(count)), count)
+}
+
+type warningsFile struct {
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func warningsFilename(homedir string) string {
+	return filepath.Join(dirs.GlobalRootDir, homedir, ".snap", "warnings.json")
+}
+
+// lastWarningTimestamp returns the time of the most recent warning the
+// user listed with 'snap warnings', or the zero time if none was.
+func lastWarningTimestamp() (time.Time, error) {
+	user, err := osutil.RealUser()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot determine real user: %v", err)
+	}
+
+	f, err := os.Open(warningsFilename(user.HomeDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("cannot open timestamp file: %v", err)
+	}
+	defer f.Close()
+
+	var wf warningsFile
+	if err := json.NewDecoder(f).Decode(&wf); err != nil {
+		return time.Time{}, fmt.Errorf("cannot decode timestamp file: %v", err)
+	}
+
+	return wf.Timestamp, nil
+}
+
+func writeWarningTimestamp(t time.Time) error {
+	user, err := osutil.RealUser()
+	if err != nil {
+		return fmt.Errorf("cannot determine real user: %v", err)
+	}
+	uid, err := strconv.Atoi(user.Uid)
+	if err != nil {
+		return err
+	}
+	gid, err := strconv.Atoi(user.Gid)
+	if err != nil {
+		return err
+	}
+
+	filename := warningsFilename(user.HomeDir)
+	if err := osutil.MkdirAllChown(filepath.Dir(filename), 0700, uid, gid); err != nil {
+		return fmt.Errorf("cannot create snap directory: %v", err)
+	}
+	data, err := json.Marshal(warningsFile{Timestamp: t})
+	if err != nil {
+		return err
+	}
+
+	return osutil.AtomicWriteFileChown(filename, data, 0600, 0, uid, gid)
+}
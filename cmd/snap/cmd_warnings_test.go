@@ -0,0 +1,177 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/client"
+	snap "github.com/snapcore/snapd/cmd/snap"
+)
+
+const twoWarnings = `{"type": "sync", "result": [
+  {"message": "hello world number one",
+   "first-added": "2017-11-01T10:00:00Z",
+   "last-added": "2017-11-01T10:00:00Z",
+   "expire-after": "672h0m0s",
+   "repeat-after": "24h0m0s"},
+  {"message": "hello world number two\nwith a second line",
+   "first-added": "2017-11-01T09:00:00Z",
+   "last-added": "2017-11-01T11:00:00Z",
+   "last-shown": "2017-11-01T10:30:00Z",
+   "expire-after": "672h0m0s",
+   "repeat-after": "24h0m0s"}
+]}`
+
+func (s *SnapSuite) TestWarnings(c *C) {
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		switch n {
+		case 1:
+			c.Check(r.Method, Equals, "GET")
+			c.Check(r.URL.Path, Equals, "/v2/warnings")
+			c.Check(r.URL.RawQuery, Equals, "")
+			fmt.Fprintln(w, twoWarnings)
+		case 2:
+			c.Check(r.Method, Equals, "POST")
+			c.Check(r.URL.Path, Equals, "/v2/warnings")
+			c.Check(DecodedRequestBody(c, r), DeepEquals, map[string]interface{}{
+				"action":    "okay",
+				"timestamp": "2017-11-01T11:00:00Z",
+			})
+			fmt.Fprintln(w, `{"type": "sync", "result": 2}`)
+		default:
+			c.Fatalf("expected 2 queries, got %d", n)
+		}
+	})
+
+	rest, err := snap.Parser().ParseArgs([]string{"warnings"})
+	c.Assert(err, IsNil)
+	c.Check(rest, HasLen, 0)
+	c.Check(s.Stderr(), Equals, "")
+	c.Check(s.Stdout(), Equals, `last-occurrence:   2017-11-01T10:00:00Z
+warning: |
+  hello world number one
+---
+last-occurrence:   2017-11-01T11:00:00Z
+warning: |
+  hello world number two
+  with a second line
+`)
+
+	s.ResetStdStreams()
+	_, err = snap.Parser().ParseArgs([]string{"okay"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, "")
+	c.Check(s.Stderr(), Equals, "")
+	c.Check(n, Equals, 2)
+}
+
+func (s *SnapSuite) TestWarningsVerboseAll(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.URL.RawQuery, Equals, "select=all")
+		fmt.Fprintln(w, twoWarnings)
+	})
+
+	_, err := snap.Parser().ParseArgs([]string{"warnings", "--all", "--verbose"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, `first-occurrence:  2017-11-01T10:00:00Z
+last-occurrence:   2017-11-01T10:00:00Z
+acknowledged:      -
+repeats-after:     24h0m0s
+expires-after:     672h0m0s
+warning: |
+  hello world number one
+---
+first-occurrence:  2017-11-01T09:00:00Z
+last-occurrence:   2017-11-01T11:00:00Z
+acknowledged:      2017-11-01T10:30:00Z
+repeats-after:     24h0m0s
+expires-after:     672h0m0s
+warning: |
+  hello world number two
+  with a second line
+`)
+}
+
+func (s *SnapSuite) TestNoWarnings(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"type": "sync", "result": []}`)
+	})
+
+	_, err := snap.Parser().ParseArgs([]string{"warnings"})
+	c.Assert(err, IsNil)
+	c.Check(s.Stdout(), Equals, "")
+	c.Check(s.Stderr(), Equals, "No warnings.\n")
+}
+
+func (s *SnapSuite) TestOkayBeforeWarnings(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Fatalf("unexpected request to %s", r.URL.Path)
+	})
+
+	_, err := snap.Parser().ParseArgs([]string{"okay"})
+	c.Assert(err, ErrorMatches, "you must have looked at the warnings before acknowledging them. Try 'snap warnings'.")
+}
+
+func (s *SnapSuite) TestWarningsHint(c *C) {
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(client.WarningCountHeader, "2")
+		w.Header().Set(client.WarningTimestampHeader, "2017-11-01T11:00:00Z")
+		switch r.URL.Path {
+		case "/v2/changes":
+			fmt.Fprintln(w, mockChangesJSON)
+		case "/v2/warnings":
+			fmt.Fprintln(w, twoWarnings)
+		default:
+			c.Fatalf("unexpected request to %s", r.URL.Path)
+		}
+	})
+
+	restore := mockArgs("snap", "changes")
+	defer restore()
+	err := snap.RunMain()
+	c.Assert(err, IsNil)
+	c.Check(s.Stderr(), Equals, "WARNING: there are 2 new warnings. See 'snap warnings'.\n")
+
+	// once listed, the same warnings are not pointed at again
+	s.ResetStdStreams()
+	restore = mockArgs("snap", "warnings")
+	defer restore()
+	err = snap.RunMain()
+	c.Assert(err, IsNil)
+	c.Check(s.Stderr(), Equals, "")
+
+	s.ResetStdStreams()
+	restore = mockArgs("snap", "changes")
+	defer restore()
+	err = snap.RunMain()
+	c.Assert(err, IsNil)
+	c.Check(s.Stderr(), Equals, "")
+
+	data, err := ioutil.ReadFile(snap.WarningsFilename())
+	c.Assert(err, IsNil)
+	c.Check(string(data), Equals, `{"timestamp":"2017-11-01T11:00:00Z"}`)
+}
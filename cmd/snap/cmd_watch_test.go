@@ -83,3 +83,38 @@ func (s *SnapSuite) TestCmdWatch(c *C) {
 	c.Assert(err, IsNil)
 	c.Check(string(buf), testutil.Contains, "\rmy-snap 0 B / 100.00 KB")
 }
+
+func (s *SnapSuite) TestCmdWatchFollowsEvents(c *C) {
+	defer snap.MockUseEventStream(true)()
+	// only the events make the change be checked again
+	defer snap.MockEventWaitTime(time.Minute)()
+
+	checked := make(chan bool, 2)
+	n := 0
+	s.RedirectClientToTestServer(func(w http.ResponseWriter, r *http.Request) {
+		c.Check(r.Method, Equals, "GET")
+		switch r.URL.Path {
+		case "/v2/events":
+			c.Check(r.URL.Query().Get("change"), Equals, "42")
+			w.Header().Set("Content-Type", "application/json-seq")
+			fmt.Fprintln(w, "\x1e"+`{"type": "change-status", "change": {"id": "42", "status": "Doing"}}`)
+			w.(http.Flusher).Flush()
+			<-checked
+			fmt.Fprintln(w, "\x1e"+`{"type": "task-status", "change": {"id": "42", "status": "Done", "ready": true}, "task": {"id": "84", "status": "Done"}}`)
+		case "/v2/changes/42":
+			if n == 0 {
+				fmt.Fprintf(w, fmtWatchChangeJSON, 0, 100*1024)
+			} else {
+				fmt.Fprintln(w, `{"type": "sync", "result": {"id": "42", "ready": true, "status": "Done"}}`)
+			}
+			n++
+			checked <- true
+		default:
+			c.Fatalf("unexpected request to %q", r.URL.Path)
+		}
+	})
+
+	_, err := snap.Parser().ParseArgs([]string{"watch", "42"})
+	c.Assert(err, IsNil)
+	c.Check(n, Equals, 2)
+}
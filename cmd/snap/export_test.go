@@ -25,16 +25,19 @@ import (
 
 	"github.com/jessevdk/go-flags"
 
+	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/store"
 )
 
 var RunMain = run
 
+var FilterStraceOutput = filterStraceOutput
+
 var (
 	CreateUserDataDirs = createUserDataDirs
-	SnapRunApp         = snapRunApp
-	SnapRunHook        = snapRunHook
+	SnapRunApp         = (&cmdRun{}).snapRunApp
+	SnapRunHook        = (&cmdRun{}).snapRunHook
 	Wait               = wait
 	ResolveApp         = resolveApp
 	IsReexeced         = isReexeced
@@ -51,6 +54,22 @@ func MockPollTime(d time.Duration) (restore func()) {
 	}
 }
 
+func MockUseEventStream(use bool) (restore func()) {
+	old := useEventStream
+	useEventStream = use
+	return func() {
+		useEventStream = old
+	}
+}
+
+func MockEventWaitTime(d time.Duration) (restore func()) {
+	d0 := eventWaitTime
+	eventWaitTime = d
+	return func() {
+		eventWaitTime = d0
+	}
+}
+
 func MockMaxGoneTime(d time.Duration) (restore func()) {
 	d0 := maxGoneTime
 	maxGoneTime = d
@@ -128,3 +147,11 @@ func AliasInfoLess(snapName1, alias1, cmd1, snapName2, alias2, cmd2 string) bool
 func AssertTypeNameCompletion(match string) []flags.Completion {
 	return assertTypeName("").Complete(match)
 }
+
+func WarningsFilename() string {
+	user, err := osutil.RealUser()
+	if err != nil {
+		panic(err)
+	}
+	return warningsFilename(user.HomeDir)
+}
@@ -0,0 +1,95 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/snapcore/snapd/i18n"
+)
+
+// formatMixin lets commands emit the client structures they display as
+// json or yaml instead of tables, for consumption by scripts. Both
+// formats use the field names of the REST API, so the schema of the
+// output is that of the corresponding /v2 endpoint.
+type formatMixin struct {
+	Format string `long:"format" choice:"text" choice:"json" choice:"yaml" default:"text"`
+}
+
+var formatDescs = mixinDescs{
+	"format": i18n.G("Print the output in the given format"),
+}
+
+// structured returns whether the output was asked to be json or yaml.
+func (mx formatMixin) structured() bool {
+	return mx.Format == "json" || mx.Format == "yaml"
+}
+
+// printStructured writes v to Stdout in the requested format.
+func (mx formatMixin) printStructured(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if mx.Format == "json" {
+		fmt.Fprintf(Stdout, "%s\n", data)
+		return nil
+	}
+
+	// go through json so that yaml uses the same field names
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return err
+	}
+	data, err = yaml.Marshal(fromJSONNumbers(generic))
+	if err != nil {
+		return err
+	}
+	Stdout.Write(data)
+	return nil
+}
+
+// fromJSONNumbers replaces the json.Numbers in v by integers or floats
+// so that yaml does not render them as strings.
+func fromJSONNumbers(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		if n, err := x.Int64(); err == nil {
+			return n
+		}
+		f, _ := x.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range x {
+			x[k] = fromJSONNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range x {
+			x[i] = fromJSONNumbers(e)
+		}
+	}
+	return v
+}
@@ -233,9 +233,15 @@ var ClientConfig = client.Config{
 	Interactive: terminal.IsTerminal(0),
 }
 
+// mostRecentClient is the client last handed out by Client; its last
+// response tells whether there are warnings to point the user at.
+var mostRecentClient *client.Client
+
 // Client returns a new client using ClientConfig as configuration.
 func Client() *client.Client {
-	return client.New(&ClientConfig)
+	cli := client.New(&ClientConfig)
+	mostRecentClient = cli
+	return cli
 }
 
 func init() {
@@ -304,6 +310,7 @@ func (e *exitStatus) Error() string {
 }
 
 func run() error {
+	mostRecentClient = nil
 	parser := Parser()
 	_, err := parser.Parse()
 	if err != nil {
@@ -328,5 +335,7 @@ func run() error {
 		fmt.Fprintf(Stderr, msg)
 	}
 
+	maybePresentWarnings(mostRecentClient)
+
 	return nil
 }
@@ -72,6 +72,8 @@ func (s *BaseSnapSuite) SetUpTest(c *C) {
 	snap.Stdout = s.stdout
 	snap.Stderr = s.stderr
 	snap.ReadPassword = s.readPassword
+	// tests that want the events stream of changes enable it
+	s.AddCleanup(snap.MockUseEventStream(false))
 	s.AuthFile = filepath.Join(c.MkDir(), "json")
 	os.Setenv(TestAuthFileEnvKey, s.AuthFile)
 }
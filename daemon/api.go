@@ -48,6 +48,7 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/jsonutil"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/metrics"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/auth"
@@ -79,10 +80,14 @@ var api = []*Command{
 	snapCmd,
 	snapConfCmd,
 	interfacesCmd,
+	connectionsCmd,
 	assertsCmd,
 	assertsFindManyCmd,
 	stateChangeCmd,
+	stateChangeTimingsCmd,
 	stateChangesCmd,
+	warningsCmd,
+	eventsCmd,
 	createUserCmd,
 	buyCmd,
 	readyToBuyCmd,
@@ -93,6 +98,7 @@ var api = []*Command{
 	appsCmd,
 	logsCmd,
 	debugCmd,
+	debugMetricsCmd,
 }
 
 var (
@@ -174,6 +180,12 @@ var (
 		POST:   changeInterfaces,
 	}
 
+	connectionsCmd = &Command{
+		Path:   "/v2/connections",
+		UserOK: true,
+		GET:    getConnections,
+	}
+
 	// TODO: allow to post assertions for UserOK? they are verified anyway
 	assertsCmd = &Command{
 		Path:   "/v2/assertions",
@@ -195,17 +207,41 @@ var (
 		POST:   abortChange,
 	}
 
+	stateChangeTimingsCmd = &Command{
+		Path:   "/v2/changes/{id}/timings",
+		UserOK: true,
+		GET:    getChangeTimings,
+	}
+
 	stateChangesCmd = &Command{
 		Path:   "/v2/changes",
 		UserOK: true,
 		GET:    getChanges,
 	}
 
+	eventsCmd = &Command{
+		Path:   "/v2/events",
+		UserOK: true,
+		GET:    getEvents,
+	}
+
 	debugCmd = &Command{
 		Path: "/v2/debug",
 		POST: postDebug,
 	}
 
+	debugMetricsCmd = &Command{
+		Path: "/v2/debug/metrics",
+		GET:  getMetrics,
+	}
+
+	warningsCmd = &Command{
+		Path:   "/v2/warnings",
+		UserOK: true,
+		GET:    getWarnings,
+		POST:   postWarnings,
+	}
+
 	createUserCmd = &Command{
 		Path:   "/v2/create-user",
 		UserOK: false,
@@ -810,6 +846,11 @@ type snapInstruction struct {
 	LeaveOld bool         `json:"temp-dropped-leave-old"`
 	License  *licenseData `json:"license"`
 	Snaps    []string     `json:"snaps"`
+	// Queue asks for the operation to wait for the changes in
+	// progress that conflict with it, instead of failing.
+	Queue bool `json:"queue"`
+	// After is the id of a change the operation must wait for.
+	After string `json:"after"`
 
 	// The fields below should not be unmarshalled into. Do not export them.
 	userID int
@@ -1166,6 +1207,16 @@ func postSnap(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("unknown action %s", inst.Action)
 	}
 
+	after, err := inst.queueAfter(state)
+	if err != nil {
+		return BadRequest("%v", err)
+	}
+	if len(after) > 0 {
+		chg := queueSnapInstruction(state, &inst, after)
+		ensureStateSoon(state)
+		return AsyncResponse(nil, &Meta{Change: chg.ID()})
+	}
+
 	msg, tsets, err := impl(&inst, state)
 	if err != nil {
 		return inst.errToResponse(err)
@@ -1178,6 +1229,78 @@ func postSnap(c *Command, r *http.Request, user *auth.UserState) Response {
 	return AsyncResponse(nil, &Meta{Change: chg.ID()})
 }
 
+// queueAfter returns the changes in progress the instruction must
+// wait for before it can be carried out.
+func (inst *snapInstruction) queueAfter(st *state.State) ([]*state.Change, error) {
+	var after []*state.Change
+	if inst.After != "" {
+		chg := st.Change(inst.After)
+		if chg == nil {
+			return nil, fmt.Errorf("cannot find change %q to queue after", inst.After)
+		}
+		if !chg.Status().Ready() {
+			after = append(after, chg)
+		}
+	}
+	if inst.Queue {
+		conflicting, err := snapstate.ConflictingChanges(st, inst.Snaps)
+		if err != nil {
+			return nil, err
+		}
+		for _, chg := range conflicting {
+			if chg.ID() != inst.After {
+				after = append(after, chg)
+			}
+		}
+	}
+	return after, nil
+}
+
+// queueSnapInstruction creates a change that carries out the
+// instruction once the given changes are ready.
+func queueSnapInstruction(st *state.State, inst *snapInstruction, after []*state.Change) *state.Change {
+	ids := make([]string, len(after))
+	for i, chg := range after {
+		ids[i] = chg.ID()
+	}
+	afterIDs := strings.Join(ids, ", ")
+
+	ts := snapstate.Queue(st, fmt.Sprintf(i18n.G("Wait for change %s to finish"), afterIDs), inst.Snaps, after, inst.Queue)
+	for _, t := range ts.Tasks() {
+		t.Set("snap-instruction", inst)
+		t.Set("user-id", inst.userID)
+	}
+
+	summary := fmt.Sprintf(i18n.G("%s %q snap after change %s"), strings.Title(inst.Action), inst.Snaps[0], afterIDs)
+	return newChange(st, inst.Action+"-snap", summary, []*state.TaskSet{ts}, inst.Snaps)
+}
+
+// runSnapInstruction plans the tasks of a queued instruction, now
+// that the changes it was queued after are ready.
+func runSnapInstruction(t *state.Task) ([]*state.TaskSet, error) {
+	var inst snapInstruction
+	if err := t.Get("snap-instruction", &inst); err != nil {
+		return nil, err
+	}
+	if err := t.Get("user-id", &inst.userID); err != nil && err != state.ErrNoState {
+		return nil, err
+	}
+
+	impl := inst.dispatch()
+	if impl == nil {
+		return nil, fmt.Errorf("unknown action %s", inst.Action)
+	}
+	_, tsets, err := impl(&inst, t.State())
+	if err != nil {
+		return nil, fmt.Errorf("cannot %s %q: %v", inst.Action, inst.Snaps[0], err)
+	}
+	return tsets, nil
+}
+
+func init() {
+	snapstate.RunQueued = runSnapInstruction
+}
+
 func newChange(st *state.State, kind, summary string, tsets []*state.TaskSet, snapNames []string) *state.Change {
 	chg := st.NewChange(kind, summary)
 	for _, ts := range tsets {
@@ -1258,7 +1381,7 @@ func snapsOp(c *Command, r *http.Request, user *auth.UserState) Response {
 		return BadRequest("cannot decode request body into snap instruction: %v", err)
 	}
 
-	if inst.Channel != "" || !inst.Revision.Unset() || inst.DevMode || inst.JailMode {
+	if inst.Channel != "" || !inst.Revision.Unset() || inst.DevMode || inst.JailMode || inst.Queue || inst.After != "" {
 		return BadRequest("unsupported option provided for multi-snap operation")
 	}
 
@@ -1616,6 +1739,91 @@ func getLegacyConnections(c *Command, r *http.Request, user *auth.UserState) Res
 	return SyncResponse(repo.Interfaces(), nil)
 }
 
+// connectionJSON aids in marshaling a connection into JSON.
+type connectionJSON struct {
+	Slot      interfaces.SlotRef     `json:"slot"`
+	Plug      interfaces.PlugRef     `json:"plug"`
+	Interface string                 `json:"interface"`
+	Origin    string                 `json:"origin"`
+	Undesired bool                   `json:"undesired,omitempty"`
+	SlotAttrs map[string]interface{} `json:"slot-attrs,omitempty"`
+	PlugAttrs map[string]interface{} `json:"plug-attrs,omitempty"`
+}
+
+type byConnRef []connectionJSON
+
+func (c byConnRef) Len() int      { return len(c) }
+func (c byConnRef) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c byConnRef) Less(i, j int) bool {
+	ci, cj := c[i], c[j]
+	if ci.Plug.Snap != cj.Plug.Snap {
+		return ci.Plug.Snap < cj.Plug.Snap
+	}
+	if ci.Plug.Name != cj.Plug.Name {
+		return ci.Plug.Name < cj.Plug.Name
+	}
+	if ci.Slot.Snap != cj.Slot.Snap {
+		return ci.Slot.Snap < cj.Slot.Snap
+	}
+	return ci.Slot.Name < cj.Slot.Name
+}
+
+func getConnections(c *Command, r *http.Request, user *auth.UserState) Response {
+	query := r.URL.Query()
+	qselect := query.Get("select")
+	if qselect != "" && qselect != "all" {
+		return BadRequest("unsupported select qualifier")
+	}
+	snapName := query.Get("snap")
+	ifaceName := query.Get("interface")
+
+	st := c.d.overlord.State()
+	st.Lock()
+	connStates, err := ifacestate.ConnectionStates(st)
+	st.Unlock()
+	if err != nil {
+		return InternalError("%v", err)
+	}
+
+	repo := c.d.overlord.InterfaceManager().Repository()
+	conns := []connectionJSON{}
+	for id, cs := range connStates {
+		if cs.Undesired && qselect != "all" {
+			continue
+		}
+		connRef, err := interfaces.ParseConnRef(id)
+		if err != nil {
+			return InternalError("%v", err)
+		}
+		if snapName != "" && connRef.PlugRef.Snap != snapName && connRef.SlotRef.Snap != snapName {
+			continue
+		}
+		conn := connectionJSON{
+			Slot:      connRef.SlotRef,
+			Plug:      connRef.PlugRef,
+			Interface: cs.Interface,
+			Origin:    cs.Origin(),
+			Undesired: cs.Undesired,
+		}
+		if plug := repo.Plug(connRef.PlugRef.Snap, connRef.PlugRef.Name); plug != nil {
+			conn.PlugAttrs = interfaces.MergeAttrs(plug.Attrs, cs.DynamicPlugAttrs)
+			if conn.Interface == "" {
+				conn.Interface = plug.Interface
+			}
+		}
+		if slot := repo.Slot(connRef.SlotRef.Snap, connRef.SlotRef.Name); slot != nil {
+			conn.SlotAttrs = interfaces.MergeAttrs(slot.Attrs, cs.DynamicSlotAttrs)
+		}
+		if ifaceName != "" && conn.Interface != ifaceName {
+			continue
+		}
+		conns = append(conns, conn)
+	}
+	sort.Sort(byConnRef(conns))
+
+	return SyncResponse(conns, nil)
+}
+
 // plugJSON aids in marshaling Plug into JSON.
 type plugJSON struct {
 	Snap        string                 `json:"snap"`
@@ -1801,6 +2009,8 @@ type changeInfo struct {
 	SpawnTime time.Time  `json:"spawn-time,omitempty"`
 	ReadyTime *time.Time `json:"ready-time,omitempty"`
 
+	QueuedAfter []string `json:"queued-after,omitempty"`
+
 	Data map[string]*json.RawMessage `json:"data,omitempty"`
 }
 
@@ -1823,6 +2033,19 @@ type taskInfoProgress struct {
 }
 
 func change2changeInfo(chg *state.Change) *changeInfo {
+	chgInfo := change2changeInfoNoTasks(chg)
+
+	tasks := chg.Tasks()
+	taskInfos := make([]*taskInfo, len(tasks))
+	for j, t := range tasks {
+		taskInfos[j] = task2taskInfo(t)
+	}
+	chgInfo.Tasks = taskInfos
+
+	return chgInfo
+}
+
+func change2changeInfoNoTasks(chg *state.Change) *changeInfo {
 	status := chg.Status()
 	chgInfo := &changeInfo{
 		ID:      chg.ID(),
@@ -1840,32 +2063,9 @@ func change2changeInfo(chg *state.Change) *changeInfo {
 	if err := chg.Err(); err != nil {
 		chgInfo.Err = err.Error()
 	}
-
-	tasks := chg.Tasks()
-	taskInfos := make([]*taskInfo, len(tasks))
-	for j, t := range tasks {
-		label, done, total := t.Progress()
-
-		taskInfo := &taskInfo{
-			ID:      t.ID(),
-			Kind:    t.Kind(),
-			Summary: t.Summary(),
-			Status:  t.Status().String(),
-			Log:     t.Log(),
-			Progress: taskInfoProgress{
-				Label: label,
-				Done:  done,
-				Total: total,
-			},
-			SpawnTime: t.SpawnTime(),
-		}
-		readyTime := t.ReadyTime()
-		if !readyTime.IsZero() {
-			taskInfo.ReadyTime = &readyTime
-		}
-		taskInfos[j] = taskInfo
+	for _, after := range snapstate.QueuedAfter(chg) {
+		chgInfo.QueuedAfter = append(chgInfo.QueuedAfter, after.ID())
 	}
-	chgInfo.Tasks = taskInfos
 
 	var data map[string]*json.RawMessage
 	if chg.Get("api-data", &data) == nil {
@@ -1875,6 +2075,29 @@ func change2changeInfo(chg *state.Change) *changeInfo {
 	return chgInfo
 }
 
+func task2taskInfo(t *state.Task) *taskInfo {
+	label, done, total := t.Progress()
+
+	taskInfo := &taskInfo{
+		ID:      t.ID(),
+		Kind:    t.Kind(),
+		Summary: t.Summary(),
+		Status:  t.Status().String(),
+		Log:     t.Log(),
+		Progress: taskInfoProgress{
+			Label: label,
+			Done:  done,
+			Total: total,
+		},
+		SpawnTime: t.SpawnTime(),
+	}
+	readyTime := t.ReadyTime()
+	if !readyTime.IsZero() {
+		taskInfo.ReadyTime = &readyTime
+	}
+	return taskInfo
+}
+
 func getChange(c *Command, r *http.Request, user *auth.UserState) Response {
 	chID := muxVars(r)["id"]
 	state := c.d.overlord.State()
@@ -1888,6 +2111,39 @@ func getChange(c *Command, r *http.Request, user *auth.UserState) Response {
 	return SyncResponse(change2changeInfo(chg), nil)
 }
 
+type taskTimings struct {
+	ID      string        `json:"id"`
+	Kind    string        `json:"kind"`
+	Summary string        `json:"summary"`
+	Status  string        `json:"status"`
+	Timings []*state.Span `json:"timings,omitempty"`
+}
+
+func getChangeTimings(c *Command, r *http.Request, user *auth.UserState) Response {
+	chID := muxVars(r)["id"]
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(chID)
+	if chg == nil {
+		return NotFound("cannot find change with id %q", chID)
+	}
+
+	tasks := chg.Tasks()
+	timings := make([]*taskTimings, len(tasks))
+	for i, t := range tasks {
+		timings[i] = &taskTimings{
+			ID:      t.ID(),
+			Kind:    t.Kind(),
+			Summary: t.Summary(),
+			Status:  t.Status().String(),
+			Timings: t.Timings(),
+		}
+	}
+
+	return SyncResponse(timings, nil)
+}
+
 func getChanges(c *Command, r *http.Request, user *auth.UserState) Response {
 	query := r.URL.Query()
 	qselect := query.Get("select")
@@ -1943,6 +2199,21 @@ func getChanges(c *Command, r *http.Request, user *auth.UserState) Response {
 	return SyncResponse(chgInfos, nil)
 }
 
+func getEvents(c *Command, r *http.Request, user *auth.UserState) Response {
+	chID := r.URL.Query().Get("change")
+	st := c.d.overlord.State()
+	if chID != "" {
+		st.Lock()
+		chg := st.Change(chID)
+		st.Unlock()
+		if chg == nil {
+			return NotFound("cannot find change with id %q", chID)
+		}
+	}
+
+	return &eventsSeqResponse{st: st, changeID: chID}
+}
+
 func abortChange(c *Command, r *http.Request, user *auth.UserState) Response {
 	chID := muxVars(r)["id"]
 	state := c.d.overlord.State()
@@ -2276,8 +2547,67 @@ func convertBuyError(err error) Response {
 	}
 }
 
+func getWarnings(c *Command, r *http.Request, user *auth.UserState) Response {
+	query := r.URL.Query()
+	qselect := query.Get("select")
+	if qselect == "" {
+		qselect = "pending"
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var warnings []*state.Warning
+	switch qselect {
+	case "all":
+		warnings = st.AllWarnings()
+	case "pending":
+		warnings, _ = st.PendingWarnings()
+	default:
+		return BadRequest("select should be one of: all,pending")
+	}
+	if warnings == nil {
+		// no null
+		warnings = []*state.Warning{}
+	}
+
+	return SyncResponse(warnings, nil)
+}
+
+type warningsAction struct {
+	Action    string    `json:"action"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func postWarnings(c *Command, r *http.Request, user *auth.UserState) Response {
+	var a warningsAction
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&a); err != nil {
+		return BadRequest("cannot decode request body into a warnings action: %v", err)
+	}
+	if a.Action != "okay" {
+		return BadRequest("unknown warning action %q", a.Action)
+	}
+	if a.Timestamp.IsZero() {
+		return BadRequest("cannot acknowledge warnings without a timestamp")
+	}
+
+	st := c.d.overlord.State()
+	st.Lock()
+	defer st.Unlock()
+
+	n := st.OkayWarnings(a.Timestamp)
+
+	return SyncResponse(n, nil)
+}
+
 type debugAction struct {
 	Action string `json:"action"`
+	Params struct {
+		Plug interfaces.PlugRef `json:"plug"`
+		Slot interfaces.SlotRef `json:"slot"`
+	} `json:"params"`
 }
 
 func postDebug(c *Command, r *http.Request, user *auth.UserState) Response {
@@ -2303,11 +2633,112 @@ func postDebug(c *Command, r *http.Request, user *auth.UserState) Response {
 		return SyncResponse(map[string]interface{}{
 			"base-declaration": string(asserts.Encode(bd)),
 		}, nil)
+	case "connectivity":
+		return checkConnectivity(st)
+	case "connect-check":
+		return checkConnectPolicy(c, a.Params.Plug, a.Params.Slot)
 	default:
 		return BadRequest("unknown debug action: %v", a.Action)
 	}
 }
 
+var changesGauge = metrics.NewGauge("snapd_changes", "Number of changes in the state, by kind and status.", "kind", "status")
+
+func getMetrics(c *Command, r *http.Request, user *auth.UserState) Response {
+	st := c.d.overlord.State()
+	st.Lock()
+	changesGauge.Reset()
+	for _, chg := range st.Changes() {
+		changesGauge.Add(1, chg.Kind(), chg.Status().String())
+	}
+	st.Unlock()
+
+	return metricsResponse{}
+}
+
+// ConnectivityStatus is the result of a store connectivity check.
+type ConnectivityStatus struct {
+	Connectivity bool     `json:"connectivity"`
+	Unreachable  []string `json:"unreachable,omitempty"`
+}
+
+func checkConnectivity(st *state.State) Response {
+	theStore := storestate.Store(st)
+	// the store needs the state lock to look up the device and proxy
+	// store details
+	st.Unlock()
+	status, err := theStore.ConnectivityCheck()
+	st.Lock()
+	if err != nil {
+		return InternalError("cannot run connectivity check: %v", err)
+	}
+
+	unreachable := []string{}
+	for host, reachable := range status {
+		if !reachable {
+			unreachable = append(unreachable, host)
+		}
+	}
+	sort.Strings(unreachable)
+
+	return SyncResponse(ConnectivityStatus{
+		Connectivity: len(unreachable) == 0,
+		Unreachable:  unreachable,
+	}, nil)
+}
+
+// PolicyCheckResult is the outcome of checking a connection against the
+// snap and base declarations.
+type PolicyCheckResult struct {
+	Allowed bool     `json:"allowed"`
+	Error   string   `json:"error,omitempty"`
+	Trace   []string `json:"trace"`
+}
+
+func policyCheckResult(check *ifacestate.PolicyCheck) PolicyCheckResult {
+	res := PolicyCheckResult{
+		Allowed: check.Error == nil,
+		Trace:   check.Trace,
+	}
+	if check.Error != nil {
+		res.Error = check.Error.Error()
+	}
+	if res.Trace == nil {
+		res.Trace = []string{}
+	}
+	return res
+}
+
+// ConnectCheckResult explains whether a plug and a slot can be connected
+// manually and automatically.
+type ConnectCheckResult struct {
+	Connection     PolicyCheckResult `json:"connection"`
+	AutoConnection PolicyCheckResult `json:"auto-connection"`
+}
+
+func checkConnectPolicy(c *Command, plugRef interfaces.PlugRef, slotRef interfaces.SlotRef) Response {
+	if plugRef.Snap == "" || plugRef.Name == "" || slotRef.Name == "" {
+		return BadRequest("connect-check needs a plug and a slot")
+	}
+	ifaceMgr := c.d.overlord.InterfaceManager()
+	if slotRef.Snap == "" {
+		// the slot is on the core snap, as when connecting
+		connRef, err := ifaceMgr.Repository().ResolveConnect(plugRef.Snap, plugRef.Name, slotRef.Snap, slotRef.Name)
+		if err != nil {
+			return BadRequest("cannot check connection policy: %v", err)
+		}
+		slotRef = connRef.SlotRef
+	}
+	connect, autoConnect, err := ifaceMgr.CheckConnectPolicy(plugRef, slotRef)
+	if err != nil {
+		return BadRequest("cannot check connection policy: %v", err)
+	}
+	return SyncResponse(ConnectCheckResult{
+		Connection:     policyCheckResult(connect),
+		AutoConnection: policyCheckResult(autoConnect),
+	}, nil)
+}
+
 func postBuy(c *Command, r *http.Request, user *auth.UserState) Response {
 	var opts store.BuyOptions
 
@@ -91,6 +91,8 @@ type apiBaseSuite struct {
 	restoreRelease    func()
 	trustedRestorer   func()
 
+	connectivityResult map[string]bool
+
 	systemctlRestorer func()
 	sysctlArgses      [][]string
 	sysctlBufs        [][]byte
@@ -151,6 +153,10 @@ func (s *apiBaseSuite) ReadyToBuy(user *auth.UserState) error {
 	return s.err
 }
 
+func (s *apiBaseSuite) ConnectivityCheck() (map[string]bool, error) {
+	return s.connectivityResult, s.err
+}
+
 func (s *apiBaseSuite) muxVars(*http.Request) map[string]string {
 	return s.vars
 }
@@ -716,6 +722,7 @@ func (s *apiSuite) TestListIncludesAll(c *check.C) {
 		"storeUserInfo",
 		"postCreateUserUcrednetGet",
 		"ensureStateSoon",
+		"changesGauge",
 	}
 	c.Check(found, check.Equals, len(api)+len(exceptions),
 		check.Commentf(`At a glance it looks like you've not added all the Commands defined in api to the api list. If that is not the case, please add the exception to the "exceptions" list in this test.`))
@@ -1947,6 +1954,101 @@ func (s *apiSuite) TestPostSnapSetsUser(c *check.C) {
 	c.Check(chg.Summary(), check.Equals, "<install by user 1>")
 }
 
+func (s *apiSuite) TestPostSnapQueue(c *check.C) {
+	d := s.daemonWithOverlordMock(c)
+	ensureStateSoon = func(st *state.State) {}
+	s.vars = map[string]string{"name": "foo"}
+
+	st := d.overlord.State()
+	st.Lock()
+	inFlight := st.NewChange("refresh-snap", "...")
+	t0 := st.NewTask("link-snap", "...")
+	t0.Set("snap-setup", &snapstate.SnapSetup{SideInfo: &snap.SideInfo{RealName: "foo"}})
+	inFlight.AddTask(t0)
+	st.Unlock()
+
+	buf := bytes.NewBufferString(`{"action": "remove", "queue": true}`)
+	req, err := http.NewRequest("POST", "/v2/snaps/foo", buf)
+	c.Assert(err, check.IsNil)
+
+	rsp := postSnap(snapCmd, req, nil).(*resp)
+	c.Assert(rsp.Type, check.Equals, ResponseTypeAsync)
+
+	st.Lock()
+	defer st.Unlock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.Kind(), check.Equals, "remove-snap")
+	c.Check(chg.Summary(), check.Equals, fmt.Sprintf(`Remove "foo" snap after change %s`, inFlight.ID()))
+	tasks := chg.Tasks()
+	c.Assert(tasks, check.HasLen, 1)
+	c.Check(tasks[0].Kind(), check.Equals, "run-queued")
+	c.Check(tasks[0].WaitTasks(), check.DeepEquals, []*state.Task{t0})
+	c.Check(change2changeInfo(chg).QueuedAfter, check.DeepEquals, []string{inFlight.ID()})
+
+	// once the change in flight is done the instruction is carried out
+	snapInstructionDispTable["remove"] = func(inst *snapInstruction, st *state.State) (string, []*state.TaskSet, error) {
+		c.Check(inst.Snaps, check.DeepEquals, []string{"foo"})
+		return "", []*state.TaskSet{state.NewTaskSet(st.NewTask("fake-remove", "..."))}, nil
+	}
+	defer func() {
+		snapInstructionDispTable["remove"] = snapRemove
+	}()
+	t0.SetStatus(state.DoneStatus)
+	c.Check(change2changeInfo(chg).QueuedAfter, check.HasLen, 0)
+
+	tsets, err := runSnapInstruction(tasks[0])
+	c.Assert(err, check.IsNil)
+	c.Assert(tsets, check.HasLen, 1)
+	c.Check(tsets[0].Tasks()[0].Kind(), check.Equals, "fake-remove")
+}
+
+func (s *apiSuite) TestPostSnapQueueAfter(c *check.C) {
+	d := s.daemonWithOverlordMock(c)
+	ensureStateSoon = func(st *state.State) {}
+	s.vars = map[string]string{"name": "foo"}
+
+	st := d.overlord.State()
+	st.Lock()
+	other := st.NewChange("install-snap", "...")
+	other.AddTask(st.NewTask("download-snap", "..."))
+	st.Unlock()
+
+	buf := bytes.NewBufferString(fmt.Sprintf(`{"action": "remove", "after": %q}`, other.ID()))
+	req, err := http.NewRequest("POST", "/v2/snaps/foo", buf)
+	c.Assert(err, check.IsNil)
+
+	rsp := postSnap(snapCmd, req, nil).(*resp)
+	c.Assert(rsp.Type, check.Equals, ResponseTypeAsync)
+
+	st.Lock()
+	chg := st.Change(rsp.Change)
+	c.Assert(chg, check.NotNil)
+	c.Check(chg.WaitChanges(), check.DeepEquals, []*state.Change{other})
+	st.Unlock()
+
+	buf = bytes.NewBufferString(`{"action": "remove", "after": "999"}`)
+	req, err = http.NewRequest("POST", "/v2/snaps/foo", buf)
+	c.Assert(err, check.IsNil)
+
+	rsp = postSnap(snapCmd, req, nil).(*resp)
+	c.Check(rsp.Status, check.Equals, 400)
+	c.Check(rsp.Result.(*errorResult).Message, check.Equals, `cannot find change "999" to queue after`)
+}
+
+func (s *apiSuite) TestPostSnapsOpQueueUnsupported(c *check.C) {
+	s.daemonWithOverlordMock(c)
+
+	buf := bytes.NewBufferString(`{"action": "refresh", "queue": true}`)
+	req, err := http.NewRequest("POST", "/v2/snaps", buf)
+	c.Assert(err, check.IsNil)
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp := postSnaps(snapsCmd, req, nil).(*resp)
+	c.Check(rsp.Status, check.Equals, 400)
+	c.Check(rsp.Result.(*errorResult).Message, check.Equals, "unsupported option provided for multi-snap operation")
+}
+
 func (s *apiSuite) TestPostSnapDispatch(c *check.C) {
 	inst := &snapInstruction{Snaps: []string{"foo"}}
 
@@ -3329,7 +3431,7 @@ func (s *apiSuite) TestInterfaces(c *check.C) {
 		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
 		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
 	}
-	c.Assert(repo.Connect(connRef), check.IsNil)
+	c.Assert(repo.Connect(connRef, nil, nil), check.IsNil)
 
 	req, err := http.NewRequest("GET", "/v2/interfaces", nil)
 	c.Assert(err, check.IsNil)
@@ -3374,6 +3476,89 @@ func (s *apiSuite) TestInterfaces(c *check.C) {
 	})
 }
 
+// Tests for GET /v2/connections
+
+func (s *apiSuite) testConnections(c *check.C, query string) interface{} {
+	req, err := http.NewRequest("GET", "/v2/connections"+query, nil)
+	c.Assert(err, check.IsNil)
+	rec := httptest.NewRecorder()
+	connectionsCmd.GET(connectionsCmd, req, nil).ServeHTTP(rec, req)
+	var body map[string]interface{}
+	c.Assert(json.Unmarshal(rec.Body.Bytes(), &body), check.IsNil)
+	if rec.Code != 200 {
+		c.Check(body["type"], check.Equals, "error")
+		return body["result"]
+	}
+	c.Check(body["type"], check.Equals, "sync")
+	return body["result"]
+}
+
+func (s *apiSuite) TestConnections(c *check.C) {
+	d := s.daemon(c)
+
+	s.mockIface(c, &ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	st := d.overlord.State()
+	st.Lock()
+	st.Set("conns", map[string]interface{}{
+		"consumer:plug producer:slot":  map[string]interface{}{"interface": "test", "auto": true, "plug-dynamic": map[string]interface{}{"number": 42}},
+		"consumer:other producer:slot": map[string]interface{}{"interface": "other"},
+		"other:plug producer:slot":     map[string]interface{}{"interface": "test", "undesired": true},
+	})
+	st.Unlock()
+
+	c.Check(s.testConnections(c, ""), check.DeepEquals, []interface{}{
+		map[string]interface{}{
+			"plug":       map[string]interface{}{"snap": "consumer", "plug": "other"},
+			"slot":       map[string]interface{}{"snap": "producer", "slot": "slot"},
+			"interface":  "other",
+			"origin":     "manual",
+			"slot-attrs": map[string]interface{}{"key": "value"},
+		},
+		map[string]interface{}{
+			"plug":       map[string]interface{}{"snap": "consumer", "plug": "plug"},
+			"slot":       map[string]interface{}{"snap": "producer", "slot": "slot"},
+			"interface":  "test",
+			"origin":     "auto",
+			"plug-attrs": map[string]interface{}{"key": "value", "number": 42.0},
+			"slot-attrs": map[string]interface{}{"key": "value"},
+		},
+	})
+
+	c.Check(s.testConnections(c, "?select=all&interface=test"), check.DeepEquals, []interface{}{
+		map[string]interface{}{
+			"plug":       map[string]interface{}{"snap": "consumer", "plug": "plug"},
+			"slot":       map[string]interface{}{"snap": "producer", "slot": "slot"},
+			"interface":  "test",
+			"origin":     "auto",
+			"plug-attrs": map[string]interface{}{"key": "value", "number": 42.0},
+			"slot-attrs": map[string]interface{}{"key": "value"},
+		},
+		map[string]interface{}{
+			"plug":       map[string]interface{}{"snap": "other", "plug": "plug"},
+			"slot":       map[string]interface{}{"snap": "producer", "slot": "slot"},
+			"interface":  "test",
+			"origin":     "manual",
+			"undesired":  true,
+			"slot-attrs": map[string]interface{}{"key": "value"},
+		},
+	})
+
+	c.Check(s.testConnections(c, "?select=all&snap=other"), check.HasLen, 1)
+	c.Check(s.testConnections(c, "?snap=other"), check.HasLen, 0)
+	c.Check(s.testConnections(c, "?snap=producer"), check.HasLen, 2)
+}
+
+func (s *apiSuite) TestConnectionsBadSelect(c *check.C) {
+	s.daemon(c)
+
+	c.Check(s.testConnections(c, "?select=connected"), check.DeepEquals, map[string]interface{}{
+		"message": "unsupported select qualifier",
+	})
+}
+
 /**
 // Tests for GET /v2/interface (note: singular!)
 
@@ -3394,7 +3579,7 @@ func (s *apiSuite) TestInterfaceIndex(c *check.C) {
 		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
 		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
 	}
-	c.Assert(repo.Connect(connRef), check.IsNil)
+	c.Assert(repo.Connect(connRef, nil, nil), check.IsNil)
 
 	req, err := http.NewRequest("GET", "/v2/interface", nil)
 	c.Assert(err, check.IsNil)
@@ -3674,7 +3859,7 @@ func (s *apiSuite) testDisconnect(c *check.C, plugSnap, plugName, slotSnap, slot
 		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
 		SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
 	}
-	c.Assert(repo.Connect(connRef), check.IsNil)
+	c.Assert(repo.Connect(connRef, nil, nil), check.IsNil)
 
 	d.overlord.Loop()
 	defer d.overlord.Stop()
@@ -4317,6 +4502,276 @@ func (s *apiSuite) TestStateChange(c *check.C) {
 	})
 }
 
+func (s *apiSuite) TestStateChangeTimings(c *check.C) {
+	restore := state.MockTime(time.Date(2016, 04, 21, 1, 2, 3, 0, time.UTC))
+	defer restore()
+
+	// Setup
+	d := newTestDaemon(c)
+	st := d.overlord.State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+	s.vars = map[string]string{"id": ids[0]}
+
+	runner := state.NewTaskRunner(st)
+	runner.AddHandler("download", func(t *state.Task, _ *tomb.Tomb) error {
+		span := t.StartSpan("fetch", "Fetch the snap")
+		span.Stop()
+		return nil
+	}, nil)
+	runner.Ensure()
+	runner.Wait()
+
+	// Execute
+	req, err := http.NewRequest("GET", "/v2/changes/"+ids[0]+"/timings", nil)
+	c.Assert(err, check.IsNil)
+	rsp := getChangeTimings(stateChangeTimingsCmd, req, nil).(*resp)
+	rec := httptest.NewRecorder()
+	rsp.ServeHTTP(rec, req)
+
+	// Verify
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(rsp.Type, check.Equals, ResponseTypeSync)
+
+	var body map[string]interface{}
+	err = json.Unmarshal(rec.Body.Bytes(), &body)
+	c.Check(err, check.IsNil)
+	c.Check(body["result"], check.DeepEquals, []interface{}{
+		map[string]interface{}{
+			"id":      ids[2],
+			"kind":    "download",
+			"summary": "1...",
+			"status":  "Done",
+			"timings": []interface{}{
+				map[string]interface{}{
+					"label":    "do",
+					"start":    "2016-04-21T01:02:03Z",
+					"duration": 0.,
+					"spans": []interface{}{
+						map[string]interface{}{
+							"label":    "fetch",
+							"summary":  "Fetch the snap",
+							"start":    "2016-04-21T01:02:03Z",
+							"duration": 0.,
+						},
+					},
+				},
+			},
+		},
+		map[string]interface{}{
+			"id":      ids[3],
+			"kind":    "activate",
+			"summary": "2...",
+			"status":  "Do",
+		},
+	})
+}
+
+func (s *apiSuite) TestStateChangeTimingsNotFound(c *check.C) {
+	d := newTestDaemon(c)
+	st := d.overlord.State()
+	st.Lock()
+	setupChanges(st)
+	st.Unlock()
+	s.vars = map[string]string{"id": "nope"}
+
+	req, err := http.NewRequest("GET", "/v2/changes/nope/timings", nil)
+	c.Assert(err, check.IsNil)
+	rsp := getChangeTimings(stateChangeTimingsCmd, req, nil).(*resp)
+	c.Check(rsp.Status, check.Equals, 404)
+}
+
+// streamRecorder is a ResponseRecorder that can be closed and that
+// blocks on every flush until the test receives from flushed.
+type streamRecorder struct {
+	*httptest.ResponseRecorder
+	closed  chan bool
+	flushed chan bool
+}
+
+func newStreamRecorder() *streamRecorder {
+	return &streamRecorder{
+		ResponseRecorder: httptest.NewRecorder(),
+		closed:           make(chan bool, 1),
+		flushed:          make(chan bool),
+	}
+}
+
+func (r *streamRecorder) CloseNotify() <-chan bool {
+	return r.closed
+}
+
+func (r *streamRecorder) Flush() {
+	r.ResponseRecorder.Flush()
+	r.flushed <- true
+}
+
+// serve serves rsp into rec in the background, returning a function
+// that lets the stream proceed until its end.
+func (r *streamRecorder) serve(c *check.C, rsp Response, req *http.Request) (finish func()) {
+	done := make(chan struct{})
+	go func() {
+		rsp.ServeHTTP(r, req)
+		close(done)
+	}()
+	return func() {
+		for {
+			select {
+			case <-r.flushed:
+			case <-done:
+				return
+			case <-time.After(5 * time.Second):
+				c.Fatal("events stream did not end")
+			}
+		}
+	}
+}
+
+func decodeEventsSeq(c *check.C, body []byte) []map[string]interface{} {
+	var events []map[string]interface{}
+	for _, rec := range bytes.Split(body, []byte{0x1E}) {
+		if len(rec) == 0 {
+			continue
+		}
+		var ev map[string]interface{}
+		c.Assert(json.Unmarshal(rec, &ev), check.IsNil)
+		events = append(events, ev)
+	}
+	return events
+}
+
+func (s *apiSuite) TestEventsChange(c *check.C) {
+	d := s.daemon(c)
+	st := d.overlord.State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/events?change="+ids[0], nil)
+	c.Assert(err, check.IsNil)
+	rsp := getEvents(eventsCmd, req, nil)
+	rec := newStreamRecorder()
+	finish := rec.serve(c, rsp, req)
+	// the current status of the change
+	<-rec.flushed
+
+	st.Lock()
+	// not the watched change
+	st.NewChange("other", "...")
+	t1 := st.Task(ids[2])
+	t1.SetStatus(state.DoingStatus)
+	t1.SetProgress("downloading", 1, 2)
+	t1.SetStatus(state.DoneStatus)
+	st.Task(ids[3]).SetStatus(state.DoneStatus)
+	st.Unlock()
+
+	// the stream ends with the change
+	finish()
+
+	c.Check(rec.HeaderMap.Get("Content-Type"), check.Equals, "application/json-seq")
+	events := decodeEventsSeq(c, rec.Body.Bytes())
+	c.Assert(events, check.HasLen, 5)
+	var types []string
+	for _, ev := range events {
+		types = append(types, ev["type"].(string))
+		c.Check(ev["change"].(map[string]interface{})["id"], check.Equals, ids[0])
+	}
+	c.Check(types, check.DeepEquals, []string{"change-status", "task-status", "task-progress", "task-status", "task-status"})
+	c.Check(events[0]["change"].(map[string]interface{})["status"], check.Equals, "Do")
+	c.Check(events[0]["task"], check.IsNil)
+	c.Check(events[2]["task"].(map[string]interface{})["progress"], check.DeepEquals, map[string]interface{}{"label": "downloading", "done": 1., "total": 2.})
+	last := events[4]
+	c.Check(last["change"].(map[string]interface{})["ready"], check.Equals, true)
+	c.Check(last["change"].(map[string]interface{})["status"], check.Equals, "Done")
+	c.Check(last["task"].(map[string]interface{})["id"], check.Equals, ids[3])
+}
+
+func (s *apiSuite) TestEventsChangeNotFound(c *check.C) {
+	s.daemon(c)
+
+	req, err := http.NewRequest("GET", "/v2/events?change=42", nil)
+	c.Assert(err, check.IsNil)
+	rsp := getEvents(eventsCmd, req, nil).(*resp)
+	c.Check(rsp.Status, check.Equals, 404)
+}
+
+func (s *apiSuite) TestEventsAll(c *check.C) {
+	d := s.daemon(c)
+	st := d.overlord.State()
+
+	req, err := http.NewRequest("GET", "/v2/events", nil)
+	c.Assert(err, check.IsNil)
+	rsp := getEvents(eventsCmd, req, nil)
+	rec := newStreamRecorder()
+	finish := rec.serve(c, rsp, req)
+
+	// create changes until the stream is set up and sends one
+	for {
+		st.Lock()
+		st.NewChange("install", "...")
+		st.Unlock()
+		select {
+		case <-rec.flushed:
+		case <-time.After(10 * time.Millisecond):
+			continue
+		}
+		break
+	}
+	st.Lock()
+	st.Change("1").SetStatus(state.DoneStatus)
+	st.Unlock()
+	<-rec.flushed
+
+	rec.closed <- true
+	finish()
+
+	events := decodeEventsSeq(c, rec.Body.Bytes())
+	c.Assert(events, check.HasLen, 2)
+	c.Check(events[0]["type"], check.Equals, "change-added")
+	c.Check(events[1]["type"], check.Equals, "change-status")
+	c.Check(events[1]["change"].(map[string]interface{})["status"], check.Equals, "Done")
+
+	st.Lock()
+	defer st.Unlock()
+	// nobody listens anymore
+	st.NewChange("install", "...")
+}
+
+func (s *apiSuite) TestEventsOverflow(c *check.C) {
+	defer func(n int) { eventsBufferSize = n }(eventsBufferSize)
+	eventsBufferSize = 2
+
+	d := s.daemon(c)
+	st := d.overlord.State()
+	st.Lock()
+	ids := setupChanges(st)
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/events?change="+ids[0], nil)
+	c.Assert(err, check.IsNil)
+	rsp := getEvents(eventsCmd, req, nil)
+	rec := newStreamRecorder()
+	finish := rec.serve(c, rsp, req)
+	<-rec.flushed
+
+	// the stream is blocked flushing at most one of these
+	st.Lock()
+	t1 := st.Task(ids[2])
+	for i := 1; i < 10; i++ {
+		t1.SetProgress("downloading", i, 10)
+	}
+	st.Unlock()
+
+	finish()
+
+	// the current status, the queued events and maybe the blocked
+	// one, and the error
+	events := decodeEventsSeq(c, rec.Body.Bytes())
+	c.Check(len(events) == 4 || len(events) == 5, check.Equals, true)
+	c.Check(events[len(events)-1], check.DeepEquals, map[string]interface{}{"error": "too many pending events"})
+}
+
 func (s *apiSuite) TestStateChangeAbort(c *check.C) {
 	restore := state.MockTime(time.Date(2016, 04, 21, 1, 2, 3, 0, time.UTC))
 	defer restore()
@@ -5692,6 +6147,263 @@ func (s *postDebugSuite) TestPostDebugGetBaseDeclaration(c *check.C) {
 		testutil.Contains, "type: base-declaration")
 }
 
+func (s *postDebugSuite) TestPostDebugConnectivityHappy(c *check.C) {
+	_ = s.daemon(c)
+
+	s.connectivityResult = map[string]bool{
+		"good.host.com":         true,
+		"another.good.host.com": true,
+	}
+
+	buf := bytes.NewBufferString(`{"action": "connectivity"}`)
+	req, err := http.NewRequest("POST", "/v2/debug", buf)
+	c.Assert(err, check.IsNil)
+
+	rsp := postDebug(debugCmd, req, nil).(*resp)
+
+	c.Check(rsp.Type, check.Equals, ResponseTypeSync)
+	c.Check(rsp.Result, check.DeepEquals, ConnectivityStatus{
+		Connectivity: true,
+		Unreachable:  []string{},
+	})
+}
+
+func (s *postDebugSuite) TestPostDebugConnectivityUnhappy(c *check.C) {
+	_ = s.daemon(c)
+
+	s.connectivityResult = map[string]bool{
+		"good.host.com": true,
+		"bad.host.com":  false,
+	}
+
+	buf := bytes.NewBufferString(`{"action": "connectivity"}`)
+	req, err := http.NewRequest("POST", "/v2/debug", buf)
+	c.Assert(err, check.IsNil)
+
+	rsp := postDebug(debugCmd, req, nil).(*resp)
+
+	c.Check(rsp.Type, check.Equals, ResponseTypeSync)
+	c.Check(rsp.Result, check.DeepEquals, ConnectivityStatus{
+		Connectivity: false,
+		Unreachable:  []string{"bad.host.com"},
+	})
+}
+
+func (s *apiSuite) TestPostDebugConnectCheck(c *check.C) {
+	_ = s.daemon(c)
+
+	s.mockIface(c, &ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	buf := bytes.NewBufferString(`{"action": "connect-check", "params": {"plug": {"snap": "consumer", "plug": "plug"}, "slot": {"snap": "producer", "slot": "slot"}}}`)
+	req, err := http.NewRequest("POST", "/v2/debug", buf)
+	c.Assert(err, check.IsNil)
+
+	rsp := postDebug(debugCmd, req, nil).(*resp)
+
+	c.Check(rsp.Type, check.Equals, ResponseTypeSync)
+	trace := []string{
+		`plug snap "consumer" has no snap-declaration`,
+		`slot snap "producer" has no snap-declaration`,
+		`no rule for interface "test"`,
+	}
+	c.Check(rsp.Result, check.DeepEquals, ConnectCheckResult{
+		Connection:     PolicyCheckResult{Allowed: true, Trace: trace},
+		AutoConnection: PolicyCheckResult{Allowed: true, Trace: trace},
+	})
+}
+
+func (s *apiSuite) TestPostDebugConnectCheckCoreSlot(c *check.C) {
+	_ = s.daemon(c)
+
+	s.mockIface(c, &ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, `
+name: core
+version: 1
+type: os
+slots:
+ slot:
+  interface: test
+`)
+
+	buf := bytes.NewBufferString(`{"action": "connect-check", "params": {"plug": {"snap": "consumer", "plug": "plug"}, "slot": {"slot": "slot"}}}`)
+	req, err := http.NewRequest("POST", "/v2/debug", buf)
+	c.Assert(err, check.IsNil)
+
+	rsp := postDebug(debugCmd, req, nil).(*resp)
+
+	c.Check(rsp.Type, check.Equals, ResponseTypeSync)
+	trace := []string{
+		`plug snap "consumer" has no snap-declaration`,
+		`slot snap "core" has no snap-declaration`,
+		`no rule for interface "test"`,
+	}
+	c.Check(rsp.Result, check.DeepEquals, ConnectCheckResult{
+		Connection:     PolicyCheckResult{Allowed: true, Trace: trace},
+		AutoConnection: PolicyCheckResult{Allowed: true, Trace: trace},
+	})
+}
+
+func (s *apiSuite) TestPostDebugConnectCheckErrors(c *check.C) {
+	_ = s.daemon(c)
+
+	s.mockIface(c, &ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnap(c, consumerYaml)
+
+	for _, t := range []struct {
+		body string
+		err  string
+	}{
+		{`{"action": "connect-check"}`, `connect-check needs a plug and a slot`},
+		{`{"action": "connect-check", "params": {"plug": {"snap": "consumer", "plug": "plug"}, "slot": {"snap": "producer", "slot": "slot"}}}`,
+			`cannot check connection policy: snap "producer" has no "slot" slot`},
+		{`{"action": "connect-check", "params": {"plug": {"snap": "consumer", "plug": "plug"}, "slot": {"slot": "slot"}}}`,
+			`cannot check connection policy: cannot resolve connection, slot snap name is empty`},
+	} {
+		req, err := http.NewRequest("POST", "/v2/debug", bytes.NewBufferString(t.body))
+		c.Assert(err, check.IsNil)
+
+		rsp := postDebug(debugCmd, req, nil).(*resp)
+		c.Check(rsp.Type, check.Equals, ResponseTypeError)
+		c.Check(rsp.Status, check.Equals, 400)
+		c.Check(rsp.Result.(*errorResult).Message, check.Equals, t.err)
+	}
+}
+
+func (s *postDebugSuite) TestGetDebugMetrics(c *check.C) {
+	d := s.daemon(c)
+
+	st := d.overlord.State()
+	st.Lock()
+	setupChanges(st)
+	st.Unlock()
+
+	req, err := http.NewRequest("GET", "/v2/debug/metrics", nil)
+	c.Assert(err, check.IsNil)
+	rec := httptest.NewRecorder()
+	getMetrics(debugMetricsCmd, req, nil).ServeHTTP(rec, req)
+
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(rec.HeaderMap.Get("Content-Type"), check.Equals, "text/plain; version=0.0.4")
+	body := rec.Body.String()
+	c.Check(body, check.Matches, `(?s).*\n# TYPE snapd_changes gauge\n.*`)
+	c.Check(body, check.Matches, `(?s).*\nsnapd_changes{kind="install",status="Do"} 1\n.*`)
+	c.Check(body, check.Matches, `(?s).*\nsnapd_changes{kind="remove",status="Error"} 1\n.*`)
+
+	// only root can see the metrics
+	c.Check(debugMetricsCmd.UserOK, check.Equals, false)
+	c.Check(debugMetricsCmd.GuestOK, check.Equals, false)
+}
+
+var _ = check.Suite(&warningsSuite{})
+
+type warningsSuite struct {
+	apiBaseSuite
+}
+
+func (s *warningsSuite) TestGetWarnings(c *check.C) {
+	d := s.daemonWithOverlordMock(c)
+
+	t0 := time.Date(2017, 11, 1, 10, 0, 0, 0, time.UTC)
+	restore := state.MockTime(t0)
+	defer restore()
+
+	st := d.overlord.State()
+	st.Lock()
+	st.Warnf("hello")
+	st.Warnf("world")
+	st.OkayWarnings(t0)
+	st.Unlock()
+	restore = state.MockTime(t0.Add(time.Minute))
+	defer restore()
+	st.Lock()
+	st.Warnf("world")
+	st.Warnf("again")
+	st.Unlock()
+
+	for _, t := range []struct {
+		query    string
+		messages []string
+	}{
+		{"", []string{"again"}},
+		{"?select=pending", []string{"again"}},
+		{"?select=all", []string{"hello", "world", "again"}},
+	} {
+		req, err := http.NewRequest("GET", "/v2/warnings"+t.query, nil)
+		c.Assert(err, check.IsNil)
+		rsp := getWarnings(warningsCmd, req, nil).(*resp)
+		c.Assert(rsp.Type, check.Equals, ResponseTypeSync, check.Commentf(t.query))
+
+		var ws []map[string]interface{}
+		data, err := json.Marshal(rsp.Result)
+		c.Assert(err, check.IsNil)
+		c.Assert(json.Unmarshal(data, &ws), check.IsNil)
+		messages := make([]string, len(ws))
+		for i, w := range ws {
+			messages[i] = w["message"].(string)
+		}
+		c.Check(messages, check.DeepEquals, t.messages, check.Commentf(t.query))
+	}
+
+	req, err := http.NewRequest("GET", "/v2/warnings?select=foo", nil)
+	c.Assert(err, check.IsNil)
+	rsp := getWarnings(warningsCmd, req, nil).(*resp)
+	c.Check(rsp.Status, check.Equals, 400)
+	c.Check(rsp.Result.(*errorResult).Message, check.Equals, "select should be one of: all,pending")
+}
+
+func (s *warningsSuite) TestGetWarningsNone(c *check.C) {
+	s.daemonWithOverlordMock(c)
+
+	req, err := http.NewRequest("GET", "/v2/warnings", nil)
+	c.Assert(err, check.IsNil)
+	rsp := getWarnings(warningsCmd, req, nil).(*resp)
+	c.Check(rsp.Result, check.DeepEquals, []*state.Warning{})
+}
+
+func (s *warningsSuite) TestPostWarningsOkay(c *check.C) {
+	d := s.daemonWithOverlordMock(c)
+
+	t0 := time.Date(2017, 11, 1, 10, 0, 0, 0, time.UTC)
+	restore := state.MockTime(t0)
+	defer restore()
+
+	st := d.overlord.State()
+	st.Lock()
+	st.Warnf("hello")
+	st.Unlock()
+
+	buf := bytes.NewBufferString(`{"action": "okay", "timestamp": "2017-11-01T10:00:00Z"}`)
+	req, err := http.NewRequest("POST", "/v2/warnings", buf)
+	c.Assert(err, check.IsNil)
+	rsp := postWarnings(warningsCmd, req, nil).(*resp)
+	c.Assert(rsp.Type, check.Equals, ResponseTypeSync)
+	c.Check(rsp.Result, check.Equals, 1)
+
+	st.Lock()
+	defer st.Unlock()
+	n, _ := st.WarningsSummary()
+	c.Check(n, check.Equals, 0)
+}
+
+func (s *warningsSuite) TestPostWarningsErrors(c *check.C) {
+	s.daemonWithOverlordMock(c)
+
+	for body, msg := range map[string]string{
+		`{"action": "snooze", "timestamp": "2017-11-01T10:00:00Z"}`: `unknown warning action "snooze"`,
+		`{"action": "okay"}`: "cannot acknowledge warnings without a timestamp",
+		`}`:                  "cannot decode request body into a warnings action: .*",
+	} {
+		req, err := http.NewRequest("POST", "/v2/warnings", bytes.NewBufferString(body))
+		c.Assert(err, check.IsNil)
+		rsp := postWarnings(warningsCmd, req, nil).(*resp)
+		c.Check(rsp.Status, check.Equals, 400, check.Commentf(body))
+		c.Check(rsp.Result.(*errorResult).Message, check.Matches, msg, check.Commentf(body))
+	}
+}
+
 type appSuite struct {
 	apiBaseSuite
 	cmd *testutil.MockCmd
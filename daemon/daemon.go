@@ -179,6 +179,15 @@ func (c *Command) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		rsp = rspf(c, r, user)
 	}
 
+	state.Lock()
+	count, stamp := state.WarningsSummary()
+	state.Unlock()
+	if count > 0 {
+		// let clients tell the user there is something to look at
+		w.Header().Set(client.WarningCountHeader, strconv.Itoa(count))
+		w.Header().Set(client.WarningTimestampHeader, stamp.Format(time.RFC3339Nano))
+	}
+
 	rsp.ServeHTTP(w, r)
 }
 
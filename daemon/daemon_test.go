@@ -140,6 +140,38 @@ func (s *daemonSuite) TestCommandMethodDispatch(c *check.C) {
 	c.Check(rec.Code, check.Equals, 405)
 }
 
+func (s *daemonSuite) TestCommandWarningHeaders(c *check.C) {
+	d := newTestDaemon(c)
+	cmd := &Command{d: d}
+	mck := &mockHandler{cmd: cmd}
+	cmd.GET = mkRF(c, cmd, mck)
+
+	req, err := http.NewRequest("GET", "", nil)
+	c.Assert(err, check.IsNil)
+	req.RemoteAddr = "pid=100;uid=0;" + req.RemoteAddr
+
+	rec := httptest.NewRecorder()
+	cmd.ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(rec.HeaderMap.Get(client.WarningCountHeader), check.Equals, "")
+	c.Check(rec.HeaderMap.Get(client.WarningTimestampHeader), check.Equals, "")
+
+	t0 := time.Date(2017, 11, 1, 10, 0, 0, 0, time.UTC)
+	restore := state.MockTime(t0)
+	defer restore()
+	st := d.overlord.State()
+	st.Lock()
+	st.Warnf("hello")
+	st.Warnf("world")
+	st.Unlock()
+
+	rec = httptest.NewRecorder()
+	cmd.ServeHTTP(rec, req)
+	c.Check(rec.Code, check.Equals, 200)
+	c.Check(rec.HeaderMap.Get(client.WarningCountHeader), check.Equals, "2")
+	c.Check(rec.HeaderMap.Get(client.WarningTimestampHeader), check.Equals, "2017-11-01T10:00:00Z")
+}
+
 func (s *daemonSuite) TestGuestAccess(c *check.C) {
 	get := &http.Request{Method: "GET"}
 	put := &http.Request{Method: "PUT"}
@@ -32,6 +32,8 @@ import (
 	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/client"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/metrics"
+	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/systemd"
 )
 
@@ -269,6 +271,128 @@ func (rr *journalLineReaderSeqResponse) ServeHTTP(w http.ResponseWriter, r *http
 	rr.Close()
 }
 
+// eventsBufferSize is how many events can be pending for a client of
+// an events stream before it's considered too slow and disconnected.
+var eventsBufferSize = 100
+
+// An eventsSeqResponse's ServeHTTP method streams, as a json-seq
+// response, the change and task events of the state as they happen,
+// possibly only those of a single change. In the latter case the
+// stream starts with the current status of the change and ends once it
+// is ready.
+type eventsSeqResponse struct {
+	st       *state.State
+	changeID string
+}
+
+type eventInfo struct {
+	Type   state.EventType `json:"type"`
+	Change *changeInfo     `json:"change"`
+	Task   *taskInfo       `json:"task,omitempty"`
+}
+
+func event2eventInfo(ev state.Event) *eventInfo {
+	info := &eventInfo{
+		Type:   ev.Type,
+		Change: change2changeInfoNoTasks(ev.Change),
+	}
+	if ev.Task != nil {
+		info.Task = task2taskInfo(ev.Task)
+	}
+	return info
+}
+
+func (er *eventsSeqResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json-seq")
+
+	flusher, hasFlusher := w.(http.Flusher)
+	var closed <-chan bool
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		closed = notifier.CloseNotify()
+	}
+
+	events := make(chan *eventInfo, eventsBufferSize)
+	overflow := make(chan struct{})
+	var ready <-chan struct{}
+
+	st := er.st
+	st.Lock()
+	// the handler is called with the state lock held
+	id := st.AddEventHandler(func(ev state.Event) {
+		if er.changeID != "" && ev.Change.ID() != er.changeID {
+			return
+		}
+		select {
+		case <-overflow:
+		case events <- event2eventInfo(ev):
+		default:
+			close(overflow)
+		}
+	})
+	if er.changeID != "" {
+		if chg := st.Change(er.changeID); chg != nil {
+			events <- event2eventInfo(state.Event{Type: state.ChangeStatusEvent, Change: chg})
+			ready = chg.Ready()
+		}
+	}
+	st.Unlock()
+
+	writer := bufio.NewWriter(w)
+	enc := json.NewEncoder(writer)
+	write := func(info *eventInfo) error {
+		writer.WriteByte(0x1E) // RS -- see ascii(7), and RFC7464
+		if err := enc.Encode(info); err != nil {
+			return err
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		if hasFlusher {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	var overflowed bool
+	drain := false
+loop:
+	for {
+		select {
+		case info := <-events:
+			if err := write(info); err != nil {
+				break loop
+			}
+		case <-overflow:
+			// send what was queued, then tell the client it fell behind
+			overflowed = true
+			drain = true
+			break loop
+		case <-ready:
+			drain = true
+			break loop
+		case <-closed:
+			break loop
+		}
+	}
+
+	// after this no more events are queued, as any concurrent
+	// emitter holds the state lock
+	st.Lock()
+	st.RemoveEventHandler(id)
+	st.Unlock()
+
+	for drain && len(events) > 0 {
+		if err := write(<-events); err != nil {
+			return
+		}
+	}
+	if overflowed {
+		logger.Noticef("Client of the events stream fell behind, disconnecting it.")
+		fmt.Fprintf(writer, "\x1E{\"error\": %q}\n", "too many pending events")
+		writer.Flush()
+	}
+}
+
 type assertResponse struct {
 	assertions []asserts.Assertion
 	bundle     bool
@@ -301,6 +425,18 @@ func (ar assertResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// A metricsResponse's ServeHTTP method serves the snapd metrics in
+// the Prometheus text format.
+type metricsResponse struct{}
+
+func (metricsResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metrics.TextContentType)
+	w.WriteHeader(200)
+	if err := metrics.WriteText(w); err != nil {
+		logger.Noticef("cannot write metrics into response: %v", err)
+	}
+}
+
 // errorResponder is a callable that produces an error Response.
 // e.g., InternalError("something broke: %v", err), etc.
 type errorResponder func(string, ...interface{}) Response
@@ -47,6 +47,7 @@ var (
 	SnapMountPolicyDir        string
 	SnapUdevRulesDir          string
 	SnapKModModulesDir        string
+	SnapKModModprobeDir       string
 	LocaleDir                 string
 	SnapMetaDir               string
 	SnapdSocket               string
@@ -65,6 +66,8 @@ var (
 
 	SnapStateFile string
 
+	SnapdStoreSSLCertsDir string
+
 	SnapRepairDir        string
 	SnapRepairStateFile  string
 	SnapRepairRunDir     string
@@ -79,6 +82,7 @@ var (
 	SnapServicesDir     string
 	SnapDesktopFilesDir string
 	SnapBusPolicyDir    string
+	SnapPolkitPolicyDir string
 
 	SystemApparmorDir      string
 	SystemApparmorCacheDir string
@@ -198,6 +202,8 @@ func SetRootDir(rootdir string) {
 
 	SnapStateFile = filepath.Join(rootdir, snappyDir, "state.json")
 
+	SnapdStoreSSLCertsDir = filepath.Join(rootdir, snappyDir, "ssl/store-certs")
+
 	SnapCacheDir = filepath.Join(rootdir, "/var/cache/snapd")
 	SnapNamesFile = filepath.Join(SnapCacheDir, "names")
 	SnapSectionsFile = filepath.Join(SnapCacheDir, "sections")
@@ -214,6 +220,7 @@ func SetRootDir(rootdir string) {
 	SnapBinariesDir = filepath.Join(SnapMountDir, "bin")
 	SnapServicesDir = filepath.Join(rootdir, "/etc/systemd/system")
 	SnapBusPolicyDir = filepath.Join(rootdir, "/etc/dbus-1/system.d")
+	SnapPolkitPolicyDir = filepath.Join(rootdir, "/usr/share/polkit-1/actions")
 
 	SystemApparmorDir = filepath.Join(rootdir, "/etc/apparmor.d")
 	SystemApparmorCacheDir = filepath.Join(rootdir, "/etc/apparmor.d/cache")
@@ -223,6 +230,7 @@ func SetRootDir(rootdir string) {
 	SnapUdevRulesDir = filepath.Join(rootdir, "/etc/udev/rules.d")
 
 	SnapKModModulesDir = filepath.Join(rootdir, "/etc/modules-load.d/")
+	SnapKModModprobeDir = filepath.Join(rootdir, "/etc/modprobe.d/")
 
 	LocaleDir = filepath.Join(rootdir, "/usr/share/locale")
 	ClassicDir = filepath.Join(rootdir, "/writable/classic")
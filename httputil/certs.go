@@ -0,0 +1,56 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package httputil
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// extraSSLCertsPool returns the system certificate pool extended with
+// the PEM encoded certificates found in the *.pem files of dir. It
+// returns a nil pool if there are no such files.
+func extraSSLCertsPool(dir string) (*x509.CertPool, error) {
+	extraCerts, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	if len(extraCerts) == 0 {
+		return nil, nil
+	}
+
+	pool, err := systemCertPool()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load system certificates: %v", err)
+	}
+	for _, p := range extraCerts {
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read extra certificate: %v", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("cannot load extra certificate %q: no certificates found", p)
+		}
+	}
+
+	return pool, nil
+}
@@ -92,6 +92,11 @@ type ClientOpts struct {
 	Timeout    time.Duration
 	TLSConfig  *tls.Config
 	MayLogBody bool
+
+	// ExtraSSLCertsDir is a directory with *.pem files holding
+	// certificates to trust in addition to the system ones. It is
+	// ignored if TLSConfig is set.
+	ExtraSSLCertsDir string
 }
 
 // NewHTTPCLient returns a new http.Client with a LoggedTransport, a
@@ -103,6 +108,15 @@ func NewHTTPClient(opts *ClientOpts) *http.Client {
 
 	transport := newDefaultTransport()
 	transport.TLSClientConfig = opts.TLSConfig
+	if opts.TLSConfig == nil && opts.ExtraSSLCertsDir != "" {
+		pool, err := extraSSLCertsPool(opts.ExtraSSLCertsDir)
+		if err != nil {
+			logger.Noticef("cannot use extra SSL certificates: %v", err)
+		}
+		if pool != nil {
+			transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+		}
+	}
 
 	return &http.Client{
 		Transport: &LoggedTransport{
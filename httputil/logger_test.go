@@ -21,11 +21,13 @@ package httputil_test
 
 import (
 	"bytes"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -180,3 +182,41 @@ func (s loggerSuite) TestRedir(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Check(n, check.Equals, 2)
 }
+
+func (s *loggerSuite) TestExtraSSLCerts(c *check.C) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	}))
+	defer server.Close()
+
+	certsDir := c.MkDir()
+
+	// no extra certs, the test server certificate is not trusted
+	client := httputil.NewHTTPClient(&httputil.ClientOpts{ExtraSSLCertsDir: certsDir})
+	_, err := client.Get(server.URL)
+	c.Assert(err, check.ErrorMatches, ".*certificate.*")
+
+	// drop the server certificate into the extra certs dir
+	certPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.TLS.Certificates[0].Certificate[0],
+	})
+	err = ioutil.WriteFile(filepath.Join(certsDir, "test.pem"), certPEM, 0644)
+	c.Assert(err, check.IsNil)
+
+	client = httputil.NewHTTPClient(&httputil.ClientOpts{ExtraSSLCertsDir: certsDir})
+	resp, err := client.Get(server.URL)
+	c.Assert(err, check.IsNil)
+	defer resp.Body.Close()
+	c.Check(resp.StatusCode, check.Equals, 200)
+}
+
+func (s *loggerSuite) TestExtraSSLCertsInvalid(c *check.C) {
+	certsDir := c.MkDir()
+	err := ioutil.WriteFile(filepath.Join(certsDir, "bad.pem"), []byte("not a cert"), 0644)
+	c.Assert(err, check.IsNil)
+
+	client := httputil.NewHTTPClient(&httputil.ClientOpts{ExtraSSLCertsDir: certsDir})
+	c.Check(httputil.BaseTransport(client).TLSClientConfig, check.IsNil)
+	c.Check(s.logbuf.String(), check.Matches, `(?s).*cannot use extra SSL certificates: cannot load extra certificate ".*/bad.pem": no certificates found.*`)
+}
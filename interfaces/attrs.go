@@ -0,0 +1,305 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package interfaces
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// AttrType is the type of the value of a plug or slot attribute.
+type AttrType int
+
+const (
+	// StringAttr is the type of string values.
+	StringAttr AttrType = iota
+	// BoolAttr is the type of boolean values.
+	BoolAttr
+	// IntAttr is the type of integer values.
+	IntAttr
+	// StringListAttr is the type of lists of strings.
+	StringListAttr
+	// MapListAttr is the type of lists of maps, whose keys are
+	// described by the Fields of the attribute.
+	MapListAttr
+)
+
+var attrTypeDescriptions = map[AttrType]string{
+	StringAttr:     "a string",
+	BoolAttr:       "a boolean",
+	IntAttr:        "an integer",
+	StringListAttr: "a list of strings",
+	MapListAttr:    "a list of maps",
+}
+
+// PathKind tells how string attribute values holding paths are treated.
+type PathKind int
+
+const (
+	// NotPath values are not paths.
+	NotPath PathKind = iota
+	// CleanPath values are canonicalised with filepath.Clean, both for
+	// checking them and in the attributes themselves.
+	CleanPath
+	// SubPath values must be clean paths that do not go up from the
+	// directory they are relative to.
+	SubPath
+	// AbsPath values must be clean absolute paths.
+	AbsPath
+)
+
+// AttrSpec describes an attribute of the plugs or slots of an interface.
+type AttrSpec struct {
+	Name     string
+	Type     AttrType
+	Required bool
+	// Path tells whether string values, or the strings of a list, are
+	// paths and how they are treated.
+	Path PathKind
+	// Pattern, if set, must match string values, or the strings of a
+	// list, after their canonicalisation.
+	Pattern *regexp.Regexp
+	// Expected describes the values matching Pattern in error messages,
+	// e.g. "a valid device node".
+	Expected string
+	// Min and Max bound integer values, unless both are zero.
+	Min, Max int64
+	// Fields describes the keys of the maps of a MapListAttr value,
+	// no other keys are allowed.
+	Fields []AttrSpec
+}
+
+// PlugAttrSchema can be implemented by Interfaces declaring the attributes of
+// their plugs, which are then checked when the plugs are sanitized.
+type PlugAttrSchema interface {
+	PlugAttrSpecs() []AttrSpec
+}
+
+// SlotAttrSchema can be implemented by Interfaces declaring the attributes of
+// their slots, which are then checked when the slots are sanitized.
+type SlotAttrSchema interface {
+	SlotAttrSpecs() []AttrSpec
+}
+
+func (spec *AttrSpec) errorf(ifaceName, kind string, format string, a ...interface{}) error {
+	return fmt.Errorf("%s %s %q attribute %s", ifaceName, kind, spec.Name, fmt.Sprintf(format, a...))
+}
+
+func (spec *AttrSpec) checkString(ifaceName, kind string, value string) (string, error) {
+	switch spec.Path {
+	case CleanPath:
+		value = filepath.Clean(value)
+	case SubPath:
+		if filepath.Clean(value) != value || value == ".." || strings.HasPrefix(value, "../") {
+			return "", spec.errorf(ifaceName, kind, "must be a clean path within its directory, not %q", value)
+		}
+	case AbsPath:
+		if filepath.Clean(value) != value || !filepath.IsAbs(value) {
+			return "", spec.errorf(ifaceName, kind, "must be a clean absolute path, not %q", value)
+		}
+	}
+	if spec.Pattern != nil && !spec.Pattern.MatchString(value) {
+		expected := spec.Expected
+		if expected == "" {
+			expected = fmt.Sprintf("a value matching %q", spec.Pattern)
+		}
+		return "", spec.errorf(ifaceName, kind, "must be %s, not %q", expected, value)
+	}
+	return value, nil
+}
+
+func (spec *AttrSpec) check(ifaceName, kind string, attrs map[string]interface{}) error {
+	value, ok := attrs[spec.Name]
+	if !ok || value == "" {
+		if spec.Required {
+			return fmt.Errorf("%s %s must have a %q attribute", ifaceName, kind, spec.Name)
+		}
+		return nil
+	}
+
+	wrongType := spec.errorf(ifaceName, kind, "must be %s", attrTypeDescriptions[spec.Type])
+	switch spec.Type {
+	case StringAttr:
+		s, ok := value.(string)
+		if !ok {
+			return wrongType
+		}
+		s, err := spec.checkString(ifaceName, kind, s)
+		if err != nil {
+			return err
+		}
+		attrs[spec.Name] = s
+	case BoolAttr:
+		if _, ok := value.(bool); !ok {
+			return wrongType
+		}
+	case IntAttr:
+		n, ok := value.(int64)
+		if !ok {
+			return wrongType
+		}
+		if (spec.Min != 0 || spec.Max != 0) && (n < spec.Min || n > spec.Max) {
+			return spec.errorf(ifaceName, kind, "must be between %d and %d, not %d", spec.Min, spec.Max, n)
+		}
+	case StringListAttr:
+		list, ok := value.([]interface{})
+		if !ok {
+			return wrongType
+		}
+		checked := make([]interface{}, len(list))
+		for i, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return wrongType
+			}
+			s, err := spec.checkString(ifaceName, kind, s)
+			if err != nil {
+				return err
+			}
+			checked[i] = s
+		}
+		attrs[spec.Name] = checked
+	case MapListAttr:
+		list, ok := value.([]interface{})
+		if !ok {
+			return wrongType
+		}
+		entryKind := fmt.Sprintf("%s %q entry", kind, spec.Name)
+		checked := make([]interface{}, len(list))
+		for i, item := range list {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				return wrongType
+			}
+			fields := make(map[string]interface{}, len(entry))
+			for key, value := range entry {
+				if !spec.hasField(key) {
+					return fmt.Errorf("%s %s has unknown %q attribute", ifaceName, entryKind, key)
+				}
+				fields[key] = value
+			}
+			if err := checkAttrs(ifaceName, entryKind, fields, spec.Fields); err != nil {
+				return err
+			}
+			checked[i] = fields
+		}
+		attrs[spec.Name] = checked
+	default:
+		return fmt.Errorf("internal error: unknown type of %s %s %q attribute", ifaceName, kind, spec.Name)
+	}
+	return nil
+}
+
+func (spec *AttrSpec) hasField(name string) bool {
+	for _, field := range spec.Fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAttrs checks the attributes of a plug or slot against the given
+// specifications, canonicalising the paths among them.
+func checkAttrs(ifaceName, kind string, attrs map[string]interface{}, specs []AttrSpec) error {
+	for i := range specs {
+		if err := specs[i].check(ifaceName, kind, attrs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDynamicAttrs checks the static and dynamic attributes of a plug or
+// slot together, and updates the dynamic ones with their checked values.
+func checkDynamicAttrs(ifaceName, kind string, static, dynamic map[string]interface{}, specs []AttrSpec) error {
+	attrs := MergeAttrs(static, dynamic)
+	if err := checkAttrs(ifaceName, kind, attrs, specs); err != nil {
+		return err
+	}
+	for name := range dynamic {
+		if _, ok := static[name]; !ok {
+			dynamic[name] = attrs[name]
+		}
+	}
+	return nil
+}
+
+// MergeAttrs returns the static attributes of a plug or slot overlaid on
+// the dynamic attributes set by its interface hooks for a connection. The
+// result is normalized, as either might have gone through JSON.
+func MergeAttrs(static, dynamic map[string]interface{}) map[string]interface{} {
+	attrs := make(map[string]interface{}, len(static)+len(dynamic))
+	for k, v := range dynamic {
+		attrs[k] = v
+	}
+	for k, v := range static {
+		attrs[k] = v
+	}
+	return normalizeAttrs(attrs)
+}
+
+// normalizeAttrs returns a copy of attributes that went through JSON, e.g.
+// dynamic attributes kept in the state, with whole numbers turned back into
+// int64 values as in attributes coming from snap.yaml.
+func normalizeAttrs(attrs map[string]interface{}) map[string]interface{} {
+	if attrs == nil {
+		return nil
+	}
+	return normalizeAttr(attrs).(map[string]interface{})
+}
+
+func normalizeAttr(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt64 && v <= math.MaxInt64 {
+			return int64(v)
+		}
+		return v
+	case int:
+		return int64(v)
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, item := range v {
+			l[i] = normalizeAttr(item)
+		}
+		return l
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = normalizeAttr(item)
+		}
+		return m
+	default:
+		return v
+	}
+}
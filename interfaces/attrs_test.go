@@ -0,0 +1,209 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package interfaces_test
+
+import (
+	"regexp"
+
+	. "gopkg.in/check.v1"
+
+	. "github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/snap/snaptest"
+)
+
+type AttrsSuite struct{}
+
+var _ = Suite(&AttrsSuite{})
+
+type schemaInterface struct {
+	ifacetest.TestInterface
+	plugSpecs []AttrSpec
+	slotSpecs []AttrSpec
+}
+
+func (iface *schemaInterface) PlugAttrSpecs() []AttrSpec { return iface.plugSpecs }
+func (iface *schemaInterface) SlotAttrSpecs() []AttrSpec { return iface.slotSpecs }
+
+var schemaSpecs = []AttrSpec{
+	{Name: "path", Type: StringAttr, Required: true, Path: CleanPath, Pattern: regexp.MustCompile("^/dev/foo[0-9]+$"), Expected: "a foo device"},
+	{Name: "enabled", Type: BoolAttr},
+	{Name: "number", Type: IntAttr, Min: 1, Max: 10},
+	{Name: "dirs", Type: StringListAttr, Path: SubPath},
+	{Name: "files", Type: StringListAttr, Path: AbsPath},
+	{Name: "tag", Type: StringAttr, Pattern: regexp.MustCompile("^[a-z]+$")},
+	{Name: "entries", Type: MapListAttr, Fields: []AttrSpec{
+		{Name: "name", Type: StringAttr, Required: true},
+		{Name: "dir", Type: StringAttr, Path: AbsPath},
+	}},
+}
+
+func (s *AttrsSuite) TestSanitizeAttrs(c *C) {
+	iface := &schemaInterface{
+		TestInterface: ifacetest.TestInterface{InterfaceName: "schema"},
+		plugSpecs:     schemaSpecs,
+		slotSpecs:     schemaSpecs,
+	}
+	info := snaptest.MockInfo(c, `name: snap
+plugs:
+  plug:
+    interface: schema
+    path: /dev//foo1
+    enabled: true
+    number: 5
+    dirs: [a, /b/c]
+    files: [/etc/foo, /]
+    entries:
+      - name: foo
+        dir: /foo
+      - name: bar
+slots:
+  slot:
+    interface: schema
+    path: /dev/./foo2/
+`, nil)
+
+	plug := &Plug{PlugInfo: info.Plugs["plug"]}
+	c.Assert(plug.Sanitize(iface), IsNil)
+	c.Check(plug.Attrs, DeepEquals, map[string]interface{}{
+		"path":    "/dev/foo1",
+		"enabled": true,
+		"number":  int64(5),
+		"dirs":    []interface{}{"a", "/b/c"},
+		"files":   []interface{}{"/etc/foo", "/"},
+		"entries": []interface{}{
+			map[string]interface{}{"name": "foo", "dir": "/foo"},
+			map[string]interface{}{"name": "bar"},
+		},
+	})
+
+	slot := &Slot{SlotInfo: info.Slots["slot"]}
+	c.Assert(slot.Sanitize(iface), IsNil)
+	c.Check(slot.Attrs, DeepEquals, map[string]interface{}{"path": "/dev/foo2"})
+}
+
+func (s *AttrsSuite) TestSanitizeAttrsErrors(c *C) {
+	iface := &schemaInterface{
+		TestInterface: ifacetest.TestInterface{InterfaceName: "schema"},
+		plugSpecs:     schemaSpecs,
+	}
+	for _, t := range []struct {
+		attrs string
+		err   string
+	}{
+		{``, `schema plug must have a "path" attribute`},
+		{`path: ""`, `schema plug must have a "path" attribute`},
+		{`path: 1`, `schema plug "path" attribute must be a string`},
+		{`path: /dev/bar1`, `schema plug "path" attribute must be a foo device, not "/dev/bar1"`},
+		{`path: /dev/foo1/../bar1`, `schema plug "path" attribute must be a foo device, not "/dev/bar1"`},
+		{"path: /dev/foo1\n    enabled: yes please", `schema plug "enabled" attribute must be a boolean`},
+		{"path: /dev/foo1\n    number: one", `schema plug "number" attribute must be an integer`},
+		{"path: /dev/foo1\n    number: 11", `schema plug "number" attribute must be between 1 and 10, not 11`},
+		{"path: /dev/foo1\n    dirs: a", `schema plug "dirs" attribute must be a list of strings`},
+		{"path: /dev/foo1\n    dirs: [a, 1]", `schema plug "dirs" attribute must be a list of strings`},
+		{"path: /dev/foo1\n    dirs: [a/../..]", `schema plug "dirs" attribute must be a clean path within its directory, not "a/../.."`},
+		{"path: /dev/foo1\n    dirs: [../a]", `schema plug "dirs" attribute must be a clean path within its directory, not "../a"`},
+		{"path: /dev/foo1\n    files: [etc/foo]", `schema plug "files" attribute must be a clean absolute path, not "etc/foo"`},
+		{"path: /dev/foo1\n    files: [/etc/../foo]", `schema plug "files" attribute must be a clean absolute path, not "/etc/../foo"`},
+		{"path: /dev/foo1\n    tag: A", `schema plug "tag" attribute must be a value matching "\^\[a-z\]\+\$", not "A"`},
+		{"path: /dev/foo1\n    entries: a", `schema plug "entries" attribute must be a list of maps`},
+		{"path: /dev/foo1\n    entries: [a]", `schema plug "entries" attribute must be a list of maps`},
+		{"path: /dev/foo1\n    entries: [{dir: /foo}]", `schema plug "entries" entry must have a "name" attribute`},
+		{"path: /dev/foo1\n    entries: [{name: 1}]", `schema plug "entries" entry "name" attribute must be a string`},
+		{"path: /dev/foo1\n    entries: [{name: foo, dir: foo}]", `schema plug "entries" entry "dir" attribute must be a clean absolute path, not "foo"`},
+		{"path: /dev/foo1\n    entries: [{name: foo, other: 1}]", `schema plug "entries" entry has unknown "other" attribute`},
+	} {
+		info := snaptest.MockInfo(c, `name: snap
+plugs:
+  plug:
+    interface: schema
+    `+t.attrs+`
+`, nil)
+		plug := &Plug{PlugInfo: info.Plugs["plug"]}
+		c.Check(plug.Sanitize(iface), ErrorMatches, t.err, Commentf("attrs: %s", t.attrs))
+	}
+}
+
+func (s *AttrsSuite) TestSanitizeAttrsBeforeSanitizer(c *C) {
+	called := false
+	iface := &schemaInterface{
+		TestInterface: ifacetest.TestInterface{
+			InterfaceName: "schema",
+			SanitizeSlotCallback: func(slot *Slot) error {
+				called = true
+				return nil
+			},
+		},
+		slotSpecs: schemaSpecs,
+	}
+	info := snaptest.MockInfo(c, `name: snap
+slots:
+  slot:
+    interface: schema
+`, nil)
+	slot := &Slot{SlotInfo: info.Slots["slot"]}
+	c.Check(slot.Sanitize(iface), ErrorMatches, `schema slot must have a "path" attribute`)
+	c.Check(called, Equals, false)
+}
+
+func (s *AttrsSuite) TestMergeAttrs(c *C) {
+	static := map[string]interface{}{"path": "/dev/foo1", "number": int64(1)}
+	dynamic := map[string]interface{}{"number": float64(2), "dirs": []interface{}{float64(3)}}
+	c.Check(MergeAttrs(static, dynamic), DeepEquals, map[string]interface{}{
+		"path":   "/dev/foo1",
+		"number": int64(1),
+		"dirs":   []interface{}{int64(3)},
+	})
+	c.Check(MergeAttrs(nil, nil), DeepEquals, map[string]interface{}{})
+}
+
+func (s *AttrsSuite) TestCheckDynamicAttrs(c *C) {
+	iface := &schemaInterface{
+		TestInterface: ifacetest.TestInterface{InterfaceName: "schema"},
+		plugSpecs:     schemaSpecs,
+		slotSpecs:     schemaSpecs,
+	}
+	info := snaptest.MockInfo(c, `name: snap
+plugs:
+  plug:
+    interface: schema
+    path: /dev/foo1
+slots:
+  slot:
+    interface: schema
+`, nil)
+
+	// dynamic attributes are checked together with the static ones
+	plug := &Plug{PlugInfo: info.Plugs["plug"]}
+	dynamic := map[string]interface{}{"number": float64(5), "dirs": []interface{}{"a/./b"}}
+	c.Check(plug.CheckDynamicAttrs(iface, dynamic), ErrorMatches, `schema plug "dirs" attribute must be a clean path within its directory, not "a/./b"`)
+	dynamic = map[string]interface{}{"number": float64(5), "tag": "foo"}
+	c.Assert(plug.CheckDynamicAttrs(iface, dynamic), IsNil)
+	c.Check(dynamic, DeepEquals, map[string]interface{}{"number": int64(5), "tag": "foo"})
+	c.Check(plug.Attrs, DeepEquals, map[string]interface{}{"path": "/dev/foo1"})
+	c.Check(plug.CheckDynamicAttrs(iface, map[string]interface{}{"number": float64(11)}), ErrorMatches, `schema plug "number" attribute must be between 1 and 10, not 11`)
+
+	// and can provide the required ones
+	slot := &Slot{SlotInfo: info.Slots["slot"]}
+	c.Check(slot.CheckDynamicAttrs(iface, nil), ErrorMatches, `schema slot must have a "path" attribute`)
+	dynamic = map[string]interface{}{"path": "/dev//foo2"}
+	c.Assert(slot.CheckDynamicAttrs(iface, dynamic), IsNil)
+	c.Check(dynamic, DeepEquals, map[string]interface{}{"path": "/dev/foo2"})
+}
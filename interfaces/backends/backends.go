@@ -27,6 +27,7 @@ import (
 	"github.com/snapcore/snapd/interfaces/dbus"
 	"github.com/snapcore/snapd/interfaces/kmod"
 	"github.com/snapcore/snapd/interfaces/mount"
+	"github.com/snapcore/snapd/interfaces/polkit"
 	"github.com/snapcore/snapd/interfaces/seccomp"
 	"github.com/snapcore/snapd/interfaces/systemd"
 	"github.com/snapcore/snapd/interfaces/udev"
@@ -43,6 +44,7 @@ func backends() []interfaces.SecurityBackend {
 		&udev.Backend{},
 		&mount.Backend{},
 		&kmod.Backend{},
+		&polkit.Backend{},
 	}
 
 	// This should be logger.Noticef but due to ordering of initialization
@@ -61,27 +61,20 @@ func (iface *boolFileInterface) StaticInfo() interfaces.StaticInfo {
 
 var boolFileGPIOValuePattern = regexp.MustCompile(
 	"^/sys/class/gpio/gpio[0-9]+/value$")
-var boolFileAllowedPathPatterns = []*regexp.Regexp{
-	// The brightness of standard LED class device
-	regexp.MustCompile("^/sys/class/leds/[^/]+/brightness$"),
-	// The value of standard exported GPIO
-	boolFileGPIOValuePattern,
-}
 
-// SanitizeSlot checks and possibly modifies a slot.
+// The brightness of standard LED class device or the value of standard
+// exported GPIO.
+var boolFilePathPattern = regexp.MustCompile(
+	"^(/sys/class/leds/[^/]+/brightness|/sys/class/gpio/gpio[0-9]+/value)$")
+
 // Valid "bool-file" slots must contain the attribute "path".
-func (iface *boolFileInterface) SanitizeSlot(slot *interfaces.Slot) error {
-	path, ok := slot.Attrs["path"].(string)
-	if !ok || path == "" {
-		return fmt.Errorf("bool-file must contain the path attribute")
-	}
-	path = filepath.Clean(path)
-	for _, pattern := range boolFileAllowedPathPatterns {
-		if pattern.MatchString(path) {
-			return nil
-		}
-	}
-	return fmt.Errorf("bool-file can only point at LED brightness or GPIO value")
+var boolFileSlotAttrs = []interfaces.AttrSpec{
+	{Name: "path", Type: interfaces.StringAttr, Required: true, Path: interfaces.CleanPath,
+		Pattern: boolFilePathPattern, Expected: "an LED brightness or GPIO value file"},
+}
+
+func (iface *boolFileInterface) SlotAttrSpecs() []interfaces.AttrSpec {
+	return boolFileSlotAttrs
 }
 
 func (iface *boolFileInterface) AppArmorPermanentSlot(spec *apparmor.Specification, slot *interfaces.Slot) error {
@@ -20,8 +20,6 @@
 package builtin
 
 import (
-	"fmt"
-
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/seccomp"
@@ -264,16 +262,12 @@ func (iface *browserSupportInterface) StaticInfo() interfaces.StaticInfo {
 	}
 }
 
-func (iface *browserSupportInterface) SanitizePlug(plug *interfaces.Plug) error {
-	// It's fine if allow-sandbox isn't specified, but it it is,
-	// it needs to be bool
-	if v, ok := plug.Attrs["allow-sandbox"]; ok {
-		if _, ok = v.(bool); !ok {
-			return fmt.Errorf("browser-support plug requires bool with 'allow-sandbox'")
-		}
-	}
+var browserSupportPlugAttrs = []interfaces.AttrSpec{
+	{Name: "allow-sandbox", Type: interfaces.BoolAttr},
+}
 
-	return nil
+func (iface *browserSupportInterface) PlugAttrSpecs() []interfaces.AttrSpec {
+	return browserSupportPlugAttrs
 }
 
 func (iface *browserSupportInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
@@ -95,7 +95,7 @@ plugs:
 	info := snaptest.MockInfo(c, mockSnapYaml, nil)
 	plug := &interfaces.Plug{PlugInfo: info.Plugs["browser-support"]}
 	c.Assert(plug.Sanitize(s.iface), ErrorMatches,
-		"browser-support plug requires bool with 'allow-sandbox'")
+		`browser-support plug "allow-sandbox" attribute must be a boolean`)
 }
 
 func (s *BrowserSupportInterfaceSuite) TestConnectedPlugSnippetWithoutAttrib(c *C) {
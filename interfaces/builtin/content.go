@@ -64,8 +64,23 @@ func (iface *contentInterface) StaticInfo() interfaces.StaticInfo {
 	}
 }
 
-func cleanSubPath(path string) bool {
-	return filepath.Clean(path) == path && path != ".." && !strings.HasPrefix(path, "../")
+var contentSlotAttrs = []interfaces.AttrSpec{
+	{Name: "content", Type: interfaces.StringAttr},
+	{Name: "read", Type: interfaces.StringListAttr, Path: interfaces.SubPath},
+	{Name: "write", Type: interfaces.StringListAttr, Path: interfaces.SubPath},
+}
+
+func (iface *contentInterface) SlotAttrSpecs() []interfaces.AttrSpec {
+	return contentSlotAttrs
+}
+
+var contentPlugAttrs = []interfaces.AttrSpec{
+	{Name: "content", Type: interfaces.StringAttr},
+	{Name: "target", Type: interfaces.StringAttr, Required: true, Path: interfaces.SubPath},
+}
+
+func (iface *contentInterface) PlugAttrSpecs() []interfaces.AttrSpec {
+	return contentPlugAttrs
 }
 
 func (iface *contentInterface) SanitizeSlot(slot *interfaces.Slot) error {
@@ -85,15 +100,6 @@ func (iface *contentInterface) SanitizeSlot(slot *interfaces.Slot) error {
 		return fmt.Errorf("read or write path must be set")
 	}
 
-	// go over both paths
-	paths := rpath
-	paths = append(paths, wpath...)
-	for _, p := range paths {
-		if !cleanSubPath(p) {
-			return fmt.Errorf("content interface path is not clean: %q", p)
-		}
-	}
-
 	return nil
 }
 
@@ -106,13 +112,6 @@ func (iface *contentInterface) SanitizePlug(plug *interfaces.Plug) error {
 		// content defaults to "plug" name if unspecified
 		plug.Attrs["content"] = plug.Name
 	}
-	target, ok := plug.Attrs["target"].(string)
-	if !ok || len(target) == 0 {
-		return fmt.Errorf("content plug must contain target path")
-	}
-	if !cleanSubPath(target) {
-		return fmt.Errorf("content interface target path is not clean: %q", target)
-	}
 
 	return nil
 }
@@ -116,7 +116,7 @@ slots:
 	for _, rw := range []string{"read: [../foo]", "write: [../bar]"} {
 		info := snaptest.MockInfo(c, mockSnapYaml+"  "+rw, nil)
 		slot := &interfaces.Slot{SlotInfo: info.Slots["content-slot"]}
-		c.Assert(slot.Sanitize(s.iface), ErrorMatches, "content interface path is not clean:.*")
+		c.Assert(slot.Sanitize(s.iface), ErrorMatches, `content slot "(read|write)" attribute must be a clean path within its directory, not "../(foo|bar)"`)
 	}
 }
 
@@ -158,7 +158,7 @@ plugs:
 `
 	info := snaptest.MockInfo(c, mockSnapYaml, nil)
 	plug := &interfaces.Plug{PlugInfo: info.Plugs["content-plug"]}
-	c.Assert(plug.Sanitize(s.iface), ErrorMatches, "content plug must contain target path")
+	c.Assert(plug.Sanitize(s.iface), ErrorMatches, `content plug must have a "target" attribute`)
 }
 
 func (s *ContentSuite) TestSanitizePlugSimpleTargetRelative(c *C) {
@@ -172,7 +172,7 @@ plugs:
 `
 	info := snaptest.MockInfo(c, mockSnapYaml, nil)
 	plug := &interfaces.Plug{PlugInfo: info.Plugs["content-plug"]}
-	c.Assert(plug.Sanitize(s.iface), ErrorMatches, "content interface target path is not clean:.*")
+	c.Assert(plug.Sanitize(s.iface), ErrorMatches, `content plug "target" attribute must be a clean path within its directory, not "../foo"`)
 }
 
 func (s *ContentSuite) TestSanitizePlugNilAttrMap(c *C) {
@@ -185,7 +185,7 @@ apps:
 `
 	info := snaptest.MockInfo(c, mockSnapYaml, nil)
 	plug := &interfaces.Plug{PlugInfo: info.Plugs["content"]}
-	c.Assert(plug.Sanitize(s.iface), ErrorMatches, "content plug must contain target path")
+	c.Assert(plug.Sanitize(s.iface), ErrorMatches, `content plug must have a "target" attribute`)
 }
 
 func (s *ContentSuite) TestSanitizeSlotNilAttrMap(c *C) {
@@ -403,6 +403,20 @@ func (iface *dbusInterface) AppArmorConnectedSlot(spec *apparmor.Specification,
 	return nil
 }
 
+// Plugs and slots must say which well-known name they use on which bus
+var dbusAttrs = []interfaces.AttrSpec{
+	{Name: "bus", Type: interfaces.StringAttr, Required: true, Pattern: regexp.MustCompile("^(session|system)$"), Expected: `one of "session" or "system"`},
+	{Name: "name", Type: interfaces.StringAttr, Required: true},
+}
+
+func (iface *dbusInterface) PlugAttrSpecs() []interfaces.AttrSpec {
+	return dbusAttrs
+}
+
+func (iface *dbusInterface) SlotAttrSpecs() []interfaces.AttrSpec {
+	return dbusAttrs
+}
+
 func (iface *dbusInterface) SanitizePlug(plug *interfaces.Plug) error {
 	_, _, err := iface.getAttribs(plug.Attrs)
 	return err
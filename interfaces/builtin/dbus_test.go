@@ -189,7 +189,7 @@ slots:
 	c.Assert(err, IsNil)
 
 	slot := &interfaces.Slot{SlotInfo: info.Slots["dbus-slot"]}
-	c.Assert(slot.Sanitize(s.iface), ErrorMatches, "bus 'nonexistent' must be one of 'session' or 'system'")
+	c.Assert(slot.Sanitize(s.iface), ErrorMatches, `dbus slot "bus" attribute must be one of "session" or "system", not "nonexistent"`)
 }
 
 // If this test is failing, be sure to verify the AppArmor rules for binding to
@@ -20,8 +20,6 @@
 package builtin
 
 import (
-	"fmt"
-
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/seccomp"
@@ -571,13 +569,12 @@ func (iface *dockerSupportInterface) SecCompConnectedPlug(spec *seccomp.Specific
 	return nil
 }
 
-func (iface *dockerSupportInterface) SanitizePlug(plug *interfaces.Plug) error {
-	if v, ok := plug.Attrs["privileged-containers"]; ok {
-		if _, ok = v.(bool); !ok {
-			return fmt.Errorf("docker-support plug requires bool with 'privileged-containers'")
-		}
-	}
-	return nil
+var dockerSupportPlugAttrs = []interfaces.AttrSpec{
+	{Name: "privileged-containers", Type: interfaces.BoolAttr},
+}
+
+func (iface *dockerSupportInterface) PlugAttrSpecs() []interfaces.AttrSpec {
+	return dockerSupportPlugAttrs
 }
 
 func (iface *dockerSupportInterface) AutoConnect(*interfaces.Plug, *interfaces.Slot) bool {
@@ -182,7 +182,7 @@ plugs:
 	c.Assert(err, IsNil)
 
 	plug := &interfaces.Plug{PlugInfo: info.Plugs["privileged"]}
-	c.Assert(plug.Sanitize(s.iface), ErrorMatches, "docker-support plug requires bool with 'privileged-containers'")
+	c.Assert(plug.Sanitize(s.iface), ErrorMatches, `docker-support plug "privileged-containers" attribute must be a boolean`)
 }
 
 func (s *DockerSupportInterfaceSuite) TestInterfaces(c *C) {
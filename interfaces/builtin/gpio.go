@@ -21,6 +21,7 @@ package builtin
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
@@ -61,25 +62,18 @@ func (iface *gpioInterface) StaticInfo() interfaces.StaticInfo {
 	}
 }
 
-// SanitizeSlot checks the slot definition is valid
-func (iface *gpioInterface) SanitizeSlot(slot *interfaces.Slot) error {
-	if err := sanitizeSlotReservedForOSOrGadget(iface, slot); err != nil {
-		return err
-	}
-
-	// Must have a GPIO number
-	number, ok := slot.Attrs["number"]
-	if !ok {
-		return fmt.Errorf("gpio slot must have a number attribute")
-	}
+// Must have a GPIO number
+var gpioSlotAttrs = []interfaces.AttrSpec{
+	{Name: "number", Type: interfaces.IntAttr, Required: true, Min: 0, Max: math.MaxInt32},
+}
 
-	// Valid values of number
-	if _, ok := number.(int64); !ok {
-		return fmt.Errorf("gpio slot number attribute must be an int")
-	}
+func (iface *gpioInterface) SlotAttrSpecs() []interfaces.AttrSpec {
+	return gpioSlotAttrs
+}
 
-	// Slot is good
-	return nil
+// SanitizeSlot checks the slot definition is valid
+func (iface *gpioInterface) SanitizeSlot(slot *interfaces.Slot) error {
+	return sanitizeSlotReservedForOSOrGadget(iface, slot)
 }
 
 func (iface *gpioInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
@@ -102,11 +102,23 @@ func (s *GpioInterfaceSuite) TestSanitizeSlotGadgetSnap(c *C) {
 
 	// slots without number attribute are rejected
 	c.Assert(s.gadgetMissingNumberSlot.Sanitize(s.iface), ErrorMatches,
-		"gpio slot must have a number attribute")
+		`gpio slot must have a "number" attribute`)
 
 	// slots with number attribute that isnt a number
 	c.Assert(s.gadgetBadNumberSlot.Sanitize(s.iface), ErrorMatches,
-		"gpio slot number attribute must be an int")
+		`gpio slot "number" attribute must be an integer`)
+
+	// slots with a negative number are rejected
+	info := snaptest.MockInfo(c, `name: my-device
+type: gadget
+slots:
+    negative-number:
+        interface: gpio
+        number: -1
+`, nil)
+	slot := &interfaces.Slot{SlotInfo: info.Slots["negative-number"]}
+	c.Assert(slot.Sanitize(s.iface), ErrorMatches,
+		`gpio slot "number" attribute must be between 0 and 2147483647, not -1`)
 }
 
 func (s *GpioInterfaceSuite) TestSanitizeSlotOsSnap(c *C) {
@@ -27,6 +27,7 @@ import (
 
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/interfaces/udev"
 )
 
@@ -69,42 +70,36 @@ var hidrawDeviceNodePattern = regexp.MustCompile("^/dev/hidraw[0-9]{1,3}$")
 // are also specified
 var hidrawUDevSymlinkPattern = regexp.MustCompile("^/dev/hidraw-[a-z0-9]+$")
 
+// Valid "hidraw" slots must have a path attribute identifying the hidraw
+// device, and may have usb vendor and product identifiers for a udev symlink
+var hidrawSlotAttrs = []interfaces.AttrSpec{
+	{Name: "path", Type: interfaces.StringAttr, Required: true, Path: interfaces.CleanPath},
+	{Name: "usb-vendor", Type: interfaces.IntAttr, Min: 0x1, Max: 0xFFFF},
+	{Name: "usb-product", Type: interfaces.IntAttr, Min: 0x0, Max: 0xFFFF},
+}
+
+func (iface *hidrawInterface) SlotAttrSpecs() []interfaces.AttrSpec {
+	return hidrawSlotAttrs
+}
+
 // SanitizeSlot checks validity of the defined slot
 func (iface *hidrawInterface) SanitizeSlot(slot *interfaces.Slot) error {
 	if err := sanitizeSlotReservedForOSOrGadget(iface, slot); err != nil {
 		return err
 	}
 
-	// Check slot has a path attribute identify hidraw device
-	path, ok := slot.Attrs["path"].(string)
-	if !ok || path == "" {
-		return fmt.Errorf("hidraw slots must have a path attribute")
-	}
-
-	// Clean the path before further checks
-	path = filepath.Clean(path)
-
+	path := slot.Attrs["path"].(string)
 	if iface.hasUsbAttrs(slot) {
 		// Must be path attribute where symlink will be placed and usb vendor and product identifiers
 		// Check the path attribute is in the allowable pattern
 		if !hidrawUDevSymlinkPattern.MatchString(path) {
 			return fmt.Errorf("hidraw path attribute specifies invalid symlink location")
 		}
-
-		usbVendor, vOk := slot.Attrs["usb-vendor"].(int64)
-		if !vOk {
-			return fmt.Errorf("hidraw slot failed to find usb-vendor attribute")
-		}
-		if (usbVendor < 0x1) || (usbVendor > 0xFFFF) {
-			return fmt.Errorf("hidraw usb-vendor attribute not valid: %d", usbVendor)
+		if _, ok := slot.Attrs["usb-vendor"]; !ok {
+			return fmt.Errorf(`hidraw slot must have a "usb-vendor" attribute`)
 		}
-
-		usbProduct, pOk := slot.Attrs["usb-product"].(int64)
-		if !pOk {
-			return fmt.Errorf("hidraw slot failed to find usb-product attribute")
-		}
-		if (usbProduct < 0x0) || (usbProduct > 0xFFFF) {
-			return fmt.Errorf("hidraw usb-product attribute not valid: %d", usbProduct)
+		if _, ok := slot.Attrs["usb-product"]; !ok {
+			return fmt.Errorf(`hidraw slot must have a "usb-product" attribute`)
 		}
 	} else {
 		// Just a path attribute - must be a valid usb device node
@@ -173,6 +168,23 @@ func (iface *hidrawInterface) AutoConnect(*interfaces.Plug, *interfaces.Slot) bo
 	return true
 }
 
+// HotplugDeviceDetected creates a slot for hidraw devices, like USB
+// keyboards or game controllers, when they are plugged in.
+func (iface *hidrawInterface) HotplugDeviceDetected(di *hotplug.HotplugDeviceInfo, spec *hotplug.Specification) error {
+	if di.Subsystem() != "hidraw" || !hidrawDeviceNodePattern.MatchString(di.DeviceName()) {
+		return nil
+	}
+	label, _ := di.Attribute("ID_MODEL_FROM_DATABASE")
+	if label == "" {
+		label, _ = di.Attribute("ID_MODEL")
+	}
+	return spec.SetSlot(&hotplug.SlotSpec{
+		Name:  "hidraw",
+		Label: label,
+		Attrs: map[string]interface{}{"path": di.DeviceName()},
+	})
+}
+
 func (iface *hidrawInterface) hasUsbAttrs(slot *interfaces.Slot) bool {
 	if _, ok := slot.Attrs["usb-vendor"]; ok {
 		return true
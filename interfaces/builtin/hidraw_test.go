@@ -25,7 +25,9 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/interfaces/udev"
+	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/testutil"
 )
@@ -158,7 +160,7 @@ func (s *HidrawInterfaceSuite) TestSanitizeCoreSnapSlots(c *C) {
 
 func (s *HidrawInterfaceSuite) TestSanitizeBadCoreSnapSlots(c *C) {
 	// Slots without the "path" attribute are rejected.
-	c.Assert(s.missingPathSlot.Sanitize(s.iface), ErrorMatches, `hidraw slots must have a path attribute`)
+	c.Assert(s.missingPathSlot.Sanitize(s.iface), ErrorMatches, `hidraw slot must have a "path" attribute`)
 
 	// Slots with incorrect value of the "path" attribute are rejected.
 	for _, slot := range []*interfaces.Slot{s.badPathSlot1, s.badPathSlot2, s.badPathSlot3} {
@@ -172,8 +174,8 @@ func (s *HidrawInterfaceSuite) TestSanitizeGadgetSnapSlots(c *C) {
 }
 
 func (s *HidrawInterfaceSuite) TestSanitizeBadGadgetSnapSlots(c *C) {
-	c.Assert(s.testUDevBadValue1.Sanitize(s.iface), ErrorMatches, "hidraw usb-vendor attribute not valid: -1")
-	c.Assert(s.testUDevBadValue2.Sanitize(s.iface), ErrorMatches, "hidraw usb-product attribute not valid: 65536")
+	c.Assert(s.testUDevBadValue1.Sanitize(s.iface), ErrorMatches, `hidraw slot "usb-vendor" attribute must be between 1 and 65535, not -1`)
+	c.Assert(s.testUDevBadValue2.Sanitize(s.iface), ErrorMatches, `hidraw slot "usb-product" attribute must be between 0 and 65535, not 65536`)
 	c.Assert(s.testUDevBadValue3.Sanitize(s.iface), ErrorMatches, "hidraw path attribute specifies invalid symlink location")
 }
 
@@ -247,6 +249,51 @@ func (s *HidrawInterfaceSuite) TestConnectedPlugAppArmorSnippets(c *C) {
 	c.Assert(snippet, DeepEquals, expectedSnippet3, Commentf("\nexpected:\n%s\nfound:\n%s", expectedSnippet3, snippet))
 }
 
+func (s *HidrawInterfaceSuite) TestHotplugDeviceDetected(c *C) {
+	di, err := hotplug.NewHotplugDeviceInfo(map[string]string{
+		"ACTION":      "add",
+		"DEVPATH":     "/devices/pci0000:00/0000:00:14.0/usb1/1-2/1-2:1.0/0003:046D:C52B.0001/hidraw/hidraw0",
+		"SUBSYSTEM":   "hidraw",
+		"DEVNAME":     "/dev/hidraw0",
+		"ID_BUS":      "usb",
+		"ID_MODEL":    "USB_Receiver",
+		"ID_VENDOR":   "Logitech",
+		"ID_MODEL_ID": "c52b",
+	})
+	c.Assert(err, IsNil)
+	spec := hotplug.NewSpecification()
+	c.Assert(s.iface.(hotplug.Definer).HotplugDeviceDetected(di, spec), IsNil)
+	c.Assert(spec.Slot(), DeepEquals, &hotplug.SlotSpec{
+		Name:  "hidraw",
+		Label: "USB_Receiver",
+		Attrs: map[string]interface{}{"path": "/dev/hidraw0"},
+	})
+
+	// the slot is valid for the core snap
+	slot := &interfaces.Slot{SlotInfo: &snap.SlotInfo{
+		Snap:      s.testSlot1.Snap,
+		Name:      spec.Slot().Name,
+		Interface: "hidraw",
+		Attrs:     spec.Slot().Attrs,
+	}}
+	c.Check(slot.Sanitize(s.iface), IsNil)
+}
+
+func (s *HidrawInterfaceSuite) TestHotplugDeviceDetectedIgnoresOtherDevices(c *C) {
+	for _, env := range []map[string]string{
+		// not a hidraw device
+		{"ACTION": "add", "DEVPATH": "/devices/a", "SUBSYSTEM": "tty", "DEVNAME": "/dev/ttyUSB0", "ID_BUS": "usb"},
+		// not a supported device node
+		{"ACTION": "add", "DEVPATH": "/devices/b", "SUBSYSTEM": "hidraw", "DEVNAME": "/dev/hidraw1234"},
+	} {
+		di, err := hotplug.NewHotplugDeviceInfo(env)
+		c.Assert(err, IsNil)
+		spec := hotplug.NewSpecification()
+		c.Assert(s.iface.(hotplug.Definer).HotplugDeviceDetected(di, spec), IsNil)
+		c.Check(spec.Slot(), IsNil, Commentf("%s", env["DEVPATH"]))
+	}
+}
+
 func (s *HidrawInterfaceSuite) TestInterfaces(c *C) {
 	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
 }
@@ -74,25 +74,18 @@ func (iface *i2cInterface) String() string {
 // identification
 var i2cControlDeviceNodePattern = regexp.MustCompile("^/dev/i2c-[0-9]+$")
 
-// Check validity of the defined slot
-func (iface *i2cInterface) SanitizeSlot(slot *interfaces.Slot) error {
-	if err := sanitizeSlotReservedForOSOrGadget(iface, slot); err != nil {
-		return err
-	}
-
-	// Validate the path
-	path, ok := slot.Attrs["path"].(string)
-	if !ok || path == "" {
-		return fmt.Errorf("%s slot must have a path attribute", iface.Name())
-	}
-
-	path = filepath.Clean(path)
+var i2cSlotAttrs = []interfaces.AttrSpec{
+	{Name: "path", Type: interfaces.StringAttr, Required: true, Path: interfaces.CleanPath,
+		Pattern: i2cControlDeviceNodePattern, Expected: "a valid device node"},
+}
 
-	if !i2cControlDeviceNodePattern.MatchString(path) {
-		return fmt.Errorf("%s path attribute must be a valid device node", iface.Name())
-	}
+func (iface *i2cInterface) SlotAttrSpecs() []interfaces.AttrSpec {
+	return i2cSlotAttrs
+}
 
-	return nil
+// Check validity of the defined slot
+func (iface *i2cInterface) SanitizeSlot(slot *interfaces.Slot) error {
+	return sanitizeSlotReservedForOSOrGadget(iface, slot)
 }
 
 func (iface *i2cInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
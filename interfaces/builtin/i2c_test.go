@@ -148,13 +148,13 @@ func (s *I2cInterfaceSuite) TestSanitizeGadgetSnapSlot(c *C) {
 }
 
 func (s *I2cInterfaceSuite) TestSanitizeBadGadgetSnapSlot(c *C) {
-	c.Assert(s.testUDevBadValue1.Sanitize(s.iface), ErrorMatches, "i2c path attribute must be a valid device node")
-	c.Assert(s.testUDevBadValue2.Sanitize(s.iface), ErrorMatches, "i2c path attribute must be a valid device node")
-	c.Assert(s.testUDevBadValue3.Sanitize(s.iface), ErrorMatches, "i2c path attribute must be a valid device node")
-	c.Assert(s.testUDevBadValue4.Sanitize(s.iface), ErrorMatches, "i2c path attribute must be a valid device node")
-	c.Assert(s.testUDevBadValue5.Sanitize(s.iface), ErrorMatches, "i2c path attribute must be a valid device node")
-	c.Assert(s.testUDevBadValue6.Sanitize(s.iface), ErrorMatches, "i2c slot must have a path attribute")
-	c.Assert(s.testUDevBadValue7.Sanitize(s.iface), ErrorMatches, "i2c slot must have a path attribute")
+	c.Assert(s.testUDevBadValue1.Sanitize(s.iface), ErrorMatches, `i2c slot "path" attribute must be a valid device node, not .*`)
+	c.Assert(s.testUDevBadValue2.Sanitize(s.iface), ErrorMatches, `i2c slot "path" attribute must be a valid device node, not .*`)
+	c.Assert(s.testUDevBadValue3.Sanitize(s.iface), ErrorMatches, `i2c slot "path" attribute must be a valid device node, not .*`)
+	c.Assert(s.testUDevBadValue4.Sanitize(s.iface), ErrorMatches, `i2c slot "path" attribute must be a valid device node, not .*`)
+	c.Assert(s.testUDevBadValue5.Sanitize(s.iface), ErrorMatches, `i2c slot "path" attribute must be a valid device node, not .*`)
+	c.Assert(s.testUDevBadValue6.Sanitize(s.iface), ErrorMatches, `i2c slot must have a "path" attribute`)
+	c.Assert(s.testUDevBadValue7.Sanitize(s.iface), ErrorMatches, `i2c slot must have a "path" attribute`)
 }
 
 func (s *I2cInterfaceSuite) TestUDevSpec(c *C) {
@@ -77,25 +77,18 @@ func (iface *iioInterface) String() string {
 // identification
 var iioControlDeviceNodePattern = regexp.MustCompile("^/dev/iio:device[0-9]+$")
 
-// Check validity of the defined slot
-func (iface *iioInterface) SanitizeSlot(slot *interfaces.Slot) error {
-	if err := sanitizeSlotReservedForOSOrGadget(iface, slot); err != nil {
-		return err
-	}
-
-	// Validate the path
-	path, ok := slot.Attrs["path"].(string)
-	if !ok || path == "" {
-		return fmt.Errorf("%s slot must have a path attribute", iface.Name())
-	}
-
-	path = filepath.Clean(path)
+var iioSlotAttrs = []interfaces.AttrSpec{
+	{Name: "path", Type: interfaces.StringAttr, Required: true, Path: interfaces.CleanPath,
+		Pattern: iioControlDeviceNodePattern, Expected: "a valid device node"},
+}
 
-	if !iioControlDeviceNodePattern.MatchString(path) {
-		return fmt.Errorf("%s path attribute must be a valid device node", iface.Name())
-	}
+func (iface *iioInterface) SlotAttrSpecs() []interfaces.AttrSpec {
+	return iioSlotAttrs
+}
 
-	return nil
+// Check validity of the defined slot
+func (iface *iioInterface) SanitizeSlot(slot *interfaces.Slot) error {
+	return sanitizeSlotReservedForOSOrGadget(iface, slot)
 }
 
 func (iface *iioInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
@@ -143,14 +143,14 @@ func (s *IioInterfaceSuite) TestName(c *C) {
 }
 
 func (s *IioInterfaceSuite) TestSanitizeBadGadgetSnapSlot(c *C) {
-	c.Assert(s.testUDevBadValue1.Sanitize(s.iface), ErrorMatches, "iio path attribute must be a valid device node")
-	c.Assert(s.testUDevBadValue2.Sanitize(s.iface), ErrorMatches, "iio path attribute must be a valid device node")
-	c.Assert(s.testUDevBadValue3.Sanitize(s.iface), ErrorMatches, "iio path attribute must be a valid device node")
-	c.Assert(s.testUDevBadValue4.Sanitize(s.iface), ErrorMatches, "iio path attribute must be a valid device node")
-	c.Assert(s.testUDevBadValue5.Sanitize(s.iface), ErrorMatches, "iio path attribute must be a valid device node")
-	c.Assert(s.testUDevBadValue6.Sanitize(s.iface), ErrorMatches, "iio path attribute must be a valid device node")
-	c.Assert(s.testUDevBadValue7.Sanitize(s.iface), ErrorMatches, "iio slot must have a path attribute")
-	c.Assert(s.testUDevBadValue8.Sanitize(s.iface), ErrorMatches, "iio slot must have a path attribute")
+	c.Assert(s.testUDevBadValue1.Sanitize(s.iface), ErrorMatches, `iio slot "path" attribute must be a valid device node, not .*`)
+	c.Assert(s.testUDevBadValue2.Sanitize(s.iface), ErrorMatches, `iio slot "path" attribute must be a valid device node, not .*`)
+	c.Assert(s.testUDevBadValue3.Sanitize(s.iface), ErrorMatches, `iio slot "path" attribute must be a valid device node, not .*`)
+	c.Assert(s.testUDevBadValue4.Sanitize(s.iface), ErrorMatches, `iio slot "path" attribute must be a valid device node, not .*`)
+	c.Assert(s.testUDevBadValue5.Sanitize(s.iface), ErrorMatches, `iio slot "path" attribute must be a valid device node, not .*`)
+	c.Assert(s.testUDevBadValue6.Sanitize(s.iface), ErrorMatches, `iio slot "path" attribute must be a valid device node, not .*`)
+	c.Assert(s.testUDevBadValue7.Sanitize(s.iface), ErrorMatches, `iio slot must have a "path" attribute`)
+	c.Assert(s.testUDevBadValue8.Sanitize(s.iface), ErrorMatches, `iio slot must have a "path" attribute`)
 }
 
 func (s *IioInterfaceSuite) TestConnectedPlugUDevSnippets(c *C) {
@@ -0,0 +1,176 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package builtin
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/kmod"
+)
+
+const kernelModuleLoadSummary = `allows constrained control over kernel module loading`
+
+// The modules a snap may load, configure or blacklist are granted per snap
+// through the snap-declaration.
+const kernelModuleLoadBaseDeclarationPlugs = `
+  kernel-module-load:
+    allow-installation: false
+    deny-auto-connection: true
+`
+
+const kernelModuleLoadBaseDeclarationSlots = `
+  kernel-module-load:
+    allow-installation:
+      slot-snap-type:
+        - core
+    deny-auto-connection: true
+`
+
+// The ways a kernel module of the "modules" attribute can be loaded.
+const (
+	// loadOnBoot modules are loaded on connection and at boot.
+	loadOnBoot = "on-boot"
+	// loadDynamic modules are only configured, for when they get loaded.
+	loadDynamic = "dynamic"
+	// loadDenied modules are blacklisted.
+	loadDenied = "denied"
+)
+
+var kernelModuleLoadPlugAttrs = []interfaces.AttrSpec{
+	{Name: "modules", Type: interfaces.MapListAttr, Required: true, Fields: []interfaces.AttrSpec{
+		{Name: "name", Type: interfaces.StringAttr, Required: true},
+		{Name: "load", Type: interfaces.StringAttr, Pattern: regexp.MustCompile("^(on-boot|dynamic|denied)$"), Expected: `one of "on-boot", "dynamic" or "denied"`},
+		{Name: "options", Type: interfaces.StringAttr},
+	}},
+}
+
+type kernelModuleLoadInterface struct{}
+
+// kernelModule is an entry of the "modules" attribute of a plug.
+type kernelModule struct {
+	name    string
+	load    string
+	options string
+}
+
+func (iface *kernelModuleLoadInterface) String() string {
+	return iface.Name()
+}
+
+func (iface *kernelModuleLoadInterface) Name() string {
+	return "kernel-module-load"
+}
+
+func (iface *kernelModuleLoadInterface) StaticInfo() interfaces.StaticInfo {
+	return interfaces.StaticInfo{
+		Summary:              kernelModuleLoadSummary,
+		ImplicitOnCore:       true,
+		ImplicitOnClassic:    true,
+		BaseDeclarationPlugs: kernelModuleLoadBaseDeclarationPlugs,
+		BaseDeclarationSlots: kernelModuleLoadBaseDeclarationSlots,
+	}
+}
+
+func (iface *kernelModuleLoadInterface) PlugAttrSpecs() []interfaces.AttrSpec {
+	return kernelModuleLoadPlugAttrs
+}
+
+func (iface *kernelModuleLoadInterface) SanitizeSlot(slot *interfaces.Slot) error {
+	return sanitizeSlotReservedForOS(iface, slot)
+}
+
+func (iface *kernelModuleLoadInterface) SanitizePlug(plug *interfaces.Plug) error {
+	_, err := kernelModules(plug)
+	return err
+}
+
+func (iface *kernelModuleLoadInterface) KModConnectedPlug(spec *kmod.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
+	modules, err := kernelModules(plug)
+	if err != nil {
+		return err
+	}
+	for _, module := range modules {
+		switch module.load {
+		case loadDenied:
+			err = spec.DisallowModule(module.name)
+		case loadOnBoot:
+			err = spec.AddModule(module.name)
+		}
+		if err != nil {
+			return err
+		}
+		if err := spec.SetModuleOptions(module.name, module.options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (iface *kernelModuleLoadInterface) AutoConnect(*interfaces.Plug, *interfaces.Slot) bool {
+	// allow what declarations allowed
+	return true
+}
+
+// kernelModules returns the kernel modules of the "modules" attribute of a
+// kernel-module-load plug, whose entries were checked against
+// kernelModuleLoadPlugAttrs.
+func kernelModules(plug *interfaces.Plug) ([]kernelModule, error) {
+	list, _ := plug.Attrs["modules"].([]interface{})
+	if len(list) == 0 {
+		return nil, fmt.Errorf(`kernel-module-load plug must have a "modules" attribute listing kernel modules`)
+	}
+	seen := make(map[string]bool, len(list))
+	modules := make([]kernelModule, 0, len(list))
+	for _, item := range list {
+		entry, _ := item.(map[string]interface{})
+		var module kernelModule
+		module.name, _ = entry["name"].(string)
+		module.load, _ = entry["load"].(string)
+		module.options, _ = entry["options"].(string)
+		if err := kmod.ValidateModuleName(module.name); err != nil {
+			return nil, err
+		}
+		if seen[module.name] {
+			return nil, fmt.Errorf(`kernel-module-load module %q is listed more than once`, module.name)
+		}
+		seen[module.name] = true
+		if module.load == "" {
+			module.load = loadOnBoot
+		}
+		if module.load == loadDenied && module.options != "" {
+			return nil, fmt.Errorf(`kernel-module-load module %q cannot have options as it is denied`, module.name)
+		}
+		if module.load == loadDynamic && module.options == "" {
+			return nil, fmt.Errorf(`kernel-module-load module %q must have options as it is loaded dynamically`, module.name)
+		}
+		if module.options != "" {
+			if err := kmod.ValidateModuleOptions(module.name, module.options); err != nil {
+				return nil, err
+			}
+		}
+		modules = append(modules, module)
+	}
+	return modules, nil
+}
+
+func init() {
+	registerIface(&kernelModuleLoadInterface{})
+}
@@ -0,0 +1,141 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package builtin_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/kmod"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type KernelModuleLoadInterfaceSuite struct {
+	iface    interfaces.Interface
+	coreSlot *interfaces.Slot
+	plug     *interfaces.Plug
+}
+
+var _ = Suite(&KernelModuleLoadInterfaceSuite{
+	iface: builtin.MustInterface("kernel-module-load"),
+})
+
+const kernelModuleLoadConsumerYaml = `name: consumer
+plugs:
+ kernel-module-load:
+  modules:
+   - name: mymodule
+     options: opt1=1 opt2=2
+   - name: other-module
+     load: dynamic
+     options: debug=1
+   - name: conflicting_module
+     load: denied
+apps:
+ app:
+  plugs: [kernel-module-load]
+`
+
+const kernelModuleLoadCoreYaml = `name: core
+type: os
+slots:
+  kernel-module-load:
+`
+
+func (s *KernelModuleLoadInterfaceSuite) SetUpTest(c *C) {
+	s.plug = MockPlug(c, kernelModuleLoadConsumerYaml, nil, "kernel-module-load")
+	s.coreSlot = MockSlot(c, kernelModuleLoadCoreYaml, nil, "kernel-module-load")
+}
+
+func (s *KernelModuleLoadInterfaceSuite) TestName(c *C) {
+	c.Assert(s.iface.Name(), Equals, "kernel-module-load")
+}
+
+func (s *KernelModuleLoadInterfaceSuite) TestSanitizeSlot(c *C) {
+	c.Assert(s.coreSlot.Sanitize(s.iface), IsNil)
+	slot := &interfaces.Slot{SlotInfo: &snap.SlotInfo{
+		Snap:      &snap.Info{SuggestedName: "some-snap"},
+		Name:      "kernel-module-load",
+		Interface: "kernel-module-load",
+	}}
+	c.Assert(slot.Sanitize(s.iface), ErrorMatches,
+		"kernel-module-load slots are reserved for the core snap")
+}
+
+func (s *KernelModuleLoadInterfaceSuite) TestSanitizePlug(c *C) {
+	c.Assert(s.plug.Sanitize(s.iface), IsNil)
+}
+
+func (s *KernelModuleLoadInterfaceSuite) TestSanitizePlugErrors(c *C) {
+	for _, t := range []struct {
+		modules string
+		err     string
+	}{
+		{``, `kernel-module-load plug must have a "modules" attribute`},
+		{`modules: []`, `kernel-module-load plug must have a "modules" attribute listing kernel modules`},
+		{`modules: [mymodule]`, `kernel-module-load plug "modules" attribute must be a list of maps`},
+		{`modules: [{load: on-boot}]`, `kernel-module-load plug "modules" entry must have a "name" attribute`},
+		{`modules: [{name: ../mymodule}]`, `invalid kernel module name "../mymodule"`},
+		{`modules: [{name: mymodule, load: 1}]`, `kernel-module-load plug "modules" entry "load" attribute must be a string`},
+		{`modules: [{name: mymodule, unload: "yes"}]`, `kernel-module-load plug "modules" entry has unknown "unload" attribute`},
+		{`modules: [{name: mymodule}, {name: mymodule}]`, `kernel-module-load module "mymodule" is listed more than once`},
+		{`modules: [{name: mymodule, load: never}]`, `kernel-module-load plug "modules" entry "load" attribute must be one of "on-boot", "dynamic" or "denied", not "never"`},
+		{`modules: [{name: mymodule, load: denied, options: opt1=1}]`, `kernel-module-load module "mymodule" cannot have options as it is denied`},
+		{`modules: [{name: mymodule, load: dynamic}]`, `kernel-module-load module "mymodule" must have options as it is loaded dynamically`},
+		{`modules: [{name: mymodule, options: "opt1=1\ninstall mymodule /bin/sh"}]`, `invalid options for kernel module "mymodule": "opt1=1\\ninstall mymodule /bin/sh"`},
+		{`modules: [{name: mymodule, options: "opt1=1\\\\"}]`, `invalid options for kernel module "mymodule": .*`},
+		{`modules: [{name: mymodule, options: "opt1=1 #opt2=2"}]`, `invalid options for kernel module "mymodule": .*`},
+		{`modules: [{name: mymodule, options: "opt1=1\topt2=2"}]`, `invalid options for kernel module "mymodule": .*`},
+		{`modules: [{name: mymodule, options: "-opt1"}]`, `invalid options for kernel module "mymodule": .*`},
+	} {
+		yaml := "name: consumer\nplugs:\n kernel-module-load:\n  " + t.modules + "\n"
+		plug := MockPlug(c, yaml, nil, "kernel-module-load")
+		c.Check(plug.Sanitize(s.iface), ErrorMatches, t.err, Commentf("modules: %s", t.modules))
+	}
+}
+
+func (s *KernelModuleLoadInterfaceSuite) TestKModSpec(c *C) {
+	spec := &kmod.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, nil, s.coreSlot, nil), IsNil)
+	c.Check(spec.Modules(), DeepEquals, map[string]bool{"mymodule": true})
+	c.Check(spec.ModuleOptions(), DeepEquals, map[string]string{
+		"mymodule":     "opt1=1 opt2=2",
+		"other-module": "debug=1",
+	})
+	c.Check(spec.DisallowedModules(), DeepEquals, map[string]bool{"conflicting_module": true})
+}
+
+func (s *KernelModuleLoadInterfaceSuite) TestStaticInfo(c *C) {
+	si := interfaces.StaticInfoOf(s.iface)
+	c.Assert(si.ImplicitOnCore, Equals, true)
+	c.Assert(si.ImplicitOnClassic, Equals, true)
+	c.Assert(si.Summary, Equals, `allows constrained control over kernel module loading`)
+	c.Assert(si.BaseDeclarationPlugs, testutil.Contains, "kernel-module-load")
+	c.Assert(si.BaseDeclarationSlots, testutil.Contains, "kernel-module-load")
+}
+
+func (s *KernelModuleLoadInterfaceSuite) TestAutoConnect(c *C) {
+	c.Assert(s.iface.AutoConnect(s.plug, s.coreSlot), Equals, true)
+}
+
+func (s *KernelModuleLoadInterfaceSuite) TestInterfaces(c *C) {
+	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
+}
@@ -220,6 +220,15 @@ func (iface *mprisInterface) getName(attribs map[string]interface{}) (string, er
 	return mprisName, nil
 }
 
+// May have a name element to use in the MPRIS bus name instead of the snap name
+var mprisSlotAttrs = []interfaces.AttrSpec{
+	{Name: "name", Type: interfaces.StringAttr, Pattern: regexp.MustCompile("^[a-zA-Z0-9_-]*$"), Expected: "a valid DBus name element"},
+}
+
+func (iface *mprisInterface) SlotAttrSpecs() []interfaces.AttrSpec {
+	return mprisSlotAttrs
+}
+
 func (iface *mprisInterface) SanitizeSlot(slot *interfaces.Slot) error {
 	_, err := iface.getName(slot.Attrs)
 	return err
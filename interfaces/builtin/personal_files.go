@@ -0,0 +1,107 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package builtin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+)
+
+const personalFilesSummary = `allows access to specific personal files or directories`
+
+// The paths are granted per snap, through the plug-attributes of an
+// allow-installation and allow-auto-connection rule in the snap-declaration.
+const personalFilesBaseDeclarationPlugs = `
+  personal-files:
+    allow-installation: false
+    deny-auto-connection: true
+`
+
+const personalFilesBaseDeclarationSlots = `
+  personal-files:
+    allow-installation:
+      slot-snap-type:
+        - core
+    deny-auto-connection: true
+`
+
+// Pattern of the paths that can be granted, in the home directory of the
+// user and leaving out the characters that have a special meaning in
+// AppArmor rules.
+var personalFilesPathPattern = regexp.MustCompile(`^\$HOME/[^\x00-\x1f"*?\[\]{}^\\@,]+$`)
+
+// Must have read or write paths, or both
+var personalFilesPlugAttrs = []interfaces.AttrSpec{
+	{Name: "read", Type: interfaces.StringListAttr, Path: interfaces.SubPath, Pattern: personalFilesPathPattern, Expected: "a path starting with $HOME/ without AppArmor special characters"},
+	{Name: "write", Type: interfaces.StringListAttr, Path: interfaces.SubPath, Pattern: personalFilesPathPattern, Expected: "a path starting with $HOME/ without AppArmor special characters"},
+}
+
+type personalFilesInterface struct{}
+
+func (iface *personalFilesInterface) String() string {
+	return iface.Name()
+}
+
+func (iface *personalFilesInterface) Name() string {
+	return "personal-files"
+}
+
+func (iface *personalFilesInterface) StaticInfo() interfaces.StaticInfo {
+	return interfaces.StaticInfo{
+		Summary:              personalFilesSummary,
+		ImplicitOnCore:       true,
+		ImplicitOnClassic:    true,
+		BaseDeclarationPlugs: personalFilesBaseDeclarationPlugs,
+		BaseDeclarationSlots: personalFilesBaseDeclarationSlots,
+	}
+}
+
+func (iface *personalFilesInterface) PlugAttrSpecs() []interfaces.AttrSpec {
+	return personalFilesPlugAttrs
+}
+
+func (iface *personalFilesInterface) SanitizeSlot(slot *interfaces.Slot) error {
+	return sanitizeSlotReservedForOS(iface, slot)
+}
+
+func (iface *personalFilesInterface) SanitizePlug(plug *interfaces.Plug) error {
+	return sanitizeFilesPlug(iface, plug)
+}
+
+func (iface *personalFilesInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
+	// The home directories are shared with the host, so there are no
+	// mount entries to add, only the access to grant to their owner.
+	spec.AddSnippet(filesAppArmor("Can access specific personal files or directories.", plug, func(path string) string {
+		return fmt.Sprintf(`owner "@{HOME}/%s{,/,/**}"`, strings.TrimPrefix(path, "$HOME/"))
+	}))
+	return nil
+}
+
+func (iface *personalFilesInterface) AutoConnect(*interfaces.Plug, *interfaces.Slot) bool {
+	// allow what declarations allowed
+	return true
+}
+
+func init() {
+	registerIface(&personalFilesInterface{})
+}
@@ -0,0 +1,128 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package builtin_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/mount"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type PersonalFilesInterfaceSuite struct {
+	iface    interfaces.Interface
+	coreSlot *interfaces.Slot
+	plug     *interfaces.Plug
+}
+
+var _ = Suite(&PersonalFilesInterfaceSuite{
+	iface: builtin.MustInterface("personal-files"),
+})
+
+const personalFilesConsumerYaml = `name: consumer
+plugs:
+ personal-files:
+  read: [$HOME/.config/vendor]
+  write: [$HOME/.our-app.conf]
+apps:
+ app:
+  plugs: [personal-files]
+`
+
+const personalFilesCoreYaml = `name: core
+type: os
+slots:
+  personal-files:
+`
+
+func (s *PersonalFilesInterfaceSuite) SetUpTest(c *C) {
+	s.plug = MockPlug(c, personalFilesConsumerYaml, nil, "personal-files")
+	s.coreSlot = MockSlot(c, personalFilesCoreYaml, nil, "personal-files")
+}
+
+func (s *PersonalFilesInterfaceSuite) TestName(c *C) {
+	c.Assert(s.iface.Name(), Equals, "personal-files")
+}
+
+func (s *PersonalFilesInterfaceSuite) TestSanitizeSlot(c *C) {
+	c.Assert(s.coreSlot.Sanitize(s.iface), IsNil)
+	slot := &interfaces.Slot{SlotInfo: &snap.SlotInfo{
+		Snap:      &snap.Info{SuggestedName: "some-snap"},
+		Name:      "personal-files",
+		Interface: "personal-files",
+	}}
+	c.Assert(slot.Sanitize(s.iface), ErrorMatches,
+		"personal-files slots are reserved for the core snap")
+}
+
+func (s *PersonalFilesInterfaceSuite) TestSanitizePlug(c *C) {
+	c.Assert(s.plug.Sanitize(s.iface), IsNil)
+}
+
+func (s *PersonalFilesInterfaceSuite) TestSanitizePlugErrors(c *C) {
+	for _, t := range []struct {
+		attrs string
+		err   string
+	}{
+		{``, `personal-files plug must have a "read" or "write" attribute`},
+		{`read: [/home/user/.config]`, `personal-files plug "read" attribute must be a path starting with \$HOME/ without AppArmor special characters, not "/home/user/.config"`},
+		{`read: [$HOME]`, `personal-files plug "read" attribute must be a path starting with \$HOME/ without AppArmor special characters, not "\$HOME"`},
+		{`write: [$HOME/../etc]`, `personal-files plug "write" attribute must be a clean path within its directory, not "\$HOME/../etc"`},
+		{`write: ["$HOME/.config/*"]`, `personal-files plug "write" attribute must be a path starting with \$HOME/ without AppArmor special characters, not "\$HOME/.config/\*"`},
+	} {
+		yaml := "name: consumer\nplugs:\n personal-files:\n  " + t.attrs + "\n"
+		plug := MockPlug(c, yaml, nil, "personal-files")
+		c.Check(plug.Sanitize(s.iface), ErrorMatches, t.err, Commentf("attrs: %s", t.attrs))
+	}
+}
+
+func (s *PersonalFilesInterfaceSuite) TestAppArmorSpec(c *C) {
+	c.Assert(s.plug.Sanitize(s.iface), IsNil)
+	spec := &apparmor.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, nil, s.coreSlot, nil), IsNil)
+	c.Assert(spec.SecurityTags(), DeepEquals, []string{"snap.consumer.app"})
+	c.Check(spec.SnippetForTag("snap.consumer.app"), Equals, `# Description: Can access specific personal files or directories.
+owner "@{HOME}/.config/vendor{,/,/**}" rk,
+owner "@{HOME}/.our-app.conf{,/,/**}" rwkl,
+`)
+}
+
+func (s *PersonalFilesInterfaceSuite) TestMountSpec(c *C) {
+	// the home directories are always shared with the host
+	spec := &mount.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, nil, s.coreSlot, nil), IsNil)
+	c.Check(spec.MountEntries(), HasLen, 0)
+}
+
+func (s *PersonalFilesInterfaceSuite) TestStaticInfo(c *C) {
+	si := interfaces.StaticInfoOf(s.iface)
+	c.Assert(si.ImplicitOnCore, Equals, true)
+	c.Assert(si.ImplicitOnClassic, Equals, true)
+	c.Assert(si.Summary, Equals, `allows access to specific personal files or directories`)
+	c.Assert(si.BaseDeclarationPlugs, testutil.Contains, "personal-files")
+	c.Assert(si.BaseDeclarationSlots, testutil.Contains, "personal-files")
+}
+
+func (s *PersonalFilesInterfaceSuite) TestInterfaces(c *C) {
+	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
+}
@@ -0,0 +1,168 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package builtin
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/polkit"
+	"github.com/snapcore/snapd/release"
+)
+
+const polkitSummary = `allows installing polkit policies for the actions of a service`
+
+// The action prefix a snap may use is granted through the snap-declaration.
+const polkitBaseDeclarationSlots = `
+  polkit:
+    allow-installation: false
+    deny-auto-connection: true
+`
+
+const polkitPermanentSlotAppArmor = `
+# Description: Allow the service to check authorizations with polkit.
+
+#include <abstractions/dbus-strict>
+
+dbus (send)
+    bus=system
+    path=/org/freedesktop/PolicyKit1/Authority
+    interface=org.freedesktop.PolicyKit1.Authority
+    member={CheckAuthorization,CancelCheckAuthorization}
+    peer=(label=unconfined),
+
+dbus (receive)
+    bus=system
+    path=/org/freedesktop/PolicyKit1/Authority
+    interface=org.freedesktop.PolicyKit1.Authority
+    member=Changed
+    peer=(label=unconfined),
+`
+
+// polkitPolicyDir is where snaps ship their polkit policy files, relative to
+// the snap mount directory.
+const polkitPolicyDir = "meta/polkit"
+
+var polkitSlotAttrs = []interfaces.AttrSpec{
+	{Name: "action-prefix", Type: interfaces.StringAttr, Required: true, Pattern: regexp.MustCompile(`^[a-z0-9]+(\.[a-z0-9-]+)+$`), Expected: "a valid polkit action prefix"},
+}
+
+type polkitInterface struct{}
+
+func (iface *polkitInterface) String() string {
+	return iface.Name()
+}
+
+func (iface *polkitInterface) Name() string {
+	return "polkit"
+}
+
+func (iface *polkitInterface) StaticInfo() interfaces.StaticInfo {
+	return interfaces.StaticInfo{
+		Summary:              polkitSummary,
+		BaseDeclarationSlots: polkitBaseDeclarationSlots,
+	}
+}
+
+func (iface *polkitInterface) SlotAttrSpecs() []interfaces.AttrSpec {
+	return polkitSlotAttrs
+}
+
+func (iface *polkitInterface) SanitizeSlot(slot *interfaces.Slot) error {
+	// The policy files are installed in /usr/share/polkit-1/actions,
+	// which is read-only on all-snaps systems.
+	if !release.OnClassic {
+		return fmt.Errorf("polkit slots are only supported on classic systems")
+	}
+	return nil
+}
+
+func (iface *polkitInterface) SanitizePlug(plug *interfaces.Plug) error {
+	return nil
+}
+
+func (iface *polkitInterface) AppArmorPermanentSlot(spec *apparmor.Specification, slot *interfaces.Slot) error {
+	spec.AddSnippet(polkitPermanentSlotAppArmor)
+	return nil
+}
+
+// PolkitConnectedSlot installs the policy files of the slot snap while the
+// slot is connected.
+//
+// The files are read as root, so only regular files that really are in the
+// snap are used, symbolic links could point anywhere.
+func (iface *polkitInterface) PolkitConnectedSlot(spec *polkit.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
+	actionPrefix, _ := slotAttrs["action-prefix"].(string)
+	mountDir, err := filepath.EvalSymlinks(slot.Snap.MountDir())
+	var dir string
+	if err == nil {
+		dir, err = filepath.EvalSymlinks(filepath.Join(mountDir, polkitPolicyDir))
+	}
+	if os.IsNotExist(err) {
+		return fmt.Errorf("cannot find any polkit policy files in %q", polkitPolicyDir)
+	}
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(dir, mountDir+"/") {
+		return fmt.Errorf("cannot use polkit policy files in %q: it is outside of the snap", polkitPolicyDir)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.policy"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("cannot find any polkit policy files in %q", polkitPolicyDir)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".policy")
+		fi, err := os.Lstat(file)
+		if err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return fmt.Errorf("cannot use polkit policy %q: not a regular file", name)
+		}
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := spec.AddPolicy(name, actionPrefix, polkit.Policy(content)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (iface *polkitInterface) AutoConnect(*interfaces.Plug, *interfaces.Slot) bool {
+	// allow what declarations allowed
+	return true
+}
+
+func init() {
+	registerIface(&polkitInterface{})
+}
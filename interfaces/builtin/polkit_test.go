@@ -0,0 +1,203 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package builtin_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/polkit"
+	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type PolkitInterfaceSuite struct {
+	iface   interfaces.Interface
+	slot    *interfaces.Slot
+	plug    *interfaces.Plug
+	restore func()
+}
+
+var _ = Suite(&PolkitInterfaceSuite{
+	iface: builtin.MustInterface("polkit"),
+})
+
+const polkitProducerYaml = `name: producer
+slots:
+ polkit:
+  action-prefix: io.snapcraft.producer
+apps:
+ app:
+  slots: [polkit]
+`
+
+const polkitConsumerYaml = `name: consumer
+plugs:
+ polkit:
+apps:
+ app:
+  plugs: [polkit]
+`
+
+const polkitTestPolicy = `<policyconfig>
+  <action id="io.snapcraft.producer.manage"/>
+</policyconfig>
+`
+
+func (s *PolkitInterfaceSuite) SetUpTest(c *C) {
+	s.restore = release.MockOnClassic(true)
+	dirs.SetRootDir(c.MkDir())
+	s.slot = MockSlot(c, polkitProducerYaml, &snap.SideInfo{Revision: snap.R(1)}, "polkit")
+	s.plug = MockPlug(c, polkitConsumerYaml, nil, "polkit")
+}
+
+func (s *PolkitInterfaceSuite) TearDownTest(c *C) {
+	s.restore()
+	dirs.SetRootDir("/")
+}
+
+func (s *PolkitInterfaceSuite) writePolicy(c *C, name, content string) {
+	dir := filepath.Join(s.slot.Snap.MountDir(), "meta/polkit")
+	c.Assert(os.MkdirAll(dir, 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644), IsNil)
+}
+
+func (s *PolkitInterfaceSuite) TestName(c *C) {
+	c.Assert(s.iface.Name(), Equals, "polkit")
+}
+
+func (s *PolkitInterfaceSuite) TestSanitizeSlot(c *C) {
+	c.Assert(s.slot.Sanitize(s.iface), IsNil)
+}
+
+func (s *PolkitInterfaceSuite) TestSanitizeSlotOnCore(c *C) {
+	restore := release.MockOnClassic(false)
+	defer restore()
+	c.Assert(s.slot.Sanitize(s.iface), ErrorMatches, "polkit slots are only supported on classic systems")
+}
+
+func (s *PolkitInterfaceSuite) TestSanitizeSlotActionPrefix(c *C) {
+	for _, t := range []struct {
+		prefix interface{}
+		err    string
+	}{
+		{nil, `polkit slot must have a "action-prefix" attribute`},
+		{42, `polkit slot "action-prefix" attribute must be a string`},
+		{"producer", `polkit slot "action-prefix" attribute must be a valid polkit action prefix, not "producer"`},
+		{"io.snapcraft.", `polkit slot "action-prefix" attribute must be a valid polkit action prefix, not "io.snapcraft."`},
+		{"Io.Snapcraft", `polkit slot "action-prefix" attribute must be a valid polkit action prefix, not "Io.Snapcraft"`},
+	} {
+		attrs := map[string]interface{}{}
+		if t.prefix != nil {
+			attrs["action-prefix"] = t.prefix
+		}
+		slot := &interfaces.Slot{SlotInfo: &snap.SlotInfo{
+			Snap:      &snap.Info{SuggestedName: "producer"},
+			Name:      "polkit",
+			Interface: "polkit",
+			Attrs:     attrs,
+		}}
+		c.Check(slot.Sanitize(s.iface), ErrorMatches, t.err)
+	}
+}
+
+func (s *PolkitInterfaceSuite) TestSanitizePlug(c *C) {
+	c.Assert(s.plug.Sanitize(s.iface), IsNil)
+}
+
+func (s *PolkitInterfaceSuite) TestAppArmorSpec(c *C) {
+	spec := &apparmor.Specification{}
+	c.Assert(spec.AddPermanentSlot(s.iface, s.slot), IsNil)
+	c.Assert(spec.SecurityTags(), DeepEquals, []string{"snap.producer.app"})
+	c.Check(spec.SnippetForTag("snap.producer.app"), testutil.Contains, "interface=org.freedesktop.PolicyKit1.Authority")
+}
+
+func (s *PolkitInterfaceSuite) TestPolkitConnectedSlot(c *C) {
+	s.writePolicy(c, "manage.policy", polkitTestPolicy)
+	s.writePolicy(c, "README", "not a policy")
+	spec := &polkit.Specification{}
+	c.Assert(spec.AddConnectedSlot(s.iface, s.plug, nil, s.slot, s.slot.Attrs), IsNil)
+	c.Check(spec.Policies(), DeepEquals, map[string]polkit.Policy{
+		"manage": polkit.Policy(polkitTestPolicy),
+	})
+}
+
+func (s *PolkitInterfaceSuite) TestPolkitConnectedSlotNoPolicies(c *C) {
+	spec := &polkit.Specification{}
+	err := spec.AddConnectedSlot(s.iface, s.plug, nil, s.slot, s.slot.Attrs)
+	c.Assert(err, ErrorMatches, `cannot find any polkit policy files in "meta/polkit"`)
+}
+
+func (s *PolkitInterfaceSuite) TestPolkitConnectedSlotForeignAction(c *C) {
+	s.writePolicy(c, "manage.policy", `<policyconfig><action id="org.freedesktop.login1.reboot"/></policyconfig>`)
+	spec := &polkit.Specification{}
+	err := spec.AddConnectedSlot(s.iface, s.plug, nil, s.slot, s.slot.Attrs)
+	c.Assert(err, ErrorMatches, `cannot use polkit policy "manage": polkit action "org.freedesktop.login1.reboot" is not below the "io.snapcraft.producer" prefix`)
+	c.Check(spec.Policies(), HasLen, 0)
+}
+
+func (s *PolkitInterfaceSuite) TestPolkitConnectedSlotSymlinkedPolicy(c *C) {
+	s.writePolicy(c, "manage.policy", polkitTestPolicy)
+	secret := filepath.Join(dirs.GlobalRootDir, "secret")
+	c.Assert(ioutil.WriteFile(secret, []byte("secret"), 0600), IsNil)
+	dir := filepath.Join(s.slot.Snap.MountDir(), "meta/polkit")
+	c.Assert(os.Symlink(secret, filepath.Join(dir, "other.policy")), IsNil)
+
+	spec := &polkit.Specification{}
+	err := spec.AddConnectedSlot(s.iface, s.plug, nil, s.slot, s.slot.Attrs)
+	c.Assert(err, ErrorMatches, `cannot use polkit policy "other": not a regular file`)
+}
+
+func (s *PolkitInterfaceSuite) TestPolkitConnectedSlotSymlinkedDir(c *C) {
+	outside := filepath.Join(dirs.GlobalRootDir, "outside")
+	c.Assert(os.MkdirAll(outside, 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(outside, "manage.policy"), []byte(polkitTestPolicy), 0644), IsNil)
+	meta := filepath.Join(s.slot.Snap.MountDir(), "meta")
+	c.Assert(os.MkdirAll(meta, 0755), IsNil)
+	c.Assert(os.Symlink(outside, filepath.Join(meta, "polkit")), IsNil)
+
+	spec := &polkit.Specification{}
+	err := spec.AddConnectedSlot(s.iface, s.plug, nil, s.slot, s.slot.Attrs)
+	c.Assert(err, ErrorMatches, `cannot use polkit policy files in "meta/polkit": it is outside of the snap`)
+	c.Check(spec.Policies(), HasLen, 0)
+}
+
+func (s *PolkitInterfaceSuite) TestStaticInfo(c *C) {
+	si := interfaces.StaticInfoOf(s.iface)
+	c.Assert(si.ImplicitOnCore, Equals, false)
+	c.Assert(si.ImplicitOnClassic, Equals, false)
+	c.Assert(si.Summary, Equals, `allows installing polkit policies for the actions of a service`)
+	c.Assert(si.BaseDeclarationSlots, testutil.Contains, "polkit")
+}
+
+func (s *PolkitInterfaceSuite) TestAutoConnect(c *C) {
+	c.Assert(s.iface.AutoConnect(s.plug, s.slot), Equals, true)
+}
+
+func (s *PolkitInterfaceSuite) TestInterfaces(c *C) {
+	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/interfaces/udev"
 )
 
@@ -75,42 +76,36 @@ var serialDeviceNodePattern = regexp.MustCompile("^/dev/tty(USB|ACM|AMA|XRUSB|S|
 // are also specified
 var serialUDevSymlinkPattern = regexp.MustCompile("^/dev/serial-port-[a-z0-9]+$")
 
+// Must have a path attribute identifying the serial device, and may have usb
+// vendor and product identifiers for a udev symlink
+var serialPortSlotAttrs = []interfaces.AttrSpec{
+	{Name: "path", Type: interfaces.StringAttr, Required: true, Path: interfaces.CleanPath},
+	{Name: "usb-vendor", Type: interfaces.IntAttr, Min: 0x1, Max: 0xFFFF},
+	{Name: "usb-product", Type: interfaces.IntAttr, Min: 0x0, Max: 0xFFFF},
+}
+
+func (iface *serialPortInterface) SlotAttrSpecs() []interfaces.AttrSpec {
+	return serialPortSlotAttrs
+}
+
 // SanitizeSlot checks validity of the defined slot
 func (iface *serialPortInterface) SanitizeSlot(slot *interfaces.Slot) error {
 	if err := sanitizeSlotReservedForOSOrGadget(iface, slot); err != nil {
 		return err
 	}
 
-	// Check slot has a path attribute identify serial device
-	path, ok := slot.Attrs["path"].(string)
-	if !ok || path == "" {
-		return fmt.Errorf("serial-port slot must have a path attribute")
-	}
-
-	// Clean the path before further checks
-	path = filepath.Clean(path)
-
+	path := slot.Attrs["path"].(string)
 	if iface.hasUsbAttrs(slot) {
 		// Must be path attribute where symlink will be placed and usb vendor and product identifiers
 		// Check the path attribute is in the allowable pattern
 		if !serialUDevSymlinkPattern.MatchString(path) {
 			return fmt.Errorf("serial-port path attribute specifies invalid symlink location")
 		}
-
-		usbVendor, vOk := slot.Attrs["usb-vendor"].(int64)
-		if !vOk {
-			return fmt.Errorf("serial-port slot failed to find usb-vendor attribute")
-		}
-		if (usbVendor < 0x1) || (usbVendor > 0xFFFF) {
-			return fmt.Errorf("serial-port usb-vendor attribute not valid: %d", usbVendor)
+		if _, ok := slot.Attrs["usb-vendor"]; !ok {
+			return fmt.Errorf(`serial-port slot must have a "usb-vendor" attribute`)
 		}
-
-		usbProduct, pOk := slot.Attrs["usb-product"].(int64)
-		if !pOk {
-			return fmt.Errorf("serial-port slot failed to find usb-product attribute")
-		}
-		if (usbProduct < 0x0) || (usbProduct > 0xFFFF) {
-			return fmt.Errorf("serial-port usb-product attribute not valid: %d", usbProduct)
+		if _, ok := slot.Attrs["usb-product"]; !ok {
+			return fmt.Errorf(`serial-port slot must have a "usb-product" attribute`)
 		}
 	} else {
 		// Just a path attribute - must be a valid usb device node
@@ -179,6 +174,24 @@ func (iface *serialPortInterface) AutoConnect(*interfaces.Plug, *interfaces.Slot
 	return true
 }
 
+// HotplugDeviceDetected creates a slot for serial ports of USB devices,
+// like USB to serial adapters, when they are plugged in.
+func (iface *serialPortInterface) HotplugDeviceDetected(di *hotplug.HotplugDeviceInfo, spec *hotplug.Specification) error {
+	bus, _ := di.Attribute("ID_BUS")
+	if di.Subsystem() != "tty" || bus != "usb" || !serialDeviceNodePattern.MatchString(di.DeviceName()) {
+		return nil
+	}
+	label, _ := di.Attribute("ID_MODEL_FROM_DATABASE")
+	if label == "" {
+		label, _ = di.Attribute("ID_MODEL")
+	}
+	return spec.SetSlot(&hotplug.SlotSpec{
+		Name:  "serial-port",
+		Label: label,
+		Attrs: map[string]interface{}{"path": di.DeviceName()},
+	})
+}
+
 func (iface *serialPortInterface) hasUsbAttrs(slot *interfaces.Slot) bool {
 	if _, ok := slot.Attrs["usb-vendor"]; ok {
 		return true
@@ -25,7 +25,9 @@ import (
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/apparmor"
 	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/hotplug"
 	"github.com/snapcore/snapd/interfaces/udev"
+	"github.com/snapcore/snapd/snap"
 	"github.com/snapcore/snapd/snap/snaptest"
 	"github.com/snapcore/snapd/testutil"
 )
@@ -218,7 +220,7 @@ func (s *SerialPortInterfaceSuite) TestSanitizeCoreSnapSlots(c *C) {
 
 func (s *SerialPortInterfaceSuite) TestSanitizeBadCoreSnapSlots(c *C) {
 	// Slots without the "path" attribute are rejected.
-	c.Assert(s.missingPathSlot.Sanitize(s.iface), ErrorMatches, `serial-port slot must have a path attribute`)
+	c.Assert(s.missingPathSlot.Sanitize(s.iface), ErrorMatches, `serial-port slot must have a "path" attribute`)
 
 	// Slots with incorrect value of the "path" attribute are rejected.
 	for _, slot := range []*interfaces.Slot{s.badPathSlot1, s.badPathSlot2, s.badPathSlot3, s.badPathSlot4, s.badPathSlot5, s.badPathSlot6, s.badPathSlot7, s.badPathSlot8, s.badPathSlot9, s.badPathSlot10} {
@@ -232,8 +234,8 @@ func (s *SerialPortInterfaceSuite) TestSanitizeGadgetSnapSlots(c *C) {
 }
 
 func (s *SerialPortInterfaceSuite) TestSanitizeBadGadgetSnapSlots(c *C) {
-	c.Assert(s.testUDevBadValue1.Sanitize(s.iface), ErrorMatches, "serial-port usb-vendor attribute not valid: -1")
-	c.Assert(s.testUDevBadValue2.Sanitize(s.iface), ErrorMatches, "serial-port usb-product attribute not valid: 65536")
+	c.Assert(s.testUDevBadValue1.Sanitize(s.iface), ErrorMatches, `serial-port slot "usb-vendor" attribute must be between 1 and 65535, not -1`)
+	c.Assert(s.testUDevBadValue2.Sanitize(s.iface), ErrorMatches, `serial-port slot "usb-product" attribute must be between 0 and 65535, not 65536`)
 	c.Assert(s.testUDevBadValue3.Sanitize(s.iface), ErrorMatches, "serial-port path attribute specifies invalid symlink location")
 }
 
@@ -325,6 +327,54 @@ func (s *SerialPortInterfaceSuite) TestConnectedPlugAppArmorSnippets(c *C) {
 	checkConnectedPlugSnippet(s.testPlugPort2, s.testUDev2, expectedSnippet9)
 }
 
+func (s *SerialPortInterfaceSuite) TestHotplugDeviceDetected(c *C) {
+	di, err := hotplug.NewHotplugDeviceInfo(map[string]string{
+		"ACTION":      "add",
+		"DEVPATH":     "/devices/pci0000:00/0000:00:14.0/usb2/2-1/2-1:1.0/ttyUSB0/tty/ttyUSB0",
+		"SUBSYSTEM":   "tty",
+		"DEVNAME":     "/dev/ttyUSB0",
+		"ID_BUS":      "usb",
+		"ID_MODEL":    "FT232R_USB_UART",
+		"ID_VENDOR":   "FTDI",
+		"ID_SERIAL":   "FTDI_FT232R_USB_UART_A1000abc",
+		"ID_MODEL_ID": "6001",
+	})
+	c.Assert(err, IsNil)
+	spec := hotplug.NewSpecification()
+	c.Assert(s.iface.(hotplug.Definer).HotplugDeviceDetected(di, spec), IsNil)
+	c.Assert(spec.Slot(), DeepEquals, &hotplug.SlotSpec{
+		Name:  "serial-port",
+		Label: "FT232R_USB_UART",
+		Attrs: map[string]interface{}{"path": "/dev/ttyUSB0"},
+	})
+
+	// the slot is valid for the core snap
+	slot := &interfaces.Slot{SlotInfo: &snap.SlotInfo{
+		Snap:      s.testSlot1.Snap,
+		Name:      spec.Slot().Name,
+		Interface: "serial-port",
+		Attrs:     spec.Slot().Attrs,
+	}}
+	c.Check(slot.Sanitize(s.iface), IsNil)
+}
+
+func (s *SerialPortInterfaceSuite) TestHotplugDeviceDetectedIgnoresOtherDevices(c *C) {
+	for _, env := range []map[string]string{
+		// built-in serial port
+		{"ACTION": "add", "DEVPATH": "/devices/platform/serial8250/tty/ttyS0", "SUBSYSTEM": "tty", "DEVNAME": "/dev/ttyS0"},
+		// not a serial port
+		{"ACTION": "add", "DEVPATH": "/devices/a", "SUBSYSTEM": "hidraw", "DEVNAME": "/dev/hidraw0", "ID_BUS": "usb"},
+		// not a supported device node
+		{"ACTION": "add", "DEVPATH": "/devices/b", "SUBSYSTEM": "tty", "DEVNAME": "/dev/ttyFOO0", "ID_BUS": "usb"},
+	} {
+		di, err := hotplug.NewHotplugDeviceInfo(env)
+		c.Assert(err, IsNil)
+		spec := hotplug.NewSpecification()
+		c.Assert(s.iface.(hotplug.Definer).HotplugDeviceDetected(di, spec), IsNil)
+		c.Check(spec.Slot(), IsNil, Commentf("%s", env["DEVPATH"]))
+	}
+}
+
 func (s *SerialPortInterfaceSuite) TestInterfaces(c *C) {
 	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
 }
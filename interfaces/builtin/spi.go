@@ -68,12 +68,17 @@ func (iface *spiInterface) path(slot *interfaces.Slot) (string, error) {
 	return path, nil
 }
 
+var spiSlotAttrs = []interfaces.AttrSpec{
+	{Name: "path", Type: interfaces.StringAttr, Required: true, Path: interfaces.CleanPath,
+		Pattern: spiDevPattern, Expected: "a valid SPI device"},
+}
+
+func (iface *spiInterface) SlotAttrSpecs() []interfaces.AttrSpec {
+	return spiSlotAttrs
+}
+
 func (iface *spiInterface) SanitizeSlot(slot *interfaces.Slot) error {
-	if err := sanitizeSlotReservedForOSOrGadget(iface, slot); err != nil {
-		return err
-	}
-	_, err := iface.path(slot)
-	return err
+	return sanitizeSlotReservedForOSOrGadget(iface, slot)
 }
 
 func (iface *spiInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
@@ -135,21 +135,22 @@ func (s *spiInterfaceSuite) TestSanitizeSlot(c *C) {
 	c.Assert(s.slotGadget1.Sanitize(s.iface), IsNil)
 	c.Assert(s.slotGadget2.Sanitize(s.iface), IsNil)
 	err := s.slotGadgetBad1.Sanitize(s.iface)
-	c.Assert(err, ErrorMatches, `"/dev/spev0.0" is not a valid SPI device`)
+	c.Assert(err, ErrorMatches, `spi slot "path" attribute must be a valid SPI device, not "/dev/spev0.0"`)
 	err = s.slotGadgetBad2.Sanitize(s.iface)
-	c.Assert(err, ErrorMatches, `"/dev/sidv0.0" is not a valid SPI device`)
+	c.Assert(err, ErrorMatches, `spi slot "path" attribute must be a valid SPI device, not "/dev/sidv0.0"`)
 	err = s.slotGadgetBad3.Sanitize(s.iface)
-	c.Assert(err, ErrorMatches, `"/dev/slpiv0.3" is not a valid SPI device`)
+	c.Assert(err, ErrorMatches, `spi slot "path" attribute must be a valid SPI device, not "/dev/slpiv0.3"`)
 	err = s.slotGadgetBad4.Sanitize(s.iface)
-	c.Assert(err, ErrorMatches, `"/dev/sdev-00" is not a valid SPI device`)
+	c.Assert(err, ErrorMatches, `spi slot "path" attribute must be a valid SPI device, not "/dev/sdev-00"`)
 	err = s.slotGadgetBad5.Sanitize(s.iface)
-	c.Assert(err, ErrorMatches, `"/dev/spi-foo" is not a valid SPI device`)
+	c.Assert(err, ErrorMatches, `spi slot "path" attribute must be a valid SPI device, not "/dev/spi-foo"`)
 	err = s.slotGadgetBad6.Sanitize(s.iface)
-	c.Assert(err, ErrorMatches, `slot "gadget:bad-spi-6" must have a path attribute`)
+	c.Assert(err, ErrorMatches, `spi slot must have a "path" attribute`)
 	slot := &interfaces.Slot{SlotInfo: &snap.SlotInfo{
 		Snap:      &snap.Info{SuggestedName: "some-snap"},
 		Name:      "spi",
 		Interface: "spi",
+		Attrs:     map[string]interface{}{"path": "/dev/spidev0.0"},
 	}}
 	c.Assert(slot.Sanitize(s.iface), ErrorMatches,
 		"spi slots are reserved for the core and gadget snaps")
@@ -0,0 +1,188 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package builtin
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/mount"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/release"
+)
+
+const systemFilesSummary = `allows access to specific system files or directories`
+
+// The paths are granted per snap, through the plug-attributes of an
+// allow-installation and allow-auto-connection rule in the snap-declaration.
+const systemFilesBaseDeclarationPlugs = `
+  system-files:
+    allow-installation: false
+    deny-auto-connection: true
+`
+
+const systemFilesBaseDeclarationSlots = `
+  system-files:
+    allow-installation:
+      slot-snap-type:
+        - core
+    deny-auto-connection: true
+`
+
+// Pattern of the paths that can be granted, leaving out the characters that
+// have a special meaning in AppArmor rules.
+var systemFilesPathPattern = regexp.MustCompile(`^/[^\x00-\x1f"*?\[\]{}^\\@,]+$`)
+
+// Must have read or write paths, or both
+var systemFilesPlugAttrs = []interfaces.AttrSpec{
+	{Name: "read", Type: interfaces.StringListAttr, Path: interfaces.AbsPath, Pattern: systemFilesPathPattern, Expected: "a path without AppArmor special characters"},
+	{Name: "write", Type: interfaces.StringListAttr, Path: interfaces.AbsPath, Pattern: systemFilesPathPattern, Expected: "a path without AppArmor special characters"},
+}
+
+// Directories that snap-confine already shares with the host on classic,
+// see mount-support.c. Paths below them need no mount entry.
+var hostSharedDirs = []string{
+	"/dev", "/etc", "/home", "/root", "/proc", "/sys", "/tmp",
+	"/var/snap", "/var/lib/snapd", "/var/tmp", "/run", "/lib/modules",
+	"/usr/src", "/var/log", "/media",
+}
+
+type systemFilesInterface struct{}
+
+func (iface *systemFilesInterface) String() string {
+	return iface.Name()
+}
+
+func (iface *systemFilesInterface) Name() string {
+	return "system-files"
+}
+
+func (iface *systemFilesInterface) StaticInfo() interfaces.StaticInfo {
+	return interfaces.StaticInfo{
+		Summary:              systemFilesSummary,
+		ImplicitOnCore:       true,
+		ImplicitOnClassic:    true,
+		BaseDeclarationPlugs: systemFilesBaseDeclarationPlugs,
+		BaseDeclarationSlots: systemFilesBaseDeclarationSlots,
+	}
+}
+
+func (iface *systemFilesInterface) PlugAttrSpecs() []interfaces.AttrSpec {
+	return systemFilesPlugAttrs
+}
+
+func (iface *systemFilesInterface) SanitizeSlot(slot *interfaces.Slot) error {
+	return sanitizeSlotReservedForOS(iface, slot)
+}
+
+func (iface *systemFilesInterface) SanitizePlug(plug *interfaces.Plug) error {
+	return sanitizeFilesPlug(iface, plug)
+}
+
+func (iface *systemFilesInterface) AppArmorConnectedPlug(spec *apparmor.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
+	spec.AddSnippet(filesAppArmor("Can access specific system files or directories.", plug, func(path string) string {
+		return fmt.Sprintf(`"%s{,/,/**}"`, path)
+	}))
+	return nil
+}
+
+func (iface *systemFilesInterface) MountConnectedPlug(spec *mount.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
+	if !release.OnClassic {
+		// The system files are those of the core snap on an all-snaps
+		// system, there is nothing to expose.
+		return nil
+	}
+	for _, which := range []string{"read", "write"} {
+		for _, path := range filesPaths(plug, which) {
+			if isHostShared(path) || !osutil.FileExists(filepath.Join(dirs.GlobalRootDir, path)) {
+				continue
+			}
+			options := []string{"bind"}
+			if which == "read" {
+				options = append(options, "ro")
+			}
+			spec.AddMountEntry(mount.Entry{
+				Name:    "/var/lib/snapd/hostfs" + path,
+				Dir:     path,
+				Options: options,
+			})
+		}
+	}
+	return nil
+}
+
+func (iface *systemFilesInterface) AutoConnect(*interfaces.Plug, *interfaces.Slot) bool {
+	// allow what declarations allowed
+	return true
+}
+
+func isHostShared(path string) bool {
+	for _, dir := range hostSharedDirs {
+		if path == dir || strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeFilesPlug checks that a system-files or personal-files plug
+// grants access to some path.
+func sanitizeFilesPlug(iface interfaces.Interface, plug *interfaces.Plug) error {
+	if len(filesPaths(plug, "read")) == 0 && len(filesPaths(plug, "write")) == 0 {
+		return fmt.Errorf(`%s plug must have a "read" or "write" attribute`, iface.Name())
+	}
+	return nil
+}
+
+// filesPaths returns the paths of the read or write attribute of the plug.
+func filesPaths(plug *interfaces.Plug, which string) []string {
+	list, _ := plug.Attrs[which].([]interface{})
+	paths := make([]string, 0, len(list))
+	for _, item := range list {
+		if path, ok := item.(string); ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// filesAppArmor returns the AppArmor rules granting read access to the read
+// paths of the plug, and write access to its write paths, as turned into
+// AppArmor globs by the given function.
+func filesAppArmor(description string, plug *interfaces.Plug, glob func(path string) string) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Description: %s\n", description)
+	for _, path := range filesPaths(plug, "read") {
+		fmt.Fprintf(&buf, "%s rk,\n", glob(path))
+	}
+	for _, path := range filesPaths(plug, "write") {
+		fmt.Fprintf(&buf, "%s rwkl,\n", glob(path))
+	}
+	return buf.String()
+}
+
+func init() {
+	registerIface(&systemFilesInterface{})
+}
@@ -0,0 +1,176 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package builtin_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/apparmor"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/mount"
+	"github.com/snapcore/snapd/release"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/testutil"
+)
+
+type SystemFilesInterfaceSuite struct {
+	iface    interfaces.Interface
+	coreSlot *interfaces.Slot
+	plug     *interfaces.Plug
+}
+
+var _ = Suite(&SystemFilesInterfaceSuite{
+	iface: builtin.MustInterface("system-files"),
+})
+
+const systemFilesConsumerYaml = `name: consumer
+plugs:
+ system-files:
+  read: [/etc/our-app.conf, /opt/vendor/share]
+  write: [/var/lib/our-app]
+apps:
+ app:
+  plugs: [system-files]
+`
+
+const systemFilesCoreYaml = `name: core
+type: os
+slots:
+  system-files:
+`
+
+func (s *SystemFilesInterfaceSuite) SetUpTest(c *C) {
+	s.plug = MockPlug(c, systemFilesConsumerYaml, nil, "system-files")
+	s.coreSlot = MockSlot(c, systemFilesCoreYaml, nil, "system-files")
+}
+
+func (s *SystemFilesInterfaceSuite) TearDownTest(c *C) {
+	dirs.SetRootDir("/")
+}
+
+func (s *SystemFilesInterfaceSuite) TestName(c *C) {
+	c.Assert(s.iface.Name(), Equals, "system-files")
+}
+
+func (s *SystemFilesInterfaceSuite) TestSanitizeSlot(c *C) {
+	c.Assert(s.coreSlot.Sanitize(s.iface), IsNil)
+	slot := &interfaces.Slot{SlotInfo: &snap.SlotInfo{
+		Snap:      &snap.Info{SuggestedName: "some-snap"},
+		Name:      "system-files",
+		Interface: "system-files",
+	}}
+	c.Assert(slot.Sanitize(s.iface), ErrorMatches,
+		"system-files slots are reserved for the core snap")
+}
+
+func (s *SystemFilesInterfaceSuite) TestSanitizePlug(c *C) {
+	c.Assert(s.plug.Sanitize(s.iface), IsNil)
+}
+
+func (s *SystemFilesInterfaceSuite) TestSanitizePlugErrors(c *C) {
+	for _, t := range []struct {
+		attrs string
+		err   string
+	}{
+		{``, `system-files plug must have a "read" or "write" attribute`},
+		{`read: []`, `system-files plug must have a "read" or "write" attribute`},
+		{`read: /etc/foo`, `system-files plug "read" attribute must be a list of strings`},
+		{`read: [etc/foo]`, `system-files plug "read" attribute must be a clean absolute path, not "etc/foo"`},
+		{`write: [/etc/foo/]`, `system-files plug "write" attribute must be a clean absolute path, not "/etc/foo/"`},
+		{`read: [/]`, `system-files plug "read" attribute must be a path without AppArmor special characters, not "/"`},
+		{`read: ["/etc/*"]`, `system-files plug "read" attribute must be a path without AppArmor special characters, not "/etc/\*"`},
+		{`write: ["/etc/{a,b}"]`, `system-files plug "write" attribute must be a path without AppArmor special characters, not "/etc/{a,b}"`},
+		{`read: ["/etc/@{HOME}"]`, `system-files plug "read" attribute must be a path without AppArmor special characters, not "/etc/@{HOME}"`},
+	} {
+		yaml := "name: consumer\nplugs:\n system-files:\n  " + t.attrs + "\n"
+		plug := MockPlug(c, yaml, nil, "system-files")
+		c.Check(plug.Sanitize(s.iface), ErrorMatches, t.err, Commentf("attrs: %s", t.attrs))
+	}
+}
+
+func (s *SystemFilesInterfaceSuite) TestAppArmorSpec(c *C) {
+	c.Assert(s.plug.Sanitize(s.iface), IsNil)
+	spec := &apparmor.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, nil, s.coreSlot, nil), IsNil)
+	c.Assert(spec.SecurityTags(), DeepEquals, []string{"snap.consumer.app"})
+	c.Check(spec.SnippetForTag("snap.consumer.app"), Equals, `# Description: Can access specific system files or directories.
+"/etc/our-app.conf{,/,/**}" rk,
+"/opt/vendor/share{,/,/**}" rk,
+"/var/lib/our-app{,/,/**}" rwkl,
+`)
+
+	spec = &apparmor.Specification{}
+	c.Assert(spec.AddConnectedSlot(s.iface, s.plug, nil, s.coreSlot, nil), IsNil)
+	c.Assert(spec.SecurityTags(), HasLen, 0)
+}
+
+func (s *SystemFilesInterfaceSuite) TestMountSpec(c *C) {
+	tmpdir := c.MkDir()
+	dirs.SetRootDir(tmpdir)
+	c.Assert(os.MkdirAll(filepath.Join(tmpdir, "/etc"), 0755), IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(tmpdir, "/opt/vendor/share"), 0755), IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(tmpdir, "/var/lib/our-app"), 0755), IsNil)
+	c.Assert(s.plug.Sanitize(s.iface), IsNil)
+
+	restore := release.MockOnClassic(false)
+	defer restore()
+
+	// On all-snaps systems, no mount entries are added
+	spec := &mount.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, nil, s.coreSlot, nil), IsNil)
+	c.Check(spec.MountEntries(), HasLen, 0)
+
+	// On classic systems, the paths that exist on the host and are not
+	// already shared with it are bind mounted from the host system.
+	restore = release.MockOnClassic(true)
+	defer restore()
+	spec = &mount.Specification{}
+	c.Assert(spec.AddConnectedPlug(s.iface, s.plug, nil, s.coreSlot, nil), IsNil)
+	c.Check(spec.MountEntries(), DeepEquals, []mount.Entry{{
+		Name:    "/var/lib/snapd/hostfs/opt/vendor/share",
+		Dir:     "/opt/vendor/share",
+		Options: []string{"bind", "ro"},
+	}, {
+		Name:    "/var/lib/snapd/hostfs/var/lib/our-app",
+		Dir:     "/var/lib/our-app",
+		Options: []string{"bind"},
+	}})
+}
+
+func (s *SystemFilesInterfaceSuite) TestStaticInfo(c *C) {
+	si := interfaces.StaticInfoOf(s.iface)
+	c.Assert(si.ImplicitOnCore, Equals, true)
+	c.Assert(si.ImplicitOnClassic, Equals, true)
+	c.Assert(si.Summary, Equals, `allows access to specific system files or directories`)
+	c.Assert(si.BaseDeclarationPlugs, testutil.Contains, "system-files")
+	c.Assert(si.BaseDeclarationSlots, testutil.Contains, "system-files")
+}
+
+func (s *SystemFilesInterfaceSuite) TestAutoConnect(c *C) {
+	c.Check(s.iface.AutoConnect(s.plug, s.coreSlot), Equals, true)
+}
+
+func (s *SystemFilesInterfaceSuite) TestInterfaces(c *C) {
+	c.Check(builtin.Interfaces(), testutil.DeepContains, s.iface)
+}
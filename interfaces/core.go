@@ -44,6 +44,11 @@ func (plug *Plug) Sanitize(iface Interface) error {
 		return fmt.Errorf("cannot sanitize plug %q (interface %q) using interface %q",
 			plug.Ref(), plug.Interface, iface.Name())
 	}
+	if schema, ok := iface.(PlugAttrSchema); ok {
+		if err := checkAttrs(iface.Name(), "plug", plug.Attrs, schema.PlugAttrSpecs()); err != nil {
+			return err
+		}
+	}
 	var err error
 	if iface, ok := iface.(PlugSanitizer); ok {
 		err = iface.SanitizePlug(plug)
@@ -51,6 +56,18 @@ func (plug *Plug) Sanitize(iface Interface) error {
 	return err
 }
 
+// CheckDynamicAttrs checks the dynamic attributes set by the interface
+// hooks for a connection of the plug, together with its static ones,
+// against the attributes declared by the given interface. The dynamic
+// attributes are normalized and their paths canonicalised in place.
+func (plug *Plug) CheckDynamicAttrs(iface Interface, dynamic map[string]interface{}) error {
+	var specs []AttrSpec
+	if schema, ok := iface.(PlugAttrSchema); ok {
+		specs = schema.PlugAttrSpecs()
+	}
+	return checkDynamicAttrs(iface.Name(), "plug", plug.Attrs, dynamic, specs)
+}
+
 // PlugRef is a reference to a plug.
 type PlugRef struct {
 	Snap string `json:"snap"`
@@ -79,6 +96,11 @@ func (slot *Slot) Sanitize(iface Interface) error {
 		return fmt.Errorf("cannot sanitize slot %q (interface %q) using interface %q",
 			slot.Ref(), slot.Interface, iface.Name())
 	}
+	if schema, ok := iface.(SlotAttrSchema); ok {
+		if err := checkAttrs(iface.Name(), "slot", slot.Attrs, schema.SlotAttrSpecs()); err != nil {
+			return err
+		}
+	}
 	var err error
 	if iface, ok := iface.(SlotSanitizer); ok {
 		err = iface.SanitizeSlot(slot)
@@ -86,6 +108,18 @@ func (slot *Slot) Sanitize(iface Interface) error {
 	return err
 }
 
+// CheckDynamicAttrs checks the dynamic attributes set by the interface
+// hooks for a connection of the slot, together with its static ones,
+// against the attributes declared by the given interface. The dynamic
+// attributes are normalized and their paths canonicalised in place.
+func (slot *Slot) CheckDynamicAttrs(iface Interface, dynamic map[string]interface{}) error {
+	var specs []AttrSpec
+	if schema, ok := iface.(SlotAttrSchema); ok {
+		specs = schema.SlotAttrSpecs()
+	}
+	return checkDynamicAttrs(iface.Name(), "slot", slot.Attrs, dynamic, specs)
+}
+
 // SlotRef is a reference to a slot.
 type SlotRef struct {
 	Snap string `json:"snap"`
@@ -121,12 +155,28 @@ type ConnRef struct {
 type Connection struct {
 	plugInfo *snap.PlugInfo
 	slotInfo *snap.SlotInfo
+	// dynamic attributes of the plug and the slot, set by the interface
+	// hooks when the connection was made
+	plugAttrs map[string]interface{}
+	slotAttrs map[string]interface{}
 }
 
 func (conn *Connection) Interface() string {
 	return conn.plugInfo.Interface
 }
 
+// PlugAttrs returns the static attributes of the plug overlaid with the
+// dynamic attributes of the connection.
+func (conn *Connection) PlugAttrs() map[string]interface{} {
+	return MergeAttrs(conn.plugInfo.Attrs, conn.plugAttrs)
+}
+
+// SlotAttrs returns the static attributes of the slot overlaid with the
+// dynamic attributes of the connection.
+func (conn *Connection) SlotAttrs() map[string]interface{} {
+	return MergeAttrs(conn.slotInfo.Attrs, conn.slotAttrs)
+}
+
 // ID returns a string identifying a given connection.
 func (conn *ConnRef) ID() string {
 	return fmt.Sprintf("%s:%s %s:%s", conn.PlugRef.Snap, conn.PlugRef.Name, conn.SlotRef.Snap, conn.SlotRef.Name)
@@ -236,6 +286,8 @@ const (
 	SecurityKMod SecuritySystem = "kmod"
 	// SecuritySystemd identifies the systemd services security system
 	SecuritySystemd SecuritySystem = "systemd"
+	// SecurityPolkit identifies the polkit security system
+	SecurityPolkit SecuritySystem = "polkit"
 )
 
 // Regular expression describing correct identifiers.
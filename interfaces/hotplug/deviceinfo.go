@@ -0,0 +1,107 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package hotplug supports interfaces creating slots on the core snap
+// for devices that are plugged in while the system runs.
+package hotplug
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// HotplugDeviceInfo carries information about a device, as reported by
+// udev when the device is added or removed.
+type HotplugDeviceInfo struct {
+	// data holds all the properties of the udev event.
+	data map[string]string
+}
+
+// NewHotplugDeviceInfo returns the information about a device from the
+// properties of a udev event, which must include ACTION, DEVPATH and
+// SUBSYSTEM.
+func NewHotplugDeviceInfo(env map[string]string) (*HotplugDeviceInfo, error) {
+	for _, attr := range []string{"ACTION", "DEVPATH", "SUBSYSTEM"} {
+		if _, ok := env[attr]; !ok {
+			return nil, fmt.Errorf("missing device attribute %q", attr)
+		}
+	}
+	return &HotplugDeviceInfo{data: env}, nil
+}
+
+// Action returns the udev action of the event, like "add" or "remove".
+func (h *HotplugDeviceInfo) Action() string {
+	return h.data["ACTION"]
+}
+
+// DevicePath returns the path of the device in /sys, without the /sys
+// prefix, like /devices/pci0000:00/0000:00:14.0/usb1/1-2/1-2:1.0/ttyUSB0.
+func (h *HotplugDeviceInfo) DevicePath() string {
+	return h.data["DEVPATH"]
+}
+
+// Subsystem returns the kernel subsystem of the device, like "tty".
+func (h *HotplugDeviceInfo) Subsystem() string {
+	return h.data["SUBSYSTEM"]
+}
+
+// DeviceName returns the path of the device node, like /dev/ttyUSB0, or
+// an empty string for devices without one.
+func (h *HotplugDeviceInfo) DeviceName() string {
+	name := h.data["DEVNAME"]
+	if name != "" && !strings.HasPrefix(name, "/dev/") {
+		name = "/dev/" + name
+	}
+	return name
+}
+
+// Attribute returns the value of the given property of the udev event.
+func (h *HotplugDeviceInfo) Attribute(name string) (string, bool) {
+	value, ok := h.data[name]
+	return value, ok
+}
+
+// Key returns an identifier of the physical device that does not change
+// when the device is unplugged and plugged in again.
+//
+// Devices that report a serial number are identified by their vendor,
+// model and serial number, wherever they are plugged in. Other devices are
+// identified by their device path, which stays the same as long as they
+// are plugged into the same port.
+func (h *HotplugDeviceInfo) Key() string {
+	var id string
+	serial := h.data["ID_SERIAL_SHORT"]
+	if serial == "" {
+		serial = h.data["ID_SERIAL"]
+	}
+	if serial != "" {
+		id = fmt.Sprintf("%s\x00%s\x00%s", h.data["ID_VENDOR_ID"], h.data["ID_MODEL_ID"], serial)
+	} else {
+		id = h.DevicePath()
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(id)))
+}
+
+func (h *HotplugDeviceInfo) String() string {
+	if name := h.DeviceName(); name != "" {
+		return name
+	}
+	return h.DevicePath()
+}
@@ -0,0 +1,107 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package hotplug_test
+
+import (
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces/hotplug"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type hotplugSuite struct{}
+
+var _ = Suite(&hotplugSuite{})
+
+func (s *hotplugSuite) TestDeviceInfo(c *C) {
+	di, err := hotplug.NewHotplugDeviceInfo(map[string]string{
+		"ACTION":       "add",
+		"DEVPATH":      "/devices/pci0000:00/usb1/1-2/1-2:1.0/ttyUSB0",
+		"SUBSYSTEM":    "tty",
+		"DEVNAME":      "/dev/ttyUSB0",
+		"ID_VENDOR_ID": "0403",
+	})
+	c.Assert(err, IsNil)
+	c.Check(di.Action(), Equals, "add")
+	c.Check(di.DevicePath(), Equals, "/devices/pci0000:00/usb1/1-2/1-2:1.0/ttyUSB0")
+	c.Check(di.Subsystem(), Equals, "tty")
+	c.Check(di.DeviceName(), Equals, "/dev/ttyUSB0")
+	c.Check(di.String(), Equals, "/dev/ttyUSB0")
+	value, ok := di.Attribute("ID_VENDOR_ID")
+	c.Check(ok, Equals, true)
+	c.Check(value, Equals, "0403")
+	_, ok = di.Attribute("ID_MODEL_ID")
+	c.Check(ok, Equals, false)
+}
+
+func (s *hotplugSuite) TestDeviceInfoKernelDeviceName(c *C) {
+	di, err := hotplug.NewHotplugDeviceInfo(map[string]string{
+		"ACTION": "add", "DEVPATH": "/devices/a", "SUBSYSTEM": "hidraw", "DEVNAME": "hidraw0",
+	})
+	c.Assert(err, IsNil)
+	c.Check(di.DeviceName(), Equals, "/dev/hidraw0")
+
+	di, err = hotplug.NewHotplugDeviceInfo(map[string]string{
+		"ACTION": "add", "DEVPATH": "/devices/a", "SUBSYSTEM": "usb",
+	})
+	c.Assert(err, IsNil)
+	c.Check(di.DeviceName(), Equals, "")
+	c.Check(di.String(), Equals, "/devices/a")
+}
+
+func (s *hotplugSuite) TestDeviceInfoMissingAttributes(c *C) {
+	_, err := hotplug.NewHotplugDeviceInfo(map[string]string{"ACTION": "add", "SUBSYSTEM": "tty"})
+	c.Check(err, ErrorMatches, `missing device attribute "DEVPATH"`)
+}
+
+func (s *hotplugSuite) TestDeviceInfoKey(c *C) {
+	mk := func(devpath, serial string) *hotplug.HotplugDeviceInfo {
+		env := map[string]string{
+			"ACTION": "add", "DEVPATH": devpath, "SUBSYSTEM": "tty",
+			"ID_VENDOR_ID": "0403", "ID_MODEL_ID": "6001",
+		}
+		if serial != "" {
+			env["ID_SERIAL_SHORT"] = serial
+		}
+		di, err := hotplug.NewHotplugDeviceInfo(env)
+		c.Assert(err, IsNil)
+		return di
+	}
+
+	// devices with a serial number keep their key in any port
+	c.Check(mk("/devices/port1", "A123").Key(), Equals, mk("/devices/port2", "A123").Key())
+	c.Check(mk("/devices/port1", "A123").Key(), Not(Equals), mk("/devices/port1", "B456").Key())
+	// other devices keep it in the same port only
+	c.Check(mk("/devices/port1", "").Key(), Equals, mk("/devices/port1", "").Key())
+	c.Check(mk("/devices/port1", "").Key(), Not(Equals), mk("/devices/port2", "").Key())
+	c.Check(mk("/devices/port1", "").Key(), HasLen, 64)
+}
+
+func (s *hotplugSuite) TestSpecification(c *C) {
+	spec := hotplug.NewSpecification()
+	c.Check(spec.Slot(), IsNil)
+	slot := &hotplug.SlotSpec{Name: "foo", Attrs: map[string]interface{}{"path": "/dev/ttyUSB0"}}
+	c.Assert(spec.SetSlot(slot), IsNil)
+	c.Check(spec.Slot(), Equals, slot)
+	c.Check(spec.SetSlot(&hotplug.SlotSpec{}), ErrorMatches, "slot specification already created")
+}
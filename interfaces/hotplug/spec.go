@@ -0,0 +1,67 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package hotplug
+
+import (
+	"fmt"
+)
+
+// SlotSpec describes the slot an interface creates for a device.
+type SlotSpec struct {
+	// Name is the suggested name of the slot. The interface name is used
+	// if empty, and a number is appended on clashes with other slots.
+	Name  string                 `json:"name,omitempty"`
+	Label string                 `json:"label,omitempty"`
+	Attrs map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// Specification holds the slot an interface creates for a device.
+type Specification struct {
+	slot *SlotSpec
+}
+
+// NewSpecification returns an empty hotplug specification.
+func NewSpecification() *Specification {
+	return &Specification{}
+}
+
+// SetSlot sets the slot to create for the device. Interfaces create at
+// most one slot per device.
+func (h *Specification) SetSlot(slotSpec *SlotSpec) error {
+	if h.slot != nil {
+		return fmt.Errorf("slot specification already created")
+	}
+	h.slot = slotSpec
+	return nil
+}
+
+// Slot returns the slot to create for the device, or nil.
+func (h *Specification) Slot() *SlotSpec {
+	return h.slot
+}
+
+// Definer can be implemented by interfaces that create slots for
+// hotplugged devices.
+type Definer interface {
+	// HotplugDeviceDetected is called for every device that is added and
+	// sets the slot to create in spec if the interface supports the
+	// device.
+	HotplugDeviceDetected(di *HotplugDeviceInfo, spec *Specification) error
+}
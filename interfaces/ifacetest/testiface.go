@@ -25,6 +25,7 @@ import (
 	"github.com/snapcore/snapd/interfaces/dbus"
 	"github.com/snapcore/snapd/interfaces/kmod"
 	"github.com/snapcore/snapd/interfaces/mount"
+	"github.com/snapcore/snapd/interfaces/polkit"
 	"github.com/snapcore/snapd/interfaces/seccomp"
 	"github.com/snapcore/snapd/interfaces/systemd"
 	"github.com/snapcore/snapd/interfaces/udev"
@@ -101,6 +102,13 @@ type TestInterface struct {
 	SystemdConnectedSlotCallback func(spec *systemd.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error
 	SystemdPermanentPlugCallback func(spec *systemd.Specification, plug *interfaces.Plug) error
 	SystemdPermanentSlotCallback func(spec *systemd.Specification, slot *interfaces.Slot) error
+
+	// Support for interacting with the polkit backend.
+
+	PolkitConnectedPlugCallback func(spec *polkit.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error
+	PolkitConnectedSlotCallback func(spec *polkit.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error
+	PolkitPermanentPlugCallback func(spec *polkit.Specification, plug *interfaces.Plug) error
+	PolkitPermanentSlotCallback func(spec *polkit.Specification, slot *interfaces.Slot) error
 }
 
 // String() returns the same value as Name().
@@ -397,3 +405,33 @@ func (t *TestInterface) SystemdPermanentPlug(spec *systemd.Specification, plug *
 	}
 	return nil
 }
+
+// Support for interacting with the polkit backend.
+
+func (t *TestInterface) PolkitConnectedPlug(spec *polkit.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
+	if t.PolkitConnectedPlugCallback != nil {
+		return t.PolkitConnectedPlugCallback(spec, plug, plugAttrs, slot, slotAttrs)
+	}
+	return nil
+}
+
+func (t *TestInterface) PolkitConnectedSlot(spec *polkit.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
+	if t.PolkitConnectedSlotCallback != nil {
+		return t.PolkitConnectedSlotCallback(spec, plug, plugAttrs, slot, slotAttrs)
+	}
+	return nil
+}
+
+func (t *TestInterface) PolkitPermanentSlot(spec *polkit.Specification, slot *interfaces.Slot) error {
+	if t.PolkitPermanentSlotCallback != nil {
+		return t.PolkitPermanentSlotCallback(spec, slot)
+	}
+	return nil
+}
+
+func (t *TestInterface) PolkitPermanentPlug(spec *polkit.Specification, plug *interfaces.Plug) error {
+	if t.PolkitPermanentPlugCallback != nil {
+		return t.PolkitPermanentPlugCallback(spec, plug)
+	}
+	return nil
+}
@@ -30,6 +30,13 @@
 // corresponding /etc/modules-load.d/ config file gets removed, however no
 // kernel modules are unloaded. This is by design.
 //
+// Interfaces may also set the options kernel modules are loaded with and
+// blacklist kernel modules. These are stored in
+// /etc/modprobe.d/snap.<snapname>.conf, which is removed in the same way.
+// As modprobe configuration is global, a snap cannot set options of a kernel
+// module that another snap set differently, nor blacklist a kernel module
+// that another snap loads, or the other way around.
+//
 // Note: this mechanism should not be confused with kernel-module-interface;
 // kmod only loads a well-defined list of modules provided by interface definition
 // and doesn't grant any special permissions related to kernel modules to snaps,
@@ -39,8 +46,11 @@ package kmod
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/interfaces"
@@ -58,7 +68,9 @@ func (b *Backend) Name() interfaces.SecuritySystem {
 
 // Setup creates a conf file with list of kernel modules required by given snap,
 // writes it in /etc/modules-load.d/ directory and immediately loads the modules
-// using /sbin/modprobe. The devMode is ignored.
+// using /sbin/modprobe. The options and blacklist of kernel modules are
+// written in /etc/modprobe.d/ beforehand so that they apply to the modules
+// being loaded. The devMode is ignored.
 //
 // If the method fails it should be re-tried (with a sensible strategy) by the caller.
 func (b *Backend) Setup(snapInfo *snap.Info, confinement interfaces.ConfinementOptions, repo *interfaces.Repository) error {
@@ -69,12 +81,22 @@ func (b *Backend) Setup(snapInfo *snap.Info, confinement interfaces.ConfinementO
 		return fmt.Errorf("cannot obtain kmod specification for snap %q: %s", snapName, err)
 	}
 
+	if err := checkConflicts(spec.(*Specification), snapName); err != nil {
+		return err
+	}
 	content, modules := deriveContent(spec.(*Specification), snapInfo)
+	modprobeContent := deriveModprobeContent(spec.(*Specification), snapInfo)
 	// synchronize the content with the filesystem
 	glob := interfaces.SecurityTagGlob(snapName)
-	dir := dirs.SnapKModModulesDir
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("cannot create directory for kmod files %q: %s", dir, err)
+	for _, dir := range []string{dirs.SnapKModModulesDir, dirs.SnapKModModprobeDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("cannot create directory for kmod files %q: %s", dir, err)
+		}
+	}
+
+	modprobeChanged, _, err := osutil.EnsureDirState(dirs.SnapKModModprobeDir, glob, modprobeContent)
+	if err != nil {
+		return err
 	}
 
 	changed, _, err := osutil.EnsureDirState(dirs.SnapKModModulesDir, glob, content)
@@ -82,7 +104,7 @@ func (b *Backend) Setup(snapInfo *snap.Info, confinement interfaces.ConfinementO
 		return err
 	}
 
-	if len(changed) > 0 {
+	if len(changed) > 0 || len(modprobeChanged) > 0 {
 		return loadModules(modules)
 	}
 	return nil
@@ -95,7 +117,10 @@ func (b *Backend) Setup(snapInfo *snap.Info, confinement interfaces.ConfinementO
 // If the method fails it should be re-tried (with a sensible strategy) by the caller.
 func (b *Backend) Remove(snapName string) error {
 	glob := interfaces.SecurityTagGlob(snapName)
-	_, _, err := osutil.EnsureDirState(dirs.SnapKModModulesDir, glob, nil)
+	if _, _, err := osutil.EnsureDirState(dirs.SnapKModModulesDir, glob, nil); err != nil {
+		return err
+	}
+	_, _, err := osutil.EnsureDirState(dirs.SnapKModModprobeDir, glob, nil)
 	return err
 }
 
@@ -123,6 +148,104 @@ func deriveContent(spec *Specification, snapInfo *snap.Info) (map[string]*osutil
 	return content, modules
 }
 
+// deriveModprobeContent returns the modprobe configuration with the
+// blacklisted kernel modules and the options of kernel modules.
+func deriveModprobeContent(spec *Specification, snapInfo *snap.Info) map[string]*osutil.FileState {
+	if len(spec.disallowedModules) == 0 && len(spec.moduleOptions) == 0 {
+		return nil
+	}
+	var disallowed []string
+	for k := range spec.disallowedModules {
+		disallowed = append(disallowed, k)
+	}
+	sort.Strings(disallowed)
+	var withOptions []string
+	for k := range spec.moduleOptions {
+		withOptions = append(withOptions, k)
+	}
+	sort.Strings(withOptions)
+
+	var buffer bytes.Buffer
+	buffer.WriteString("# This file is automatically generated.\n")
+	for _, module := range disallowed {
+		fmt.Fprintf(&buffer, "blacklist %s\n", module)
+	}
+	for _, module := range withOptions {
+		fmt.Fprintf(&buffer, "options %s %s\n", module, spec.moduleOptions[module])
+	}
+	return map[string]*osutil.FileState{
+		fmt.Sprintf("%s.conf", snap.SecurityTag(snapInfo.Name())): {
+			Content: buffer.Bytes(),
+			Mode:    0644,
+		},
+	}
+}
+
+// otherSnapsFiles returns the content of the configuration files of the other
+// snaps in the given directory, by snap file name.
+func otherSnapsFiles(dir, snapName string) (map[string]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "snap.*.conf"))
+	if err != nil {
+		return nil, err
+	}
+	glob := interfaces.SecurityTagGlob(snapName)
+	files := make(map[string]string, len(matches))
+	for _, path := range matches {
+		name := filepath.Base(path)
+		if ours, _ := filepath.Match(glob, name); ours {
+			continue
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = string(content)
+	}
+	return files, nil
+}
+
+// checkConflicts checks that the kernel modules the snap loads, configures
+// and blacklists agree with the configuration written for the other snaps.
+func checkConflicts(spec *Specification, snapName string) error {
+	if len(spec.modules) == 0 && len(spec.disallowedModules) == 0 && len(spec.moduleOptions) == 0 {
+		return nil
+	}
+	modprobeFiles, err := otherSnapsFiles(dirs.SnapKModModprobeDir, snapName)
+	if err != nil {
+		return fmt.Errorf("cannot read modprobe configuration of other snaps: %s", err)
+	}
+	for name, content := range modprobeFiles {
+		for _, line := range strings.Split(content, "\n") {
+			fields := strings.SplitN(line, " ", 3)
+			switch {
+			case len(fields) == 2 && fields[0] == "blacklist":
+				if spec.modules[fields[1]] {
+					return fmt.Errorf("cannot load kernel module %q: it is blacklisted by %s", fields[1], name)
+				}
+			case len(fields) == 3 && fields[0] == "options":
+				if options, ok := spec.moduleOptions[fields[1]]; ok && options != fields[2] {
+					return fmt.Errorf("cannot set options %q for kernel module %q: already set to %q by %s", options, fields[1], fields[2], name)
+				}
+			}
+		}
+	}
+	if len(spec.disallowedModules) == 0 {
+		return nil
+	}
+	modulesFiles, err := otherSnapsFiles(dirs.SnapKModModulesDir, snapName)
+	if err != nil {
+		return fmt.Errorf("cannot read kernel modules of other snaps: %s", err)
+	}
+	for name, content := range modulesFiles {
+		for _, line := range strings.Split(content, "\n") {
+			if spec.disallowedModules[line] {
+				return fmt.Errorf("cannot blacklist kernel module %q: it is loaded by %s", line, name)
+			}
+		}
+	}
+	return nil
+}
+
 func (b *Backend) NewSpecification() interfaces.Specification {
 	return &Specification{}
 }
@@ -21,6 +21,7 @@ package kmod_test
 
 import (
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -133,3 +134,151 @@ func (s *backendSuite) TestSecurityIsStable(c *C) {
 		s.RemoveSnap(c, snapInfo)
 	}
 }
+
+func (s *backendSuite) TestInstallingSnapCreatesModprobeConf(c *C) {
+	// NOTE: Hand out a permanent snippet so that .conf file is generated.
+	s.Iface.KModPermanentSlotCallback = func(spec *kmod.Specification, slot *interfaces.Slot) error {
+		spec.AddModule("module1")
+		spec.SetModuleOptions("module1", "opt1=1 opt2=2")
+		spec.SetModuleOptions("module3", "opt3=3")
+		spec.DisallowModule("module2")
+		return nil
+	}
+
+	path := filepath.Join(dirs.SnapKModModprobeDir, "snap.samba.conf")
+	c.Assert(osutil.FileExists(path), Equals, false)
+
+	for _, opts := range testedConfinementOpts {
+		s.modprobeCmd.ForgetCalls()
+		snapInfo := s.InstallSnap(c, opts, ifacetest.SambaYamlV1, 0)
+
+		c.Assert(osutil.FileExists(path), Equals, true)
+		modfile, err := ioutil.ReadFile(path)
+		c.Assert(err, IsNil)
+		c.Assert(string(modfile), Equals, "# This file is automatically generated.\nblacklist module2\noptions module1 opt1=1 opt2=2\noptions module3 opt3=3\n")
+
+		c.Assert(s.modprobeCmd.Calls(), DeepEquals, [][]string{
+			{"modprobe", "--syslog", "module1"},
+		})
+		s.RemoveSnap(c, snapInfo)
+		c.Assert(osutil.FileExists(path), Equals, false)
+	}
+}
+
+func (s *backendSuite) TestChangingModuleOptionsReloadsModules(c *C) {
+	s.Iface.KModPermanentSlotCallback = func(spec *kmod.Specification, slot *interfaces.Slot) error {
+		spec.AddModule("module1")
+		spec.SetModuleOptions("module1", "opt1=1")
+		return nil
+	}
+
+	for _, opts := range testedConfinementOpts {
+		snapInfo := s.InstallSnap(c, opts, ifacetest.SambaYamlV1, 0)
+		s.modprobeCmd.ForgetCalls()
+		s.Iface.KModPermanentSlotCallback = func(spec *kmod.Specification, slot *interfaces.Slot) error {
+			spec.AddModule("module1")
+			spec.SetModuleOptions("module1", "opt1=2")
+			return nil
+		}
+		err := s.Backend.Setup(snapInfo, opts, s.Repo)
+		c.Assert(err, IsNil)
+		c.Check(s.modprobeCmd.Calls(), DeepEquals, [][]string{
+			{"modprobe", "--syslog", "module1"},
+		})
+		s.RemoveSnap(c, snapInfo)
+		s.Iface.KModPermanentSlotCallback = func(spec *kmod.Specification, slot *interfaces.Slot) error {
+			spec.AddModule("module1")
+			spec.SetModuleOptions("module1", "opt1=1")
+			return nil
+		}
+	}
+}
+
+func (s *backendSuite) TestConflictsWithOtherSnaps(c *C) {
+	c.Assert(os.MkdirAll(dirs.SnapKModModprobeDir, 0755), IsNil)
+	c.Assert(os.MkdirAll(dirs.SnapKModModulesDir, 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dirs.SnapKModModprobeDir, "snap.other.conf"),
+		[]byte("# This file is automatically generated.\nblacklist module2\noptions module1 opt1=1\n"), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dirs.SnapKModModulesDir, "snap.other.conf"),
+		[]byte("# This file is automatically generated.\nmodule3\n"), 0644), IsNil)
+
+	s.Iface.KModPermanentSlotCallback = func(spec *kmod.Specification, slot *interfaces.Slot) error {
+		spec.AddModule("module1")
+		return spec.SetModuleOptions("module1", "opt1=1")
+	}
+	snapInfo := s.InstallSnap(c, interfaces.ConfinementOptions{}, ifacetest.SambaYamlV1, 0)
+	path := filepath.Join(dirs.SnapKModModprobeDir, "snap.samba.conf")
+	content := "# This file is automatically generated.\noptions module1 opt1=1\n"
+	modfile, err := ioutil.ReadFile(path)
+	c.Assert(err, IsNil)
+	c.Assert(string(modfile), Equals, content)
+
+	for _, t := range []struct {
+		callback func(spec *kmod.Specification, slot *interfaces.Slot) error
+		err      string
+	}{{
+		func(spec *kmod.Specification, slot *interfaces.Slot) error {
+			return spec.SetModuleOptions("module1", "opt1=2")
+		},
+		`cannot set options "opt1=2" for kernel module "module1": already set to "opt1=1" by snap.other.conf`,
+	}, {
+		func(spec *kmod.Specification, slot *interfaces.Slot) error {
+			return spec.AddModule("module2")
+		},
+		`cannot load kernel module "module2": it is blacklisted by snap.other.conf`,
+	}, {
+		func(spec *kmod.Specification, slot *interfaces.Slot) error {
+			return spec.DisallowModule("module3")
+		},
+		`cannot blacklist kernel module "module3": it is loaded by snap.other.conf`,
+	}} {
+		s.Iface.KModPermanentSlotCallback = t.callback
+		err := s.Backend.Setup(snapInfo, interfaces.ConfinementOptions{}, s.Repo)
+		c.Check(err, ErrorMatches, t.err)
+		modfile, err := ioutil.ReadFile(path)
+		c.Assert(err, IsNil)
+		c.Check(string(modfile), Equals, content)
+	}
+}
+
+func (s *backendSuite) TestDisconnectingRemovesModprobeConf(c *C) {
+	s.Iface.KModConnectedPlugCallback = func(spec *kmod.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
+		return spec.DisallowModule("module1")
+	}
+
+	path := filepath.Join(dirs.SnapKModModprobeDir, "snap.consumer.conf")
+	for _, opts := range testedConfinementOpts {
+		slotInfo := s.InstallSnap(c, opts, producerYaml, 0)
+		plugInfo := s.InstallSnap(c, opts, consumerYaml, 0)
+		connRef := interfaces.ConnRef{
+			PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+			SlotRef: interfaces.SlotRef{Snap: "producer", Name: "slot"},
+		}
+		c.Assert(s.Repo.Connect(connRef, nil, nil), IsNil)
+		c.Assert(s.Backend.Setup(plugInfo, opts, s.Repo), IsNil)
+		c.Assert(osutil.FileExists(path), Equals, true)
+
+		c.Assert(s.Repo.Disconnect("consumer", "plug", "producer", "slot"), IsNil)
+		c.Assert(s.Backend.Setup(plugInfo, opts, s.Repo), IsNil)
+		c.Assert(osutil.FileExists(path), Equals, false)
+
+		s.RemoveSnap(c, plugInfo)
+		s.RemoveSnap(c, slotInfo)
+	}
+}
+
+const producerYaml = `name: producer
+slots:
+  slot:
+    interface: iface
+apps:
+  app:
+`
+
+const consumerYaml = `name: consumer
+plugs:
+  plug:
+    interface: iface
+apps:
+  app:
+`
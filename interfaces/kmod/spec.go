@@ -20,6 +20,8 @@
 package kmod
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/snapcore/snapd/interfaces"
@@ -31,7 +33,33 @@ import (
 // holds internal state that is used by the kmod backend during the interface
 // setup process.
 type Specification struct {
-	modules map[string]bool
+	modules           map[string]bool
+	moduleOptions     map[string]string
+	disallowedModules map[string]bool
+}
+
+var validModuleName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// ValidateModuleName checks that the given name is a valid kernel module name.
+func ValidateModuleName(module string) error {
+	if !validModuleName.MatchString(module) {
+		return fmt.Errorf("invalid kernel module name %q", module)
+	}
+	return nil
+}
+
+// validModuleOptions matches space separated name[=value] options, values
+// being printable ASCII characters other than the '#' and '\' that modprobe
+// interprets in its configuration files.
+var validModuleOptions = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*(=[!"$-\[\]-~]+)?( +[a-zA-Z][a-zA-Z0-9_]*(=[!"$-\[\]-~]+)?)*$`)
+
+// ValidateModuleOptions checks that the given options of a kernel module can
+// be written to a modprobe configuration file.
+func ValidateModuleOptions(module, options string) error {
+	if !validModuleOptions.MatchString(options) {
+		return fmt.Errorf("invalid options for kernel module %q: %q", module, options)
+	}
+	return nil
 }
 
 // AddModule adds a kernel module, trimming spaces and ignoring duplicated modules.
@@ -56,6 +84,62 @@ func (spec *Specification) Modules() map[string]bool {
 	return result
 }
 
+// SetModuleOptions sets the parameters a kernel module is loaded with,
+// trimming spaces. Setting different options for the same module is an error.
+func (spec *Specification) SetModuleOptions(module, options string) error {
+	m := strings.TrimSpace(module)
+	if err := ValidateModuleName(m); err != nil {
+		return err
+	}
+	o := strings.TrimSpace(options)
+	if o == "" {
+		return nil
+	}
+	if err := ValidateModuleOptions(m, o); err != nil {
+		return err
+	}
+	if old, ok := spec.moduleOptions[m]; ok && old != o {
+		return fmt.Errorf("cannot set options %q for kernel module %q: already set to %q", o, m, old)
+	}
+	if spec.moduleOptions == nil {
+		spec.moduleOptions = make(map[string]string)
+	}
+	spec.moduleOptions[m] = o
+	return nil
+}
+
+// ModuleOptions returns a copy of the kernel module options set, by module.
+func (spec *Specification) ModuleOptions() map[string]string {
+	result := make(map[string]string, len(spec.moduleOptions))
+	for k, v := range spec.moduleOptions {
+		result[k] = v
+	}
+	return result
+}
+
+// DisallowModule blacklists a kernel module, trimming spaces and ignoring
+// duplicated modules.
+func (spec *Specification) DisallowModule(module string) error {
+	m := strings.TrimSpace(module)
+	if err := ValidateModuleName(m); err != nil {
+		return err
+	}
+	if spec.disallowedModules == nil {
+		spec.disallowedModules = make(map[string]bool)
+	}
+	spec.disallowedModules[m] = true
+	return nil
+}
+
+// DisallowedModules returns a copy of the blacklisted kernel module names.
+func (spec *Specification) DisallowedModules() map[string]bool {
+	result := make(map[string]bool, len(spec.disallowedModules))
+	for k, v := range spec.disallowedModules {
+		result[k] = v
+	}
+	return result
+}
+
 // Implementation of methods required by interfaces.Specification
 
 // AddConnectedPlug records kmod-specific side-effects of having a connected plug.
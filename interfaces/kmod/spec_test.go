@@ -127,3 +127,38 @@ func (s *specSuite) TestSpecificationIface(c *C) {
 	c.Assert(s.spec.Modules(), DeepEquals, map[string]bool{
 		"module1": true, "module2": true, "module3": true, "module4": true})
 }
+
+func (s *specSuite) TestModuleOptions(c *C) {
+	c.Assert(s.spec.SetModuleOptions("module1", " opt1=1 opt2=2 "), IsNil)
+	c.Assert(s.spec.SetModuleOptions("module1", "opt1=1 opt2=2"), IsNil)
+	c.Assert(s.spec.SetModuleOptions("module2", ""), IsNil)
+	c.Assert(s.spec.ModuleOptions(), DeepEquals, map[string]string{"module1": "opt1=1 opt2=2"})
+
+	err := s.spec.SetModuleOptions("module1", "opt1=2")
+	c.Assert(err, ErrorMatches, `cannot set options "opt1=2" for kernel module "module1": already set to "opt1=1 opt2=2"`)
+	for _, options := range []string{
+		"opt1=1\ninstall module3 /bin/sh",
+		"opt1=1\topt2=2",
+		"opt1=1 # comment",
+		"opt1=1\\",
+		"opt1=a#b",
+		"1opt=1",
+		"opt-1=1",
+	} {
+		err = s.spec.SetModuleOptions("module3", options)
+		c.Check(err, ErrorMatches, `invalid options for kernel module "module3": .*`, Commentf("options: %q", options))
+	}
+	c.Assert(s.spec.SetModuleOptions("module3", `opt1 opt2=a,b/c:"d" opt_3=-1`), IsNil)
+	err = s.spec.SetModuleOptions("../module", "opt1=1")
+	c.Assert(err, ErrorMatches, `invalid kernel module name "../module"`)
+}
+
+func (s *specSuite) TestDisallowModule(c *C) {
+	c.Assert(s.spec.DisallowModule("module1"), IsNil)
+	c.Assert(s.spec.DisallowModule(" module1"), IsNil)
+	c.Assert(s.spec.DisallowModule("module2"), IsNil)
+	c.Assert(s.spec.DisallowedModules(), DeepEquals, map[string]bool{"module1": true, "module2": true})
+
+	c.Assert(s.spec.DisallowModule(""), ErrorMatches, `invalid kernel module name ""`)
+	c.Assert(s.spec.DisallowModule("module 3"), ErrorMatches, `invalid kernel module name "module 3"`)
+}
@@ -175,3 +175,20 @@ func (s *backendSuite) TestSetupSetsupWithoutDir(c *C) {
 		c.Assert(osutil.FileExists(fn), Equals, true, Commentf("Expected mount file for %q", binary))
 	}
 }
+
+func (s *backendSuite) TestSetupSetsupLayout(c *C) {
+	snapYaml := `
+name: snap-name
+version: 1
+layout:
+  /usr/share/foo:
+    bind: $SNAP/usr/share/foo
+`
+	// confinement options are irrelevant to this security backend
+	s.InstallSnap(c, interfaces.ConfinementOptions{}, snapYaml, 42)
+
+	fn := filepath.Join(dirs.SnapMountPolicyDir, "snap.snap-name.fstab")
+	content, err := ioutil.ReadFile(fn)
+	c.Assert(err, IsNil, Commentf("Expected mount profile for the whole snap"))
+	c.Check(string(content), Equals, "/snap/snap-name/42/usr/share/foo /usr/share/foo none rbind,rw 0 0\n")
+}
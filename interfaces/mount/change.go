@@ -21,7 +21,10 @@ package mount
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path"
+	"path/filepath"
 	"sort"
 	"strings"
 	"syscall"
@@ -51,22 +54,209 @@ func (c Change) String() string {
 	return fmt.Sprintf("%s (%s)", c.Action, c.Entry)
 }
 
+// System calls used to change the mount table, mocked in tests.
+var (
+	sysMount   = syscall.Mount
+	sysUnmount = syscall.Unmount
+)
+
+// ensureTarget creates the missing mount point, or symbolic link, of the
+// entry, using the mode and owner given by its x-snapd options.
+func (c *Change) ensureTarget() error {
+	mode, err := c.Entry.XSnapdMode()
+	if err != nil {
+		return err
+	}
+	uid, err := c.Entry.XSnapdUID()
+	if err != nil {
+		return err
+	}
+	gid, err := c.Entry.XSnapdGID()
+	if err != nil {
+		return err
+	}
+
+	switch kind := c.Entry.XSnapdKind(); kind {
+	case "":
+		// Bind mounting a file requires a file as the mount point. The
+		// name of other mounts is not a path, like "tmpfs".
+		if c.Entry.isBindMount() {
+			if fi, err := os.Stat(c.Entry.Name); err == nil && !fi.IsDir() {
+				return secureMkfileAll(c.Entry.Dir, mode, uid, gid)
+			}
+		}
+		return secureMkdirAll(c.Entry.Dir, mode, uid, gid)
+	case "symlink":
+		target, err := c.Entry.XSnapdSymlink()
+		if err != nil {
+			return err
+		}
+		return secureMksymlinkAll(c.Entry.Dir, mode, uid, gid, target)
+	default:
+		return fmt.Errorf("cannot create mount point of unknown kind %q", kind)
+	}
+}
+
+// safeKeepingDir is where the original content of a read-only directory
+// is bind mounted while a writable mimic is constructed over it.
+var safeKeepingDir = "/tmp/.snap"
+
+// fileOwner returns the user and group owning the given file.
+func fileOwner(fi os.FileInfo) (uid, gid int) {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return int(st.Uid), int(st.Gid)
+	}
+	return 0, 0
+}
+
+// createWritableMimic replaces the given read-only directory with a tmpfs
+// that mimics its original content, so that the mount point of the entry
+// can be created in it.
+//
+// The original directory is bind mounted aside, a tmpfs is mounted over it
+// and each file, directory and symbolic link of the original is recreated
+// on the tmpfs, with files and directories bind mounted from the
+// original. The returned changes are synthetic mount entries that need to
+// be recorded in the current mount profile so that the mimic can be torn
+// down once the entry is gone.
+func (c *Change) createWritableMimic(dir string) ([]Change, error) {
+	dirInfo, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	// Symbolic links are recreated rather than bind mounted.
+	symlinks := make(map[string]string)
+	for _, fi := range fis {
+		if fi.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(filepath.Join(dir, fi.Name()))
+			if err != nil {
+				return nil, err
+			}
+			symlinks[fi.Name()] = target
+		}
+	}
+
+	safeKeeping := filepath.Join(safeKeepingDir, dir)
+	if err := secureMkdirAll(safeKeeping, 0755, 0, 0); err != nil {
+		return nil, err
+	}
+	if err := sysMount(dir, safeKeeping, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+		return nil, fmt.Errorf("cannot bind mount %q to %q: %v", dir, safeKeeping, err)
+	}
+	defer sysUnmount(safeKeeping, syscall.MNT_DETACH)
+
+	var changes []Change
+	undo := func() {
+		for i := len(changes) - 1; i >= 0; i-- {
+			change := Change{Action: Unmount, Entry: changes[i].Entry}
+			change.Perform()
+		}
+	}
+	synthetic := []string{xSnapdPrefix + "synthetic", xSnapdPrefix + "needed-by=" + c.Entry.Dir}
+
+	uid, gid := fileOwner(dirInfo)
+	data := fmt.Sprintf("mode=%#o,uid=%d,gid=%d", uint32(dirInfo.Mode().Perm()), uid, gid)
+	if err := sysMount("tmpfs", dir, "tmpfs", 0, data); err != nil {
+		return nil, fmt.Errorf("cannot mount tmpfs over %q: %v", dir, err)
+	}
+	changes = append(changes, Change{Action: Mount, Entry: Entry{
+		Name: "tmpfs", Dir: dir, Type: "tmpfs", Options: synthetic}})
+
+	for _, fi := range fis {
+		path := filepath.Join(dir, fi.Name())
+		source := filepath.Join(safeKeeping, fi.Name())
+		uid, gid := fileOwner(fi)
+		entry := Entry{Dir: path}
+		var err error
+		switch {
+		case fi.Mode()&os.ModeSymlink != 0:
+			target := symlinks[fi.Name()]
+			entry.Options = append([]string{xSnapdPrefix + "kind=symlink", xSnapdPrefix + "symlink=" + target}, synthetic...)
+			err = secureMksymlinkAll(path, 0755, uid, gid, target)
+		case fi.IsDir():
+			entry.Name = path
+			entry.Options = append([]string{"rbind"}, synthetic...)
+			if err = secureMkdirAll(path, fi.Mode().Perm(), uid, gid); err == nil {
+				err = sysMount(source, path, "", syscall.MS_BIND|syscall.MS_REC, "")
+			}
+		default:
+			entry.Name = path
+			entry.Options = append([]string{"bind"}, synthetic...)
+			if err = secureMkfileAll(path, fi.Mode().Perm(), uid, gid); err == nil {
+				err = sysMount(source, path, "", syscall.MS_BIND, "")
+			}
+		}
+		if err != nil {
+			undo()
+			return nil, fmt.Errorf("cannot recreate %q: %v", path, err)
+		}
+		changes = append(changes, Change{Action: Mount, Entry: entry})
+	}
+	return changes, nil
+}
+
+// ensureTargetOrMimic creates the missing mount point of the entry,
+// constructing writable mimics over the read-only directories that are in
+// the way. The synthetic changes made for the mimics are returned, even
+// on failure.
+func (c *Change) ensureTargetOrMimic() ([]Change, error) {
+	var changes []Change
+	for {
+		err := c.ensureTarget()
+		rofsErr, ok := err.(*ReadOnlyFsError)
+		if !ok {
+			return changes, err
+		}
+		// Don't try again over a mimic we have made already.
+		for _, change := range changes {
+			if change.Entry.Dir == rofsErr.Path {
+				return changes, err
+			}
+		}
+		mimicChanges, err := c.createWritableMimic(rofsErr.Path)
+		if err != nil {
+			return changes, fmt.Errorf("cannot create writable mimic over %q: %v", rofsErr.Path, err)
+		}
+		changes = append(changes, mimicChanges...)
+	}
+}
+
 // Perform executes the desired mount or unmount change using system calls.
 // Filesystems that depend on helper programs or multiple independent calls to
 // the kernel (--make-shared, for example) are unsupported.
-func (c *Change) Perform() error {
+//
+// Missing mount points are created before mounting, with writable mimics
+// over read-only directories in the way. The synthetic changes made for
+// the mimics are returned, even on failure, so that they can be recorded.
+// Entries of the "symlink" kind create, or remove, a symbolic link instead
+// of a mount.
+func (c *Change) Perform() ([]Change, error) {
 	switch c.Action {
 	case Mount:
 		flags, err := OptsToFlags(c.Entry.Options)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		changes, err := c.ensureTargetOrMimic()
+		if err != nil {
+			return changes, err
 		}
-		return syscall.Mount(c.Entry.Name, c.Entry.Dir, c.Entry.Type, uintptr(flags), "")
+		if c.Entry.XSnapdKind() == "symlink" {
+			return changes, nil
+		}
+		return changes, sysMount(c.Entry.Name, c.Entry.Dir, c.Entry.Type, uintptr(flags), "")
 	case Unmount:
+		if c.Entry.XSnapdKind() == "symlink" {
+			return nil, os.Remove(c.Entry.Dir)
+		}
 		const UMOUNT_NOFOLLOW = 8
-		return syscall.Unmount(c.Entry.Dir, UMOUNT_NOFOLLOW)
+		return nil, sysUnmount(c.Entry.Dir, UMOUNT_NOFOLLOW)
 	}
-	return fmt.Errorf("cannot process mount change, unknown action: %q", c.Action)
+	return nil, fmt.Errorf("cannot process mount change, unknown action: %q", c.Action)
 }
 
 // NeededChanges computes the changes required to change current to desired mount entries.
@@ -108,6 +298,10 @@ func NeededChanges(currentProfile, desiredProfile *Profile) []Change {
 	var skipDir string
 	for i := range current {
 		dir := current[i].Dir
+		if current[i].XSnapdSynthetic() {
+			// Synthetic entries are handled below.
+			continue
+		}
 		if skipDir != "" && strings.HasPrefix(dir, skipDir) {
 			continue
 		}
@@ -122,6 +316,37 @@ func NeededChanges(currentProfile, desiredProfile *Profile) []Change {
 		skipDir = strings.TrimSuffix(dir, "/") + "/"
 	}
 
+	// Synthetic entries, made for writable mimics, are reused as long as the
+	// entry that needed them is reused. When a synthetic entry goes away
+	// nothing mounted below it can be reused.
+	for i := range current {
+		if current[i].XSnapdSynthetic() && reuse[current[i].XSnapdNeededBy()] {
+			reuse[current[i].Dir] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for i := range current {
+			if !current[i].XSnapdSynthetic() {
+				continue
+			}
+			dir := current[i].Dir
+			if reuse[dir] && !reuse[current[i].XSnapdNeededBy()] {
+				delete(reuse, dir)
+				changed = true
+			}
+			if reuse[dir] {
+				continue
+			}
+			for j := range current {
+				if reuse[current[j].Dir] && strings.HasPrefix(current[j].Dir, dir+"/") {
+					delete(reuse, current[j].Dir)
+					changed = true
+				}
+			}
+		}
+	}
+
 	// We are now ready to compute the necessary mount changes.
 	var changes []Change
 
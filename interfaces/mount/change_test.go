@@ -20,15 +20,48 @@
 package mount_test
 
 import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
 	. "gopkg.in/check.v1"
 
 	"github.com/snapcore/snapd/interfaces/mount"
+	"github.com/snapcore/snapd/snap"
 )
 
-type changeSuite struct{}
+type changeSuite struct {
+	calls   []string
+	restore func()
+}
 
 var _ = Suite(&changeSuite{})
 
+func (s *changeSuite) SetUpTest(c *C) {
+	s.calls = nil
+	s.restore = mount.MockSystemCalls(
+		func(source, target, fstype string, flags uintptr, data string) error {
+			s.calls = append(s.calls, fmt.Sprintf("mount %q %q %q %d", source, target, fstype, flags))
+			return nil
+		},
+		func(target string, flags int) error {
+			s.calls = append(s.calls, fmt.Sprintf("unmount %q %d", target, flags))
+			return nil
+		},
+		func(fd, uid, gid int) error {
+			s.calls = append(s.calls, fmt.Sprintf("fchown %d %d", uid, gid))
+			return nil
+		})
+}
+
+func (s *changeSuite) TearDownTest(c *C) {
+	s.restore()
+}
+
 func (s *changeSuite) TestString(c *C) {
 	change := mount.Change{
 		Entry:  mount.Entry{Dir: "/a/b", Name: "/dev/sda1"},
@@ -146,6 +179,46 @@ func (s *changeSuite) TestNeededChangesSameParentChangedChild(c *C) {
 	})
 }
 
+// Synthetic entries are kept while the entry that needed them is kept.
+func (s *changeSuite) TestNeededChangesKeepsSyntheticEntries(c *C) {
+	current := &mount.Profile{Entries: []mount.Entry{
+		{Dir: "/snap/foo/1", Name: "tmpfs", Options: []string{"x-snapd.synthetic", "x-snapd.needed-by=/snap/foo/1/dir"}},
+		{Dir: "/snap/foo/1/bin", Options: []string{"rbind", "x-snapd.synthetic", "x-snapd.needed-by=/snap/foo/1/dir"}},
+		{Dir: "/snap/foo/1/dir", Name: "/src"},
+	}}
+	desired := &mount.Profile{Entries: []mount.Entry{
+		{Dir: "/snap/foo/1/dir", Name: "/src"},
+	}}
+	changes := mount.NeededChanges(current, desired)
+	c.Assert(changes, DeepEquals, []mount.Change{
+		{Entry: current.Entries[2], Action: mount.Keep},
+		{Entry: current.Entries[1], Action: mount.Keep},
+		{Entry: current.Entries[0], Action: mount.Keep},
+	})
+}
+
+// Synthetic entries go away with the entry that needed them, and so does
+// everything mounted below them.
+func (s *changeSuite) TestNeededChangesRemovesSyntheticEntries(c *C) {
+	current := &mount.Profile{Entries: []mount.Entry{
+		{Dir: "/snap/foo/1", Name: "tmpfs", Options: []string{"x-snapd.synthetic", "x-snapd.needed-by=/snap/foo/1/dir"}},
+		{Dir: "/snap/foo/1/bin", Options: []string{"rbind", "x-snapd.synthetic", "x-snapd.needed-by=/snap/foo/1/dir"}},
+		{Dir: "/snap/foo/1/dir", Name: "/src"},
+		{Dir: "/snap/foo/1/other", Name: "/other"},
+	}}
+	desired := &mount.Profile{Entries: []mount.Entry{
+		{Dir: "/snap/foo/1/other", Name: "/other"},
+	}}
+	changes := mount.NeededChanges(current, desired)
+	c.Assert(changes, DeepEquals, []mount.Change{
+		{Entry: current.Entries[3], Action: mount.Unmount},
+		{Entry: current.Entries[2], Action: mount.Unmount},
+		{Entry: current.Entries[1], Action: mount.Unmount},
+		{Entry: current.Entries[0], Action: mount.Unmount},
+		{Entry: desired.Entries[0], Action: mount.Mount},
+	})
+}
+
 // cur = ['/a/b', '/a/b-1', '/a/b-1/3', '/a/b/c']
 // des = ['/a/b', '/a/b-1', '/a/b/c'
 //
@@ -174,3 +247,225 @@ func (s *changeSuite) TestNeededChangesSmartEntryComparison(c *C) {
 		{Entry: mount.Entry{Dir: "/a/b/c"}, Action: mount.Mount},
 	})
 }
+
+// perform performs a change that makes no synthetic changes.
+func (s *changeSuite) perform(c *C, change *mount.Change) error {
+	synthesised, err := change.Perform()
+	c.Check(synthesised, HasLen, 0)
+	return err
+}
+
+// Mounting creates the missing mount point.
+func (s *changeSuite) TestPerformMountCreatesMountPoint(c *C) {
+	dir := c.MkDir()
+	target := filepath.Join(dir, "a", "b")
+	change := mount.Change{Action: mount.Mount, Entry: mount.Entry{
+		Name: "tmpfs", Dir: target, Type: "tmpfs", Options: []string{"x-snapd.uid=65534", "x-snapd.gid=65534"}}}
+	c.Assert(s.perform(c, &change), IsNil)
+	c.Check(s.calls, DeepEquals, []string{
+		"fchown 65534 65534",
+		"fchown 65534 65534",
+		fmt.Sprintf(`mount "tmpfs" %q "tmpfs" 0`, target),
+	})
+	fi, err := os.Stat(target)
+	c.Assert(err, IsNil)
+	c.Check(fi.IsDir(), Equals, true)
+
+	// existing mount points are reused
+	s.calls = nil
+	c.Assert(s.perform(c, &change), IsNil)
+	c.Check(s.calls, DeepEquals, []string{fmt.Sprintf(`mount "tmpfs" %q "tmpfs" 0`, target)})
+}
+
+// Bind mounting a file creates a file as the mount point.
+func (s *changeSuite) TestPerformMountCreatesFileMountPoint(c *C) {
+	dir := c.MkDir()
+	source := filepath.Join(dir, "source")
+	c.Assert(ioutil.WriteFile(source, nil, 0644), IsNil)
+	target := filepath.Join(dir, "target", "file")
+	change := mount.Change{Action: mount.Mount, Entry: mount.Entry{
+		Name: source, Dir: target, Options: []string{"rbind", "rw"}}}
+	c.Assert(s.perform(c, &change), IsNil)
+	c.Check(s.calls, DeepEquals, []string{
+		"fchown 0 0",
+		"fchown 0 0",
+		fmt.Sprintf(`mount %q %q "" %d`, source, target, syscall.MS_BIND|syscall.MS_REC),
+	})
+	fi, err := os.Stat(target)
+	c.Assert(err, IsNil)
+	c.Check(fi.Mode().IsRegular(), Equals, true)
+}
+
+// Only bind mounts look at their source to pick the kind of mount point.
+func (s *changeSuite) TestPerformMountNonBindIgnoresSource(c *C) {
+	dir := c.MkDir()
+	cwd, err := os.Getwd()
+	c.Assert(err, IsNil)
+	c.Assert(os.Chdir(dir), IsNil)
+	defer os.Chdir(cwd)
+	// a file that happens to be named after the tmpfs source
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "tmpfs"), nil, 0644), IsNil)
+
+	target := filepath.Join(dir, "target")
+	change := mount.Change{Action: mount.Mount, Entry: mount.Entry{
+		Name: "tmpfs", Dir: target, Type: "tmpfs"}}
+	c.Assert(s.perform(c, &change), IsNil)
+	fi, err := os.Stat(target)
+	c.Assert(err, IsNil)
+	c.Check(fi.IsDir(), Equals, true)
+}
+
+// Symlink entries create and remove symbolic links instead of mounting.
+func (s *changeSuite) TestPerformSymlink(c *C) {
+	target := filepath.Join(c.MkDir(), "link")
+	entry := mount.Entry{Dir: target, Options: []string{"x-snapd.kind=symlink", "x-snapd.symlink=/oldname"}}
+
+	change := mount.Change{Action: mount.Mount, Entry: entry}
+	c.Assert(s.perform(c, &change), IsNil)
+	c.Check(s.calls, HasLen, 0)
+	oldname, err := os.Readlink(target)
+	c.Assert(err, IsNil)
+	c.Check(oldname, Equals, "/oldname")
+	// creating it again is fine
+	c.Assert(s.perform(c, &change), IsNil)
+
+	change = mount.Change{Action: mount.Unmount, Entry: entry}
+	c.Assert(s.perform(c, &change), IsNil)
+	c.Check(s.calls, HasLen, 0)
+	_, err = os.Lstat(target)
+	c.Check(os.IsNotExist(err), Equals, true)
+}
+
+// The layout of a snap can be mounted after a round trip through a profile.
+func (s *changeSuite) TestPerformLayoutFromProfile(c *C) {
+	dir := c.MkDir()
+	info := &snap.Info{SuggestedName: "vanguard", SideInfo: snap.SideInfo{Revision: snap.R(42)}}
+	info.Layout = map[string]*snap.Layout{
+		dir + "/tmp":  {Snap: info, Path: dir + "/tmp", Type: "tmpfs", Mode: 0755},
+		dir + "/link": {Snap: info, Path: dir + "/link", Symlink: "$SNAP/target", Mode: 0755},
+	}
+	spec := &mount.Specification{}
+	spec.AddSnapLayout(info)
+
+	var buf bytes.Buffer
+	_, err := (&mount.Profile{Entries: spec.MountEntries()}).WriteTo(&buf)
+	c.Assert(err, IsNil)
+	c.Check(buf.String(), Equals, fmt.Sprintf(""+
+		"none %s/link none x-snapd.kind=symlink,x-snapd.symlink=/snap/vanguard/42/target 0 0\n"+
+		"tmpfs %s/tmp tmpfs defaults 0 0\n", dir, dir))
+	profile, err := mount.ReadProfile(&buf)
+	c.Assert(err, IsNil)
+
+	for _, change := range mount.NeededChanges(&mount.Profile{}, profile) {
+		c.Assert(s.perform(c, &change), IsNil)
+	}
+	c.Check(s.calls, DeepEquals, []string{
+		"fchown 0 0",
+		fmt.Sprintf(`mount "tmpfs" "%s/tmp" "tmpfs" 0`, dir),
+	})
+	oldname, err := os.Readlink(dir + "/link")
+	c.Assert(err, IsNil)
+	c.Check(oldname, Equals, "/snap/vanguard/42/target")
+}
+
+// Unmounting does not touch the mount point.
+func (s *changeSuite) TestPerformUnmount(c *C) {
+	change := mount.Change{Action: mount.Unmount, Entry: mount.Entry{Dir: "/target"}}
+	c.Assert(s.perform(c, &change), IsNil)
+	c.Check(s.calls, DeepEquals, []string{`unmount "/target" 8`})
+}
+
+// Bad x-snapd options are reported before doing anything.
+func (s *changeSuite) TestPerformBadOptions(c *C) {
+	target := filepath.Join(c.MkDir(), "target")
+	change := mount.Change{Action: mount.Mount, Entry: mount.Entry{Dir: target, Options: []string{"x-snapd.mode=bogus"}}}
+	c.Assert(s.perform(c, &change), ErrorMatches, `cannot parse octal file mode from "bogus"`)
+	change = mount.Change{Action: mount.Mount, Entry: mount.Entry{Dir: target, Options: []string{"x-snapd.kind=pipe"}}}
+	c.Assert(s.perform(c, &change), ErrorMatches, `cannot create mount point of unknown kind "pipe"`)
+	c.Check(s.calls, HasLen, 0)
+	_, err := os.Lstat(target)
+	c.Check(os.IsNotExist(err), Equals, true)
+}
+
+// Mounting in a read-only directory creates a writable mimic over it.
+func (s *changeSuite) TestPerformMountCreatesWritableMimic(c *C) {
+	restore := mount.MockSafeKeepingDir(c.MkDir())
+	defer restore()
+
+	dir := filepath.Join(c.MkDir(), "ro")
+	c.Assert(os.MkdirAll(filepath.Join(dir, "d"), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "f"), nil, 0644), IsNil)
+	c.Assert(os.Symlink("f", filepath.Join(dir, "l")), IsNil)
+
+	// the directory is read-only until the (pretend) tmpfs is mounted
+	readOnly := true
+	restore = mount.MockSysMkdirat(func(dirfd int, path string, mode uint32) error {
+		if readOnly && path == "new" {
+			return syscall.EROFS
+		}
+		return syscall.Mkdirat(dirfd, path, mode)
+	})
+	defer restore()
+	mountCalls := 0
+	restore = mount.MockSystemCalls(
+		func(source, target, fstype string, flags uintptr, data string) error {
+			s.calls = append(s.calls, fmt.Sprintf("mount %q %q %q %d %q", source, target, fstype, flags, data))
+			if fstype == "tmpfs" && mountCalls == 1 {
+				readOnly = false
+			}
+			mountCalls++
+			return nil
+		},
+		func(target string, flags int) error {
+			s.calls = append(s.calls, fmt.Sprintf("unmount %q %d", target, flags))
+			return nil
+		},
+		func(fd, uid, gid int) error { return nil })
+	defer restore()
+
+	target := filepath.Join(dir, "new")
+	change := mount.Change{Action: mount.Mount, Entry: mount.Entry{Name: "tmpfs", Dir: target, Type: "tmpfs"}}
+	synthesised, err := change.Perform()
+	c.Assert(err, IsNil)
+
+	synthetic := []string{"x-snapd.synthetic", "x-snapd.needed-by=" + target}
+	c.Check(synthesised, DeepEquals, []mount.Change{
+		{Action: mount.Mount, Entry: mount.Entry{Name: "tmpfs", Dir: dir, Type: "tmpfs", Options: synthetic}},
+		{Action: mount.Mount, Entry: mount.Entry{Name: filepath.Join(dir, "d"), Dir: filepath.Join(dir, "d"), Options: append([]string{"rbind"}, synthetic...)}},
+		{Action: mount.Mount, Entry: mount.Entry{Name: filepath.Join(dir, "f"), Dir: filepath.Join(dir, "f"), Options: append([]string{"bind"}, synthetic...)}},
+		{Action: mount.Mount, Entry: mount.Entry{Dir: filepath.Join(dir, "l"), Options: append([]string{"x-snapd.kind=symlink", "x-snapd.symlink=f"}, synthetic...)}},
+	})
+
+	safeKeeping := filepath.Join(mount.SafeKeepingDir(), dir)
+	uid, gid := os.Getuid(), os.Getgid()
+	c.Check(s.calls, DeepEquals, []string{
+		fmt.Sprintf(`mount %q %q "" %d ""`, dir, safeKeeping, syscall.MS_BIND|syscall.MS_REC),
+		fmt.Sprintf(`mount "tmpfs" %q "tmpfs" 0 "mode=0755,uid=%d,gid=%d"`, dir, uid, gid),
+		fmt.Sprintf(`mount %q %q "" %d ""`, filepath.Join(safeKeeping, "d"), filepath.Join(dir, "d"), syscall.MS_BIND|syscall.MS_REC),
+		fmt.Sprintf(`mount %q %q "" %d ""`, filepath.Join(safeKeeping, "f"), filepath.Join(dir, "f"), syscall.MS_BIND),
+		fmt.Sprintf(`unmount %q %d`, safeKeeping, syscall.MNT_DETACH),
+		fmt.Sprintf(`mount "tmpfs" %q "tmpfs" 0 ""`, target),
+	})
+}
+
+// A directory that stays read-only is reported after one mimic attempt.
+func (s *changeSuite) TestPerformMountWritableMimicDoesNotLoop(c *C) {
+	restore := mount.MockSafeKeepingDir(c.MkDir())
+	defer restore()
+	restore = mount.MockSysMkdirat(func(dirfd int, path string, mode uint32) error {
+		if path == "new" {
+			return syscall.EROFS
+		}
+		return syscall.Mkdirat(dirfd, path, mode)
+	})
+	defer restore()
+
+	dir := c.MkDir()
+	change := mount.Change{Action: mount.Mount, Entry: mount.Entry{Name: "tmpfs", Dir: filepath.Join(dir, "new"), Type: "tmpfs"}}
+	synthesised, err := change.Perform()
+	c.Assert(err, ErrorMatches, `cannot operate on read-only filesystem at .*`)
+	c.Check(synthesised, HasLen, 1)
+	for _, call := range s.calls {
+		c.Check(strings.HasPrefix(call, "mount \"tmpfs\" \""+filepath.Join(dir, "new")), Equals, false)
+	}
+}
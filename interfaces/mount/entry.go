@@ -21,6 +21,7 @@ package mount
 
 import (
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 	"syscall"
@@ -141,10 +142,122 @@ func ParseEntry(s string) (Entry, error) {
 	return e, nil
 }
 
+// xSnapdPrefix is the prefix of mount options that are not passed to the
+// kernel but instead tell snap-update-ns how to prepare the mount point.
+const xSnapdPrefix = "x-snapd."
+
+// xSnapdOption returns the value of the given x-snapd option.
+func (e *Entry) xSnapdOption(name string) (value string, ok bool) {
+	prefix := xSnapdPrefix + name + "="
+	for _, opt := range e.Options {
+		if strings.HasPrefix(opt, prefix) {
+			return opt[len(prefix):], true
+		}
+	}
+	return "", false
+}
+
+// XSnapdKind returns the kind of file system object the entry is about.
+//
+// The kind is empty for regular mount entries and "symlink" for entries
+// that describe a symbolic link to create instead of a mount.
+func (e *Entry) XSnapdKind() string {
+	kind, _ := e.xSnapdOption("kind")
+	return kind
+}
+
+// XSnapdSymlink returns the target of the symbolic link described by the entry.
+func (e *Entry) XSnapdSymlink() (string, error) {
+	target, _ := e.xSnapdOption("symlink")
+	if target == "" {
+		return "", fmt.Errorf("cannot use symlink entry %q without a target", e.Dir)
+	}
+	return target, nil
+}
+
+// isBindMount returns whether the entry bind mounts its name, which is
+// then a path, over its directory.
+func (e *Entry) isBindMount() bool {
+	for _, opt := range e.Options {
+		if opt == "bind" || opt == "rbind" {
+			return true
+		}
+	}
+	return false
+}
+
+// XSnapdSynthetic returns whether the entry was created by snap-update-ns
+// itself, to make room for another entry, rather than requested.
+func (e *Entry) XSnapdSynthetic() bool {
+	for _, opt := range e.Options {
+		if opt == xSnapdPrefix+"synthetic" {
+			return true
+		}
+	}
+	return false
+}
+
+// XSnapdNeededBy returns the mount point of the entry that required the
+// creation of this synthetic entry.
+func (e *Entry) XSnapdNeededBy() string {
+	dir, _ := e.xSnapdOption("needed-by")
+	return dir
+}
+
+// XSnapdMode returns the mode of the mount point, if it has to be created.
+//
+// The default mode is 0755.
+func (e *Entry) XSnapdMode() (os.FileMode, error) {
+	value, ok := e.xSnapdOption("mode")
+	if !ok {
+		return 0755, nil
+	}
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode&^0777 != 0 {
+		return 0, fmt.Errorf("cannot parse octal file mode from %q", value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// XSnapdUID returns the user owning the mount point, if it has to be created.
+//
+// The default user is root.
+func (e *Entry) XSnapdUID() (int, error) {
+	return e.xSnapdID("uid")
+}
+
+// XSnapdGID returns the group owning the mount point, if it has to be created.
+//
+// The default group is root.
+func (e *Entry) XSnapdGID() (int, error) {
+	return e.xSnapdID("gid")
+}
+
+func (e *Entry) xSnapdID(name string) (int, error) {
+	value, ok := e.xSnapdOption(name)
+	if !ok {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse %s from %q", name, value)
+	}
+	return int(id), nil
+}
+
 // OptsToFlags converts mount options strings to a mount flag.
+//
+// The x-snapd options are not passed to the kernel and are ignored.
 func OptsToFlags(opts []string) (flags int, err error) {
 	for _, opt := range opts {
+		if strings.HasPrefix(opt, xSnapdPrefix) {
+			continue
+		}
 		switch opt {
+		case "defaults":
+			// written in place of an empty list of options
+		case "rw":
+			// this is the default
 		case "ro":
 			flags |= syscall.MS_RDONLY
 		case "nosuid":
@@ -20,6 +20,7 @@
 package mount_test
 
 import (
+	"os"
 	"syscall"
 
 	. "gopkg.in/check.v1"
@@ -156,9 +157,65 @@ func (s *entrySuite) TestOptsToFlags(c *C) {
 	flags, err := mount.OptsToFlags(nil)
 	c.Assert(err, IsNil)
 	c.Assert(flags, Equals, 0)
+	flags, err = mount.OptsToFlags([]string{"defaults"})
+	c.Assert(err, IsNil)
+	c.Assert(flags, Equals, 0)
 	flags, err = mount.OptsToFlags([]string{"ro", "nodev", "nosuid"})
 	c.Assert(err, IsNil)
 	c.Assert(flags, Equals, syscall.MS_RDONLY|syscall.MS_NODEV|syscall.MS_NOSUID)
 	_, err = mount.OptsToFlags([]string{"bogus"})
 	c.Assert(err, ErrorMatches, `unsupported mount option: "bogus"`)
 }
+
+// Test that rw and x-snapd options are accepted but do not map to flags.
+func (s *entrySuite) TestOptsToFlagsIgnoresXSnapd(c *C) {
+	flags, err := mount.OptsToFlags([]string{"rbind", "rw", "x-snapd.mode=0700", "x-snapd.kind=symlink"})
+	c.Assert(err, IsNil)
+	c.Assert(flags, Equals, syscall.MS_BIND|syscall.MS_REC)
+}
+
+// Test the defaults of x-snapd options.
+func (s *entrySuite) TestXSnapdDefaults(c *C) {
+	e := &mount.Entry{Options: []string{"bind"}}
+	c.Check(e.XSnapdKind(), Equals, "")
+	mode, err := e.XSnapdMode()
+	c.Assert(err, IsNil)
+	c.Check(mode, Equals, os.FileMode(0755))
+	uid, err := e.XSnapdUID()
+	c.Assert(err, IsNil)
+	c.Check(uid, Equals, 0)
+	gid, err := e.XSnapdGID()
+	c.Assert(err, IsNil)
+	c.Check(gid, Equals, 0)
+	_, err = e.XSnapdSymlink()
+	c.Check(err, ErrorMatches, `cannot use symlink entry "" without a target`)
+}
+
+// Test parsing of x-snapd options.
+func (s *entrySuite) TestXSnapdOptions(c *C) {
+	e := &mount.Entry{Options: []string{
+		"x-snapd.kind=symlink", "x-snapd.symlink=/oldname",
+		"x-snapd.mode=0700", "x-snapd.uid=65534", "x-snapd.gid=1000",
+	}}
+	c.Check(e.XSnapdKind(), Equals, "symlink")
+	target, err := e.XSnapdSymlink()
+	c.Assert(err, IsNil)
+	c.Check(target, Equals, "/oldname")
+	mode, err := e.XSnapdMode()
+	c.Assert(err, IsNil)
+	c.Check(mode, Equals, os.FileMode(0700))
+	uid, err := e.XSnapdUID()
+	c.Assert(err, IsNil)
+	c.Check(uid, Equals, 65534)
+	gid, err := e.XSnapdGID()
+	c.Assert(err, IsNil)
+	c.Check(gid, Equals, 1000)
+
+	e = &mount.Entry{Options: []string{"x-snapd.mode=01777", "x-snapd.uid=bob", "x-snapd.gid=-1"}}
+	_, err = e.XSnapdMode()
+	c.Check(err, ErrorMatches, `cannot parse octal file mode from "01777"`)
+	_, err = e.XSnapdUID()
+	c.Check(err, ErrorMatches, `cannot parse uid from "bob"`)
+	_, err = e.XSnapdGID()
+	c.Check(err, ErrorMatches, `cannot parse gid from "-1"`)
+}
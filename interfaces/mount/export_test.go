@@ -0,0 +1,61 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package mount
+
+var (
+	SecureMkdirAll     = secureMkdirAll
+	SecureMkfileAll    = secureMkfileAll
+	SecureMksymlinkAll = secureMksymlinkAll
+)
+
+// MockSystemCalls replaces the system calls that change the mount table
+// and the ownership of files.
+func MockSystemCalls(mount func(source, target, fstype string, flags uintptr, data string) error, unmount func(target string, flags int) error, fchown func(fd, uid, gid int) error) (restore func()) {
+	oldMount, oldUnmount, oldFchown := sysMount, sysUnmount, sysFchown
+	sysMount, sysUnmount, sysFchown = mount, unmount, fchown
+	return func() {
+		sysMount, sysUnmount, sysFchown = oldMount, oldUnmount, oldFchown
+	}
+}
+
+// MockSysMkdirat replaces the system call used to create directories.
+func MockSysMkdirat(fn func(dirfd int, path string, mode uint32) error) (restore func()) {
+	old := sysMkdirat
+	sysMkdirat = fn
+	return func() {
+		sysMkdirat = old
+	}
+}
+
+// MockSafeKeepingDir replaces the directory where read-only directories
+// are bind mounted while writable mimics are constructed.
+func MockSafeKeepingDir(dir string) (restore func()) {
+	old := safeKeepingDir
+	safeKeepingDir = dir
+	return func() {
+		safeKeepingDir = old
+	}
+}
+
+// SafeKeepingDir returns the directory where read-only directories are
+// bind mounted while writable mimics are constructed.
+func SafeKeepingDir() string {
+	return safeKeepingDir
+}
@@ -20,7 +20,11 @@
 package mount
 
 import (
+	"fmt"
+	"sort"
+
 	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/snap"
 )
 
 // Specification assists in collecting mount entries associated with an interface.
@@ -29,7 +33,8 @@ import (
 // holds internal state that is used by the mount backend during the interface
 // setup process.
 type Specification struct {
-	mountEntries []Entry
+	layoutMountEntries []Entry
+	mountEntries       []Entry
 }
 
 // AddMountEntry adds a new mount entry.
@@ -38,10 +43,65 @@ func (spec *Specification) AddMountEntry(e Entry) error {
 	return nil
 }
 
+// nobodyID is the user and group ID of "nobody", the only non-root user and
+// group allowed in layouts.
+const nobodyID = 65534
+
+func mountEntryFromLayout(si *snap.Info, layout *snap.Layout) Entry {
+	var entry Entry
+
+	entry.Dir = si.ExpandSnapVariables(layout.Path)
+	switch {
+	case layout.Bind != "":
+		entry.Name = si.ExpandSnapVariables(layout.Bind)
+		entry.Options = []string{"rbind", "rw"}
+	case layout.Type == "tmpfs":
+		entry.Name = "tmpfs"
+		entry.Type = "tmpfs"
+	case layout.Symlink != "":
+		entry.Options = []string{
+			xSnapdPrefix + "kind=symlink",
+			xSnapdPrefix + "symlink=" + si.ExpandSnapVariables(layout.Symlink),
+		}
+	}
+
+	if layout.Mode != 0755 {
+		entry.Options = append(entry.Options, fmt.Sprintf("%smode=%#o", xSnapdPrefix, uint32(layout.Mode)))
+	}
+	if layout.User == "nobody" {
+		entry.Options = append(entry.Options, fmt.Sprintf("%suid=%d", xSnapdPrefix, nobodyID))
+	}
+	if layout.Group == "nobody" {
+		entry.Options = append(entry.Options, fmt.Sprintf("%sgid=%d", xSnapdPrefix, nobodyID))
+	}
+	return entry
+}
+
+// AddSnapLayout adds mount entries based on the layout of the snap.
+func (spec *Specification) AddSnapLayout(si *snap.Info) {
+	// TODO: handle layouts in base snaps as well as in this snap.
+
+	// walk the layout elements in deterministic order, by mount point name
+	paths := make([]string, 0, len(si.Layout))
+	for path := range si.Layout {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		entry := mountEntryFromLayout(si, si.Layout[path])
+		spec.layoutMountEntries = append(spec.layoutMountEntries, entry)
+	}
+}
+
 // MountEntries returns a copy of the added mount entries.
+//
+// The entries derived from the layout of the snap come first, so that
+// interfaces can mount over the directories they provide.
 func (spec *Specification) MountEntries() []Entry {
-	result := make([]Entry, len(spec.mountEntries))
-	copy(result, spec.mountEntries)
+	result := make([]Entry, 0, len(spec.layoutMountEntries)+len(spec.mountEntries))
+	result = append(result, spec.layoutMountEntries...)
+	result = append(result, spec.mountEntries...)
 	return result
 }
 
@@ -26,6 +26,7 @@ import (
 	"github.com/snapcore/snapd/interfaces/ifacetest"
 	"github.com/snapcore/snapd/interfaces/mount"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snaptest"
 )
 
 type specSuite struct {
@@ -91,3 +92,39 @@ func (s *specSuite) TestSpecificationIface(c *C) {
 		{Name: "connected-plug"}, {Name: "connected-slot"},
 		{Name: "permanent-plug"}, {Name: "permanent-slot"}})
 }
+
+const snapWithLayout = `
+name: vanguard
+version: 0
+layout:
+  /usr:
+    bind: $SNAP/usr
+  /mytmp:
+    type: tmpfs
+    user: nobody
+    group: nobody
+    mode: 0777
+  /mylink:
+    symlink: $SNAP/link/target
+  /opt/foo.conf:
+    bind: $SNAP_DATA/foo.conf
+  /var/cache/mylink:
+    symlink: $SNAP_COMMON/cache
+    mode: 0700
+`
+
+// The mount.Specification can describe the layout of a snap, before
+// the mount entries of interfaces
+func (s *specSuite) TestMountEntryFromLayout(c *C) {
+	snapInfo := snaptest.MockInfo(c, snapWithLayout, &snap.SideInfo{Revision: snap.R(42)})
+	c.Assert(s.spec.AddMountEntry(mount.Entry{Name: "/", Dir: "/foo", Options: []string{"bind"}}), IsNil)
+	s.spec.AddSnapLayout(snapInfo)
+	c.Assert(s.spec.MountEntries(), DeepEquals, []mount.Entry{
+		{Dir: "/mylink", Options: []string{"x-snapd.kind=symlink", "x-snapd.symlink=/snap/vanguard/42/link/target"}},
+		{Name: "tmpfs", Dir: "/mytmp", Type: "tmpfs", Options: []string{"x-snapd.mode=0777", "x-snapd.uid=65534", "x-snapd.gid=65534"}},
+		{Name: "/var/snap/vanguard/42/foo.conf", Dir: "/opt/foo.conf", Options: []string{"rbind", "rw"}},
+		{Name: "/snap/vanguard/42/usr", Dir: "/usr", Options: []string{"rbind", "rw"}},
+		{Dir: "/var/cache/mylink", Options: []string{"x-snapd.kind=symlink", "x-snapd.symlink=/var/snap/vanguard/common/cache", "x-snapd.mode=0700"}},
+		{Name: "/", Dir: "/foo", Options: []string{"bind"}},
+	})
+}
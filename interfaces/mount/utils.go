@@ -0,0 +1,181 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package mount
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// System calls used to create mount points, mocked in tests.
+var (
+	sysOpen      = syscall.Open
+	sysOpenat    = syscall.Openat
+	sysMkdirat   = syscall.Mkdirat
+	sysFchown    = syscall.Fchown
+	sysClose     = syscall.Close
+	sysSymlinkat = symlinkat
+)
+
+// ReadOnlyFsError is returned when a file system object cannot be created
+// because its parent directory is on a read-only file system.
+type ReadOnlyFsError struct {
+	Path string
+}
+
+func (e *ReadOnlyFsError) Error() string {
+	return fmt.Sprintf("cannot operate on read-only filesystem at %s", e.Path)
+}
+
+// symlinkat is missing from the syscall package.
+func symlinkat(oldname string, newdirfd int, newname string) error {
+	oldnamePtr, err := syscall.BytePtrFromString(oldname)
+	if err != nil {
+		return err
+	}
+	newnamePtr, err := syscall.BytePtrFromString(newname)
+	if err != nil {
+		return err
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_SYMLINKAT, uintptr(unsafe.Pointer(oldnamePtr)), uintptr(newdirfd), uintptr(unsafe.Pointer(newnamePtr)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// secureOpenDirAll creates the given absolute directory, and its missing
+// parents, and returns a file descriptor to it.
+//
+// Each path segment is opened relative to the previous one and symbolic
+// links are never followed, so that a snap cannot redirect the creation
+// elsewhere. Directories that get created are owned by the given user and
+// group.
+func secureOpenDirAll(name string, perm os.FileMode, uid, gid int) (int, error) {
+	if !filepath.IsAbs(name) {
+		return -1, fmt.Errorf("cannot create directory with relative path: %q", name)
+	}
+	const openFlags = syscall.O_NOFOLLOW | syscall.O_CLOEXEC | syscall.O_DIRECTORY
+
+	fd, err := sysOpen("/", openFlags, 0)
+	if err != nil {
+		return -1, fmt.Errorf("cannot open root directory: %v", err)
+	}
+	parent := "/"
+	for _, segment := range strings.Split(filepath.Clean(name), "/") {
+		if segment == "" {
+			continue
+		}
+		created := true
+		if err := sysMkdirat(fd, segment, uint32(perm.Perm())); err != nil {
+			if err == syscall.EROFS {
+				sysClose(fd)
+				return -1, &ReadOnlyFsError{Path: parent}
+			}
+			if err != syscall.EEXIST {
+				sysClose(fd)
+				return -1, fmt.Errorf("cannot mkdir path segment %q: %v", segment, err)
+			}
+			created = false
+		}
+		newFd, err := sysOpenat(fd, segment, openFlags, 0)
+		sysClose(fd)
+		if err != nil {
+			return -1, fmt.Errorf("cannot open path segment %q: %v", segment, err)
+		}
+		fd = newFd
+		parent = filepath.Join(parent, segment)
+		if created {
+			if err := sysFchown(fd, uid, gid); err != nil {
+				sysClose(fd)
+				return -1, fmt.Errorf("cannot chown path segment %q to %d.%d: %v", segment, uid, gid, err)
+			}
+		}
+	}
+	return fd, nil
+}
+
+// secureMkdirAll creates the given absolute directory, and its missing
+// parents, without following symbolic links.
+func secureMkdirAll(name string, perm os.FileMode, uid, gid int) error {
+	fd, err := secureOpenDirAll(name, perm, uid, gid)
+	if err != nil {
+		return err
+	}
+	return sysClose(fd)
+}
+
+// secureMkfileAll creates the given empty file, and its missing parent
+// directories, without following symbolic links.
+func secureMkfileAll(name string, perm os.FileMode, uid, gid int) error {
+	fd, err := secureOpenDirAll(filepath.Dir(name), 0755, uid, gid)
+	if err != nil {
+		return err
+	}
+	defer sysClose(fd)
+
+	base := filepath.Base(name)
+	const openFlags = syscall.O_NOFOLLOW | syscall.O_CLOEXEC | syscall.O_CREAT | syscall.O_EXCL | syscall.O_WRONLY
+	newFd, err := sysOpenat(fd, base, openFlags, uint32(perm.Perm()))
+	if err == syscall.EEXIST {
+		return nil
+	}
+	if err == syscall.EROFS {
+		return &ReadOnlyFsError{Path: filepath.Dir(name)}
+	}
+	if err != nil {
+		return fmt.Errorf("cannot create file %q: %v", name, err)
+	}
+	defer sysClose(newFd)
+	if err := sysFchown(newFd, uid, gid); err != nil {
+		return fmt.Errorf("cannot chown file %q to %d.%d: %v", name, uid, gid, err)
+	}
+	return nil
+}
+
+// secureMksymlinkAll creates the given symbolic link, pointing to oldname,
+// and its missing parent directories, without following symbolic links.
+//
+// An existing symbolic link pointing to oldname is left alone.
+func secureMksymlinkAll(name string, perm os.FileMode, uid, gid int, oldname string) error {
+	fd, err := secureOpenDirAll(filepath.Dir(name), perm, uid, gid)
+	if err != nil {
+		return err
+	}
+	defer sysClose(fd)
+
+	if err := sysSymlinkat(oldname, fd, filepath.Base(name)); err != nil {
+		if err == syscall.EEXIST {
+			if target, rerr := os.Readlink(name); rerr == nil && target == oldname {
+				return nil
+			}
+			return fmt.Errorf("cannot create symlink %q: existing file in the way", name)
+		}
+		if err == syscall.EROFS {
+			return &ReadOnlyFsError{Path: filepath.Dir(name)}
+		}
+		return fmt.Errorf("cannot create symlink %q: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package mount_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces/mount"
+)
+
+type utilsSuite struct {
+	restore func()
+}
+
+var _ = Suite(&utilsSuite{})
+
+func (s *utilsSuite) SetUpTest(c *C) {
+	s.restore = mount.MockSystemCalls(nil, nil, func(fd, uid, gid int) error { return nil })
+}
+
+func (s *utilsSuite) TearDownTest(c *C) {
+	s.restore()
+}
+
+func (s *utilsSuite) TestSecureMkdirAll(c *C) {
+	dir := filepath.Join(c.MkDir(), "a", "b", "c")
+	c.Assert(mount.SecureMkdirAll(dir, 0700, 0, 0), IsNil)
+	fi, err := os.Stat(dir)
+	c.Assert(err, IsNil)
+	c.Check(fi.IsDir(), Equals, true)
+	c.Check(fi.Mode().Perm(), Equals, os.FileMode(0700))
+	// existing directories are fine
+	c.Assert(mount.SecureMkdirAll(dir, 0700, 0, 0), IsNil)
+}
+
+func (s *utilsSuite) TestSecureMkdirAllRelative(c *C) {
+	c.Assert(mount.SecureMkdirAll("a/b", 0755, 0, 0), ErrorMatches, `cannot create directory with relative path: "a/b"`)
+}
+
+func (s *utilsSuite) TestSecureMkdirAllDoesNotFollowSymlinks(c *C) {
+	dir := c.MkDir()
+	c.Assert(os.Symlink(c.MkDir(), filepath.Join(dir, "link")), IsNil)
+	err := mount.SecureMkdirAll(filepath.Join(dir, "link", "sub"), 0755, 0, 0)
+	c.Assert(err, ErrorMatches, `cannot open path segment "link": not a directory`)
+}
+
+func (s *utilsSuite) TestSecureMkfileAll(c *C) {
+	name := filepath.Join(c.MkDir(), "a", "file")
+	c.Assert(mount.SecureMkfileAll(name, 0600, 0, 0), IsNil)
+	fi, err := os.Stat(name)
+	c.Assert(err, IsNil)
+	c.Check(fi.Mode(), Equals, os.FileMode(0600))
+	// existing files are fine
+	c.Assert(mount.SecureMkfileAll(name, 0600, 0, 0), IsNil)
+}
+
+func (s *utilsSuite) TestSecureMksymlinkAll(c *C) {
+	name := filepath.Join(c.MkDir(), "a", "link")
+	c.Assert(mount.SecureMksymlinkAll(name, 0755, 0, 0, "/oldname"), IsNil)
+	oldname, err := os.Readlink(name)
+	c.Assert(err, IsNil)
+	c.Check(oldname, Equals, "/oldname")
+	// the same symlink can be created again, but not a different one
+	c.Assert(mount.SecureMksymlinkAll(name, 0755, 0, 0, "/oldname"), IsNil)
+	c.Assert(mount.SecureMksymlinkAll(name, 0755, 0, 0, "/other"), ErrorMatches, `cannot create symlink ".*/a/link": existing file in the way`)
+}
@@ -353,6 +353,87 @@ plugs:
 	c.Check(err, IsNil)
 }
 
+func (s *baseDeclSuite) TestSystemFilesAllowedBySnapDecl(c *C) {
+	plugYaml := func(attrs string) string {
+		return `name: plug-snap
+plugs:
+  system-files:
+` + attrs
+	}
+	granted := plugYaml("    read: [/etc/our-app.conf]\n")
+
+	cand := s.connectCand(c, "system-files", "", granted)
+	c.Check(cand.CheckAutoConnect(), ErrorMatches, `auto-connection denied by plug rule of interface "system-files"`)
+	ic := s.installPlugCand(c, "system-files", snap.TypeApp, granted)
+	c.Check(ic.Check(), ErrorMatches, `installation not allowed by "system-files" plug rule of interface "system-files"`)
+
+	// the snap-declaration grants exactly the given paths
+	plugsSlots := `
+plugs:
+  system-files:
+    allow-installation:
+      plug-attributes:
+        read: /etc/our-app\.conf
+        write: $MISSING
+    allow-auto-connection:
+      plug-attributes:
+        read: /etc/our-app\.conf
+        write: $MISSING
+`
+	snapDecl := s.mockSnapDecl(c, "plug-snap", "J60k4JY0HppjwOjW8dZdYc8obXKxujRu", "canonical", plugsSlots)
+
+	cand = s.connectCand(c, "system-files", "", granted)
+	cand.PlugSnapDeclaration = snapDecl
+	c.Check(cand.CheckAutoConnect(), IsNil)
+	ic = s.installPlugCand(c, "system-files", snap.TypeApp, granted)
+	ic.SnapDeclaration = snapDecl
+	c.Check(ic.Check(), IsNil)
+
+	for _, attrs := range []string{
+		"    read: [/etc/our-app.conf, /etc/shadow]\n",
+		"    read: [/etc/our-app.conf]\n    write: [/etc/our-app.conf]\n",
+	} {
+		cand = s.connectCand(c, "system-files", "", plugYaml(attrs))
+		cand.PlugSnapDeclaration = snapDecl
+		c.Check(cand.CheckAutoConnect(), NotNil, Commentf(attrs))
+		ic = s.installPlugCand(c, "system-files", snap.TypeApp, plugYaml(attrs))
+		ic.SnapDeclaration = snapDecl
+		c.Check(ic.Check(), NotNil, Commentf(attrs))
+	}
+}
+
+func (s *baseDeclSuite) TestPolkitAllowedBySnapDecl(c *C) {
+	slotYaml := `name: slot-snap
+slots:
+  polkit:
+    action-prefix: io.snapcraft.slot-snap
+`
+	ic := s.installSlotCand(c, "polkit", snap.TypeApp, slotYaml)
+	c.Check(ic.Check(), ErrorMatches, `installation not allowed by "polkit" slot rule of interface "polkit"`)
+
+	// the snap-declaration grants the action prefix
+	plugsSlots := `
+slots:
+  polkit:
+    allow-installation:
+      slot-attributes:
+        action-prefix: io\.snapcraft\.slot-snap
+`
+	snapDecl := s.mockSnapDecl(c, "slot-snap", "J60k4JY0HppjwOjW8dZdYc8obXKxujRu", "canonical", plugsSlots)
+
+	ic = s.installSlotCand(c, "polkit", snap.TypeApp, slotYaml)
+	ic.SnapDeclaration = snapDecl
+	c.Check(ic.Check(), IsNil)
+
+	ic = s.installSlotCand(c, "polkit", snap.TypeApp, `name: slot-snap
+slots:
+  polkit:
+    action-prefix: org.freedesktop
+`)
+	ic.SnapDeclaration = snapDecl
+	c.Check(ic.Check(), NotNil)
+}
+
 func (s *baseDeclSuite) TestAutoConnectionClassicSupportOverride(c *C) {
 	cand := s.connectCand(c, "classic-support", "", "")
 	err := cand.CheckAutoConnect()
@@ -487,6 +568,7 @@ var (
 		"network-status":          {"app"},
 		"ofono":                   {"app", "core"},
 		"online-accounts-service": {"app"},
+		"polkit":      {},
 		"ppp":         {"core"},
 		"pulseaudio":  {"app", "core"},
 		"serial-port": {"core", "gadget"},
@@ -577,9 +659,12 @@ func (s *baseDeclSuite) TestPlugInstallation(c *C) {
 		"docker-support":        true,
 		"greengrass-support":    true,
 		"kernel-module-control": true,
+		"kernel-module-load":    true,
 		"kubernetes-support":    true,
 		"lxd-support":           true,
+		"personal-files":        true,
 		"snapd-control":         true,
+		"system-files":          true,
 		"unity8":                true,
 	}
 
@@ -703,9 +788,12 @@ func (s *baseDeclSuite) TestSanity(c *C) {
 		"docker-support":        true,
 		"greengrass-support":    true,
 		"kernel-module-control": true,
+		"kernel-module-load":    true,
 		"kubernetes-support":    true,
 		"lxd-support":           true,
+		"personal-files":        true,
 		"snapd-control":         true,
+		"system-files":          true,
 		"unity8":                true,
 	}
 
@@ -30,6 +30,22 @@ import (
 
 // check helpers
 
+// constraintsOutcome records whether the i-th of n alternative constraints
+// matched.
+func (tr *Trace) constraintsOutcome(what string, i, n int, err error) {
+	if tr == nil {
+		return
+	}
+	if n > 1 {
+		what = fmt.Sprintf("%s alternative %d of %d", what, i+1, n)
+	}
+	if err != nil {
+		tr.add("%s constraints do not match: %v", what, err)
+	} else {
+		tr.add("%s constraints match", what)
+	}
+}
+
 func checkSnapType(snapType snap.Type, types []string) error {
 	if len(types) == 0 {
 		return nil
@@ -105,11 +121,12 @@ func checkPlugConnectionConstraints1(connc *ConnectCandidate, cstrs *asserts.Plu
 	return nil
 }
 
-func checkPlugConnectionConstraints(connc *ConnectCandidate, cstrs []*asserts.PlugConnectionConstraints) error {
+func checkPlugConnectionConstraints(connc *ConnectCandidate, cstrs []*asserts.PlugConnectionConstraints, what string) error {
 	var firstErr error
 	// OR of constraints
-	for _, cstrs1 := range cstrs {
+	for i, cstrs1 := range cstrs {
 		err := checkPlugConnectionConstraints1(connc, cstrs1)
+		connc.Trace.constraintsOutcome(what, i, len(cstrs), err)
 		if err == nil {
 			return nil
 		}
@@ -145,11 +162,12 @@ func checkSlotConnectionConstraints1(connc *ConnectCandidate, cstrs *asserts.Slo
 	return nil
 }
 
-func checkSlotConnectionConstraints(connc *ConnectCandidate, cstrs []*asserts.SlotConnectionConstraints) error {
+func checkSlotConnectionConstraints(connc *ConnectCandidate, cstrs []*asserts.SlotConnectionConstraints, what string) error {
 	var firstErr error
 	// OR of constraints
-	for _, cstrs1 := range cstrs {
+	for i, cstrs1 := range cstrs {
 		err := checkSlotConnectionConstraints1(connc, cstrs1)
+		connc.Trace.constraintsOutcome(what, i, len(cstrs), err)
 		if err == nil {
 			return nil
 		}
@@ -174,11 +192,12 @@ func checkSlotInstallationConstraints1(slot *snap.SlotInfo, cstrs *asserts.SlotI
 	return nil
 }
 
-func checkSlotInstallationConstraints(slot *snap.SlotInfo, cstrs []*asserts.SlotInstallationConstraints) error {
+func checkSlotInstallationConstraints(slot *snap.SlotInfo, cstrs []*asserts.SlotInstallationConstraints, tr *Trace, what string) error {
 	var firstErr error
 	// OR of constraints
-	for _, cstrs1 := range cstrs {
+	for i, cstrs1 := range cstrs {
 		err := checkSlotInstallationConstraints1(slot, cstrs1)
+		tr.constraintsOutcome(what, i, len(cstrs), err)
 		if err == nil {
 			return nil
 		}
@@ -203,11 +222,12 @@ func checkPlugInstallationConstraints1(plug *snap.PlugInfo, cstrs *asserts.PlugI
 	return nil
 }
 
-func checkPlugInstallationConstraints(plug *snap.PlugInfo, cstrs []*asserts.PlugInstallationConstraints) error {
+func checkPlugInstallationConstraints(plug *snap.PlugInfo, cstrs []*asserts.PlugInstallationConstraints, tr *Trace, what string) error {
 	var firstErr error
 	// OR of constraints
-	for _, cstrs1 := range cstrs {
+	for i, cstrs1 := range cstrs {
 		err := checkPlugInstallationConstraints1(plug, cstrs1)
+		tr.constraintsOutcome(what, i, len(cstrs), err)
 		if err == nil {
 			return nil
 		}
@@ -30,11 +30,27 @@ import (
 	"github.com/snapcore/snapd/snap"
 )
 
+// Trace records the rules and constraints considered by a policy check,
+// to explain its outcome.
+type Trace struct {
+	Steps []string
+}
+
+func (tr *Trace) add(format string, args ...interface{}) {
+	if tr == nil {
+		return
+	}
+	tr.Steps = append(tr.Steps, fmt.Sprintf(format, args...))
+}
+
 // InstallCandidate represents a candidate snap for installation.
 type InstallCandidate struct {
 	Snap            *snap.Info
 	SnapDeclaration *asserts.SnapDeclaration
 	BaseDeclaration *asserts.BaseDeclaration
+
+	// Trace, if set, is filled with the steps of the checks.
+	Trace *Trace
 }
 
 func (ic *InstallCandidate) checkSlotRule(slot *snap.SlotInfo, rule *asserts.SlotRule, snapRule bool) error {
@@ -42,10 +58,10 @@ func (ic *InstallCandidate) checkSlotRule(slot *snap.SlotInfo, rule *asserts.Slo
 	if snapRule {
 		context = fmt.Sprintf(" for %q snap", ic.SnapDeclaration.SnapName())
 	}
-	if checkSlotInstallationConstraints(slot, rule.DenyInstallation) == nil {
+	if checkSlotInstallationConstraints(slot, rule.DenyInstallation, ic.Trace, "deny-installation") == nil {
 		return fmt.Errorf("installation denied by %q slot rule of interface %q%s", slot.Name, slot.Interface, context)
 	}
-	if checkSlotInstallationConstraints(slot, rule.AllowInstallation) != nil {
+	if checkSlotInstallationConstraints(slot, rule.AllowInstallation, ic.Trace, "allow-installation") != nil {
 		return fmt.Errorf("installation not allowed by %q slot rule of interface %q%s", slot.Name, slot.Interface, context)
 	}
 	return nil
@@ -56,10 +72,10 @@ func (ic *InstallCandidate) checkPlugRule(plug *snap.PlugInfo, rule *asserts.Plu
 	if snapRule {
 		context = fmt.Sprintf(" for %q snap", ic.SnapDeclaration.SnapName())
 	}
-	if checkPlugInstallationConstraints(plug, rule.DenyInstallation) == nil {
+	if checkPlugInstallationConstraints(plug, rule.DenyInstallation, ic.Trace, "deny-installation") == nil {
 		return fmt.Errorf("installation denied by %q plug rule of interface %q%s", plug.Name, plug.Interface, context)
 	}
-	if checkPlugInstallationConstraints(plug, rule.AllowInstallation) != nil {
+	if checkPlugInstallationConstraints(plug, rule.AllowInstallation, ic.Trace, "allow-installation") != nil {
 		return fmt.Errorf("installation not allowed by %q plug rule of interface %q%s", plug.Name, plug.Interface, context)
 	}
 	return nil
@@ -69,12 +85,15 @@ func (ic *InstallCandidate) checkSlot(slot *snap.SlotInfo) error {
 	iface := slot.Interface
 	if snapDecl := ic.SnapDeclaration; snapDecl != nil {
 		if rule := snapDecl.SlotRule(iface); rule != nil {
+			ic.Trace.add("using %q slot rule of interface %q from snap-declaration of %q", slot.Name, iface, snapDecl.SnapName())
 			return ic.checkSlotRule(slot, rule, true)
 		}
 	}
 	if rule := ic.BaseDeclaration.SlotRule(iface); rule != nil {
+		ic.Trace.add("using %q slot rule of interface %q from base-declaration", slot.Name, iface)
 		return ic.checkSlotRule(slot, rule, false)
 	}
+	ic.Trace.add("no slot rule for interface %q of slot %q", iface, slot.Name)
 	return nil
 }
 
@@ -82,12 +101,15 @@ func (ic *InstallCandidate) checkPlug(plug *snap.PlugInfo) error {
 	iface := plug.Interface
 	if snapDecl := ic.SnapDeclaration; snapDecl != nil {
 		if rule := snapDecl.PlugRule(iface); rule != nil {
+			ic.Trace.add("using %q plug rule of interface %q from snap-declaration of %q", plug.Name, iface, snapDecl.SnapName())
 			return ic.checkPlugRule(plug, rule, true)
 		}
 	}
 	if rule := ic.BaseDeclaration.PlugRule(iface); rule != nil {
+		ic.Trace.add("using %q plug rule of interface %q from base-declaration", plug.Name, iface)
 		return ic.checkPlugRule(plug, rule, false)
 	}
+	ic.Trace.add("no plug rule for interface %q of plug %q", iface, plug.Name)
 	return nil
 }
 
@@ -124,6 +146,9 @@ type ConnectCandidate struct {
 	SlotSnapDeclaration *asserts.SnapDeclaration
 
 	BaseDeclaration *asserts.BaseDeclaration
+
+	// Trace, if set, is filled with the steps of the checks.
+	Trace *Trace
 }
 
 func (connc *ConnectCandidate) plugAttrs() map[string]interface{} {
@@ -206,10 +231,10 @@ func (connc *ConnectCandidate) checkPlugRule(kind string, rule *asserts.PlugRule
 		denyConst = rule.DenyAutoConnection
 		allowConst = rule.AllowAutoConnection
 	}
-	if checkPlugConnectionConstraints(connc, denyConst) == nil {
+	if checkPlugConnectionConstraints(connc, denyConst, "deny-"+kind) == nil {
 		return fmt.Errorf("%s denied by plug rule of interface %q%s", kind, connc.Plug.Interface, context)
 	}
-	if checkPlugConnectionConstraints(connc, allowConst) != nil {
+	if checkPlugConnectionConstraints(connc, allowConst, "allow-"+kind) != nil {
 		return fmt.Errorf("%s not allowed by plug rule of interface %q%s", kind, connc.Plug.Interface, context)
 	}
 	return nil
@@ -226,10 +251,10 @@ func (connc *ConnectCandidate) checkSlotRule(kind string, rule *asserts.SlotRule
 		denyConst = rule.DenyAutoConnection
 		allowConst = rule.AllowAutoConnection
 	}
-	if checkSlotConnectionConstraints(connc, denyConst) == nil {
+	if checkSlotConnectionConstraints(connc, denyConst, "deny-"+kind) == nil {
 		return fmt.Errorf("%s denied by slot rule of interface %q%s", kind, connc.Plug.Interface, context)
 	}
-	if checkSlotConnectionConstraints(connc, allowConst) != nil {
+	if checkSlotConnectionConstraints(connc, allowConst, "allow-"+kind) != nil {
 		return fmt.Errorf("%s not allowed by slot rule of interface %q%s", kind, connc.Plug.Interface, context)
 	}
 	return nil
@@ -249,20 +274,29 @@ func (connc *ConnectCandidate) check(kind string) error {
 
 	if plugDecl := connc.PlugSnapDeclaration; plugDecl != nil {
 		if rule := plugDecl.PlugRule(iface); rule != nil {
+			connc.Trace.add("using plug rule of interface %q from snap-declaration of %q", iface, plugDecl.SnapName())
 			return connc.checkPlugRule(kind, rule, true)
 		}
+	} else {
+		connc.Trace.add("plug snap %q has no snap-declaration", connc.Plug.Snap.Name())
 	}
 	if slotDecl := connc.SlotSnapDeclaration; slotDecl != nil {
 		if rule := slotDecl.SlotRule(iface); rule != nil {
+			connc.Trace.add("using slot rule of interface %q from snap-declaration of %q", iface, slotDecl.SnapName())
 			return connc.checkSlotRule(kind, rule, true)
 		}
+	} else {
+		connc.Trace.add("slot snap %q has no snap-declaration", connc.Slot.Snap.Name())
 	}
 	if rule := baseDecl.PlugRule(iface); rule != nil {
+		connc.Trace.add("using plug rule of interface %q from base-declaration", iface)
 		return connc.checkPlugRule(kind, rule, false)
 	}
 	if rule := baseDecl.SlotRule(iface); rule != nil {
+		connc.Trace.add("using slot rule of interface %q from base-declaration", iface)
 		return connc.checkSlotRule(kind, rule, false)
 	}
+	connc.Trace.add("no rule for interface %q", iface)
 	return nil
 }
 
@@ -761,6 +761,63 @@ func (s *policySuite) TestBaseDeclAllowDenyConnection(c *C) {
 	}
 }
 
+func (s *policySuite) TestTraceConnection(c *C) {
+	var trace policy.Trace
+	cand := policy.ConnectCandidate{
+		Plug:            s.plugSnap.Plugs["plug-or-p1-s2"],
+		Slot:            s.slotSnap.Slots["plug-or-p1-s2"],
+		BaseDeclaration: s.baseDecl,
+		Trace:           &trace,
+	}
+
+	c.Check(cand.Check(), ErrorMatches, "connection not allowed by plug rule.*")
+	c.Check(trace.Steps, DeepEquals, []string{
+		`plug snap "plug-snap" has no snap-declaration`,
+		`slot snap "slot-snap" has no snap-declaration`,
+		`using plug rule of interface "plug-or" from base-declaration`,
+		`deny-connection constraints do not match: not allowed`,
+		`allow-connection alternative 1 of 2 constraints do not match: attribute "s" value "S2" does not match ^(S1)$`,
+		`allow-connection alternative 2 of 2 constraints do not match: attribute "p" value "P1" does not match ^(P2)$`,
+	})
+}
+
+func (s *policySuite) TestTraceAutoConnection(c *C) {
+	var trace policy.Trace
+	cand := policy.ConnectCandidate{
+		Plug:            s.plugSnap.Plugs["auto-base-plug-deny"],
+		Slot:            s.slotSnap.Slots["auto-base-plug-deny"],
+		BaseDeclaration: s.baseDecl,
+		Trace:           &trace,
+	}
+
+	c.Check(cand.CheckAutoConnect(), ErrorMatches, "auto-connection denied by plug rule.*")
+	c.Check(trace.Steps, DeepEquals, []string{
+		`plug snap "plug-snap" has no snap-declaration`,
+		`slot snap "slot-snap" has no snap-declaration`,
+		`using plug rule of interface "auto-base-plug-deny" from base-declaration`,
+		`deny-auto-connection constraints match`,
+	})
+}
+
+func (s *policySuite) TestTraceInstallation(c *C) {
+	var trace policy.Trace
+	cand := policy.InstallCandidate{
+		Snap: snaptest.MockInfo(c, `name: install-snap
+plugs:
+  install-plug-gadget-only:
+`, nil),
+		BaseDeclaration: s.baseDecl,
+		Trace:           &trace,
+	}
+
+	c.Check(cand.Check(), ErrorMatches, "installation not allowed by .*")
+	c.Check(trace.Steps, DeepEquals, []string{
+		`using "install-plug-gadget-only" plug rule of interface "install-plug-gadget-only" from base-declaration`,
+		`deny-installation constraints do not match: not allowed`,
+		`allow-installation constraints do not match: snap type does not match`,
+	})
+}
+
 func (s *policySuite) TestBaseDeclAllowDenyAutoConnection(c *C) {
 	tests := []struct {
 		iface    string
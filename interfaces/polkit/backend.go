@@ -0,0 +1,103 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+// Package polkit implements interaction between snapd and polkit.
+//
+// Snapd installs the polkit policy files shipped by snaps, which define the
+// actions that the D-Bus services of those snaps check authorization for.
+// The files are named after the snap so that they can be removed again, and
+// the actions they define must all be below the prefix declared by the
+// interface providing them.
+package polkit
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/snap"
+)
+
+// Backend is responsible for maintaining polkit policy files.
+type Backend struct{}
+
+// Name returns the name of the backend.
+func (b *Backend) Name() interfaces.SecuritySystem {
+	return interfaces.SecurityPolkit
+}
+
+// Setup installs the polkit policy files specific to a given snap.
+//
+// Polkit has no concept of a complain mode so confinement type is ignored.
+func (b *Backend) Setup(snapInfo *snap.Info, opts interfaces.ConfinementOptions, repo *interfaces.Repository) error {
+	snapName := snapInfo.Name()
+	spec, err := repo.SnapSpecification(b.Name(), snapName)
+	if err != nil {
+		return fmt.Errorf("cannot obtain polkit specification for snap %q: %s", snapName, err)
+	}
+
+	content := deriveContent(spec.(*Specification), snapName)
+	dir := dirs.SnapPolkitPolicyDir
+	if len(content) > 0 {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("cannot create directory for polkit policy files %q: %s", dir, err)
+		}
+	}
+	if _, _, err := osutil.EnsureDirState(dir, policyGlob(snapName), content); err != nil {
+		return fmt.Errorf("cannot synchronize polkit policy files for snap %q: %s", snapName, err)
+	}
+	return nil
+}
+
+// Remove removes the polkit policy files of a given snap.
+//
+// This method should be called after removing a snap.
+func (b *Backend) Remove(snapName string) error {
+	if _, _, err := osutil.EnsureDirState(dirs.SnapPolkitPolicyDir, policyGlob(snapName), nil); err != nil {
+		return fmt.Errorf("cannot synchronize polkit policy files for snap %q: %s", snapName, err)
+	}
+	return nil
+}
+
+// NewSpecification returns a new polkit specification.
+func (b *Backend) NewSpecification() interfaces.Specification {
+	return &Specification{}
+}
+
+func policyGlob(snapName string) string {
+	return fmt.Sprintf("%s.policy", interfaces.SecurityTagGlob(snapName))
+}
+
+// deriveContent returns the policy files of the specification, named after
+// the snap, as a content map applicable to EnsureDirState.
+func deriveContent(spec *Specification, snapName string) map[string]*osutil.FileState {
+	policies := spec.Policies()
+	if len(policies) == 0 {
+		return nil
+	}
+	content := make(map[string]*osutil.FileState, len(policies))
+	for name, policy := range policies {
+		content[fmt.Sprintf("snap.%s.%s.policy", snapName, name)] = &osutil.FileState{
+			Content: policy,
+			Mode:    0644,
+		}
+	}
+	return content
+}
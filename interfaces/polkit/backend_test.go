@@ -0,0 +1,139 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package polkit_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/interfaces/polkit"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type backendSuite struct {
+	ifacetest.BackendSuite
+}
+
+var _ = Suite(&backendSuite{})
+
+var testedConfinementOpts = []interfaces.ConfinementOptions{
+	{},
+	{DevMode: true},
+	{JailMode: true},
+	{Classic: true},
+}
+
+const testPolicy = `<policyconfig>
+  <action id="io.snapcraft.samba.manage">
+    <defaults><allow_active>auth_admin</allow_active></defaults>
+  </action>
+</policyconfig>
+`
+
+func (s *backendSuite) SetUpTest(c *C) {
+	s.Backend = &polkit.Backend{}
+	s.BackendSuite.SetUpTest(c)
+	c.Assert(s.Repo.AddBackend(s.Backend), IsNil)
+}
+
+func (s *backendSuite) TearDownTest(c *C) {
+	s.BackendSuite.TearDownTest(c)
+}
+
+// Tests for Setup() and Remove()
+func (s *backendSuite) TestName(c *C) {
+	c.Check(s.Backend.Name(), Equals, interfaces.SecurityPolkit)
+}
+
+func (s *backendSuite) TestInstallingSnapWritesPolicyFiles(c *C) {
+	s.Iface.PolkitPermanentSlotCallback = func(spec *polkit.Specification, slot *interfaces.Slot) error {
+		return spec.AddPolicy("manage", "io.snapcraft.samba", polkit.Policy(testPolicy))
+	}
+	for _, opts := range testedConfinementOpts {
+		snapInfo := s.InstallSnap(c, opts, ifacetest.SambaYamlV1, 0)
+		policy := filepath.Join(dirs.SnapPolkitPolicyDir, "snap.samba.manage.policy")
+		data, err := ioutil.ReadFile(policy)
+		c.Assert(err, IsNil)
+		c.Check(string(data), Equals, testPolicy)
+		fi, err := os.Stat(policy)
+		c.Assert(err, IsNil)
+		c.Check(fi.Mode().Perm(), Equals, os.FileMode(0644))
+		s.RemoveSnap(c, snapInfo)
+	}
+}
+
+func (s *backendSuite) TestInstallingSnapWithoutPoliciesWritesNothing(c *C) {
+	for _, opts := range testedConfinementOpts {
+		snapInfo := s.InstallSnap(c, opts, ifacetest.SambaYamlV1, 0)
+		// the policy directory is not even created
+		_, err := os.Stat(dirs.SnapPolkitPolicyDir)
+		c.Check(os.IsNotExist(err), Equals, true)
+		s.RemoveSnap(c, snapInfo)
+	}
+}
+
+func (s *backendSuite) TestRemovingSnapRemovesPolicyFiles(c *C) {
+	s.Iface.PolkitPermanentSlotCallback = func(spec *polkit.Specification, slot *interfaces.Slot) error {
+		return spec.AddPolicy("manage", "io.snapcraft.samba", polkit.Policy(testPolicy))
+	}
+	for _, opts := range testedConfinementOpts {
+		snapInfo := s.InstallSnap(c, opts, ifacetest.SambaYamlV1, 0)
+		s.RemoveSnap(c, snapInfo)
+		policy := filepath.Join(dirs.SnapPolkitPolicyDir, "snap.samba.manage.policy")
+		_, err := os.Stat(policy)
+		c.Check(os.IsNotExist(err), Equals, true)
+	}
+}
+
+func (s *backendSuite) TestUpdatingSnapRemovesStalePolicyFiles(c *C) {
+	s.Iface.PolkitPermanentSlotCallback = func(spec *polkit.Specification, slot *interfaces.Slot) error {
+		return spec.AddPolicy("manage", "io.snapcraft.samba", polkit.Policy(testPolicy))
+	}
+	for _, opts := range testedConfinementOpts {
+		snapInfo := s.InstallSnap(c, opts, ifacetest.SambaYamlV1, 0)
+		s.Iface.PolkitPermanentSlotCallback = nil
+		snapInfo = s.UpdateSnap(c, snapInfo, opts, ifacetest.SambaYamlV1, 0)
+		policy := filepath.Join(dirs.SnapPolkitPolicyDir, "snap.samba.manage.policy")
+		_, err := os.Stat(policy)
+		c.Check(os.IsNotExist(err), Equals, true)
+		s.RemoveSnap(c, snapInfo)
+		s.Iface.PolkitPermanentSlotCallback = func(spec *polkit.Specification, slot *interfaces.Slot) error {
+			return spec.AddPolicy("manage", "io.snapcraft.samba", polkit.Policy(testPolicy))
+		}
+	}
+}
+
+func (s *backendSuite) TestRemovingSnapKeepsOtherSnapsPolicies(c *C) {
+	c.Assert(os.MkdirAll(dirs.SnapPolkitPolicyDir, 0755), IsNil)
+	other := filepath.Join(dirs.SnapPolkitPolicyDir, "org.freedesktop.foo.policy")
+	c.Assert(ioutil.WriteFile(other, []byte(testPolicy), 0644), IsNil)
+	c.Assert(s.Backend.Remove("samba"), IsNil)
+	_, err := os.Stat(other)
+	c.Check(err, IsNil)
+}
@@ -0,0 +1,159 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package polkit
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/snapcore/snapd/interfaces"
+)
+
+// Policy is the content of a polkit policy file.
+type Policy []byte
+
+// Specification keeps the polkit policies of a snap, by name.
+type Specification struct {
+	policies map[string]Policy
+}
+
+var validPolicyName = regexp.MustCompile(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*$`)
+
+// AddPolicy adds a polkit policy file, installed under the given name. All
+// the actions it defines, or implies, must be below the action prefix.
+func (spec *Specification) AddPolicy(name, actionPrefix string, policy Policy) error {
+	if !validPolicyName.MatchString(name) {
+		return fmt.Errorf("invalid polkit policy name %q", name)
+	}
+	if err := ValidatePolicy(policy, actionPrefix); err != nil {
+		return fmt.Errorf("cannot use polkit policy %q: %v", name, err)
+	}
+	if old, ok := spec.policies[name]; ok {
+		if !bytes.Equal(old, policy) {
+			return fmt.Errorf("cannot use polkit policy %q: another policy of the same name was added", name)
+		}
+		return nil
+	}
+	if spec.policies == nil {
+		spec.policies = make(map[string]Policy)
+	}
+	spec.policies[name] = policy
+	return nil
+}
+
+// Policies returns a copy of the added policies, by name.
+func (spec *Specification) Policies() map[string]Policy {
+	if spec.policies == nil {
+		return nil
+	}
+	result := make(map[string]Policy, len(spec.policies))
+	for name, policy := range spec.policies {
+		result[name] = append(Policy(nil), policy...)
+	}
+	return result
+}
+
+// policyConfig is the part of a polkit policy file that is validated.
+type policyConfig struct {
+	XMLName xml.Name `xml:"policyconfig"`
+	Actions []struct {
+		ID          string `xml:"id,attr"`
+		Annotations []struct {
+			Key   string `xml:"key,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"annotate"`
+	} `xml:"action"`
+}
+
+// ValidatePolicy checks that a polkit policy file defines actions below the
+// given prefix, and only implies actions below it too.
+func ValidatePolicy(policy Policy, actionPrefix string) error {
+	var config policyConfig
+	if err := xml.Unmarshal(policy, &config); err != nil {
+		return fmt.Errorf("cannot parse polkit policy: %v", err)
+	}
+	if len(config.Actions) == 0 {
+		return fmt.Errorf("polkit policy defines no actions")
+	}
+	for _, action := range config.Actions {
+		if !strings.HasPrefix(action.ID, actionPrefix+".") {
+			return fmt.Errorf("polkit action %q is not below the %q prefix", action.ID, actionPrefix)
+		}
+		for _, annotation := range action.Annotations {
+			if annotation.Key != "org.freedesktop.policykit.imply" {
+				continue
+			}
+			for _, implied := range strings.Fields(annotation.Value) {
+				if !strings.HasPrefix(implied, actionPrefix+".") {
+					return fmt.Errorf("polkit action %q implies action %q which is not below the %q prefix", action.ID, implied, actionPrefix)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Implementation of methods required by interfaces.Specification
+
+// AddConnectedPlug records polkit-specific side-effects of having a connected plug.
+func (spec *Specification) AddConnectedPlug(iface interfaces.Interface, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
+	type definer interface {
+		PolkitConnectedPlug(spec *Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error
+	}
+	if iface, ok := iface.(definer); ok {
+		return iface.PolkitConnectedPlug(spec, plug, plugAttrs, slot, slotAttrs)
+	}
+	return nil
+}
+
+// AddConnectedSlot records polkit-specific side-effects of having a connected slot.
+func (spec *Specification) AddConnectedSlot(iface interfaces.Interface, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
+	type definer interface {
+		PolkitConnectedSlot(spec *Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error
+	}
+	if iface, ok := iface.(definer); ok {
+		return iface.PolkitConnectedSlot(spec, plug, plugAttrs, slot, slotAttrs)
+	}
+	return nil
+}
+
+// AddPermanentPlug records polkit-specific side-effects of having a plug.
+func (spec *Specification) AddPermanentPlug(iface interfaces.Interface, plug *interfaces.Plug) error {
+	type definer interface {
+		PolkitPermanentPlug(spec *Specification, plug *interfaces.Plug) error
+	}
+	if iface, ok := iface.(definer); ok {
+		return iface.PolkitPermanentPlug(spec, plug)
+	}
+	return nil
+}
+
+// AddPermanentSlot records polkit-specific side-effects of having a slot.
+func (spec *Specification) AddPermanentSlot(iface interfaces.Interface, slot *interfaces.Slot) error {
+	type definer interface {
+		PolkitPermanentSlot(spec *Specification, slot *interfaces.Slot) error
+	}
+	if iface, ok := iface.(definer); ok {
+		return iface.PolkitPermanentSlot(spec, slot)
+	}
+	return nil
+}
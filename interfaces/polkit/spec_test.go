@@ -0,0 +1,166 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+package polkit_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/interfaces/polkit"
+	"github.com/snapcore/snapd/snap"
+)
+
+type specSuite struct {
+	iface *ifacetest.TestInterface
+	spec  *polkit.Specification
+	plug  *interfaces.Plug
+	slot  *interfaces.Slot
+}
+
+var _ = Suite(&specSuite{
+	iface: &ifacetest.TestInterface{
+		InterfaceName: "test",
+		PolkitConnectedPlugCallback: func(spec *polkit.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
+			return spec.AddPolicy("connected-plug", "io.snapcraft.test", polkit.Policy(policyWithAction("io.snapcraft.test.one")))
+		},
+		PolkitConnectedSlotCallback: func(spec *polkit.Specification, plug *interfaces.Plug, plugAttrs map[string]interface{}, slot *interfaces.Slot, slotAttrs map[string]interface{}) error {
+			return spec.AddPolicy("connected-slot", "io.snapcraft.test", polkit.Policy(policyWithAction("io.snapcraft.test.two")))
+		},
+		PolkitPermanentPlugCallback: func(spec *polkit.Specification, plug *interfaces.Plug) error {
+			return spec.AddPolicy("permanent-plug", "io.snapcraft.test", polkit.Policy(policyWithAction("io.snapcraft.test.three")))
+		},
+		PolkitPermanentSlotCallback: func(spec *polkit.Specification, slot *interfaces.Slot) error {
+			return spec.AddPolicy("permanent-slot", "io.snapcraft.test", polkit.Policy(policyWithAction("io.snapcraft.test.four")))
+		},
+	},
+	plug: &interfaces.Plug{
+		PlugInfo: &snap.PlugInfo{
+			Snap:      &snap.Info{SuggestedName: "snap"},
+			Name:      "name",
+			Interface: "test",
+		},
+	},
+	slot: &interfaces.Slot{
+		SlotInfo: &snap.SlotInfo{
+			Snap:      &snap.Info{SuggestedName: "snap"},
+			Name:      "name",
+			Interface: "test",
+		},
+	},
+})
+
+func policyWithAction(id string) string {
+	return `<policyconfig><action id="` + id + `"/></policyconfig>`
+}
+
+func (s *specSuite) SetUpTest(c *C) {
+	s.spec = &polkit.Specification{}
+}
+
+// The spec.Specification can be used through the interfaces.Specification interface
+func (s *specSuite) TestSpecificationIface(c *C) {
+	var r interfaces.Specification = s.spec
+	c.Assert(r.AddConnectedPlug(s.iface, s.plug, nil, s.slot, nil), IsNil)
+	c.Assert(r.AddConnectedSlot(s.iface, s.plug, nil, s.slot, nil), IsNil)
+	c.Assert(r.AddPermanentPlug(s.iface, s.plug), IsNil)
+	c.Assert(r.AddPermanentSlot(s.iface, s.slot), IsNil)
+	c.Assert(s.spec.Policies(), DeepEquals, map[string]polkit.Policy{
+		"connected-plug": polkit.Policy(policyWithAction("io.snapcraft.test.one")),
+		"connected-slot": polkit.Policy(policyWithAction("io.snapcraft.test.two")),
+		"permanent-plug": polkit.Policy(policyWithAction("io.snapcraft.test.three")),
+		"permanent-slot": polkit.Policy(policyWithAction("io.snapcraft.test.four")),
+	})
+}
+
+func (s *specSuite) TestAddPolicyIdempotent(c *C) {
+	policy := polkit.Policy(policyWithAction("io.snapcraft.test.one"))
+	c.Assert(s.spec.AddPolicy("foo", "io.snapcraft.test", policy), IsNil)
+	c.Assert(s.spec.AddPolicy("foo", "io.snapcraft.test", policy), IsNil)
+	c.Assert(s.spec.Policies(), HasLen, 1)
+}
+
+func (s *specSuite) TestAddPolicyConflict(c *C) {
+	c.Assert(s.spec.AddPolicy("foo", "io.snapcraft.test", polkit.Policy(policyWithAction("io.snapcraft.test.one"))), IsNil)
+	err := s.spec.AddPolicy("foo", "io.snapcraft.test", polkit.Policy(policyWithAction("io.snapcraft.test.two")))
+	c.Assert(err, ErrorMatches, `cannot use polkit policy "foo": another policy of the same name was added`)
+}
+
+func (s *specSuite) TestAddPolicyInvalidName(c *C) {
+	policy := polkit.Policy(policyWithAction("io.snapcraft.test.one"))
+	for _, name := range []string{"", "../foo", "foo/bar", "foo..bar", ".foo"} {
+		err := s.spec.AddPolicy(name, "io.snapcraft.test", policy)
+		c.Check(err, ErrorMatches, `invalid polkit policy name ".*"`, Commentf("name %q", name))
+	}
+}
+
+func (s *specSuite) TestPoliciesReturnsCopy(c *C) {
+	c.Assert(s.spec.AddPolicy("foo", "io.snapcraft.test", polkit.Policy(policyWithAction("io.snapcraft.test.one"))), IsNil)
+	s.spec.Policies()["foo"][0] = 'X'
+	c.Check(string(s.spec.Policies()["foo"]), Equals, policyWithAction("io.snapcraft.test.one"))
+}
+
+func (s *specSuite) TestValidatePolicy(c *C) {
+	for _, t := range []struct {
+		policy string
+		err    string
+	}{{
+		policy: policyWithAction("io.snapcraft.test.one"),
+	}, {
+		policy: `<policyconfig>
+  <action id="io.snapcraft.test.one"/>
+  <action id="io.snapcraft.test.two">
+    <annotate key="org.freedesktop.policykit.imply">io.snapcraft.test.one io.snapcraft.test.three</annotate>
+    <annotate key="org.freedesktop.policykit.exec.path">/usr/bin/foo</annotate>
+  </action>
+</policyconfig>`,
+	}, {
+		policy: `<policyconfig/>`,
+		err:    `polkit policy defines no actions`,
+	}, {
+		policy: `<policyconfig>`,
+		err:    `cannot parse polkit policy: .*`,
+	}, {
+		policy: `<other><action id="io.snapcraft.test.one"/></other>`,
+		err:    `cannot parse polkit policy: .*`,
+	}, {
+		policy: policyWithAction("io.snapcraft.test"),
+		err:    `polkit action "io.snapcraft.test" is not below the "io.snapcraft.test" prefix`,
+	}, {
+		policy: policyWithAction("io.snapcraft.testing.one"),
+		err:    `polkit action "io.snapcraft.testing.one" is not below the "io.snapcraft.test" prefix`,
+	}, {
+		policy: policyWithAction("org.freedesktop.login1.reboot"),
+		err:    `polkit action "org.freedesktop.login1.reboot" is not below the "io.snapcraft.test" prefix`,
+	}, {
+		policy: `<policyconfig>
+  <action id="io.snapcraft.test.one">
+    <annotate key="org.freedesktop.policykit.imply">org.freedesktop.login1.reboot</annotate>
+  </action>
+</policyconfig>`,
+		err: `polkit action "io.snapcraft.test.one" implies action "org.freedesktop.login1.reboot" which is not below the "io.snapcraft.test" prefix`,
+	}} {
+		err := polkit.ValidatePolicy(polkit.Policy(t.policy), "io.snapcraft.test")
+		if t.err == "" {
+			c.Check(err, IsNil, Commentf("policy %s", t.policy))
+		} else {
+			c.Check(err, ErrorMatches, t.err, Commentf("policy %s", t.policy))
+		}
+	}
+}
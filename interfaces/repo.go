@@ -80,6 +80,19 @@ func (r *Repository) AddInterface(i Interface) error {
 	return nil
 }
 
+// AllInterfaces returns all the interfaces added to the repository, ordered by name.
+func (r *Repository) AllInterfaces() []Interface {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	result := make([]Interface, 0, len(r.ifaces))
+	for _, iface := range r.ifaces {
+		result = append(result, iface)
+	}
+	sort.Sort(byInterfaceName(result))
+	return result
+}
+
 // InfoOptions describes options for Info.
 //
 // Names: return just this subset if non-empty.
@@ -546,8 +559,10 @@ func (r *Repository) ResolveDisconnect(plugSnapName, plugName, slotSnapName, slo
 }
 
 // Connect establishes a connection between a plug and a slot.
-// The plug and the slot must have the same interface.
-func (r *Repository) Connect(ref ConnRef) error {
+// The plug and the slot must have the same interface. The dynamic
+// attributes, set by the interface hooks of the plug and slot snaps,
+// are kept with the connection and passed to the security backends.
+func (r *Repository) Connect(ref ConnRef, plugDynamicAttrs, slotDynamicAttrs map[string]interface{}) error {
 	r.m.Lock()
 	defer r.m.Unlock()
 
@@ -583,7 +598,12 @@ func (r *Repository) Connect(ref ConnRef) error {
 	if r.plugSlots[plug] == nil {
 		r.plugSlots[plug] = make(map[*Slot]*Connection)
 	}
-	conn := &Connection{plugInfo: plug.PlugInfo, slotInfo: slot.SlotInfo}
+	conn := &Connection{
+		plugInfo:  plug.PlugInfo,
+		slotInfo:  slot.SlotInfo,
+		plugAttrs: normalizeAttrs(plugDynamicAttrs),
+		slotAttrs: normalizeAttrs(slotDynamicAttrs),
+	}
 	r.slotPlugs[slot][plug] = conn
 	r.plugSlots[plug][slot] = conn
 	slot.Connections = append(slot.Connections, PlugRef{plug.Snap.Name(), plug.Name})
@@ -794,8 +814,8 @@ func (r *Repository) SnapSpecification(securitySystem SecuritySystem, snapName s
 		if err := spec.AddPermanentSlot(iface, slot); err != nil {
 			return nil, err
 		}
-		for plug := range r.slotPlugs[slot] {
-			if err := spec.AddConnectedSlot(iface, plug, nil, slot, nil); err != nil {
+		for plug, conn := range r.slotPlugs[slot] {
+			if err := spec.AddConnectedSlot(iface, plug, conn.PlugAttrs(), slot, conn.SlotAttrs()); err != nil {
 				return nil, err
 			}
 		}
@@ -806,8 +826,8 @@ func (r *Repository) SnapSpecification(securitySystem SecuritySystem, snapName s
 		if err := spec.AddPermanentPlug(iface, plug); err != nil {
 			return nil, err
 		}
-		for slot := range r.plugSlots[plug] {
-			if err := spec.AddConnectedPlug(iface, plug, nil, slot, nil); err != nil {
+		for slot, conn := range r.plugSlots[plug] {
+			if err := spec.AddConnectedPlug(iface, plug, conn.PlugAttrs(), slot, conn.SlotAttrs()); err != nil {
 				return nil, err
 			}
 		}
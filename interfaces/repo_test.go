@@ -164,6 +164,15 @@ func (s *RepositorySuite) TestInterface(c *C) {
 	c.Assert(iface, Equals, s.iface)
 }
 
+func (s *RepositorySuite) TestAllInterfaces(c *C) {
+	c.Assert(s.emptyRepo.AllInterfaces(), HasLen, 0)
+	iface1 := &ifacetest.TestInterface{InterfaceName: "b-iface"}
+	iface2 := &ifacetest.TestInterface{InterfaceName: "a-iface"}
+	c.Assert(s.emptyRepo.AddInterface(iface1), IsNil)
+	c.Assert(s.emptyRepo.AddInterface(iface2), IsNil)
+	c.Assert(s.emptyRepo.AllInterfaces(), DeepEquals, []Interface{iface2, iface1})
+}
+
 func (s *RepositorySuite) TestInterfaceSearch(c *C) {
 	ifaceA := &ifacetest.TestInterface{InterfaceName: "a"}
 	ifaceB := &ifacetest.TestInterface{InterfaceName: "b"}
@@ -318,7 +327,7 @@ func (s *RepositorySuite) TestRemovePlugFailsWhenPlugIsConnected(c *C) {
 	err = s.testRepo.AddSlot(s.slot)
 	c.Assert(err, IsNil)
 	connRef := ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}
-	err = s.testRepo.Connect(connRef)
+	err = s.testRepo.Connect(connRef, nil, nil)
 	c.Assert(err, IsNil)
 	// Removing a plug used by a slot returns an appropriate error
 	err = s.testRepo.RemovePlug(s.plug.Snap.Name(), s.plug.Name)
@@ -574,7 +583,7 @@ func (s *RepositorySuite) TestRemoveSlotFailsWhenSlotIsConnected(c *C) {
 	err = s.testRepo.AddSlot(s.slot)
 	c.Assert(err, IsNil)
 	connRef := ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}
-	err = s.testRepo.Connect(connRef)
+	err = s.testRepo.Connect(connRef, nil, nil)
 	c.Assert(err, IsNil)
 	// Removing a slot occupied by a plug returns an appropriate error
 	err = s.testRepo.RemoveSlot(s.slot.Snap.Name(), s.slot.Name)
@@ -988,7 +997,7 @@ func (s *RepositorySuite) TestResolveDisconnectMatrixTypical(c *C) {
 	c.Assert(s.testRepo.AddPlug(s.plug), IsNil)
 	c.Assert(s.testRepo.AddSlot(s.slot), IsNil)
 	connRef := ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}
-	c.Assert(s.testRepo.Connect(connRef), IsNil)
+	c.Assert(s.testRepo.Connect(connRef, nil, nil), IsNil)
 
 	scenarios := []struct {
 		plugSnapName, plugName, slotSnapName, slotName string
@@ -1069,7 +1078,7 @@ func (s *RepositorySuite) TestConnectFailsWhenPlugDoesNotExist(c *C) {
 	c.Assert(err, IsNil)
 	// Connecting an unknown plug returns an appropriate error
 	connRef := ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}
-	err = s.testRepo.Connect(connRef)
+	err = s.testRepo.Connect(connRef, nil, nil)
 	c.Assert(err, ErrorMatches, `cannot connect plug "plug" from snap "consumer", no such plug`)
 }
 
@@ -1078,7 +1087,7 @@ func (s *RepositorySuite) TestConnectFailsWhenSlotDoesNotExist(c *C) {
 	c.Assert(err, IsNil)
 	// Connecting to an unknown slot returns an error
 	connRef := ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}
-	err = s.testRepo.Connect(connRef)
+	err = s.testRepo.Connect(connRef, nil, nil)
 	c.Assert(err, ErrorMatches, `cannot connect plug to slot "slot" from snap "producer", no such slot`)
 }
 
@@ -1088,10 +1097,10 @@ func (s *RepositorySuite) TestConnectSucceedsWhenIdenticalConnectExists(c *C) {
 	err = s.testRepo.AddSlot(s.slot)
 	c.Assert(err, IsNil)
 	connRef := ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}
-	err = s.testRepo.Connect(connRef)
+	err = s.testRepo.Connect(connRef, nil, nil)
 	c.Assert(err, IsNil)
 	// Connecting exactly the same thing twice succeeds without an error but does nothing.
-	err = s.testRepo.Connect(connRef)
+	err = s.testRepo.Connect(connRef, nil, nil)
 	c.Assert(err, IsNil)
 	// Only one connection is actually present.
 	c.Assert(s.testRepo.Interfaces(), DeepEquals, &Interfaces{
@@ -1123,7 +1132,7 @@ func (s *RepositorySuite) TestConnectFailsWhenSlotAndPlugAreIncompatible(c *C) {
 	c.Assert(err, IsNil)
 	// Connecting a plug to an incompatible slot fails with an appropriate error
 	connRef := ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}
-	err = s.testRepo.Connect(connRef)
+	err = s.testRepo.Connect(connRef, nil, nil)
 	c.Assert(err, ErrorMatches, `cannot connect plug "consumer:plug" \(interface "other-interface"\) to "producer:slot" \(interface "interface"\)`)
 }
 
@@ -1134,7 +1143,7 @@ func (s *RepositorySuite) TestConnectSucceeds(c *C) {
 	c.Assert(err, IsNil)
 	// Connecting a plug works okay
 	connRef := ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}
-	err = s.testRepo.Connect(connRef)
+	err = s.testRepo.Connect(connRef, nil, nil)
 	c.Assert(err, IsNil)
 }
 
@@ -1178,7 +1187,7 @@ func (s *RepositorySuite) TestDisconnectFailsWhenNotConnected(c *C) {
 func (s *RepositorySuite) TestDisconnectSucceeds(c *C) {
 	c.Assert(s.testRepo.AddPlug(s.plug), IsNil)
 	c.Assert(s.testRepo.AddSlot(s.slot), IsNil)
-	c.Assert(s.testRepo.Connect(ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}), IsNil)
+	c.Assert(s.testRepo.Connect(ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}, nil, nil), IsNil)
 	err := s.testRepo.Disconnect(s.plug.Snap.Name(), s.plug.Name, s.slot.Snap.Name(), s.slot.Name)
 	c.Assert(err, IsNil)
 	c.Assert(s.testRepo.Interfaces(), DeepEquals, &Interfaces{
@@ -1213,7 +1222,7 @@ func (s *RepositorySuite) TestConnectedFailsWithoutPlugOrSlot(c *C) {
 func (s *RepositorySuite) TestConnectedFindsConnections(c *C) {
 	c.Assert(s.testRepo.AddPlug(s.plug), IsNil)
 	c.Assert(s.testRepo.AddSlot(s.slot), IsNil)
-	c.Assert(s.testRepo.Connect(ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}), IsNil)
+	c.Assert(s.testRepo.Connect(ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}, nil, nil), IsNil)
 
 	conns, err := s.testRepo.Connected(s.plug.Snap.Name(), s.plug.Name)
 	c.Assert(err, IsNil)
@@ -1239,7 +1248,7 @@ func (s *RepositorySuite) TestConnectedFindsCoreSnap(c *C) {
 	}
 	c.Assert(s.testRepo.AddPlug(s.plug), IsNil)
 	c.Assert(s.testRepo.AddSlot(slot), IsNil)
-	c.Assert(s.testRepo.Connect(ConnRef{PlugRef: s.plug.Ref(), SlotRef: slot.Ref()}), IsNil)
+	c.Assert(s.testRepo.Connect(ConnRef{PlugRef: s.plug.Ref(), SlotRef: slot.Ref()}, nil, nil), IsNil)
 
 	conns, err := s.testRepo.Connected("", s.slot.Name)
 	c.Assert(err, IsNil)
@@ -1253,7 +1262,7 @@ func (s *RepositorySuite) TestConnectedFindsCoreSnap(c *C) {
 func (s *RepositorySuite) TestDisconnectAll(c *C) {
 	c.Assert(s.testRepo.AddPlug(s.plug), IsNil)
 	c.Assert(s.testRepo.AddSlot(s.slot), IsNil)
-	c.Assert(s.testRepo.Connect(ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}), IsNil)
+	c.Assert(s.testRepo.Connect(ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}, nil, nil), IsNil)
 
 	conns := []ConnRef{{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}}
 	s.testRepo.DisconnectAll(conns)
@@ -1272,7 +1281,7 @@ func (s *RepositorySuite) TestInterfacesSmokeTest(c *C) {
 	c.Assert(err, IsNil)
 	// After connecting the result is as expected
 	connRef := ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}
-	err = s.testRepo.Connect(connRef)
+	err = s.testRepo.Connect(connRef, nil, nil)
 	c.Assert(err, IsNil)
 	ifaces := s.testRepo.Interfaces()
 	c.Assert(ifaces, DeepEquals, &Interfaces{
@@ -1338,7 +1347,7 @@ func (s *RepositorySuite) TestSnapSpecification(c *C) {
 
 	// Establish connection between plug and slot
 	connRef := ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}
-	err = repo.Connect(connRef)
+	err = repo.Connect(connRef, nil, nil)
 	c.Assert(err, IsNil)
 
 	// Snaps should get static and connection-specific security now
@@ -1357,6 +1366,39 @@ func (s *RepositorySuite) TestSnapSpecification(c *C) {
 	})
 }
 
+func (s *RepositorySuite) TestSnapSpecificationWithDynamicAttributes(c *C) {
+	var testSecurity SecuritySystem = "security"
+	backend := &ifacetest.TestSecurityBackend{BackendName: testSecurity}
+	var plugAttrs, slotAttrs map[string]interface{}
+	iface := &ifacetest.TestInterface{
+		InterfaceName: "interface",
+		TestConnectedPlugCallback: func(spec *ifacetest.Specification, plug *Plug, pAttrs map[string]interface{}, slot *Slot, sAttrs map[string]interface{}) error {
+			plugAttrs, slotAttrs = pAttrs, sAttrs
+			return nil
+		},
+	}
+	repo := s.emptyRepo
+	c.Assert(repo.AddBackend(backend), IsNil)
+	c.Assert(repo.AddInterface(iface), IsNil)
+	c.Assert(repo.AddPlug(s.plug), IsNil)
+	c.Assert(repo.AddSlot(s.slot), IsNil)
+	connRef := ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}
+	// dynamic attributes come back from the state as decoded JSON
+	plugDynamic := map[string]interface{}{"number": 42.0, "list": []interface{}{1.0, "a"}}
+	slotDynamic := map[string]interface{}{"attr": "other", "extra": 1.5}
+	c.Assert(repo.Connect(connRef, plugDynamic, slotDynamic), IsNil)
+
+	_, err := repo.SnapSpecification(testSecurity, s.plug.Snap.Name())
+	c.Assert(err, IsNil)
+	c.Check(plugAttrs, DeepEquals, map[string]interface{}{
+		"attr":   "value",
+		"number": int64(42),
+		"list":   []interface{}{int64(1), "a"},
+	})
+	// static attributes cannot be overridden
+	c.Check(slotAttrs, DeepEquals, map[string]interface{}{"attr": "value", "extra": 1.5})
+}
+
 func (s *RepositorySuite) TestSnapSpecificationFailureWithConnectionSnippets(c *C) {
 	var testSecurity SecuritySystem = "security"
 	backend := &ifacetest.TestSecurityBackend{BackendName: testSecurity}
@@ -1376,7 +1418,7 @@ func (s *RepositorySuite) TestSnapSpecificationFailureWithConnectionSnippets(c *
 	c.Assert(repo.AddPlug(s.plug), IsNil)
 	c.Assert(repo.AddSlot(s.slot), IsNil)
 	connRef := ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}
-	c.Assert(repo.Connect(connRef), IsNil)
+	c.Assert(repo.Connect(connRef, nil, nil), IsNil)
 
 	spec, err := repo.SnapSpecification(testSecurity, s.plug.Snap.Name())
 	c.Assert(err, ErrorMatches, "cannot compute snippet for consumer")
@@ -1405,7 +1447,7 @@ func (s *RepositorySuite) TestSnapSpecificationFailureWithPermanentSnippets(c *C
 	c.Assert(repo.AddPlug(s.plug), IsNil)
 	c.Assert(repo.AddSlot(s.slot), IsNil)
 	connRef := ConnRef{PlugRef: s.plug.Ref(), SlotRef: s.slot.Ref()}
-	c.Assert(repo.Connect(connRef), IsNil)
+	c.Assert(repo.Connect(connRef, nil, nil), IsNil)
 
 	spec, err := repo.SnapSpecification(testSecurity, s.plug.Snap.Name())
 	c.Assert(err, ErrorMatches, "cannot compute snippet for consumer")
@@ -1669,7 +1711,7 @@ func (s *AddRemoveSuite) TestRemoveSnapErrorsOnStillConnectedPlug(c *C) {
 	_, err = s.addSnap(c, testProducerYaml)
 	c.Assert(err, IsNil)
 	connRef := ConnRef{PlugRef: PlugRef{Snap: "consumer", Name: "iface"}, SlotRef: SlotRef{Snap: "producer", Name: "iface"}}
-	err = s.repo.Connect(connRef)
+	err = s.repo.Connect(connRef, nil, nil)
 	c.Assert(err, IsNil)
 	err = s.repo.RemoveSnap("consumer")
 	c.Assert(err, ErrorMatches, "cannot remove connected plug consumer.iface")
@@ -1681,7 +1723,7 @@ func (s *AddRemoveSuite) TestRemoveSnapErrorsOnStillConnectedSlot(c *C) {
 	_, err = s.addSnap(c, testProducerYaml)
 	c.Assert(err, IsNil)
 	connRef := ConnRef{PlugRef: PlugRef{Snap: "consumer", Name: "iface"}, SlotRef: SlotRef{Snap: "producer", Name: "iface"}}
-	err = s.repo.Connect(connRef)
+	err = s.repo.Connect(connRef, nil, nil)
 	c.Assert(err, IsNil)
 	err = s.repo.RemoveSnap("producer")
 	c.Assert(err, ErrorMatches, "cannot remove connected slot producer.iface")
@@ -1732,7 +1774,7 @@ func (s *DisconnectSnapSuite) TestNotConnected(c *C) {
 
 func (s *DisconnectSnapSuite) TestOutgoingConnection(c *C) {
 	connRef := ConnRef{PlugRef: PlugRef{Snap: "s1", Name: "iface-a"}, SlotRef: SlotRef{Snap: "s2", Name: "iface-a"}}
-	err := s.repo.Connect(connRef)
+	err := s.repo.Connect(connRef, nil, nil)
 	c.Assert(err, IsNil)
 	// Disconnect s1 with which has an outgoing connection to s2
 	affected, err := s.repo.DisconnectSnap("s1")
@@ -1743,7 +1785,7 @@ func (s *DisconnectSnapSuite) TestOutgoingConnection(c *C) {
 
 func (s *DisconnectSnapSuite) TestIncomingConnection(c *C) {
 	connRef := ConnRef{PlugRef: PlugRef{Snap: "s2", Name: "iface-b"}, SlotRef: SlotRef{Snap: "s1", Name: "iface-b"}}
-	err := s.repo.Connect(connRef)
+	err := s.repo.Connect(connRef, nil, nil)
 	c.Assert(err, IsNil)
 	// Disconnect s1 with which has an incoming connection from s2
 	affected, err := s.repo.DisconnectSnap("s1")
@@ -1756,10 +1798,10 @@ func (s *DisconnectSnapSuite) TestCrossConnection(c *C) {
 	// This test is symmetric wrt s1 <-> s2 connections
 	for _, snapName := range []string{"s1", "s2"} {
 		connRef1 := ConnRef{PlugRef: PlugRef{Snap: "s1", Name: "iface-a"}, SlotRef: SlotRef{Snap: "s2", Name: "iface-a"}}
-		err := s.repo.Connect(connRef1)
+		err := s.repo.Connect(connRef1, nil, nil)
 		c.Assert(err, IsNil)
 		connRef2 := ConnRef{PlugRef: PlugRef{Snap: "s2", Name: "iface-b"}, SlotRef: SlotRef{Snap: "s1", Name: "iface-b"}}
-		err = s.repo.Connect(connRef2)
+		err = s.repo.Connect(connRef2, nil, nil)
 		c.Assert(err, IsNil)
 		affected, err := s.repo.DisconnectSnap(snapName)
 		c.Assert(err, IsNil)
@@ -1883,8 +1925,8 @@ slots:
 	c.Assert(r.AddSnap(s3), IsNil)
 
 	// Connect a few things for the tests below.
-	c.Assert(r.Connect(ConnRef{PlugRef: PlugRef{Snap: "s1", Name: "i1"}, SlotRef: SlotRef{Snap: "s2", Name: "i1"}}), IsNil)
-	c.Assert(r.Connect(ConnRef{PlugRef: PlugRef{Snap: "s1", Name: "i2"}, SlotRef: SlotRef{Snap: "s3", Name: "i2"}}), IsNil)
+	c.Assert(r.Connect(ConnRef{PlugRef: PlugRef{Snap: "s1", Name: "i1"}, SlotRef: SlotRef{Snap: "s2", Name: "i1"}}, nil, nil), IsNil)
+	c.Assert(r.Connect(ConnRef{PlugRef: PlugRef{Snap: "s1", Name: "i2"}, SlotRef: SlotRef{Snap: "s3", Name: "i2"}}, nil, nil), IsNil)
 
 	// Without any names or options we get the summary of all the interfaces.
 	infos := r.Info(nil)
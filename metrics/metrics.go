@@ -0,0 +1,264 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package metrics implements the counters and gauges snapd keeps about
+// its own health, and their exposition in the Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TextContentType is the content type of the output of WriteText.
+const TextContentType = "text/plain; version=0.0.4"
+
+type metricType string
+
+const (
+	counterType metricType = "counter"
+	gaugeType   metricType = "gauge"
+	summaryType metricType = "summary"
+)
+
+type sample struct {
+	labelValues []string
+	value       float64
+	// count is used only by summaries
+	count uint64
+}
+
+type metric struct {
+	name       string
+	help       string
+	typ        metricType
+	labelNames []string
+
+	mu      sync.Mutex
+	samples map[string]*sample
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]*metric)
+)
+
+func register(name, help string, typ metricType, labelNames []string) *metric {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("internal error: metric %q registered twice", name))
+	}
+	m := &metric{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: labelNames,
+		samples:    make(map[string]*sample),
+	}
+	registry[name] = m
+	return m
+}
+
+// sample returns the sample for the label values, creating it if
+// needed. It must be called with m.mu held.
+func (m *metric) sample(labelValues []string) *sample {
+	if len(labelValues) != len(m.labelNames) {
+		panic(fmt.Sprintf("internal error: metric %q expects %d label values, got %d", m.name, len(m.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\x00")
+	s := m.samples[key]
+	if s == nil {
+		s = &sample{labelValues: append([]string(nil), labelValues...)}
+		m.samples[key] = s
+	}
+	return s
+}
+
+func (m *metric) add(v float64, labelValues []string) {
+	m.mu.Lock()
+	m.sample(labelValues).value += v
+	m.mu.Unlock()
+}
+
+func (m *metric) value(labelValues []string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sample(labelValues).value
+}
+
+// A Counter is a metric that only goes up, like a number of requests.
+type Counter struct{ m *metric }
+
+// NewCounter registers a new counter with the given name, help text
+// and label names.
+func NewCounter(name, help string, labelNames ...string) *Counter {
+	return &Counter{register(name, help, counterType, labelNames)}
+}
+
+// Inc increments the counter for the given label values by one.
+func (c *Counter) Inc(labelValues ...string) {
+	c.m.add(1, labelValues)
+}
+
+// Add increments the counter for the given label values by v, which
+// must not be negative.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("internal error: counter %q cannot decrease", c.m.name))
+	}
+	c.m.add(v, labelValues)
+}
+
+// Value returns the current value of the counter for the given label values.
+func (c *Counter) Value(labelValues ...string) float64 {
+	return c.m.value(labelValues)
+}
+
+// A Gauge is a metric that can go up and down, like a number of
+// pending tasks.
+type Gauge struct{ m *metric }
+
+// NewGauge registers a new gauge with the given name, help text and
+// label names.
+func NewGauge(name, help string, labelNames ...string) *Gauge {
+	return &Gauge{register(name, help, gaugeType, labelNames)}
+}
+
+// Set sets the gauge for the given label values to v.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.m.mu.Lock()
+	g.m.sample(labelValues).value = v
+	g.m.mu.Unlock()
+}
+
+// Add adds v, which can be negative, to the gauge for the given label values.
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.m.add(v, labelValues)
+}
+
+// Reset forgets all the label values the gauge was set for.
+func (g *Gauge) Reset() {
+	g.m.mu.Lock()
+	g.m.samples = make(map[string]*sample)
+	g.m.mu.Unlock()
+}
+
+// Value returns the current value of the gauge for the given label values.
+func (g *Gauge) Value(labelValues ...string) float64 {
+	return g.m.value(labelValues)
+}
+
+// A Summary tracks the count and sum of observations, like request
+// durations.
+type Summary struct{ m *metric }
+
+// NewSummary registers a new summary with the given name, help text
+// and label names.
+func NewSummary(name, help string, labelNames ...string) *Summary {
+	return &Summary{register(name, help, summaryType, labelNames)}
+}
+
+// Observe records the observation v for the given label values.
+func (s *Summary) Observe(v float64, labelValues ...string) {
+	s.m.mu.Lock()
+	sample := s.m.sample(labelValues)
+	sample.value += v
+	sample.count++
+	s.m.mu.Unlock()
+}
+
+// Count returns the number of observations for the given label values.
+func (s *Summary) Count(labelValues ...string) uint64 {
+	s.m.mu.Lock()
+	defer s.m.mu.Unlock()
+	return s.m.sample(labelValues).count
+}
+
+// Sum returns the sum of the observations for the given label values.
+func (s *Summary) Sum(labelValues ...string) float64 {
+	return s.m.value(labelValues)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (m *metric) labels(labelValues []string) string {
+	if len(m.labelNames) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(m.labelNames))
+	for i, name := range m.labelNames {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, labelValueEscaper.Replace(labelValues[i]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func (m *metric) writeText(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", m.name, strings.Replace(m.help, "\n", " ", -1))
+	fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.typ)
+
+	keys := make([]string, 0, len(m.samples))
+	for key := range m.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := m.samples[key]
+		labels := m.labels(s.labelValues)
+		if m.typ == summaryType {
+			fmt.Fprintf(w, "%s_sum%s %s\n", m.name, labels, formatValue(s.value))
+			fmt.Fprintf(w, "%s_count%s %d\n", m.name, labels, s.count)
+			continue
+		}
+		fmt.Fprintf(w, "%s%s %s\n", m.name, labels, formatValue(s.value))
+	}
+}
+
+// WriteText writes all the metrics, in the Prometheus text format.
+func WriteText(w io.Writer) error {
+	registryMu.Lock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	metrics := make([]*metric, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		metrics[i] = registry[name]
+	}
+	registryMu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.writeText(bw)
+	}
+	return bw.Flush()
+}
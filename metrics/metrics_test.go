@@ -0,0 +1,112 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package metrics_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/metrics"
+)
+
+// Hook up check.v1 into the "go test" runner
+func Test(t *testing.T) { TestingT(t) }
+
+type metricsSuite struct {
+	restore func()
+}
+
+var _ = Suite(&metricsSuite{})
+
+func (s *metricsSuite) SetUpTest(c *C) {
+	s.restore = metrics.MockRegistry()
+}
+
+func (s *metricsSuite) TearDownTest(c *C) {
+	s.restore()
+}
+
+func (s *metricsSuite) TestCounter(c *C) {
+	counter := metrics.NewCounter("requests_total", "Number of requests.", "method")
+	counter.Inc("GET")
+	counter.Add(2, "GET")
+	counter.Inc("POST")
+
+	c.Check(counter.Value("GET"), Equals, 3.0)
+	c.Check(counter.Value("POST"), Equals, 1.0)
+	c.Check(counter.Value("PUT"), Equals, 0.0)
+	c.Check(func() { counter.Add(-1, "GET") }, PanicMatches, `internal error: counter "requests_total" cannot decrease`)
+	c.Check(func() { counter.Inc() }, PanicMatches, `internal error: metric "requests_total" expects 1 label values, got 0`)
+}
+
+func (s *metricsSuite) TestGauge(c *C) {
+	gauge := metrics.NewGauge("queue", "Queue depth.")
+	gauge.Set(5)
+	gauge.Add(-2)
+	c.Check(gauge.Value(), Equals, 3.0)
+
+	gauge.Reset()
+	var buf bytes.Buffer
+	c.Assert(metrics.WriteText(&buf), IsNil)
+	c.Check(buf.String(), Equals, "# HELP queue Queue depth.\n# TYPE queue gauge\n")
+}
+
+func (s *metricsSuite) TestSummary(c *C) {
+	summary := metrics.NewSummary("duration_seconds", "Durations.", "op")
+	summary.Observe(0.5, "a")
+	summary.Observe(1.25, "a")
+
+	c.Check(summary.Count("a"), Equals, uint64(2))
+	c.Check(summary.Sum("a"), Equals, 1.75)
+}
+
+func (s *metricsSuite) TestRegisterTwice(c *C) {
+	metrics.NewCounter("foo", "Foo.")
+	c.Check(func() { metrics.NewGauge("foo", "Foo.") }, PanicMatches, `internal error: metric "foo" registered twice`)
+}
+
+func (s *metricsSuite) TestWriteText(c *C) {
+	counter := metrics.NewCounter("requests_total", "Number of requests.", "method", "code")
+	gauge := metrics.NewGauge("queue", "Queue\ndepth.")
+	summary := metrics.NewSummary("duration_seconds", "Durations.", "op")
+
+	counter.Inc("POST", "200")
+	counter.Add(3, "GET", "200")
+	gauge.Set(1.5)
+	summary.Observe(0.25, `a "quoted"\op`)
+	summary.Observe(0.5, `a "quoted"\op`)
+
+	var buf bytes.Buffer
+	c.Assert(metrics.WriteText(&buf), IsNil)
+	c.Check(buf.String(), Equals, `# HELP duration_seconds Durations.
+# TYPE duration_seconds summary
+duration_seconds_sum{op="a \"quoted\"\\op"} 0.75
+duration_seconds_count{op="a \"quoted\"\\op"} 2
+# HELP queue Queue depth.
+# TYPE queue gauge
+queue 1.5
+# HELP requests_total Number of requests.
+# TYPE requests_total counter
+requests_total{method="GET",code="200"} 3
+requests_total{method="POST",code="200"} 1
+`)
+}
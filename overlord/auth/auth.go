@@ -24,6 +24,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"sort"
 	"strconv"
@@ -359,6 +360,9 @@ type DeviceAssertions interface {
 
 	// DeviceSessionRequestParams produces a device-session-request with the given nonce, together with other required parameters, the device serial and model assertions.
 	DeviceSessionRequestParams(nonce string) (*DeviceSessionRequestParams, error)
+
+	// ProxyStore returns the store assertion for the proxy store if one is set.
+	ProxyStore() (*asserts.Store, error)
 }
 
 var (
@@ -377,6 +381,8 @@ type AuthContext interface {
 	StoreID(fallback string) (string, error)
 
 	DeviceSessionRequestParams(nonce string) (*DeviceSessionRequestParams, error)
+
+	ProxyStoreParams(defaultURL *url.URL) (proxyStoreID string, proxyStoreURL *url.URL, err error)
 }
 
 // authContext helps keeping track of auth data in the state and exposing it.
@@ -481,3 +487,19 @@ func (ac *authContext) DeviceSessionRequestParams(nonce string) (*DeviceSessionR
 	}
 	return params, nil
 }
+
+// ProxyStoreParams returns the id and URL of the proxy store if one is set. Returns the defaultURL otherwise and id = "".
+func (ac *authContext) ProxyStoreParams(defaultURL *url.URL) (proxyStoreID string, proxyStoreURL *url.URL, err error) {
+	var sto *asserts.Store
+	if ac.deviceAsserts != nil {
+		var err error
+		sto, err = ac.deviceAsserts.ProxyStore()
+		if err != nil && err != state.ErrNoState {
+			return "", nil, err
+		}
+	}
+	if sto != nil && sto.URL() != nil {
+		return sto.Store(), sto.URL(), nil
+	}
+	return "", defaultURL, nil
+}
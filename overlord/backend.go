@@ -30,9 +30,15 @@ type overlordStateBackend struct {
 	path           string
 	ensureBefore   func(d time.Duration)
 	requestRestart func(t state.RestartType)
+
+	// journal, if set, persists checkpoints incrementally
+	journal *state.Journal
 }
 
 func (osb *overlordStateBackend) Checkpoint(data []byte) error {
+	if osb.journal != nil {
+		return osb.journal.Checkpoint(data)
+	}
 	return osutil.AtomicWriteFile(osb.path, data, 0600, 0)
 }
 
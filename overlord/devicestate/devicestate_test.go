@@ -45,6 +45,7 @@ import (
 	"github.com/snapcore/snapd/overlord"
 	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/auth"
+	"github.com/snapcore/snapd/overlord/configstate/config"
 	"github.com/snapcore/snapd/overlord/devicestate"
 	"github.com/snapcore/snapd/overlord/hookstate"
 	"github.com/snapcore/snapd/overlord/hookstate/ctlcmd"
@@ -1254,6 +1255,48 @@ func (s *deviceMgrSuite) TestDeviceAssertionsDeviceSessionRequestParams(c *C) {
 	c.Check(sessReq.Nonce(), Equals, "NONCE-1")
 }
 
+func (s *deviceMgrSuite) TestProxyStore(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// nothing set
+	_, err := devicestate.ProxyStore(s.state)
+	c.Check(err, Equals, state.ErrNoState)
+
+	// proxy store set but assertion missing
+	tr := config.NewTransaction(s.state)
+	tr.Set("core", "proxy.store", "foo")
+	tr.Commit()
+
+	_, err = devicestate.ProxyStore(s.state)
+	c.Check(err, ErrorMatches, `cannot find store assertion for proxy store "foo"`)
+
+	// have the store assertion
+	operatorAcct := assertstest.NewAccount(s.storeSigning, "foo-operator", nil, "")
+	err = assertstate.Add(s.state, operatorAcct)
+	c.Assert(err, IsNil)
+	stoAs, err := s.storeSigning.Sign(asserts.StoreType, map[string]interface{}{
+		"store":       "foo",
+		"operator-id": operatorAcct.AccountID(),
+		"url":         "http://foo.internal",
+		"timestamp":   time.Now().Format(time.RFC3339),
+	}, nil, "")
+	c.Assert(err, IsNil)
+	err = assertstate.Add(s.state, stoAs)
+	c.Assert(err, IsNil)
+
+	sto, err := devicestate.ProxyStore(s.state)
+	c.Assert(err, IsNil)
+	c.Check(sto.Store(), Equals, "foo")
+	c.Check(sto.URL().String(), Equals, "http://foo.internal")
+
+	s.state.Unlock()
+	sto, err = s.mgr.ProxyStore()
+	s.state.Lock()
+	c.Assert(err, IsNil)
+	c.Check(sto.Store(), Equals, "foo")
+}
+
 func (s *deviceMgrSuite) TestDeviceManagerEnsureSeedYamlAlreadySeeded(c *C) {
 	s.state.Lock()
 	s.state.Set("seeded", true)
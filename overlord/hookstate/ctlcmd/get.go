@@ -258,6 +258,46 @@ func attributesTask(context *hookstate.Context) (*state.Task, error) {
 	return attrsTask, nil
 }
 
+// staticAttributes returns the attributes of the plug or slot side of the
+// connection being made by the given task, as specified in the snap details.
+func staticAttributes(attrsTask *state.Task, side string) (map[string]interface{}, error) {
+	var static map[string]interface{}
+	err := attrsTask.Get(side+"-static", &static)
+	if err == state.ErrNoState {
+		// tasks made before the dynamic attributes were kept apart
+		err = attrsTask.Get(side+"-attrs", &static)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(i18n.G("internal error: cannot get %s from appropriate task"), side+"-static")
+	}
+	return static, nil
+}
+
+// dynamicAttributes returns the attributes of the plug or slot side of the
+// connection being made by the given task, set by the prepare hooks so far.
+func dynamicAttributes(attrsTask *state.Task, side string) (map[string]interface{}, error) {
+	dynamic := make(map[string]interface{})
+	if err := attrsTask.Get(side+"-dynamic", &dynamic); err != nil && err != state.ErrNoState {
+		return nil, fmt.Errorf(i18n.G("internal error: cannot get %s from appropriate task"), side+"-dynamic")
+	}
+	return dynamic, nil
+}
+
+// connectionAttributes returns the static attributes of the plug or slot side
+// of the connection being made by the given task, together with the dynamic
+// ones set by the prepare hooks so far.
+func connectionAttributes(attrsTask *state.Task, side string) (map[string]interface{}, error) {
+	static, err := staticAttributes(attrsTask, side)
+	if err != nil {
+		return nil, err
+	}
+	dynamic, err := dynamicAttributes(attrsTask, side)
+	if err != nil {
+		return nil, err
+	}
+	return interfaces.MergeAttrs(static, dynamic), nil
+}
+
 func (c *getCommand) getInterfaceSetting(context *hookstate.Context, plugOrSlot string) error {
 	// Make sure get :<plug|slot> is only supported during the execution of interface hooks
 	hookType, err := interfaceHookType(context.HookName())
@@ -280,20 +320,20 @@ func (c *getCommand) getInterfaceSetting(context *hookstate.Context, plugOrSlot
 		return err
 	}
 
-	var which string
+	var side string
 	if c.ForcePlugSide || (isPlugSide && !c.ForceSlotSide) {
-		which = "plug-attrs"
+		side = "plug"
 	} else {
-		which = "slot-attrs"
+		side = "slot"
 	}
 
 	st := context.State()
 	st.Lock()
 	defer st.Unlock()
 
-	attributes := make(map[string]interface{})
-	if err = attrsTask.Get(which, &attributes); err != nil {
-		return fmt.Errorf(i18n.G("internal error: cannot get %s from appropriate task"), which)
+	attributes, err := connectionAttributes(attrsTask, side)
+	if err != nil {
+		return err
 	}
 
 	return c.printValues(func(key string) (interface{}, bool, error) {
@@ -166,8 +166,9 @@ func (s *getAttrSuite) SetUpTest(c *C) {
 	plugAttrs["aattr"] = "foo"
 	plugAttrs["baz"] = []string{"a", "b"}
 	slotAttrs["battr"] = "bar"
-	attrsTask.Set("plug-attrs", plugAttrs)
-	attrsTask.Set("slot-attrs", slotAttrs)
+	attrsTask.Set("plug-static", plugAttrs)
+	attrsTask.Set("plug-dynamic", map[string]interface{}{"dyn": int64(42)})
+	attrsTask.Set("slot-static", slotAttrs)
 	ch.AddTask(attrsTask)
 	state.Unlock()
 
@@ -223,6 +224,39 @@ func (s *getAttrSuite) TestGetPlugAttributesInPlugHook(c *C) {
 	c.Check(string(stderr), Equals, "")
 }
 
+func (s *getAttrSuite) TestGetDynamicPlugAttribute(c *C) {
+	stdout, stderr, err := ctlcmd.Run(s.mockPlugHookContext, []string{"get", ":aplug", "dyn"})
+	c.Check(err, IsNil)
+	c.Check(string(stdout), Equals, "42\n")
+	c.Check(string(stderr), Equals, "")
+
+	stdout, stderr, err = ctlcmd.Run(s.mockSlotHookContext, []string{"get", "--plug", ":bslot", "dyn"})
+	c.Check(err, IsNil)
+	c.Check(string(stdout), Equals, "42\n")
+	c.Check(string(stderr), Equals, "")
+}
+
+func (s *getAttrSuite) TestGetAttributesOfOlderTask(c *C) {
+	st := s.mockPlugHookContext.State()
+	s.mockPlugHookContext.Lock()
+	var attrsTaskID string
+	c.Assert(s.mockPlugHookContext.Get("attrs-task", &attrsTaskID), IsNil)
+	s.mockPlugHookContext.Unlock()
+
+	// tasks made before the dynamic attributes were kept apart
+	st.Lock()
+	attrsTask := st.Task(attrsTaskID)
+	attrsTask.Clear("plug-static")
+	attrsTask.Clear("plug-dynamic")
+	attrsTask.Set("plug-attrs", map[string]interface{}{"aattr": "old"})
+	st.Unlock()
+
+	stdout, stderr, err := ctlcmd.Run(s.mockPlugHookContext, []string{"get", ":aplug", "aattr"})
+	c.Check(err, IsNil)
+	c.Check(string(stdout), Equals, "old\n")
+	c.Check(string(stderr), Equals, "")
+}
+
 func (s *getAttrSuite) TestGetSlotAttributesInSlotHook(c *C) {
 	stdout, stderr, err := ctlcmd.Run(s.mockSlotHookContext, []string{"get", ":bslot", "battr"})
 	c.Check(err, IsNil)
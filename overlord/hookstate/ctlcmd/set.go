@@ -51,10 +51,13 @@ Nested values may be modified via a dotted path:
 
     $ snapctl set author.name=frank
 
-Plug and slot attributes may be set in the respective prepare and connect hooks by
+Plug and slot attributes may be set in the respective prepare hooks by
 naming the respective plug or slot:
 
     $ snapctl set :myplug path=/dev/ttyS0
+
+Such dynamic attributes are kept with the connection. Attributes
+specified in the snap details cannot be changed this way.
 `)
 
 func init() {
@@ -130,20 +133,24 @@ func (s *setCommand) setInterfaceSetting(context *hookstate.Context, plugOrSlot
 		return err
 	}
 
-	var which string
+	var side string
 	if hookType == preparePlugHook {
-		which = "plug-attrs"
+		side = "plug"
 	} else {
-		which = "slot-attrs"
+		side = "slot"
 	}
 
 	st := context.State()
 	st.Lock()
 	defer st.Unlock()
 
-	attributes := make(map[string]interface{})
-	if err := attrsTask.Get(which, &attributes); err != nil {
-		return fmt.Errorf(i18n.G("internal error: cannot get %s from appropriate task"), which)
+	static, err := staticAttributes(attrsTask, side)
+	if err != nil {
+		return err
+	}
+	dynamic, err := dynamicAttributes(attrsTask, side)
+	if err != nil {
+		return err
 	}
 
 	for _, attrValue := range s.Positional.ConfValues {
@@ -151,16 +158,19 @@ func (s *setCommand) setInterfaceSetting(context *hookstate.Context, plugOrSlot
 		if len(parts) != 2 {
 			return fmt.Errorf(i18n.G("invalid parameter: %q (want key=value)"), attrValue)
 		}
+		if _, ok := static[parts[0]]; ok {
+			return fmt.Errorf(i18n.G("cannot change attribute %q as it was statically specified in the snap details"), parts[0])
+		}
 
 		var value interface{}
 		if err := jsonutil.DecodeWithNumber(strings.NewReader(parts[1]), &value); err != nil {
 			// Not valid JSON, save the string as-is
 			value = parts[1]
 		}
-		attributes[parts[0]] = value
+		dynamic[parts[0]] = value
 	}
 
-	attrsTask.Set(which, attributes)
+	attrsTask.Set(side+"-dynamic", dynamic)
 	return nil
 }
 
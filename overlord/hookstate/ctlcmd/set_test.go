@@ -180,9 +180,8 @@ func (s *setAttrSuite) SetUpTest(c *C) {
 	attrsTask := state.NewTask("connect-task", "my connect task")
 	attrsTask.Set("plug", &interfaces.PlugRef{Snap: "a", Name: "aplug"})
 	attrsTask.Set("slot", &interfaces.SlotRef{Snap: "b", Name: "bslot"})
-	attrs := make(map[string]interface{})
-	attrsTask.Set("plug-attrs", attrs)
-	attrsTask.Set("slot-attrs", attrs)
+	attrsTask.Set("plug-static", map[string]interface{}{"lorem": "ipsum"})
+	attrsTask.Set("slot-static", map[string]interface{}{"lorem": "ipsum"})
 	ch.AddTask(attrsTask)
 	state.Unlock()
 
@@ -232,7 +231,7 @@ func (s *setAttrSuite) TestSetPlugAttributesInPlugHook(c *C) {
 	st.Lock()
 	defer st.Unlock()
 	attrs := make(map[string]interface{})
-	err = attrsTask.Get("plug-attrs", &attrs)
+	err = attrsTask.Get("plug-dynamic", &attrs)
 	c.Assert(err, IsNil)
 	c.Check(attrs["foo"], Equals, "bar")
 }
@@ -249,11 +248,26 @@ func (s *setAttrSuite) TestSetSlotAttributesInSlotHook(c *C) {
 	st.Lock()
 	defer st.Unlock()
 	attrs := make(map[string]interface{})
-	err = attrsTask.Get("slot-attrs", &attrs)
+	err = attrsTask.Get("slot-dynamic", &attrs)
 	c.Assert(err, IsNil)
 	c.Check(attrs["foo"], Equals, "bar")
 }
 
+func (s *setAttrSuite) TestSetStaticAttributeFails(c *C) {
+	stdout, stderr, err := ctlcmd.Run(s.mockPlugHookContext, []string{"set", ":aplug", "lorem=dolor"})
+	c.Check(err, ErrorMatches, `cannot change attribute "lorem" as it was statically specified in the snap details`)
+	c.Check(string(stdout), Equals, "")
+	c.Check(string(stderr), Equals, "")
+
+	attrsTask, err := ctlcmd.AttributesTask(s.mockPlugHookContext)
+	c.Assert(err, IsNil)
+	st := s.mockPlugHookContext.State()
+	st.Lock()
+	defer st.Unlock()
+	var attrs map[string]interface{}
+	c.Check(attrsTask.Get("plug-dynamic", &attrs), Equals, state.ErrNoState)
+}
+
 func (s *setAttrSuite) TestPlugOrSlotEmpty(c *C) {
 	stdout, stderr, err := ctlcmd.Run(s.mockPlugHookContext, []string{"set", ":", "foo=bar"})
 	c.Check(err.Error(), Equals, "plug or slot name not provided")
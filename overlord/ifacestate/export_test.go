@@ -19,9 +19,11 @@ package ifacestate
 
 import (
 	"errors"
+	"time"
 
 	"gopkg.in/tomb.v2"
 
+	"github.com/snapcore/snapd/overlord/ifacestate/udevmonitor"
 	"github.com/snapcore/snapd/overlord/state"
 )
 
@@ -44,3 +46,15 @@ func (m *InterfaceManager) AddForeignTaskHandlers() {
 	}
 	m.runner.AddHandler("error-trigger", erroringHandler, nil)
 }
+
+func MockCreateUDevMonitor(fn func(udevmonitor.DeviceAddedFunc, udevmonitor.DeviceRemovedFunc) udevmonitor.Interface) (restore func()) {
+	old := createUDevMonitor
+	createUDevMonitor = fn
+	return func() { createUDevMonitor = old }
+}
+
+func MockTimeNow(fn func() time.Time) (restore func()) {
+	old := timeNow
+	timeNow = fn
+	return func() { timeNow = old }
+}
@@ -26,10 +26,8 @@ import (
 
 	"gopkg.in/tomb.v2"
 
-	"github.com/snapcore/snapd/asserts"
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/policy"
-	"github.com/snapcore/snapd/overlord/assertstate"
 	"github.com/snapcore/snapd/overlord/snapstate"
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/release"
@@ -154,11 +152,15 @@ func (m *InterfaceManager) setupProfilesForSnap(task *state.Task, _ *tomb.Tomb,
 			return err
 		}
 	}
+	if snapInfo.Type == snap.TypeOS {
+		// the slots of hotplugged devices are not part of the snap
+		if err := m.addHotplugSlots(snapInfo); err != nil {
+			return err
+		}
+	}
 	if err := m.reloadConnections(snapName); err != nil {
 		return err
 	}
-	// FIXME: here we should not reconnect auto-connect plug/slot
-	// pairs that were explicitly disconnected by the user
 	connectedSnaps, err := m.autoConnect(task, snapName, nil)
 	if err != nil {
 		return err
@@ -292,6 +294,8 @@ func (m *InterfaceManager) doDiscardConns(task *state.Task, _ *tomb.Tomb) error
 	if err != nil {
 		return err
 	}
+	// Connections that were explicitly disconnected are forgotten as
+	// well, a snap installed again with the same name starts afresh.
 	removed := make(map[string]connState)
 	for id := range conns {
 		connRef, err := interfaces.ParseConnRef(id)
@@ -353,53 +357,47 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) error {
 	if plug == nil {
 		return fmt.Errorf("snap %q has no %q plug", connRef.PlugRef.Snap, connRef.PlugRef.Name)
 	}
-	var plugDecl *asserts.SnapDeclaration
-	if plug.Snap.SnapID != "" {
-		var err error
-		plugDecl, err = assertstate.SnapDeclaration(st, plug.Snap.SnapID)
-		if err != nil {
-			return fmt.Errorf("cannot find snap declaration for %q: %v", plug.Snap.Name(), err)
-		}
-	}
-
 	slot := m.repo.Slot(connRef.SlotRef.Snap, connRef.SlotRef.Name)
 	if slot == nil {
 		return fmt.Errorf("snap %q has no %q slot", connRef.SlotRef.Snap, connRef.SlotRef.Name)
 	}
-	var slotDecl *asserts.SnapDeclaration
-	if slot.Snap.SnapID != "" {
-		var err error
-		slotDecl, err = assertstate.SnapDeclaration(st, slot.Snap.SnapID)
-		if err != nil {
-			return fmt.Errorf("cannot find snap declaration for %q: %v", slot.Snap.Name(), err)
-		}
-	}
 
-	baseDecl, err := assertstate.BaseDeclaration(st)
+	ic, err := connectCandidate(st, plug, slot)
 	if err != nil {
-		return fmt.Errorf("internal error: cannot find base declaration: %v", err)
-	}
-
-	// check the connection against the declarations' rules
-	ic := policy.ConnectCandidate{
-		Plug:                plug.PlugInfo,
-		PlugSnapDeclaration: plugDecl,
-		Slot:                slot.SlotInfo,
-		SlotSnapDeclaration: slotDecl,
-		BaseDeclaration:     baseDecl,
+		return err
 	}
 
 	// if either of plug or slot snaps don't have a declaration it
 	// means they were installed with "dangerous", so the security
 	// check should be skipped at this point.
-	if plugDecl != nil && slotDecl != nil {
+	if ic.PlugSnapDeclaration != nil && ic.SlotSnapDeclaration != nil {
+		ic.Trace = &policy.Trace{}
 		err = ic.Check()
 		if err != nil {
+			for _, step := range ic.Trace.Steps {
+				task.Logf("policy: %s", step)
+			}
 			return err
 		}
 	}
 
-	err = m.repo.Connect(connRef)
+	// attributes set by the prepare hooks via snapctl
+	var plugDynamicAttrs, slotDynamicAttrs map[string]interface{}
+	if err := task.Get("plug-dynamic", &plugDynamicAttrs); err != nil && err != state.ErrNoState {
+		return err
+	}
+	if err := task.Get("slot-dynamic", &slotDynamicAttrs); err != nil && err != state.ErrNoState {
+		return err
+	}
+	iface := m.repo.Interface(plug.Interface)
+	if err := plug.CheckDynamicAttrs(iface, plugDynamicAttrs); err != nil {
+		return err
+	}
+	if err := slot.CheckDynamicAttrs(iface, slotDynamicAttrs); err != nil {
+		return err
+	}
+
+	err = m.repo.Connect(connRef, plugDynamicAttrs, slotDynamicAttrs)
 	if err != nil {
 		return err
 	}
@@ -423,7 +421,11 @@ func (m *InterfaceManager) doConnect(task *state.Task, _ *tomb.Tomb) error {
 		return err
 	}
 
-	conns[connRef.ID()] = connState{Interface: plug.Interface}
+	conns[connRef.ID()] = connState{
+		Interface:        plug.Interface,
+		DynamicPlugAttrs: plugDynamicAttrs,
+		DynamicSlotAttrs: slotDynamicAttrs,
+	}
 	setConns(st, conns)
 
 	return nil
@@ -473,8 +475,13 @@ func (m *InterfaceManager) doDisconnect(task *state.Task, _ *tomb.Tomb) error {
 		}
 	}
 
+	// Remember the connection was undone on purpose, so that it is not
+	// made again automatically, e.g. when either snap is refreshed.
 	conn := interfaces.ConnRef{PlugRef: plugRef, SlotRef: slotRef}
-	delete(conns, conn.ID())
+	if cs, ok := conns[conn.ID()]; ok {
+		cs.Undesired = true
+		conns[conn.ID()] = cs
+	}
 
 	setConns(st, conns)
 	return nil
@@ -194,7 +194,10 @@ func (m *InterfaceManager) reloadConnections(snapName string) error {
 	if err != nil {
 		return err
 	}
-	for id := range conns {
+	for id, conn := range conns {
+		if conn.Undesired {
+			continue
+		}
 		connRef, err := interfaces.ParseConnRef(id)
 		if err != nil {
 			return err
@@ -202,7 +205,12 @@ func (m *InterfaceManager) reloadConnections(snapName string) error {
 		if snapName != "" && connRef.PlugRef.Snap != snapName && connRef.SlotRef.Snap != snapName {
 			continue
 		}
-		if err := m.repo.Connect(connRef); err != nil {
+		// connections of hotplug slots are restored when their
+		// device is plugged in
+		if m.repo.Slot(connRef.SlotRef.Snap, connRef.SlotRef.Name) == nil && isHotplugSlot(m.state, connRef.SlotRef) {
+			continue
+		}
+		if err := m.repo.Connect(connRef, conn.DynamicPlugAttrs, conn.DynamicSlotAttrs); err != nil {
 			logger.Noticef("%s", err)
 		}
 	}
@@ -215,7 +223,9 @@ func (m *InterfaceManager) setupSnapSecurity(task *state.Task, snapInfo *snap.In
 
 	for _, backend := range m.repo.Backends() {
 		st.Unlock()
+		span := task.StartSpan("setup-"+string(backend.Name()), fmt.Sprintf("Setup %s for snap %q", backend.Name(), snapName))
 		err := backend.Setup(snapInfo, opts, m.repo)
+		span.Stop()
 		st.Lock()
 		if err != nil {
 			task.Errorf("cannot setup %s for snap %q: %s", backend.Name(), snapName, err)
@@ -241,7 +251,15 @@ func (m *InterfaceManager) removeSnapSecurity(task *state.Task, snapName string)
 
 type connState struct {
 	Auto      bool   `json:"auto,omitempty"`
+	ByGadget  bool   `json:"by-gadget,omitempty"`
 	Interface string `json:"interface,omitempty"`
+	// Undesired is set for connections the user explicitly
+	// disconnected, which must not be made again automatically.
+	Undesired bool `json:"undesired,omitempty"`
+	// DynamicPlugAttrs and DynamicSlotAttrs are the attributes set by
+	// the prepare hooks of the plug and slot snaps.
+	DynamicPlugAttrs map[string]interface{} `json:"plug-dynamic,omitempty"`
+	DynamicSlotAttrs map[string]interface{} `json:"slot-dynamic,omitempty"`
 }
 
 type autoConnectChecker struct {
@@ -308,6 +326,41 @@ func (c *autoConnectChecker) check(plug *interfaces.Plug, slot *interfaces.Slot)
 	return ic.CheckAutoConnect() == nil
 }
 
+// connectCandidate returns the policy candidate for connecting the given
+// plug and slot, carrying the declarations of their snaps.
+func connectCandidate(st *state.State, plug *interfaces.Plug, slot *interfaces.Slot) (*policy.ConnectCandidate, error) {
+	var plugDecl *asserts.SnapDeclaration
+	if plug.Snap.SnapID != "" {
+		var err error
+		plugDecl, err = assertstate.SnapDeclaration(st, plug.Snap.SnapID)
+		if err != nil {
+			return nil, fmt.Errorf("cannot find snap declaration for %q: %v", plug.Snap.Name(), err)
+		}
+	}
+
+	var slotDecl *asserts.SnapDeclaration
+	if slot.Snap.SnapID != "" {
+		var err error
+		slotDecl, err = assertstate.SnapDeclaration(st, slot.Snap.SnapID)
+		if err != nil {
+			return nil, fmt.Errorf("cannot find snap declaration for %q: %v", slot.Snap.Name(), err)
+		}
+	}
+
+	baseDecl, err := assertstate.BaseDeclaration(st)
+	if err != nil {
+		return nil, fmt.Errorf("internal error: cannot find base declaration: %v", err)
+	}
+
+	return &policy.ConnectCandidate{
+		Plug:                plug.PlugInfo,
+		PlugSnapDeclaration: plugDecl,
+		Slot:                slot.SlotInfo,
+		SlotSnapDeclaration: slotDecl,
+		BaseDeclaration:     baseDecl,
+	}, nil
+}
+
 // autoConnect connects the given snap to viable candidates returning the list
 // of connected snap names.  The blacklist can prevent auto-connection to
 // specific interfaces (blacklist entries are plug or slot names).
@@ -360,12 +413,14 @@ func (m *InterfaceManager) autoConnect(task *state.Task, snapName string, blackl
 		connRef := interfaces.ConnRef{PlugRef: plug.Ref(), SlotRef: slot.Ref()}
 		key := connRef.ID()
 		if _, ok := conns[key]; ok {
-			// Suggested connection already exist so don't clobber it.
+			// Suggested connection already exist, or was explicitly
+			// disconnected, so don't clobber it.
 			// NOTE: we don't log anything here as this is a normal and common condition.
 			continue
 		}
-		if err := m.repo.Connect(connRef); err != nil {
+		if err := m.repo.Connect(connRef, nil, nil); err != nil {
 			task.Logf("cannot auto connect %s to %s: %s (plug auto-connection)", connRef.PlugRef, connRef.SlotRef, err)
+			task.State().Warnf("cannot auto-connect %s to %s: %s", connRef.PlugRef, connRef.SlotRef, err)
 			continue
 		}
 		affectedSnapNames = append(affectedSnapNames, connRef.PlugRef.Snap)
@@ -400,12 +455,14 @@ func (m *InterfaceManager) autoConnect(task *state.Task, snapName string, blackl
 			connRef := interfaces.ConnRef{PlugRef: plug.Ref(), SlotRef: slot.Ref()}
 			key := connRef.ID()
 			if _, ok := conns[key]; ok {
-				// Suggested connection already exist so don't clobber it.
+				// Suggested connection already exist, or was explicitly
+				// disconnected, so don't clobber it.
 				// NOTE: we don't log anything here as this is a normal and common condition.
 				continue
 			}
-			if err := m.repo.Connect(connRef); err != nil {
+			if err := m.repo.Connect(connRef, nil, nil); err != nil {
 				task.Logf("cannot auto connect %s to %s: %s (slot auto-connection)", connRef.PlugRef, connRef.SlotRef, err)
+				task.State().Warnf("cannot auto-connect %s to %s: %s", connRef.PlugRef, connRef.SlotRef, err)
 				continue
 			}
 			affectedSnapNames = append(affectedSnapNames, connRef.PlugRef.Snap)
@@ -0,0 +1,472 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/i18n"
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/hotplug"
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/ifacestate/udevmonitor"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// hotplugSlotDef is the state of a slot created on the core snap for a
+// hotplugged device. It is kept after the device is removed, so that the
+// slot gets the same name and its connections back when the device is
+// plugged in again.
+type hotplugSlotDef struct {
+	Name        string                 `json:"name"`
+	Interface   string                 `json:"interface"`
+	Label       string                 `json:"label,omitempty"`
+	StaticAttrs map[string]interface{} `json:"static-attrs,omitempty"`
+	HotplugKey  string                 `json:"hotplug-key"`
+	HotplugGone bool                   `json:"hotplug-gone,omitempty"`
+}
+
+func getHotplugSlots(st *state.State) (map[string]*hotplugSlotDef, error) {
+	var slots map[string]*hotplugSlotDef
+	err := st.Get("hotplug-slots", &slots)
+	if err != nil && err != state.ErrNoState {
+		return nil, fmt.Errorf("cannot obtain data about hotplug slots: %s", err)
+	}
+	if slots == nil {
+		slots = make(map[string]*hotplugSlotDef)
+	}
+	return slots, nil
+}
+
+func setHotplugSlots(st *state.State, slots map[string]*hotplugSlotDef) {
+	st.Set("hotplug-slots", slots)
+}
+
+// findHotplugSlot returns the slot made for the given device by the given
+// interface, or nil.
+func findHotplugSlot(slots map[string]*hotplugSlotDef, hotplugKey, ifaceName string) *hotplugSlotDef {
+	for _, slot := range slots {
+		if slot.HotplugKey == hotplugKey && slot.Interface == ifaceName {
+			return slot
+		}
+	}
+	return nil
+}
+
+// hotplugSlotName returns a name for a new hotplug slot of the core snap,
+// based on the suggested one and not clashing with other plugs and slots.
+func hotplugSlotName(coreInfo *snap.Info, slots map[string]*hotplugSlotDef, suggested string) string {
+	taken := func(name string) bool {
+		_, isPlug := coreInfo.Plugs[name]
+		_, isSlot := coreInfo.Slots[name]
+		_, isHotplugSlot := slots[name]
+		return isPlug || isSlot || isHotplugSlot
+	}
+	name := suggested
+	for i := 1; taken(name); i++ {
+		name = fmt.Sprintf("%s-%d", suggested, i)
+	}
+	return name
+}
+
+// hotplugSlot returns the slot of the core snap described by def.
+func hotplugSlot(coreInfo *snap.Info, def *hotplugSlotDef) *interfaces.Slot {
+	return &interfaces.Slot{SlotInfo: &snap.SlotInfo{
+		Snap:      coreInfo,
+		Name:      def.Name,
+		Interface: def.Interface,
+		Label:     def.Label,
+		Attrs:     def.StaticAttrs,
+	}}
+}
+
+// addHotplugSlots adds back to the repository the slots of the devices
+// that are still plugged in, once the core snap was added to it again.
+func (m *InterfaceManager) addHotplugSlots(coreInfo *snap.Info) error {
+	slots, err := getHotplugSlots(m.state)
+	if err != nil {
+		return err
+	}
+	present := make(map[string]bool, len(m.hotplugDevices))
+	for _, hotplugKey := range m.hotplugDevices {
+		present[hotplugKey] = true
+	}
+	names := make([]string, 0, len(slots))
+	for name := range slots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		def := slots[name]
+		if def.HotplugGone || !present[def.HotplugKey] || m.repo.Slot(coreInfo.Name(), name) != nil {
+			continue
+		}
+		if err := m.repo.AddSlot(hotplugSlot(coreInfo, def)); err != nil {
+			logger.Noticef("cannot restore hotplug slot %q: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// isHotplugSlot returns whether the given slot was made for a hotplugged
+// device.
+func isHotplugSlot(st *state.State, slotRef interfaces.SlotRef) bool {
+	coreInfo, err := snapstate.CoreInfo(st)
+	if err != nil || coreInfo.Name() != slotRef.Snap {
+		return false
+	}
+	slots, err := getHotplugSlots(st)
+	if err != nil {
+		return false
+	}
+	_, ok := slots[slotRef.Name]
+	return ok
+}
+
+func sortedSnapNames(snapNames map[string]bool) []string {
+	result := make([]string, 0, len(snapNames))
+	for snapName := range snapNames {
+		result = append(result, snapName)
+	}
+	sort.Strings(result)
+	return result
+}
+
+var (
+	createUDevMonitor = udevmonitor.New
+	timeNow           = time.Now
+)
+
+var (
+	// hotplugCheckInterval is how often the experimental.hotplug option
+	// is read while hotplug support is disabled.
+	hotplugCheckInterval = time.Minute
+	// udevMonitorRetryInterval is how long to wait before starting the
+	// udev monitor again after a failure.
+	udevMonitorRetryInterval = 5 * time.Minute
+)
+
+// hotplugEnabled returns whether the experimental.hotplug option of the
+// core snap is set.
+func hotplugEnabled(st *state.State) (bool, error) {
+	var enabled bool
+	tr := config.NewTransaction(st)
+	if err := tr.Get("core", "experimental.hotplug", &enabled); err != nil && !config.IsNoOption(err) {
+		return false, err
+	}
+	return enabled, nil
+}
+
+// ensureUDevMonitor starts listening to udev events once hotplug support
+// is enabled. The option is not read on every ensure pass and failures to
+// start the monitor are reported once, until it is tried again.
+func (m *InterfaceManager) ensureUDevMonitor() error {
+	if m.udevMon != nil {
+		return nil
+	}
+	now := timeNow()
+	if now.Before(m.udevMonNextTry) {
+		return nil
+	}
+	m.state.Lock()
+	enabled, err := hotplugEnabled(m.state)
+	m.state.Unlock()
+	if err != nil || !enabled {
+		m.udevMonNextTry = now.Add(hotplugCheckInterval)
+		return err
+	}
+
+	mon := createUDevMonitor(m.hotplugDeviceAdded, m.hotplugDeviceRemoved)
+	if err := mon.Connect(); err != nil {
+		m.udevMonNextTry = now.Add(udevMonitorRetryInterval)
+		return fmt.Errorf("cannot start udev monitor: %v", err)
+	}
+	if err := mon.Run(); err != nil {
+		mon.Stop()
+		m.udevMonNextTry = now.Add(udevMonitorRetryInterval)
+		return fmt.Errorf("cannot start udev monitor: %v", err)
+	}
+	m.udevMon = mon
+	return nil
+}
+
+// hotplugDeviceAdded is called by the udev monitor for devices that are
+// plugged in. It makes a change creating the slots that interfaces define
+// for the device, and connecting them again as they were before.
+func (m *InterfaceManager) hotplugDeviceAdded(devinfo *hotplug.HotplugDeviceInfo) {
+	st := m.state
+	st.Lock()
+	defer st.Unlock()
+
+	hotplugKey := devinfo.Key()
+	m.hotplugDevices[devinfo.DevicePath()] = hotplugKey
+
+	var tasks []*state.Task
+	for _, iface := range m.repo.AllInterfaces() {
+		definer, ok := iface.(hotplug.Definer)
+		if !ok {
+			continue
+		}
+		spec := hotplug.NewSpecification()
+		if err := definer.HotplugDeviceDetected(devinfo, spec); err != nil {
+			logger.Noticef("cannot process hotplug event for device %s with interface %q: %s", devinfo, iface.Name(), err)
+			continue
+		}
+		slotSpec := spec.Slot()
+		if slotSpec == nil {
+			continue
+		}
+
+		addSlot := st.NewTask("hotplug-add-slot", fmt.Sprintf(i18n.G("Create slot for device %s with interface %q"), devinfo, iface.Name()))
+		addSlot.Set("hotplug-key", hotplugKey)
+		addSlot.Set("interface", iface.Name())
+		addSlot.Set("slot-spec", slotSpec)
+		connect := st.NewTask("hotplug-connect", fmt.Sprintf(i18n.G("Connect slot for device %s with interface %q"), devinfo, iface.Name()))
+		connect.Set("hotplug-key", hotplugKey)
+		connect.Set("interface", iface.Name())
+		connect.WaitFor(addSlot)
+		tasks = append(tasks, addSlot, connect)
+	}
+	if len(tasks) == 0 {
+		return
+	}
+
+	chg := st.NewChange("hotplug-add-slots", fmt.Sprintf(i18n.G("Add slots for device %s"), devinfo))
+	chg.AddAll(state.NewTaskSet(tasks...))
+	st.EnsureBefore(0)
+}
+
+// hotplugDeviceRemoved is called by the udev monitor for devices that are
+// unplugged. It makes a change disconnecting and removing the slots made
+// for the device, while remembering their connections.
+func (m *InterfaceManager) hotplugDeviceRemoved(devinfo *hotplug.HotplugDeviceInfo) {
+	st := m.state
+	st.Lock()
+	defer st.Unlock()
+
+	hotplugKey, ok := m.hotplugDevices[devinfo.DevicePath()]
+	if !ok {
+		hotplugKey = devinfo.Key()
+	}
+	delete(m.hotplugDevices, devinfo.DevicePath())
+
+	slots, err := getHotplugSlots(st)
+	if err != nil {
+		logger.Noticef("cannot process hotplug event for device %s: %s", devinfo, err)
+		return
+	}
+	var names []string
+	for name, slot := range slots {
+		if slot.HotplugKey == hotplugKey && !slot.HotplugGone {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+
+	var tasks []*state.Task
+	for _, name := range names {
+		disconnect := st.NewTask("hotplug-disconnect", fmt.Sprintf(i18n.G("Disconnect slot %q of device %s"), name, devinfo))
+		disconnect.Set("slot-name", name)
+		removeSlot := st.NewTask("hotplug-remove-slot", fmt.Sprintf(i18n.G("Remove slot %q of device %s"), name, devinfo))
+		removeSlot.Set("slot-name", name)
+		removeSlot.WaitFor(disconnect)
+		tasks = append(tasks, disconnect, removeSlot)
+	}
+	chg := st.NewChange("hotplug-remove-slots", fmt.Sprintf(i18n.G("Remove slots of device %s"), devinfo))
+	chg.AddAll(state.NewTaskSet(tasks...))
+	st.EnsureBefore(0)
+}
+
+func (m *InterfaceManager) doHotplugAddSlot(task *state.Task, _ *tomb.Tomb) error {
+	st := task.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var hotplugKey, ifaceName string
+	var slotSpec hotplug.SlotSpec
+	if err := task.Get("hotplug-key", &hotplugKey); err != nil {
+		return err
+	}
+	if err := task.Get("interface", &ifaceName); err != nil {
+		return err
+	}
+	if err := task.Get("slot-spec", &slotSpec); err != nil {
+		return err
+	}
+
+	coreInfo, err := snapstate.CoreInfo(st)
+	if err != nil {
+		return fmt.Errorf("cannot create hotplug slot: %v", err)
+	}
+	slots, err := getHotplugSlots(st)
+	if err != nil {
+		return err
+	}
+
+	def := findHotplugSlot(slots, hotplugKey, ifaceName)
+	if def == nil {
+		suggested := slotSpec.Name
+		if suggested == "" {
+			suggested = ifaceName
+		}
+		def = &hotplugSlotDef{
+			Name:       hotplugSlotName(coreInfo, slots, suggested),
+			Interface:  ifaceName,
+			HotplugKey: hotplugKey,
+		}
+		slots[def.Name] = def
+	}
+	def.Label = slotSpec.Label
+	def.StaticAttrs = slotSpec.Attrs
+	def.HotplugGone = false
+
+	// the device may have been reported twice
+	if m.repo.Slot(coreInfo.Name(), def.Name) == nil {
+		if err := m.repo.AddSlot(hotplugSlot(coreInfo, def)); err != nil {
+			return fmt.Errorf("cannot create hotplug slot: %v", err)
+		}
+	}
+	setHotplugSlots(st, slots)
+	return nil
+}
+
+func (m *InterfaceManager) doHotplugConnect(task *state.Task, _ *tomb.Tomb) error {
+	st := task.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var hotplugKey, ifaceName string
+	if err := task.Get("hotplug-key", &hotplugKey); err != nil {
+		return err
+	}
+	if err := task.Get("interface", &ifaceName); err != nil {
+		return err
+	}
+
+	coreInfo, err := snapstate.CoreInfo(st)
+	if err != nil {
+		return err
+	}
+	slots, err := getHotplugSlots(st)
+	if err != nil {
+		return err
+	}
+	def := findHotplugSlot(slots, hotplugKey, ifaceName)
+	if def == nil {
+		return fmt.Errorf("internal error: cannot find hotplug slot for interface %q", ifaceName)
+	}
+	conns, err := getConns(st)
+	if err != nil {
+		return err
+	}
+
+	// restore the connections the slot had before the device went away
+	affected := map[string]bool{coreInfo.Name(): true}
+	for id, conn := range conns {
+		if conn.Undesired {
+			continue
+		}
+		connRef, err := interfaces.ParseConnRef(id)
+		if err != nil {
+			return err
+		}
+		if connRef.SlotRef.Snap != coreInfo.Name() || connRef.SlotRef.Name != def.Name {
+			continue
+		}
+		if err := m.repo.Connect(connRef, conn.DynamicPlugAttrs, conn.DynamicSlotAttrs); err != nil {
+			task.Logf("cannot restore connection %s: %s", id, err)
+			continue
+		}
+		affected[connRef.PlugRef.Snap] = true
+	}
+	return m.setupAffectedSnaps(task, "", sortedSnapNames(affected))
+}
+
+func (m *InterfaceManager) doHotplugDisconnect(task *state.Task, _ *tomb.Tomb) error {
+	st := task.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var slotName string
+	if err := task.Get("slot-name", &slotName); err != nil {
+		return err
+	}
+	coreInfo, err := snapstate.CoreInfo(st)
+	if err != nil {
+		return err
+	}
+
+	// the connections are kept in the state, to be restored when the
+	// device is plugged in again
+	connRefs, err := m.repo.Connected(coreInfo.Name(), slotName)
+	if err != nil {
+		return err
+	}
+	affected := map[string]bool{coreInfo.Name(): true}
+	for _, connRef := range connRefs {
+		if err := m.repo.Disconnect(connRef.PlugRef.Snap, connRef.PlugRef.Name, connRef.SlotRef.Snap, connRef.SlotRef.Name); err != nil {
+			return err
+		}
+		affected[connRef.PlugRef.Snap] = true
+	}
+	return m.setupAffectedSnaps(task, "", sortedSnapNames(affected))
+}
+
+func (m *InterfaceManager) doHotplugRemoveSlot(task *state.Task, _ *tomb.Tomb) error {
+	st := task.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var slotName string
+	if err := task.Get("slot-name", &slotName); err != nil {
+		return err
+	}
+	coreInfo, err := snapstate.CoreInfo(st)
+	if err != nil {
+		return err
+	}
+	slots, err := getHotplugSlots(st)
+	if err != nil {
+		return err
+	}
+	def, ok := slots[slotName]
+	if !ok {
+		return fmt.Errorf("internal error: cannot find hotplug slot %q", slotName)
+	}
+
+	if m.repo.Slot(coreInfo.Name(), slotName) != nil {
+		if err := m.repo.RemoveSlot(coreInfo.Name(), slotName); err != nil {
+			return err
+		}
+	}
+	def.HotplugGone = true
+	setHotplugSlots(st, slots)
+	return nil
+}
@@ -0,0 +1,337 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package ifacestate_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/interfaces"
+	"github.com/snapcore/snapd/interfaces/hotplug"
+	"github.com/snapcore/snapd/interfaces/ifacetest"
+	"github.com/snapcore/snapd/overlord/configstate/config"
+	"github.com/snapcore/snapd/overlord/ifacestate"
+	"github.com/snapcore/snapd/overlord/ifacestate/udevmonitor"
+	"github.com/snapcore/snapd/overlord/snapstate"
+	"github.com/snapcore/snapd/overlord/state"
+	"github.com/snapcore/snapd/snap"
+)
+
+// hotplugTestInterface creates a slot for every tty device.
+type hotplugTestInterface struct {
+	ifacetest.TestInterface
+}
+
+func (iface *hotplugTestInterface) HotplugDeviceDetected(di *hotplug.HotplugDeviceInfo, spec *hotplug.Specification) error {
+	if di.Subsystem() != "tty" {
+		return nil
+	}
+	return spec.SetSlot(&hotplug.SlotSpec{
+		Name:  "hotplug-slot",
+		Label: "A tty",
+		Attrs: map[string]interface{}{"path": di.DeviceName()},
+	})
+}
+
+type fakeUDevMonitor struct {
+	added      udevmonitor.DeviceAddedFunc
+	removed    udevmonitor.DeviceRemovedFunc
+	calls      []string
+	connectErr error
+}
+
+func (m *fakeUDevMonitor) Connect() error {
+	m.calls = append(m.calls, "connect")
+	return m.connectErr
+}
+
+func (m *fakeUDevMonitor) Run() error {
+	m.calls = append(m.calls, "run")
+	return nil
+}
+
+func (m *fakeUDevMonitor) Stop() error {
+	m.calls = append(m.calls, "stop")
+	return nil
+}
+
+func (s *interfaceManagerSuite) mockUDevMonitor(c *C) (*fakeUDevMonitor, func()) {
+	mon := &fakeUDevMonitor{}
+	restore := ifacestate.MockCreateUDevMonitor(func(added udevmonitor.DeviceAddedFunc, removed udevmonitor.DeviceRemovedFunc) udevmonitor.Interface {
+		mon.added = added
+		mon.removed = removed
+		return mon
+	})
+	return mon, restore
+}
+
+func (s *interfaceManagerSuite) enableHotplug(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+	tr := config.NewTransaction(s.state)
+	c.Assert(tr.Set("core", "experimental.hotplug", true), IsNil)
+	tr.Commit()
+}
+
+// mockTypedSnap mocks a snap recording its type in the state, so that the
+// core snap can be found by snapstate.CoreInfo.
+func (s *interfaceManagerSuite) mockTypedSnap(c *C, yamlText string) *snap.Info {
+	info := s.mockSnap(c, yamlText)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, info.Name(), &snapst), IsNil)
+	snapst.SetType(info.Type)
+	snapstate.Set(s.state, info.Name(), &snapst)
+	return info
+}
+
+func mockDevice(c *C, action, devpath, devname string) *hotplug.HotplugDeviceInfo {
+	di, err := hotplug.NewHotplugDeviceInfo(map[string]string{
+		"ACTION":          action,
+		"DEVPATH":         devpath,
+		"SUBSYSTEM":       "tty",
+		"DEVNAME":         devname,
+		"ID_VENDOR_ID":    "0403",
+		"ID_MODEL_ID":     "6001",
+		"ID_SERIAL_SHORT": "A1000abc",
+	})
+	c.Assert(err, IsNil)
+	return di
+}
+
+func (s *interfaceManagerSuite) TestHotplugDisabled(c *C) {
+	mon, restore := s.mockUDevMonitor(c)
+	defer restore()
+	mgr := s.manager(c)
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Check(mon.calls, HasLen, 0)
+}
+
+func (s *interfaceManagerSuite) TestHotplugStartsAndStopsMonitor(c *C) {
+	s.enableHotplug(c)
+	mon, restore := s.mockUDevMonitor(c)
+	defer restore()
+	mgr := s.manager(c)
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Check(mon.calls, DeepEquals, []string{"connect", "run"})
+	mgr.Stop()
+	c.Check(mon.calls, DeepEquals, []string{"connect", "run", "stop"})
+}
+
+func (s *interfaceManagerSuite) TestHotplugEnabledIsNotReadOnEveryEnsure(c *C) {
+	now := time.Now()
+	restore := ifacestate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+	mon, restore := s.mockUDevMonitor(c)
+	defer restore()
+	mgr := s.manager(c)
+	c.Assert(mgr.Ensure(), IsNil)
+
+	// enabling is noticed at the next check
+	s.enableHotplug(c)
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Check(mon.calls, HasLen, 0)
+	now = now.Add(time.Minute)
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Check(mon.calls, DeepEquals, []string{"connect", "run"})
+}
+
+func (s *interfaceManagerSuite) TestHotplugMonitorFailureIsRetriedLater(c *C) {
+	now := time.Now()
+	restore := ifacestate.MockTimeNow(func() time.Time { return now })
+	defer restore()
+	s.enableHotplug(c)
+	mon, restore := s.mockUDevMonitor(c)
+	defer restore()
+	mon.connectErr = errors.New("boom")
+	mgr := s.manager(c)
+
+	// the failure is reported once
+	c.Assert(mgr.Ensure(), ErrorMatches, "cannot start udev monitor: boom")
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Check(mon.calls, DeepEquals, []string{"connect"})
+
+	now = now.Add(5 * time.Minute)
+	mon.connectErr = nil
+	c.Assert(mgr.Ensure(), IsNil)
+	c.Check(mon.calls, DeepEquals, []string{"connect", "connect", "run"})
+}
+
+func (s *interfaceManagerSuite) TestHotplugAddRemoveDevice(c *C) {
+	s.enableHotplug(c)
+	mon, restore := s.mockUDevMonitor(c)
+	defer restore()
+	s.mockIface(c, &hotplugTestInterface{ifacetest.TestInterface{InterfaceName: "test"}})
+	s.mockTypedSnap(c, coreSnapYaml)
+	s.mockTypedSnap(c, consumerYaml)
+	mgr := s.manager(c)
+	c.Assert(mgr.Ensure(), IsNil)
+	repo := mgr.Repository()
+
+	mon.added(mockDevice(c, "add", "/devices/port1/ttyUSB0", "/dev/ttyUSB0"))
+	s.settle(c)
+
+	slot := repo.Slot("core", "hotplug-slot")
+	c.Assert(slot, NotNil)
+	c.Check(slot.Interface, Equals, "test")
+	c.Check(slot.Label, Equals, "A tty")
+	c.Check(slot.Attrs, DeepEquals, map[string]interface{}{"path": "/dev/ttyUSB0"})
+
+	// connect the slot as a user would
+	s.state.Lock()
+	s.state.Set("conns", map[string]interface{}{
+		"consumer:plug core:hotplug-slot": map[string]interface{}{"interface": "test"},
+	})
+	s.state.Unlock()
+
+	// the device goes away, the connection is remembered
+	mon.removed(mockDevice(c, "remove", "/devices/port1/ttyUSB0", "/dev/ttyUSB0"))
+	s.settle(c)
+	c.Check(repo.Slot("core", "hotplug-slot"), IsNil)
+
+	s.state.Lock()
+	var conns map[string]interface{}
+	c.Assert(s.state.Get("conns", &conns), IsNil)
+	c.Check(conns, HasLen, 1)
+	var hotplugSlots map[string]interface{}
+	c.Assert(s.state.Get("hotplug-slots", &hotplugSlots), IsNil)
+	c.Check(hotplugSlots["hotplug-slot"], DeepEquals, map[string]interface{}{
+		"name":         "hotplug-slot",
+		"interface":    "test",
+		"label":        "A tty",
+		"static-attrs": map[string]interface{}{"path": "/dev/ttyUSB0"},
+		"hotplug-key":  mockDevice(c, "add", "/devices/port1/ttyUSB0", "/dev/ttyUSB0").Key(),
+		"hotplug-gone": true,
+	})
+	s.state.Unlock()
+
+	// the same device in another port gets the same slot, connected again
+	s.secBackend.SetupCalls = nil
+	mon.added(mockDevice(c, "add", "/devices/port2/ttyUSB1", "/dev/ttyUSB1"))
+	s.settle(c)
+
+	slot = repo.Slot("core", "hotplug-slot")
+	c.Assert(slot, NotNil)
+	c.Check(slot.Attrs, DeepEquals, map[string]interface{}{"path": "/dev/ttyUSB1"})
+	c.Check(slot.Connections, DeepEquals, []interfaces.PlugRef{{Snap: "consumer", Name: "plug"}})
+	var setupSnaps []string
+	for _, call := range s.secBackend.SetupCalls {
+		setupSnaps = append(setupSnaps, call.SnapInfo.Name())
+	}
+	c.Check(setupSnaps, DeepEquals, []string{"consumer", "core"})
+
+	// the device is removed from the other port
+	mon.removed(mockDevice(c, "remove", "/devices/port2/ttyUSB1", "/dev/ttyUSB1"))
+	s.settle(c)
+	c.Check(repo.Slot("core", "hotplug-slot"), IsNil)
+	c.Check(repo.Plug("consumer", "plug").Connections, HasLen, 0)
+}
+
+func (s *interfaceManagerSuite) TestHotplugSlotsSurviveCoreRefresh(c *C) {
+	s.enableHotplug(c)
+	mon, restore := s.mockUDevMonitor(c)
+	defer restore()
+	s.mockIface(c, &hotplugTestInterface{ifacetest.TestInterface{InterfaceName: "test"}})
+	coreInfo := s.mockTypedSnap(c, coreSnapYaml)
+	s.mockTypedSnap(c, consumerYaml)
+	mgr := s.manager(c)
+	c.Assert(mgr.Ensure(), IsNil)
+	repo := mgr.Repository()
+
+	// one device is plugged in and connected, another one is gone
+	mon.added(mockDevice(c, "add", "/devices/port1/ttyUSB0", "/dev/ttyUSB0"))
+	s.settle(c)
+	c.Assert(repo.Slot("core", "hotplug-slot"), NotNil)
+	connRef := interfaces.ConnRef{
+		PlugRef: interfaces.PlugRef{Snap: "consumer", Name: "plug"},
+		SlotRef: interfaces.SlotRef{Snap: "core", Name: "hotplug-slot"},
+	}
+	c.Assert(repo.Connect(connRef, nil, nil), IsNil)
+	s.state.Lock()
+	s.state.Set("conns", map[string]interface{}{
+		"consumer:plug core:hotplug-slot": map[string]interface{}{"interface": "test"},
+	})
+	var hotplugSlots map[string]interface{}
+	c.Assert(s.state.Get("hotplug-slots", &hotplugSlots), IsNil)
+	hotplugSlots["gone-slot"] = map[string]interface{}{
+		"name":         "gone-slot",
+		"interface":    "test",
+		"hotplug-key":  "gone",
+		"hotplug-gone": true,
+	}
+	s.state.Set("hotplug-slots", hotplugSlots)
+	s.state.Unlock()
+
+	// core is refreshed
+	chg := s.addSetupSnapSecurityChange(c, &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{
+			RealName: coreInfo.Name(),
+			Revision: coreInfo.Revision,
+		},
+	})
+	s.settle(c)
+	s.state.Lock()
+	c.Assert(chg.Status(), Equals, state.DoneStatus, Commentf("%s", chg.Err()))
+	s.state.Unlock()
+
+	// the slot of the device and its connection are still there
+	slot := repo.Slot("core", "hotplug-slot")
+	c.Assert(slot, NotNil)
+	c.Check(slot.Attrs, DeepEquals, map[string]interface{}{"path": "/dev/ttyUSB0"})
+	c.Check(slot.Connections, DeepEquals, []interfaces.PlugRef{{Snap: "consumer", Name: "plug"}})
+	c.Check(repo.Slot("core", "gone-slot"), IsNil)
+}
+
+func (s *interfaceManagerSuite) TestHotplugSlotNamesDoNotClash(c *C) {
+	s.enableHotplug(c)
+	mon, restore := s.mockUDevMonitor(c)
+	defer restore()
+	s.mockIface(c, &hotplugTestInterface{ifacetest.TestInterface{InterfaceName: "test"}})
+	s.mockTypedSnap(c, coreSnapYaml)
+	mgr := s.manager(c)
+	c.Assert(mgr.Ensure(), IsNil)
+
+	for i := 0; i < 3; i++ {
+		di, err := hotplug.NewHotplugDeviceInfo(map[string]string{
+			"ACTION":    "add",
+			"DEVPATH":   fmt.Sprintf("/devices/port%d/ttyUSB%d", i, i),
+			"SUBSYSTEM": "tty",
+			"DEVNAME":   fmt.Sprintf("/dev/ttyUSB%d", i),
+		})
+		c.Assert(err, IsNil)
+		mon.added(di)
+		s.settle(c)
+	}
+
+	var names []string
+	for _, slot := range mgr.Repository().Slots("core") {
+		if strings.HasPrefix(slot.Name, "hotplug-slot") {
+			names = append(names, slot.Name)
+		}
+	}
+	c.Check(names, DeepEquals, []string{"hotplug-slot", "hotplug-slot-1", "hotplug-slot-2"})
+}
@@ -20,9 +20,13 @@
 package ifacestate
 
 import (
+	"time"
+
 	"github.com/snapcore/snapd/interfaces"
 	"github.com/snapcore/snapd/interfaces/backends"
+	"github.com/snapcore/snapd/logger"
 	"github.com/snapcore/snapd/overlord/hookstate"
+	"github.com/snapcore/snapd/overlord/ifacestate/udevmonitor"
 	"github.com/snapcore/snapd/overlord/state"
 )
 
@@ -33,6 +37,14 @@ type InterfaceManager struct {
 	state  *state.State
 	runner *state.TaskRunner
 	repo   *interfaces.Repository
+
+	udevMon udevmonitor.Interface
+	// udevMonNextTry is when to check again whether the udev monitor
+	// needs to be started.
+	udevMonNextTry time.Time
+	// hotplugDevices maps the device paths of the hotplugged devices
+	// seen so far to their hotplug keys.
+	hotplugDevices map[string]string
 }
 
 // Manager returns a new InterfaceManager.
@@ -50,6 +62,8 @@ func Manager(s *state.State, hookManager *hookstate.HookManager, extraInterfaces
 		state:  s,
 		runner: runner,
 		repo:   interfaces.NewRepository(),
+
+		hotplugDevices: make(map[string]string),
 	}
 	if err := m.initialize(extraInterfaces, extraBackends); err != nil {
 		return nil, err
@@ -60,12 +74,22 @@ func Manager(s *state.State, hookManager *hookstate.HookManager, extraInterfaces
 		return len(running) != 0
 	})
 
+	// compiling security profiles is CPU heavy, keep these limited
+	// even if the serialisation above is relaxed
+	profilesOpts := &state.HandlerOptions{MaxConcurrent: 1}
+
 	runner.AddHandler("connect", m.doConnect, nil)
 	runner.AddHandler("disconnect", m.doDisconnect, nil)
-	runner.AddHandler("setup-profiles", m.doSetupProfiles, m.undoSetupProfiles)
-	runner.AddHandler("remove-profiles", m.doRemoveProfiles, m.doSetupProfiles)
+	runner.AddHandler("setup-profiles", m.doSetupProfiles, m.undoSetupProfiles, profilesOpts)
+	runner.AddHandler("remove-profiles", m.doRemoveProfiles, m.doSetupProfiles, profilesOpts)
 	runner.AddHandler("discard-conns", m.doDiscardConns, m.undoDiscardConns)
 
+	// hotplug
+	runner.AddHandler("hotplug-add-slot", m.doHotplugAddSlot, nil)
+	runner.AddHandler("hotplug-connect", m.doHotplugConnect, nil)
+	runner.AddHandler("hotplug-disconnect", m.doHotplugDisconnect, nil)
+	runner.AddHandler("hotplug-remove-slot", m.doHotplugRemoveSlot, nil)
+
 	// helper for ubuntu-core -> core
 	runner.AddHandler("transition-ubuntu-core", m.doTransitionUbuntuCore, m.undoTransitionUbuntuCore)
 
@@ -75,7 +99,7 @@ func Manager(s *state.State, hookManager *hookstate.HookManager, extraInterfaces
 // Ensure implements StateManager.Ensure.
 func (m *InterfaceManager) Ensure() error {
 	m.runner.Ensure()
-	return nil
+	return m.ensureUDevMonitor()
 }
 
 // Wait implements StateManager.Wait.
@@ -86,7 +110,12 @@ func (m *InterfaceManager) Wait() {
 // Stop implements StateManager.Stop.
 func (m *InterfaceManager) Stop() {
 	m.runner.Stop()
-
+	if m.udevMon != nil {
+		if err := m.udevMon.Stop(); err != nil {
+			logger.Noticef("cannot stop udev monitor: %s", err)
+		}
+		m.udevMon = nil
+	}
 }
 
 // Repository returns the interface repository used internally by the manager.
@@ -134,7 +134,7 @@ func setInitialConnectAttributes(ts *state.Task, plugSnap string, plugName strin
 		return err
 	}
 	if plug, ok := snapInfo.Plugs[plugName]; ok {
-		ts.Set("plug-attrs", plug.Attrs)
+		ts.Set("plug-static", plug.Attrs)
 	} else {
 		return fmt.Errorf("snap %q has no plug named %q", plugSnap, plugName)
 	}
@@ -148,7 +148,7 @@ func setInitialConnectAttributes(ts *state.Task, plugSnap string, plugName strin
 	}
 	addImplicitSlots(snapInfo)
 	if slot, ok := snapInfo.Slots[slotName]; ok {
-		ts.Set("slot-attrs", slot.Attrs)
+		ts.Set("slot-static", slot.Attrs)
 	} else {
 		return fmt.Errorf("snap %q has no slot named %q", slotSnap, slotName)
 	}
@@ -202,6 +202,92 @@ func CheckInterfaces(st *state.State, snapInfo *snap.Info) error {
 	return ic.Check()
 }
 
+// ConnectionState describes a connection, or a connection that was
+// explicitly undone, as recorded in the state.
+type ConnectionState struct {
+	Interface string
+	// Auto is set for connections made automatically.
+	Auto bool
+	// ByGadget is set for connections made as requested by the gadget.
+	ByGadget bool
+	// Undesired is set for connections that were explicitly disconnected.
+	Undesired bool
+	// DynamicPlugAttrs and DynamicSlotAttrs are the attributes set by
+	// the interface hooks when the connection was made.
+	DynamicPlugAttrs map[string]interface{}
+	DynamicSlotAttrs map[string]interface{}
+}
+
+// Origin returns how the connection came to be, one of "auto",
+// "gadget" or "manual".
+func (cs ConnectionState) Origin() string {
+	switch {
+	case cs.ByGadget:
+		return "gadget"
+	case cs.Auto:
+		return "auto"
+	default:
+		return "manual"
+	}
+}
+
+// ConnectionStates returns the state of all the connections recorded in
+// the state, keyed by connection id (see interfaces.ConnRef.ID).
+func ConnectionStates(st *state.State) (map[string]ConnectionState, error) {
+	conns, err := getConns(st)
+	if err != nil {
+		return nil, err
+	}
+
+	connStates := make(map[string]ConnectionState, len(conns))
+	for id, cs := range conns {
+		connStates[id] = ConnectionState{
+			Interface: cs.Interface,
+			Auto:      cs.Auto,
+			ByGadget:  cs.ByGadget,
+			Undesired: cs.Undesired,
+
+			DynamicPlugAttrs: cs.DynamicPlugAttrs,
+			DynamicSlotAttrs: cs.DynamicSlotAttrs,
+		}
+	}
+	return connStates, nil
+}
+
+// PolicyCheck is the outcome of checking a connection against the base
+// and snap declarations.
+type PolicyCheck struct {
+	// Error is why the check failed, nil if it passed.
+	Error error
+	// Trace lists the rules and constraints considered by the check.
+	Trace []string
+}
+
+// CheckConnectPolicy checks whether the given plug and slot can be
+// connected, manually and automatically, according to the declarations,
+// explaining the outcome. The state must be locked by the caller.
+func (m *InterfaceManager) CheckConnectPolicy(plugRef interfaces.PlugRef, slotRef interfaces.SlotRef) (connect, autoConnect *PolicyCheck, err error) {
+	plug := m.repo.Plug(plugRef.Snap, plugRef.Name)
+	if plug == nil {
+		return nil, nil, fmt.Errorf("snap %q has no %q plug", plugRef.Snap, plugRef.Name)
+	}
+	slot := m.repo.Slot(slotRef.Snap, slotRef.Name)
+	if slot == nil {
+		return nil, nil, fmt.Errorf("snap %q has no %q slot", slotRef.Snap, slotRef.Name)
+	}
+
+	ic, err := connectCandidate(m.state, plug, slot)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ic.Trace = &policy.Trace{}
+	connect = &PolicyCheck{Error: ic.Check(), Trace: ic.Trace.Steps}
+	ic.Trace = &policy.Trace{}
+	autoConnect = &PolicyCheck{Error: ic.CheckAutoConnect(), Trace: ic.Trace.Steps}
+	return connect, autoConnect, nil
+}
+
 var once sync.Once
 
 func delayedCrossMgrInit() {
@@ -176,10 +176,10 @@ func (s *interfaceManagerSuite) TestConnectTask(c *C) {
 	c.Assert(slot.Name, Equals, "slot")
 	// verify initial attributes are present in connect task
 	var attrs map[string]interface{}
-	err = task.Get("plug-attrs", &attrs)
+	err = task.Get("plug-static", &attrs)
 	c.Assert(err, IsNil)
 	c.Assert(attrs["attr1"], Equals, "value1")
-	err = task.Get("slot-attrs", &attrs)
+	err = task.Get("slot-static", &attrs)
 	c.Assert(err, IsNil)
 	c.Assert(attrs["attr2"], Equals, "value2")
 	i++
@@ -379,6 +379,16 @@ func (s *interfaceManagerSuite) TestConnectTaskCheckNotAllowed(c *C) {
 		c.Check(change.Err(), ErrorMatches, `(?s).*connection not allowed by slot rule of interface "test".*`)
 		c.Check(change.Status(), Equals, state.ErrorStatus)
 
+		// the policy check is explained in the log of the task
+		var connect *state.Task
+		for _, t := range change.Tasks() {
+			if t.Kind() == "connect" {
+				connect = t
+			}
+		}
+		c.Assert(connect, NotNil)
+		c.Check(strings.Join(connect.Log(), "\n"), Matches, `(?s).* INFO policy: using slot rule of interface "test" from base-declaration\n.* INFO policy: allow-connection constraints do not match: publisher id does not match\n.* ERROR connection not allowed by slot rule of interface "test"`)
+
 		repo := s.manager(c).Repository()
 		plug := repo.Plug("consumer", "plug")
 		slot := repo.Slot("producer", "slot")
@@ -462,6 +472,49 @@ slots:
 	check(change)
 }
 
+func (s *interfaceManagerSuite) TestCheckConnectPolicy(c *C) {
+	restore := assertstest.MockBuiltinBaseDeclaration([]byte(`
+type: base-declaration
+authority-id: canonical
+series: 16
+slots:
+  test:
+    allow-connection:
+      plug-publisher-id:
+        - $SLOT_PUBLISHER_ID
+    deny-auto-connection: true
+`))
+	defer restore()
+	s.mockIface(c, &ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnapDecl(c, "consumer", "one-publisher", nil)
+	s.mockSnap(c, consumerYaml)
+	s.mockSnapDecl(c, "producer", "one-publisher", nil)
+	s.mockSnap(c, producerYaml)
+	mgr := s.manager(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	plugRef := interfaces.PlugRef{Snap: "consumer", Name: "plug"}
+	slotRef := interfaces.SlotRef{Snap: "producer", Name: "slot"}
+	connect, autoConnect, err := mgr.CheckConnectPolicy(plugRef, slotRef)
+	c.Assert(err, IsNil)
+	c.Check(connect.Error, IsNil)
+	c.Check(connect.Trace, DeepEquals, []string{
+		`using slot rule of interface "test" from base-declaration`,
+		`deny-connection constraints do not match: not allowed`,
+		`allow-connection constraints match`,
+	})
+	c.Check(autoConnect.Error, ErrorMatches, `auto-connection denied by slot rule of interface "test"`)
+	c.Check(autoConnect.Trace, DeepEquals, []string{
+		`using slot rule of interface "test" from base-declaration`,
+		`deny-auto-connection constraints match`,
+	})
+
+	_, _, err = mgr.CheckConnectPolicy(interfaces.PlugRef{Snap: "consumer", Name: "missing"}, slotRef)
+	c.Check(err, ErrorMatches, `snap "consumer" has no "missing" plug`)
+}
+
 func (s *interfaceManagerSuite) TestDisconnectTask(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
@@ -533,11 +586,13 @@ func (s *interfaceManagerSuite) testDisconnect(c *C, plugSnap, plugName, slotSna
 
 	c.Check(change.Status(), Equals, state.DoneStatus)
 
-	// Ensure that the connection has been removed from the state
+	// Ensure that the connection has been marked as undesired in the state
 	var conns map[string]interface{}
 	err = s.state.Get("conns", &conns)
 	c.Assert(err, IsNil)
-	c.Check(conns, HasLen, 0)
+	c.Check(conns, DeepEquals, map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{"interface": "test", "undesired": true},
+	})
 
 	// Ensure that the connection has been removed from the repository
 	repo := mgr.Repository()
@@ -1115,6 +1170,55 @@ func (s *interfaceManagerSuite) TestDoSetupSnapSecuirtyKeepsExistingConnectionSt
 	})
 }
 
+func (s *interfaceManagerSuite) TestDoSetupSnapSecurityIgnoresUndesiredConnections(c *C) {
+	// Add an OS snap in place.
+	s.mockSnap(c, ubuntuCoreSnapYaml)
+
+	// Initialize the manager. This registers the OS snap.
+	mgr := s.manager(c)
+
+	// Add a sample snap with a "network" plug which would be auto-connected.
+	snapInfo := s.mockSnap(c, sampleSnapYaml)
+
+	// The connection was explicitly disconnected before.
+	s.state.Lock()
+	s.state.Set("conns", map[string]interface{}{
+		"snap:network ubuntu-core:network": map[string]interface{}{
+			"interface": "network", "auto": true, "undesired": true,
+		},
+	})
+	s.state.Unlock()
+
+	// Run the setup-snap-security task and let it finish.
+	change := s.addSetupSnapSecurityChange(c, &snapstate.SnapSetup{
+		SideInfo: &snap.SideInfo{
+			RealName: snapInfo.Name(),
+			Revision: snapInfo.Revision,
+		},
+	})
+	mgr.Ensure()
+	mgr.Wait()
+	mgr.Stop()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	// Ensure that the task succeeded.
+	c.Assert(change.Status(), Equals, state.DoneStatus)
+
+	// The connection was not made again.
+	var conns map[string]interface{}
+	err := s.state.Get("conns", &conns)
+	c.Assert(err, IsNil)
+	c.Check(conns, DeepEquals, map[string]interface{}{
+		"snap:network ubuntu-core:network": map[string]interface{}{
+			"interface": "network", "auto": true, "undesired": true,
+		},
+	})
+	repo := mgr.Repository()
+	c.Check(repo.Plug("snap", "network").Connections, HasLen, 0)
+}
+
 // The setup-profiles task will add implicit slots necessary for the OS snap.
 func (s *interfaceManagerSuite) TestDoSetupProfilesAddsImplicitSlots(c *C) {
 	// Initialize the manager.
@@ -1386,6 +1490,35 @@ func (s *interfaceManagerSuite) testDoDicardConns(c *C, snapName string) {
 	})
 }
 
+func (s *interfaceManagerSuite) TestDoDiscardConnsForgetsUndesired(c *C) {
+	s.state.Lock()
+	s.state.Set("conns", map[string]interface{}{
+		"consumer:plug producer:slot":  map[string]interface{}{"interface": "test", "auto": true, "undesired": true},
+		"consumer:plug other:slot":     map[string]interface{}{"interface": "test", "auto": true, "undesired": true},
+		"unrelated:plug producer:slot": map[string]interface{}{"interface": "test", "auto": true, "undesired": true},
+	})
+	snapstate.Set(s.state, "consumer", &snapstate.SnapState{})
+	s.state.Unlock()
+
+	mgr := s.manager(c)
+
+	change := s.addDiscardConnsChange(c, "consumer")
+	mgr.Ensure()
+	mgr.Wait()
+	mgr.Stop()
+
+	s.state.Lock()
+	defer s.state.Unlock()
+	c.Check(change.Status(), Equals, state.DoneStatus)
+
+	// only the connections of other snaps are still remembered
+	connStates, err := ifacestate.ConnectionStates(s.state)
+	c.Assert(err, IsNil)
+	c.Check(connStates, DeepEquals, map[string]ifacestate.ConnectionState{
+		"unrelated:plug producer:slot": {Interface: "test", Auto: true, Undesired: true},
+	})
+}
+
 func (s *interfaceManagerSuite) testUndoDicardConns(c *C, snapName string) {
 	s.state.Lock()
 	// Store information about a connection in the state.
@@ -1520,6 +1653,95 @@ func (s *interfaceManagerSuite) TestConnectTracksConnectionsInState(c *C) {
 	})
 }
 
+func (s *interfaceManagerSuite) TestConnectTracksDynamicAttributes(c *C) {
+	var plugAttrs, slotAttrs map[string]interface{}
+	s.mockIface(c, &ifacetest.TestInterface{
+		InterfaceName: "test",
+		TestConnectedPlugCallback: func(spec *ifacetest.Specification, plug *interfaces.Plug, pAttrs map[string]interface{}, slot *interfaces.Slot, sAttrs map[string]interface{}) error {
+			plugAttrs, slotAttrs = pAttrs, sAttrs
+			return nil
+		},
+	})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	mgr := s.manager(c)
+
+	s.state.Lock()
+	ts, err := ifacestate.Connect(s.state, "consumer", "plug", "producer", "slot")
+	c.Assert(err, IsNil)
+	c.Assert(ts.Tasks(), HasLen, 5)
+
+	// as set by snapctl in the prepare hooks
+	ts.Tasks()[2].Set("plug-dynamic", map[string]interface{}{"number": 42})
+	ts.Tasks()[2].Set("slot-dynamic", map[string]interface{}{"path": "/dev/foo"})
+
+	change := s.state.NewChange("connect", "")
+	change.AddAll(ts)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Assert(change.Err(), IsNil)
+	c.Check(change.Status(), Equals, state.DoneStatus)
+	var conns map[string]interface{}
+	err = s.state.Get("conns", &conns)
+	c.Assert(err, IsNil)
+	c.Check(conns, DeepEquals, map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{
+			"interface":    "test",
+			"plug-dynamic": map[string]interface{}{"number": 42.0},
+			"slot-dynamic": map[string]interface{}{"path": "/dev/foo"},
+		},
+	})
+
+	// the backends see both the static and the dynamic attributes
+	_, err = mgr.Repository().SnapSpecification(s.secBackend.Name(), "consumer")
+	c.Assert(err, IsNil)
+	c.Check(plugAttrs, DeepEquals, map[string]interface{}{"attr1": "value1", "number": int64(42)})
+	c.Check(slotAttrs, DeepEquals, map[string]interface{}{"attr2": "value2", "path": "/dev/foo"})
+}
+
+// schemaInterface declares the attributes of its plugs.
+type schemaInterface struct {
+	ifacetest.TestInterface
+}
+
+func (iface *schemaInterface) PlugAttrSpecs() []interfaces.AttrSpec {
+	return []interfaces.AttrSpec{{Name: "number", Type: interfaces.IntAttr, Min: 1, Max: 10}}
+}
+
+func (s *interfaceManagerSuite) TestConnectChecksDynamicAttributes(c *C) {
+	s.mockIface(c, &schemaInterface{ifacetest.TestInterface{InterfaceName: "test"}})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	_ = s.manager(c)
+
+	s.state.Lock()
+	ts, err := ifacestate.Connect(s.state, "consumer", "plug", "producer", "slot")
+	c.Assert(err, IsNil)
+	ts.Tasks()[2].Set("plug-dynamic", map[string]interface{}{"number": 42})
+
+	change := s.state.NewChange("connect", "")
+	change.AddAll(ts)
+	s.state.Unlock()
+
+	s.settle(c)
+
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	c.Check(change.Status(), Equals, state.ErrorStatus)
+	c.Check(change.Err(), ErrorMatches, `(?s).*test plug "number" attribute must be between 1 and 10, not 42.*`)
+	var conns map[string]interface{}
+	err = s.state.Get("conns", &conns)
+	c.Check(err, Equals, state.ErrNoState)
+}
+
 func (s *interfaceManagerSuite) TestConnectSetsUpSecurity(c *C) {
 	s.mockIface(c, &ifacetest.TestInterface{InterfaceName: "test"})
 	s.mockSnap(c, consumerYaml)
@@ -1639,7 +1861,9 @@ func (s *interfaceManagerSuite) TestDisconnectTracksConnectionsInState(c *C) {
 	var conns map[string]interface{}
 	err = s.state.Get("conns", &conns)
 	c.Assert(err, IsNil)
-	c.Check(conns, DeepEquals, map[string]interface{}{})
+	c.Check(conns, DeepEquals, map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{"interface": "test", "undesired": true},
+	})
 }
 
 func (s *interfaceManagerSuite) TestManagerReloadsConnections(c *C) {
@@ -1664,6 +1888,52 @@ func (s *interfaceManagerSuite) TestManagerReloadsConnections(c *C) {
 	c.Check(slot.Connections[0], DeepEquals, interfaces.PlugRef{Snap: "consumer", Name: "plug"})
 }
 
+func (s *interfaceManagerSuite) TestManagerDoesNotReloadUndesiredConnections(c *C) {
+	s.mockIface(c, &ifacetest.TestInterface{InterfaceName: "test"})
+	s.mockSnap(c, consumerYaml)
+	s.mockSnap(c, producerYaml)
+
+	s.state.Lock()
+	s.state.Set("conns", map[string]interface{}{
+		"consumer:plug producer:slot": map[string]interface{}{"interface": "test", "undesired": true},
+	})
+	s.state.Unlock()
+
+	mgr := s.manager(c)
+	repo := mgr.Repository()
+
+	c.Check(repo.Plug("consumer", "plug").Connections, HasLen, 0)
+	c.Check(repo.Slot("producer", "slot").Connections, HasLen, 0)
+}
+
+func (s *interfaceManagerSuite) TestConnectionStates(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	connStates, err := ifacestate.ConnectionStates(s.state)
+	c.Assert(err, IsNil)
+	c.Check(connStates, HasLen, 0)
+
+	s.state.Set("conns", map[string]interface{}{
+		"consumer:plug producer:slot":   map[string]interface{}{"interface": "test"},
+		"consumer:auto producer:slot":   map[string]interface{}{"interface": "test", "auto": true},
+		"consumer:gadget producer:slot": map[string]interface{}{"interface": "test", "auto": true, "by-gadget": true},
+		"consumer:gone producer:slot":   map[string]interface{}{"interface": "test", "auto": true, "undesired": true},
+	})
+
+	connStates, err = ifacestate.ConnectionStates(s.state)
+	c.Assert(err, IsNil)
+	c.Check(connStates, DeepEquals, map[string]ifacestate.ConnectionState{
+		"consumer:plug producer:slot":   {Interface: "test"},
+		"consumer:auto producer:slot":   {Interface: "test", Auto: true},
+		"consumer:gadget producer:slot": {Interface: "test", Auto: true, ByGadget: true},
+		"consumer:gone producer:slot":   {Interface: "test", Auto: true, Undesired: true},
+	})
+	c.Check(connStates["consumer:plug producer:slot"].Origin(), Equals, "manual")
+	c.Check(connStates["consumer:auto producer:slot"].Origin(), Equals, "auto")
+	c.Check(connStates["consumer:gadget producer:slot"].Origin(), Equals, "gadget")
+}
+
 func (s *interfaceManagerSuite) TestSetupProfilesDevModeMultiple(c *C) {
 	mgr := s.manager(c)
 	repo := mgr.Repository()
@@ -1695,7 +1965,7 @@ func (s *interfaceManagerSuite) TestSetupProfilesDevModeMultiple(c *C) {
 		PlugRef: interfaces.PlugRef{Snap: siP.Name(), Name: "plug"},
 		SlotRef: interfaces.SlotRef{Snap: siC.Name(), Name: "slot"},
 	}
-	err = repo.Connect(connRef)
+	err = repo.Connect(connRef, nil, nil)
 	c.Assert(err, IsNil)
 
 	change := s.addSetupSnapSecurityChange(c, &snapstate.SnapSetup{
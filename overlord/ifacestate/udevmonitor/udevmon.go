@@ -0,0 +1,359 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package udevmonitor listens to udev events about devices being added
+// and removed, to support hotplugged interface slots.
+package udevmonitor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/interfaces/hotplug"
+	"github.com/snapcore/snapd/logger"
+)
+
+// Interface is the interface of the udev monitor, replaced by a fake in
+// tests.
+type Interface interface {
+	Connect() error
+	Run() error
+	Stop() error
+}
+
+// DeviceAddedFunc is called for devices that are added, including the
+// ones present when the monitor starts.
+type DeviceAddedFunc func(device *hotplug.HotplugDeviceInfo)
+
+// DeviceRemovedFunc is called for devices that are removed.
+type DeviceRemovedFunc func(device *hotplug.HotplugDeviceInfo)
+
+// Monitor reads udev events from a netlink socket.
+type Monitor struct {
+	tomb          tomb.Tomb
+	deviceAdded   DeviceAddedFunc
+	deviceRemoved DeviceRemovedFunc
+
+	fd    int
+	stopR *os.File
+	stopW *os.File
+}
+
+// New returns a udev monitor calling the given functions for devices that
+// are added and removed.
+func New(added DeviceAddedFunc, removed DeviceRemovedFunc) Interface {
+	return &Monitor{
+		deviceAdded:   added,
+		deviceRemoved: removed,
+		fd:            -1,
+	}
+}
+
+// kernelEventGroup and udevEventGroup are the netlink multicast groups
+// of the events that the kernel sends, and of the events that udev sends
+// once it processed them, with all the device properties set by udev
+// rules.
+const (
+	kernelEventGroup = 1
+	udevEventGroup   = 2
+)
+
+// Connect opens the netlink socket the udev events are read from.
+func (m *Monitor) Connect() error {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return fmt.Errorf("cannot open netlink socket: %v", err)
+	}
+	addr := &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: udevEventGroup}
+	if err := syscall.Bind(fd, addr); err != nil {
+		syscall.Close(fd)
+		return fmt.Errorf("cannot bind netlink socket: %v", err)
+	}
+	// get the credentials of the sender of each event
+	if err := syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_PASSCRED, 1); err != nil {
+		syscall.Close(fd)
+		return fmt.Errorf("cannot enable credentials on netlink socket: %v", err)
+	}
+	stopR, stopW, err := os.Pipe()
+	if err != nil {
+		syscall.Close(fd)
+		return err
+	}
+	m.fd, m.stopR, m.stopW = fd, stopR, stopW
+	return nil
+}
+
+// Run reports the devices that are present, then the udev events as they
+// come, until the monitor is stopped.
+func (m *Monitor) Run() error {
+	if m.fd < 0 {
+		return fmt.Errorf("cannot run udev monitor that is not connected")
+	}
+	m.tomb.Go(func() error {
+		devices, err := enumerateExistingDevices()
+		if err != nil {
+			logger.Noticef("cannot enumerate existing devices: %s", err)
+		}
+		for _, env := range devices {
+			m.dispatch(env)
+		}
+		return m.loop()
+	})
+	return nil
+}
+
+// Stop stops reporting events and closes the netlink socket.
+func (m *Monitor) Stop() error {
+	if m.fd < 0 {
+		return nil
+	}
+	m.tomb.Kill(nil)
+	// wake up the loop waiting for events
+	m.stopW.Write([]byte{0})
+	err := m.tomb.Wait()
+	m.stopR.Close()
+	m.stopW.Close()
+	syscall.Close(m.fd)
+	m.fd = -1
+	return err
+}
+
+func (m *Monitor) loop() error {
+	buf := make([]byte, 64*1024)
+	oob := make([]byte, syscall.CmsgSpace(syscall.SizeofUcred))
+	stopFd := int(m.stopR.Fd())
+	for {
+		var fds syscall.FdSet
+		fdSet(&fds, m.fd)
+		fdSet(&fds, stopFd)
+		maxFd := m.fd
+		if stopFd > maxFd {
+			maxFd = stopFd
+		}
+		if _, err := syscall.Select(maxFd+1, &fds, nil, nil, nil); err != nil {
+			if err == syscall.EINTR {
+				continue
+			}
+			return fmt.Errorf("cannot wait for udev events: %v", err)
+		}
+		if fdIsSet(&fds, stopFd) {
+			return nil
+		}
+		n, oobn, _, from, err := syscall.Recvmsg(m.fd, buf, oob, 0)
+		if err != nil {
+			if err == syscall.EINTR || err == syscall.ENOBUFS {
+				continue
+			}
+			return fmt.Errorf("cannot read udev event: %v", err)
+		}
+		if err := checkSender(from, oob[:oobn]); err != nil {
+			logger.Debugf("ignoring udev event: %v", err)
+			continue
+		}
+		env, err := parseUEvent(buf[:n])
+		if err != nil {
+			logger.Debugf("cannot parse udev event: %v", err)
+			continue
+		}
+		m.dispatch(env)
+	}
+}
+
+// checkSender checks, like libudev does, that an event was multicast by
+// root, and that events of the kernel group come from the kernel itself.
+// Events of the udev group come from udevd, whose port ID is its PID.
+func checkSender(from syscall.Sockaddr, oob []byte) error {
+	addr, ok := from.(*syscall.SockaddrNetlink)
+	if !ok {
+		return fmt.Errorf("unexpected sender address %T", from)
+	}
+	if addr.Groups == 0 {
+		return fmt.Errorf("unicast message from port %d", addr.Pid)
+	}
+	if addr.Groups&kernelEventGroup != 0 && addr.Pid != 0 {
+		return fmt.Errorf("kernel event sent by port %d", addr.Pid)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return fmt.Errorf("cannot parse sender credentials: %v", err)
+	}
+	for _, msg := range msgs {
+		cred, err := syscall.ParseUnixCredentials(&msg)
+		if err != nil {
+			continue
+		}
+		if cred.Uid != 0 {
+			return fmt.Errorf("message sent by user %d", cred.Uid)
+		}
+		return nil
+	}
+	return fmt.Errorf("no sender credentials")
+}
+
+func (m *Monitor) dispatch(env map[string]string) {
+	device, err := hotplug.NewHotplugDeviceInfo(env)
+	if err != nil {
+		logger.Debugf("cannot use udev event: %v", err)
+		return
+	}
+	switch device.Action() {
+	case "add":
+		m.deviceAdded(device)
+	case "remove":
+		m.deviceRemoved(device)
+	}
+}
+
+// fdBits is the number of bits in a word of syscall.FdSet, which
+// depends on the architecture.
+const fdBits = int(unsafe.Sizeof(syscall.FdSet{}.Bits[0]) * 8)
+
+func fdSet(fds *syscall.FdSet, fd int) {
+	fds.Bits[fd/fdBits] |= 1 << (uint(fd) % uint(fdBits))
+}
+
+func fdIsSet(fds *syscall.FdSet, fd int) bool {
+	return fds.Bits[fd/fdBits]&(1<<(uint(fd)%uint(fdBits))) != 0
+}
+
+// udevHeader is the header of the events that udev sends over netlink,
+// see monitor_netlink_header in libudev-monitor.c. The fields are in host
+// byte order, except for the magic.
+type udevHeader struct {
+	Prefix            [8]byte
+	Magic             [4]byte
+	HeaderSize        uint32
+	PropertiesOff     uint32
+	PropertiesLen     uint32
+	FilterSubsystem   uint32
+	FilterDevtype     uint32
+	FilterTagBloomHi  uint32
+	FilterTagBloomLow uint32
+}
+
+var udevMagic = [4]byte{0xfe, 0xed, 0xca, 0xfe}
+
+// nativeEndian is the byte order of the host.
+var nativeEndian binary.ByteOrder = binary.LittleEndian
+
+func init() {
+	one := uint16(1)
+	if *(*byte)(unsafe.Pointer(&one)) == 0 {
+		nativeEndian = binary.BigEndian
+	}
+}
+
+// parseUEvent returns the properties of a uevent, either in the format of
+// udev or in the simpler one of the kernel.
+func parseUEvent(buf []byte) (map[string]string, error) {
+	if bytes.HasPrefix(buf, []byte("libudev\x00")) {
+		var hdr udevHeader
+		if err := binary.Read(bytes.NewReader(buf), nativeEndian, &hdr); err != nil {
+			return nil, fmt.Errorf("cannot read udev event header: %v", err)
+		}
+		if hdr.Magic != udevMagic {
+			return nil, fmt.Errorf("invalid udev event magic %x", hdr.Magic)
+		}
+		start, end := int(hdr.PropertiesOff), int(hdr.PropertiesOff+hdr.PropertiesLen)
+		if start < binary.Size(hdr) || end > len(buf) || start > end {
+			return nil, fmt.Errorf("invalid udev event properties at %d-%d", start, end)
+		}
+		return parseProperties(buf[start:end]), nil
+	}
+
+	// kernel events start with "action@devpath"
+	i := bytes.IndexByte(buf, 0)
+	if i < 0 || !bytes.Contains(buf[:i], []byte("@")) {
+		return nil, fmt.Errorf("invalid kernel event header")
+	}
+	return parseProperties(buf[i+1:]), nil
+}
+
+// parseProperties parses NUL separated KEY=value pairs.
+func parseProperties(buf []byte) map[string]string {
+	env := make(map[string]string)
+	for _, prop := range bytes.Split(buf, []byte{0}) {
+		kv := strings.SplitN(string(prop), "=", 2)
+		if len(kv) == 2 {
+			env[kv[0]] = kv[1]
+		}
+	}
+	return env
+}
+
+// enumerateExistingDevices returns the properties of the devices known
+// to udev, as if they were just added.
+func enumerateExistingDevices() ([]map[string]string, error) {
+	cmd := exec.Command("udevadm", "info", "-e")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	devices, parseErr := parseUdevadmInfo(stdout)
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("cannot run udevadm: %v", err)
+	}
+	return devices, parseErr
+}
+
+// parseUdevadmInfo parses the output of "udevadm info -e", which is made
+// of blocks of lines like "E: KEY=value" separated by empty lines.
+func parseUdevadmInfo(r io.Reader) ([]map[string]string, error) {
+	var devices []map[string]string
+	var env map[string]string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if env != nil {
+				devices = append(devices, env)
+				env = nil
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, "E: ") {
+			continue
+		}
+		kv := strings.SplitN(line[len("E: "):], "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("cannot parse udevadm output line %q", line)
+		}
+		if env == nil {
+			env = map[string]string{"ACTION": "add"}
+		}
+		env[kv[0]] = kv[1]
+	}
+	if env != nil {
+		devices = append(devices, env)
+	}
+	return devices, scanner.Err()
+}
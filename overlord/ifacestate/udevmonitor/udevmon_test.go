@@ -0,0 +1,165 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package udevmonitor_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"syscall"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/ifacestate/udevmonitor"
+	"github.com/snapcore/snapd/testutil"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type udevMonitorSuite struct{}
+
+var _ = Suite(&udevMonitorSuite{})
+
+func (s *udevMonitorSuite) TestParseKernelEvent(c *C) {
+	buf := []byte("add@/devices/a/ttyUSB0\x00ACTION=add\x00DEVPATH=/devices/a/ttyUSB0\x00SUBSYSTEM=tty\x00DEVNAME=ttyUSB0\x00")
+	env, err := udevmonitor.ParseUEvent(buf)
+	c.Assert(err, IsNil)
+	c.Check(env, DeepEquals, map[string]string{
+		"ACTION":    "add",
+		"DEVPATH":   "/devices/a/ttyUSB0",
+		"SUBSYSTEM": "tty",
+		"DEVNAME":   "ttyUSB0",
+	})
+
+	_, err = udevmonitor.ParseUEvent([]byte("garbage"))
+	c.Check(err, ErrorMatches, "invalid kernel event header")
+}
+
+func udevEvent(c *C, magic []byte, props string) []byte {
+	const headerSize = 40
+	var buf bytes.Buffer
+	buf.WriteString("libudev\x00")
+	buf.Write(magic)
+	for _, v := range []uint32{headerSize, headerSize, uint32(len(props)), 0, 0, 0, 0} {
+		c.Assert(binary.Write(&buf, udevmonitor.NativeEndian, v), IsNil)
+	}
+	buf.WriteString(props)
+	return buf.Bytes()
+}
+
+func (s *udevMonitorSuite) TestParseUdevEvent(c *C) {
+	buf := udevEvent(c, []byte{0xfe, 0xed, 0xca, 0xfe}, "ACTION=remove\x00DEVPATH=/devices/a\x00SUBSYSTEM=hidraw\x00ID_VENDOR_ID=0403\x00")
+	env, err := udevmonitor.ParseUEvent(buf)
+	c.Assert(err, IsNil)
+	c.Check(env, DeepEquals, map[string]string{
+		"ACTION":       "remove",
+		"DEVPATH":      "/devices/a",
+		"SUBSYSTEM":    "hidraw",
+		"ID_VENDOR_ID": "0403",
+	})
+
+	buf = udevEvent(c, []byte{1, 2, 3, 4}, "ACTION=remove\x00")
+	_, err = udevmonitor.ParseUEvent(buf)
+	c.Check(err, ErrorMatches, "invalid udev event magic 01020304")
+
+	buf = udevEvent(c, []byte{0xfe, 0xed, 0xca, 0xfe}, "ACTION=remove\x00")
+	_, err = udevmonitor.ParseUEvent(buf[:len(buf)-2])
+	c.Check(err, ErrorMatches, "invalid udev event properties at 40-54")
+}
+
+const udevadmInfo = `P: /devices/a/ttyUSB0
+N: ttyUSB0
+E: DEVNAME=/dev/ttyUSB0
+E: DEVPATH=/devices/a/ttyUSB0
+E: SUBSYSTEM=tty
+
+P: /devices/b
+E: DEVPATH=/devices/b
+E: SUBSYSTEM=usb
+`
+
+func (s *udevMonitorSuite) TestParseUdevadmInfo(c *C) {
+	devices, err := udevmonitor.ParseUdevadmInfo(strings.NewReader(udevadmInfo))
+	c.Assert(err, IsNil)
+	c.Check(devices, DeepEquals, []map[string]string{
+		{"ACTION": "add", "DEVNAME": "/dev/ttyUSB0", "DEVPATH": "/devices/a/ttyUSB0", "SUBSYSTEM": "tty"},
+		{"ACTION": "add", "DEVPATH": "/devices/b", "SUBSYSTEM": "usb"},
+	})
+
+	_, err = udevmonitor.ParseUdevadmInfo(strings.NewReader("E: garbage\n"))
+	c.Check(err, ErrorMatches, `cannot parse udevadm output line "E: garbage"`)
+}
+
+func (s *udevMonitorSuite) TestFdSet(c *C) {
+	var fds syscall.FdSet
+	for _, fd := range []int{0, 31, 32, 63, 64, 1023} {
+		c.Check(udevmonitor.FdIsSet(&fds, fd), Equals, false, Commentf("%d", fd))
+		udevmonitor.FdSet(&fds, fd)
+		c.Check(udevmonitor.FdIsSet(&fds, fd), Equals, true, Commentf("%d", fd))
+	}
+	c.Check(udevmonitor.FdIsSet(&fds, 33), Equals, false)
+}
+
+func (s *udevMonitorSuite) TestCheckSender(c *C) {
+	creds := func(uid uint32) []byte {
+		return syscall.UnixCredentials(&syscall.Ucred{Pid: 42, Uid: uid, Gid: uid})
+	}
+	for _, t := range []struct {
+		from syscall.Sockaddr
+		oob  []byte
+		err  string
+	}{
+		// udevd multicasting to the udev group
+		{&syscall.SockaddrNetlink{Groups: 2, Pid: 42}, creds(0), ""},
+		// the kernel multicasting to the kernel group
+		{&syscall.SockaddrNetlink{Groups: 1}, creds(0), ""},
+		{&syscall.SockaddrNetlink{Groups: 2, Pid: 42}, creds(1000), "message sent by user 1000"},
+		{&syscall.SockaddrNetlink{Groups: 2, Pid: 42}, nil, "no sender credentials"},
+		{&syscall.SockaddrNetlink{Groups: 0, Pid: 42}, creds(0), "unicast message from port 42"},
+		{&syscall.SockaddrNetlink{Groups: 1, Pid: 42}, creds(0), "kernel event sent by port 42"},
+		{&syscall.SockaddrUnix{Name: "foo"}, creds(0), "unexpected sender address \\*syscall.SockaddrUnix"},
+	} {
+		err := udevmonitor.CheckSender(t.from, t.oob)
+		if t.err == "" {
+			c.Check(err, IsNil, Commentf("%#v", t.from))
+		} else {
+			c.Check(err, ErrorMatches, t.err, Commentf("%#v", t.from))
+		}
+	}
+}
+
+func (s *udevMonitorSuite) TestEnumerateExistingDevices(c *C) {
+	udevadm := testutil.MockCommand(c, "udevadm", `
+cat <<EOF
+P: /devices/b
+E: DEVPATH=/devices/b
+E: SUBSYSTEM=usb
+EOF
+`)
+	defer udevadm.Restore()
+
+	devices, err := udevmonitor.EnumerateExisting()
+	c.Assert(err, IsNil)
+	c.Check(devices, DeepEquals, []map[string]string{
+		{"ACTION": "add", "DEVPATH": "/devices/b", "SUBSYSTEM": "usb"},
+	})
+	c.Check(udevadm.Calls(), DeepEquals, [][]string{{"udevadm", "info", "-e"}})
+}
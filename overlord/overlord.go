@@ -21,7 +21,9 @@
 package overlord
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -173,7 +175,7 @@ func (o *Overlord) addManager(mgr StateManager) {
 	o.stateEng.AddManager(mgr)
 }
 
-func loadState(backend state.Backend) (*state.State, error) {
+func loadState(backend *overlordStateBackend) (*state.State, error) {
 	if !osutil.FileExists(dirs.SnapStateFile) {
 		// fail fast, mostly interesting for tests, this dir is setup
 		// by the snapd package
@@ -181,17 +183,41 @@ func loadState(backend state.Backend) (*state.State, error) {
 		if !osutil.IsDirectory(stateDir) {
 			return nil, fmt.Errorf("fatal: directory %q must be present", stateDir)
 		}
+	}
+
+	var r io.Reader
+	journalFile := state.JournalPath(dirs.SnapStateFile)
+	useJournal := osutil.GetenvBool("SNAPD_JOURNALED_STATE")
+	if useJournal || osutil.FileExists(journalFile) {
+		// the journal is recovered into the state file also when
+		// going back to plain checkpoints
+		j, data, err := state.OpenJournal(dirs.SnapStateFile, journalFile)
+		if err != nil {
+			return nil, err
+		}
+		if useJournal {
+			backend.journal = j
+		} else if err := j.Remove(); err != nil {
+			return nil, fmt.Errorf("cannot remove the state journal: %s", err)
+		}
+		if data != nil {
+			r = bytes.NewReader(data)
+		}
+	} else if osutil.FileExists(dirs.SnapStateFile) {
+		f, err := os.Open(dirs.SnapStateFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the state file: %s", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	if r == nil {
 		s := state.New(backend)
 		patch.Init(s)
 		return s, nil
 	}
 
-	r, err := os.Open(dirs.SnapStateFile)
-	if err != nil {
-		return nil, fmt.Errorf("cannot read the state file: %s", err)
-	}
-	defer r.Close()
-
 	s, err := state.ReadState(backend, r)
 	if err != nil {
 		return nil, err
@@ -34,6 +34,7 @@ import (
 	"gopkg.in/tomb.v2"
 
 	"github.com/snapcore/snapd/dirs"
+	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/overlord/patch"
@@ -158,6 +159,49 @@ func (ovs *overlordSuite) TestNewWithPatches(c *C) {
 	c.Check(b, Equals, true)
 }
 
+func (ovs *overlordSuite) TestNewWithJournaledState(c *C) {
+	os.Setenv("SNAPD_JOURNALED_STATE", "1")
+	defer os.Unsetenv("SNAPD_JOURNALED_STATE")
+
+	fakeState := []byte(fmt.Sprintf(`{"data":{"patch-level":%d,"some":"data"},"changes":null,"tasks":null,"last-change-id":0,"last-task-id":0,"last-lane-id":0}`, patch.Level))
+	err := ioutil.WriteFile(dirs.SnapStateFile, fakeState, 0600)
+	c.Assert(err, IsNil)
+
+	o, err := overlord.New()
+	c.Assert(err, IsNil)
+
+	st := o.State()
+	st.Lock()
+	var some string
+	c.Check(st.Get("some", &some), IsNil)
+	c.Check(some, Equals, "data")
+	st.Set("some", "other")
+	st.Unlock()
+
+	// the change went to the journal only
+	journal, err := ioutil.ReadFile(dirs.SnapStateFile + ".journal")
+	c.Assert(err, IsNil)
+	c.Check(string(journal), Matches, `.*"k":"some","v":"other".*\n`)
+	content, err := ioutil.ReadFile(dirs.SnapStateFile)
+	c.Assert(err, IsNil)
+	c.Check(string(content), testutil.Contains, `"some":"data"`)
+
+	// going back to plain checkpoints recovers the journal
+	os.Unsetenv("SNAPD_JOURNALED_STATE")
+	o, err = overlord.New()
+	c.Assert(err, IsNil)
+	c.Check(osutil.FileExists(dirs.SnapStateFile+".journal"), Equals, false)
+	content, err = ioutil.ReadFile(dirs.SnapStateFile)
+	c.Assert(err, IsNil)
+	c.Check(string(content), testutil.Contains, `"some":"other"`)
+
+	st = o.State()
+	st.Lock()
+	defer st.Unlock()
+	c.Check(st.Get("some", &some), IsNil)
+	c.Check(some, Equals, "other")
+}
+
 func (ovs *overlordSuite) TestNewWithSetupStoreError(c *C) {
 	defer overlord.MockSetupStore(func(*state.State, auth.AuthContext) error {
 		return errors.New("fake error")
@@ -10,6 +10,11 @@ func PatchesForTest() map[int]func(*state.State) error {
 	return patches
 }
 
+// ReversePatchesForTest returns the registered set of reverse patches for testing purposes.
+func ReversePatchesForTest() map[int]func(*state.State) error {
+	return reversePatches
+}
+
 // MockPatch1ReadType replaces patch1ReadType.
 func MockPatch1ReadType(f func(name string, rev snap.Revision) (snap.Type, error)) (restore func()) {
 	old := patch1ReadType
@@ -47,3 +52,9 @@ func Patch6SnapSetup(task *state.Task) (patch6SnapSetup, error) {
 	err := task.Get("snap-setup", &snapsup)
 	return snapsup, err
 }
+
+func Patch1SnapSetup(task *state.Task) (patch1SnapSetup, error) {
+	var snapsup patch1SnapSetup
+	err := task.Get("snap-setup", &snapsup)
+	return snapsup, err
+}
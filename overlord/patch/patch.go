@@ -29,9 +29,24 @@ import (
 // Level is the current implemented patch level of the state format and content.
 var Level = 6
 
+// Sublevel is the current implemented sublevel of the patch level.
+// Sublevel patches only make additions to the state that a snapd
+// implementing an older sublevel of the same level copes with, so
+// they don't get in the way of going back to it. They must also be
+// safe to reapply, which happens after such a trip.
+var Sublevel = 0
+
 // patches maps from patch level L to the function that moves from L-1 to L.
 var patches = make(map[int]func(s *state.State) error)
 
+// reversePatches maps from patch level L to the function that moves
+// back from L to L-1, for the patches that can be reverted.
+var reversePatches = make(map[int]func(s *state.State) error)
+
+// sublevelPatches maps from sublevel S of the current patch level to
+// the function that moves from S-1 to S.
+var sublevelPatches = make(map[int]func(s *state.State) error)
+
 // Init initializes an empty state to the current implemented patch level.
 func Init(s *state.State) {
 	s.Lock()
@@ -40,22 +55,16 @@ func Init(s *state.State) {
 		panic("internal error: expected empty state, attempting to override patch-level without actual patching")
 	}
 	s.Set("patch-level", Level)
+	s.Set("patch-sublevel", Sublevel)
 }
 
 // Apply applies any necessary patches to update the provided state to
 // conventions required by the current patch level of the system.
 func Apply(s *state.State) error {
-	var stateLevel int
-	s.Lock()
-	err := s.Get("patch-level", &stateLevel)
-	s.Unlock()
-	if err != nil && err != state.ErrNoState {
+	stateLevel, stateSublevel, err := levels(s)
+	if err != nil {
 		return err
 	}
-	if stateLevel == Level {
-		// already at right level, nothing to do
-		return nil
-	}
 	if stateLevel > Level {
 		return fmt.Errorf("cannot downgrade: snapd is too old for the current system state (patch level %d)", stateLevel)
 	}
@@ -67,18 +76,107 @@ func Apply(s *state.State) error {
 		if patch == nil {
 			return fmt.Errorf("cannot upgrade: snapd is too new for the current system state (patch level %d)", level)
 		}
-		err := applyOne(patch, s, level)
+		err := applyOne(patch, s, func() {
+			s.Set("patch-level", level+1)
+			s.Set("patch-sublevel", 0)
+		})
 		if err != nil {
 			logger.Noticef("Cannot patch: %v", err)
 			return fmt.Errorf("cannot patch system state from level %d to %d: %v", level, level+1, err)
 		}
 		level++
+		stateSublevel = 0
+	}
+
+	return applySublevels(s, stateSublevel)
+}
+
+// levels returns the patch level and sublevel of the state.
+func levels(s *state.State) (level, sublevel int, err error) {
+	s.Lock()
+	defer s.Unlock()
+	err = s.Get("patch-level", &level)
+	if err != nil && err != state.ErrNoState {
+		return 0, 0, err
+	}
+	err = s.Get("patch-sublevel", &sublevel)
+	if err != nil && err != state.ErrNoState {
+		return 0, 0, err
+	}
+	return level, sublevel, nil
+}
+
+// applySublevels applies the sublevel patches of the current level
+// above the given one.
+func applySublevels(s *state.State, sublevel int) error {
+	if sublevel > Sublevel {
+		// patched by a newer snapd, whose additions we can live
+		// with; forget about them so that they get redone then
+		logger.Noticef("Resetting system state patch sublevel from %d to %d", sublevel, Sublevel)
+		s.Lock()
+		s.Set("patch-sublevel", Sublevel)
+		s.Unlock()
+		return nil
+	}
+
+	for sublevel < Sublevel {
+		logger.Noticef("Patching system state level %d from sublevel %d to %d", Level, sublevel, sublevel+1)
+		patch := sublevelPatches[sublevel+1]
+		if patch == nil {
+			return fmt.Errorf("internal error: missing patch for sublevel %d of patch level %d", sublevel+1, Level)
+		}
+		err := applyOne(patch, s, func() {
+			s.Set("patch-sublevel", sublevel+1)
+		})
+		if err != nil {
+			logger.Noticef("Cannot patch: %v", err)
+			return fmt.Errorf("cannot patch system state level %d from sublevel %d to %d: %v", Level, sublevel, sublevel+1, err)
+		}
+		sublevel++
 	}
 
 	return nil
 }
 
-func applyOne(patch func(s *state.State) error, s *state.State, level int) error {
+// Downgrade reverts the provided state to the given patch level by
+// applying in turn the reverse of each patch above it, so that an
+// older snapd can use it. It's meant to be used on the state of a
+// system where snapd isn't running. On error the state is left at
+// the level of the patch that couldn't be reverted.
+func Downgrade(s *state.State, toLevel int) error {
+	stateLevel, _, err := levels(s)
+	if err != nil {
+		return err
+	}
+	if toLevel < 0 {
+		return fmt.Errorf("cannot downgrade to invalid patch level %d", toLevel)
+	}
+	if toLevel > stateLevel {
+		return fmt.Errorf("cannot downgrade system state at patch level %d to higher level %d", stateLevel, toLevel)
+	}
+
+	level := stateLevel
+	for level > toLevel {
+		logger.Noticef("Reverting system state patch level %d to %d", level, level-1)
+		reverse := reversePatches[level]
+		if reverse == nil {
+			return fmt.Errorf("cannot downgrade: patch level %d cannot be reverted", level)
+		}
+		err := applyOne(reverse, s, func() {
+			s.Set("patch-level", level-1)
+			// sublevel patches of the older level get redone
+			s.Set("patch-sublevel", 0)
+		})
+		if err != nil {
+			return fmt.Errorf("cannot revert system state from patch level %d to %d: %v", level, level-1, err)
+		}
+		level--
+	}
+
+	return nil
+}
+
+func applyOne(patch func(s *state.State) error, s *state.State, record func()) error {
 	s.Lock()
 	defer s.Unlock()
 
@@ -87,7 +185,7 @@ func applyOne(patch func(s *state.State) error, s *state.State, level int) error
 		return err
 	}
 
-	s.Set("patch-level", level+1)
+	record()
 	return nil
 }
 
@@ -102,3 +200,24 @@ func Mock(level int, p map[int]func(*state.State) error) (restore func()) {
 		patches = oldPatches
 	}
 }
+
+// MockReverse mocks the available reverse patches.
+func MockReverse(p map[int]func(*state.State) error) (restore func()) {
+	old := reversePatches
+	reversePatches = p
+	return func() {
+		reversePatches = old
+	}
+}
+
+// MockSublevel mocks the current patch sublevel and available sublevel patches.
+func MockSublevel(sublevel int, p map[int]func(*state.State) error) (restore func()) {
+	oldSublevel := Sublevel
+	oldPatches := sublevelPatches
+	Sublevel = sublevel
+	sublevelPatches = p
+	return func() {
+		Sublevel = oldSublevel
+		sublevelPatches = oldPatches
+	}
+}
@@ -20,6 +20,7 @@
 package patch
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"path/filepath"
 
@@ -30,6 +31,7 @@ import (
 
 func init() {
 	patches[1] = patch1
+	reversePatches[1] = patch1Reverse
 }
 
 type patch1SideInfo struct {
@@ -121,3 +123,24 @@ func patch1(s *state.State) error {
 	s.Set("snaps", stateMap)
 	return nil
 }
+
+// patch1Reverse drops the snap type and the current revision from the snap state.
+func patch1Reverse(s *state.State) error {
+	var stateMap map[string]map[string]*json.RawMessage
+
+	err := s.Get("snaps", &stateMap)
+	if err == state.ErrNoState {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, snapst := range stateMap {
+		delete(snapst, "type")
+		delete(snapst, "current")
+	}
+
+	s.Set("snaps", stateMap)
+	return nil
+}
@@ -20,6 +20,8 @@
 package patch_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"io/ioutil"
 	"os"
@@ -156,3 +158,79 @@ func (s *patch1Suite) readType(name string, rev snap.Revision) (snap.Type, error
 
 	return snap.TypeApp, nil
 }
+
+func (s *patch1Suite) TestPatch1RoundTrip(c *C) {
+	restore := patch.MockPatch1ReadType(s.readType)
+	defer restore()
+
+	r, err := os.Open(dirs.SnapStateFile)
+	c.Assert(err, IsNil)
+	defer r.Close()
+	st, err := state.ReadState(nil, r)
+	c.Assert(err, IsNil)
+
+	restorer := patch.MockLevel(1)
+	defer restorer()
+	c.Assert(patch.Apply(st), IsNil)
+
+	// go back from patch-level 1 to patch-level 0
+	c.Assert(patch.Downgrade(st, 0), IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+
+	var patchLevel int
+	c.Assert(st.Get("patch-level", &patchLevel), IsNil)
+	c.Check(patchLevel, Equals, 0)
+
+	var stateMap map[string]map[string]*json.RawMessage
+	c.Assert(st.Get("snaps", &stateMap), IsNil)
+	c.Check(stateMap, HasLen, 4)
+	for name, snapst := range stateMap {
+		c.Check(snapst["type"], IsNil, Commentf(name))
+		c.Check(snapst["current"], IsNil, Commentf(name))
+	}
+
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(st, "core", &snapst), IsNil)
+	c.Assert(snapst.Sequence, HasLen, 3)
+	c.Check(snapst.Sequence[2].Revision, Equals, snap.R(111))
+
+	// and forward again
+	st.Unlock()
+	c.Assert(patch.Apply(st), IsNil)
+	st.Lock()
+	c.Assert(snapstate.Get(st, "core", &snapst), IsNil)
+	c.Check(snapst.SnapType, Equals, string(snap.TypeOS))
+	c.Check(snapst.Current, Equals, snap.R(111))
+}
+
+func (s *patch1Suite) TestPatch1RoundTripJSON(c *C) {
+	restore := patch.MockPatch1ReadType(s.readType)
+	defer restore()
+	restorer := patch.MockLevel(1)
+	defer restorer()
+
+	st, err := state.ReadState(nil, bytes.NewReader([]byte(`{
+	"data": {
+		"patch-level": 0,
+		"snaps": {
+			"core": {
+				"sequence": [{"name": "core", "snap-id": "core-id", "revision": "1"}],
+				"active": true,
+				"channel": "stable",
+				"flags": 1,
+				"local-revision": "x1"
+			}
+		}
+	}
+}`)))
+	c.Assert(err, IsNil)
+	before := patchedData(c, st)
+
+	// go from patch level 0 -> 1 and back
+	c.Assert(patch.Apply(st), IsNil)
+	c.Assert(patch.Downgrade(st, 0), IsNil)
+
+	c.Check(patchedData(c, st), DeepEquals, before)
+}
@@ -20,12 +20,15 @@
 package patch
 
 import (
+	"encoding/json"
+
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
 )
 
 func init() {
 	patches[2] = patch2
+	reversePatches[2] = patch2Reverse
 }
 
 type patch2SideInfo struct {
@@ -163,3 +166,49 @@ func patch2(s *state.State) error {
 
 	return nil
 }
+
+// patch2Reverse:
+// - migrates SnapSetup.SideInfo.{RealName,Revision} back to SnapSetup.{Name,Revision}
+// - the snap state is kept as is, candidates dropped by patch2 can't be restored
+func patch2Reverse(s *state.State) error {
+	for _, t := range s.Tasks() {
+		var snapsup map[string]*json.RawMessage
+		err := t.Get("snap-setup", &snapsup)
+		if err == state.ErrNoState {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if raw := snapsup["side-info"]; raw != nil {
+			var si patch2SideInfo
+			if err := json.Unmarshal(*raw, &si); err != nil {
+				return err
+			}
+			if si.RealName != "" {
+				if err := patch2SetRaw(snapsup, "name", si.RealName); err != nil {
+					return err
+				}
+			}
+			if !si.Revision.Unset() {
+				if err := patch2SetRaw(snapsup, "revision", si.Revision); err != nil {
+					return err
+				}
+			}
+		}
+		delete(snapsup, "side-info")
+		t.Set("snap-setup", snapsup)
+	}
+
+	return nil
+}
+
+func patch2SetRaw(m map[string]*json.RawMessage, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	raw := json.RawMessage(data)
+	m[key] = &raw
+	return nil
+}
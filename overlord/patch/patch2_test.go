@@ -20,6 +20,7 @@
 package patch_test
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -177,3 +178,142 @@ func (s *patch2Suite) TestPatch2(c *C) {
 	err = snapstate.Get(st, "bar", &snapst)
 	c.Assert(err, IsNil)
 }
+
+func (s *patch2Suite) TestPatch2RoundTrip(c *C) {
+	restorer := patch.MockLevel(2)
+	defer restorer()
+
+	r, err := os.Open(dirs.SnapStateFile)
+	c.Assert(err, IsNil)
+	defer r.Close()
+	st, err := state.ReadState(nil, r)
+	c.Assert(err, IsNil)
+
+	st.Lock()
+	before, err := patch.Patch1SnapSetup(st.Task("1"))
+	st.Unlock()
+	c.Assert(err, IsNil)
+
+	// go from patch level 1 -> 2 and back
+	c.Assert(patch.Apply(st), IsNil)
+	c.Assert(patch.Downgrade(st, 1), IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+
+	var patchLevel int
+	c.Assert(st.Get("patch-level", &patchLevel), IsNil)
+	c.Check(patchLevel, Equals, 1)
+
+	// SnapSetup.SideInfo.{RealName,Revision} -> SnapSetup.{Name,Revision}
+	after, err := patch.Patch1SnapSetup(st.Task("1"))
+	c.Assert(err, IsNil)
+	c.Check(after, DeepEquals, before)
+
+	// the sequence is kept, with the backfilled names
+	var snapst snapstate.SnapState
+	err = snapstate.Get(st, "foo", &snapst)
+	c.Assert(err, IsNil)
+	c.Assert(snapst.Sequence, HasLen, 2)
+	c.Check(snapst.Sequence[1].RealName, Equals, "foo")
+	c.Check(snapst.Sequence[1].Revision, Equals, snap.R("x2"))
+	c.Check(snapst.Current, Equals, snap.R("x2"))
+}
+
+func (s *patch2Suite) TestPatch2RoundTripJSON(c *C) {
+	restorer := patch.MockLevel(2)
+	defer restorer()
+
+	st, err := state.ReadState(nil, bytes.NewReader([]byte(`{
+	"data": {
+		"patch-level": 1,
+		"snaps": {
+			"foo": {
+				"type": "app",
+				"sequence": [{"name": "foo", "snap-id": "foo-id", "revision": "x1", "developer": "dev"}],
+				"active": true,
+				"current": "x1",
+				"channel": "edge",
+				"flags": 1
+			}
+		}
+	},
+	"changes": {"1": {"id": "1", "kind": "install-snap", "task-ids": ["11"]}},
+	"tasks": {
+		"11": {
+			"id": "11",
+			"change": "1",
+			"kind": "prepare-snap",
+			"data": {"snap-setup": {
+				"name": "foo",
+				"revision": "x1",
+				"channel": "edge",
+				"flags": 1,
+				"snap-path": "/some/path"
+			}}
+		}
+	}
+}`)))
+	c.Assert(err, IsNil)
+	before := patchedData(c, st)
+
+	// go from patch level 1 -> 2 and back
+	c.Assert(patch.Apply(st), IsNil)
+	c.Assert(patch.Downgrade(st, 1), IsNil)
+
+	c.Check(patchedData(c, st), DeepEquals, before)
+}
+
+func (s *patch2Suite) TestPatch2ReverseKeepsOtherKeys(c *C) {
+	restorer := patch.MockLevel(2)
+	defer restorer()
+
+	st, err := state.ReadState(nil, bytes.NewReader([]byte(`{
+	"data": {
+		"patch-level": 2,
+		"snaps": {
+			"foo": {
+				"type": "app",
+				"sequence": [{"name": "foo", "revision": "x1", "developer-id": "dev-id"}],
+				"current": "x1",
+				"aliases": {"bar": {"auto": "bar"}}
+			}
+		}
+	},
+	"changes": {"1": {"id": "1", "kind": "install-snap", "task-ids": ["11"]}},
+	"tasks": {
+		"11": {
+			"id": "11",
+			"change": "1",
+			"kind": "prepare-snap",
+			"data": {"snap-setup": {
+				"base": "core18",
+				"devmode": true,
+				"download-info": {"download-url": "http://example.com/foo"},
+				"side-info": {"name": "foo", "revision": "x1", "developer-id": "dev-id"}
+			}}
+		}
+	}
+}`)))
+	c.Assert(err, IsNil)
+
+	c.Assert(patch.Downgrade(st, 1), IsNil)
+
+	c.Check(patchedData(c, st), DeepEquals, map[string]interface{}{
+		"snaps": map[string]interface{}{
+			"foo": map[string]interface{}{
+				"type":     "app",
+				"sequence": []interface{}{map[string]interface{}{"name": "foo", "revision": "x1", "developer-id": "dev-id"}},
+				"current":  "x1",
+				"aliases":  map[string]interface{}{"bar": map[string]interface{}{"auto": "bar"}},
+			},
+		},
+		"task 11": map[string]interface{}{
+			"name":          "foo",
+			"revision":      "x1",
+			"base":          "core18",
+			"devmode":       true,
+			"download-info": map[string]interface{}{"download-url": "http://example.com/foo"},
+		},
+	})
+}
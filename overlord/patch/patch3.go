@@ -28,6 +28,7 @@ import (
 
 func init() {
 	patches[3] = patch3
+	reversePatches[3] = patch3Reverse
 }
 
 // patch3:
@@ -61,3 +62,20 @@ func patch3(s *state.State) error {
 
 	return nil
 }
+
+// patch3Reverse:
+// - marks pending {start,stop}-snap-services tasks as done, snapd at
+//   patch level 2 takes care of services itself and doesn't know them
+func patch3Reverse(s *state.State) error {
+	for _, t := range s.Tasks() {
+		if t.Status().Ready() {
+			continue
+		}
+
+		if t.Kind() == "start-snap-services" || t.Kind() == "stop-snap-services" {
+			t.SetStatus(state.DoneStatus)
+		}
+	}
+
+	return nil
+}
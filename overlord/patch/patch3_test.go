@@ -147,3 +147,38 @@ func (s *patch3Suite) TestPatch3(c *C) {
 		}
 	}
 }
+
+func (s *patch3Suite) TestPatch3RoundTrip(c *C) {
+	restorer := patch.MockLevel(3)
+	defer restorer()
+
+	r, err := os.Open(dirs.SnapStateFile)
+	c.Assert(err, IsNil)
+	defer r.Close()
+	st, err := state.ReadState(nil, r)
+	c.Assert(err, IsNil)
+
+	// go from patch level 2 -> 3 and back
+	c.Assert(patch.Apply(st), IsNil)
+	c.Assert(patch.Downgrade(st, 2), IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+
+	var patchLevel int
+	c.Assert(st.Get("patch-level", &patchLevel), IsNil)
+	c.Check(patchLevel, Equals, 2)
+
+	// the added tasks are out of the way
+	c.Assert(st.Tasks(), HasLen, 7)
+	for _, t := range st.Tasks() {
+		switch t.Kind() {
+		case "start-snap-services", "stop-snap-services":
+			c.Check(t.Status(), Equals, state.DoneStatus)
+		case "unrelated":
+			c.Check(t.Status(), Equals, state.DoneStatus)
+		default:
+			c.Check(t.Status(), Equals, state.DoStatus)
+		}
+	}
+}
@@ -30,6 +30,7 @@ import (
 
 func init() {
 	patches[4] = patch4
+	reversePatches[4] = patch4Reverse
 }
 
 type patch4Flags int
@@ -320,3 +321,83 @@ func patch4(s *state.State) error {
 
 	return nil
 }
+
+func (p4 patch4T) clearRevertFlag(task *state.Task) error {
+	var snapsup patch4SnapSetup
+	err := p4.getMaybe(task, "snap-setup", &snapsup)
+	switch err {
+	case nil:
+		snapsup.Flags &^= patch4FlagRevert
+
+		// save it back
+		task.Set("snap-setup", &snapsup)
+		return nil
+	case state.ErrNoState:
+		return nil
+	default:
+		return err
+	}
+}
+
+func (p4 patch4T) unmangle(task *state.Task) error {
+	var idx int
+	switch err := p4.getMaybe(task, "old-candidate-index", &idx); err {
+	case nil:
+		// continue below
+	case state.ErrNoState:
+		return nil
+	default:
+		return err
+	}
+
+	task.Clear("old-candidate-index")
+	if task.Status() == state.DoStatus {
+		// had-candidate gets set when the task runs
+		return nil
+	}
+	// only reverts link to a revision that is in the sequence already
+	task.Set("had-candidate", idx >= 0 && task.Change().Kind() == "revert-snap")
+
+	return nil
+}
+
+// patch4Reverse:
+//  - drop the Revert flag from in-progress revert-snap changes
+//  - move from old-candidate-index back to had-candidate in link-snap tasks
+//  - mark pending cleanup tasks as done, snapd at patch level 3 doesn't know them
+func patch4Reverse(s *state.State) error {
+	p4 := patch4T{}
+	for _, change := range s.Changes() {
+		if change.Status().Ready() {
+			continue
+		}
+
+		if change.Kind() != "revert-snap" {
+			continue
+		}
+		for _, task := range change.Tasks() {
+			if err := p4.clearRevertFlag(task); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, task := range s.Tasks() {
+		if task.Change().Status().Ready() {
+			continue
+		}
+
+		switch task.Kind() {
+		case "link-snap":
+			if err := p4.unmangle(task); err != nil {
+				return err
+			}
+		case "cleanup":
+			if !task.Status().Ready() {
+				task.SetStatus(state.DoneStatus)
+			}
+		}
+	}
+
+	return nil
+}
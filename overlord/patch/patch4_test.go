@@ -449,3 +449,68 @@ func (s *patch4Suite) TestPatch4OnRefreshesNoHadCandidateYet(c *C) {
 	// we added cleanup
 	c.Check(len(task.Change().Tasks()), Equals, 7+1)
 }
+
+func (s *patch4Suite) TestPatch4RoundTrip(c *C) {
+	restorer := patch.MockLevel(4)
+	defer restorer()
+
+	r, err := os.Open(dirs.SnapStateFile)
+	c.Assert(err, IsNil)
+	defer r.Close()
+	st, err := state.ReadState(nil, r)
+	c.Assert(err, IsNil)
+
+	st.Lock()
+	// simulate that the link-snap tasks ran (but the changes are
+	// not fully done yet)
+	st.Task("4").SetStatus(state.DoneStatus)
+	st.Task("16").SetStatus(state.DoneStatus)
+	st.Unlock()
+
+	// go from patch level 3 -> 4 and back
+	c.Assert(patch.Apply(st), IsNil)
+	c.Assert(patch.Downgrade(st, 3), IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+
+	var patchLevel int
+	c.Assert(st.Get("patch-level", &patchLevel), IsNil)
+	c.Check(patchLevel, Equals, 3)
+
+	for _, tc := range []struct {
+		id           string
+		hadCandidate bool
+		tasks        int
+	}{
+		{"4", true, 4},
+		{"16", false, 7 + 1},
+	} {
+		task := st.Task(tc.id)
+		c.Assert(task, NotNil)
+
+		snapsup, err := patch.Patch4TaskSnapSetup(task)
+		c.Assert(err, IsNil)
+		c.Check(snapsup.Flags.Revert(), Equals, false)
+
+		var had bool
+		var idx int
+		c.Check(task.Get("had-candidate", &had), IsNil)
+		c.Check(had, Equals, tc.hadCandidate)
+		c.Check(task.Get("old-candidate-index", &idx), Equals, state.ErrNoState)
+
+		// the added cleanup is out of the way
+		tasks := task.Change().Tasks()
+		c.Check(tasks, HasLen, tc.tasks)
+		for _, t := range tasks {
+			if t.Kind() == "cleanup" {
+				c.Check(t.Status(), Equals, state.DoneStatus)
+			}
+		}
+	}
+
+	// a link-snap that didn't run yet is just back as it was
+	task := st.Task("18")
+	var had bool
+	c.Check(task.Get("had-candidate", &had), Equals, state.ErrNoState)
+}
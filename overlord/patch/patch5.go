@@ -28,6 +28,7 @@ import (
 
 func init() {
 	patches[5] = patch5
+	reversePatches[5] = patch5Reverse
 }
 
 type log struct{}
@@ -82,3 +83,10 @@ func patch5(st *state.State) error {
 
 	return nil
 }
+
+// patch5Reverse:
+//  - nothing to do, the regenerated .service files work as well with
+//    snapd at patch level 4
+func patch5Reverse(st *state.State) error {
+	return nil
+}
@@ -20,12 +20,15 @@
 package patch
 
 import (
+	"encoding/json"
+
 	"github.com/snapcore/snapd/overlord/state"
 	"github.com/snapcore/snapd/snap"
 )
 
 func init() {
 	patches[6] = patch6
+	reversePatches[6] = patch6Reverse
 }
 
 type patch6Flags struct {
@@ -113,3 +116,88 @@ func patch6(st *state.State) error {
 
 	return nil
 }
+
+func patch4FlagsFromPatch6(newFlags patch6Flags) patch4Flags {
+	var flags patch4Flags
+	if newFlags.DevMode {
+		flags |= patch4FlagDevMode
+	}
+	if newFlags.TryMode {
+		flags |= patch4FlagTryMode
+	}
+	if newFlags.JailMode {
+		flags |= patch4FlagJailMode
+	}
+	if newFlags.Revert {
+		flags |= patch4FlagRevert
+	}
+	return flags
+}
+
+// patch6ReverseFlags replaces the devmode, jailmode, trymode and revert
+// bools with the patch4 flags int, leaving every other key alone.
+func patch6ReverseFlags(m map[string]*json.RawMessage) error {
+	var newFlags patch6Flags
+	for key, flag := range map[string]*bool{
+		"devmode":  &newFlags.DevMode,
+		"jailmode": &newFlags.JailMode,
+		"trymode":  &newFlags.TryMode,
+		"revert":   &newFlags.Revert,
+	} {
+		if raw := m[key]; raw != nil {
+			if err := json.Unmarshal(*raw, flag); err != nil {
+				return err
+			}
+		}
+		delete(m, key)
+	}
+
+	delete(m, "flags")
+	if flags := patch4FlagsFromPatch6(newFlags); flags != 0 {
+		data, err := json.Marshal(flags)
+		if err != nil {
+			return err
+		}
+		raw := json.RawMessage(data)
+		m["flags"] = &raw
+	}
+	return nil
+}
+
+// patch6Reverse:
+//  - move back from flags-are-struct-of-bools to flags-are-ints
+func patch6Reverse(st *state.State) error {
+	var stateMap map[string]map[string]*json.RawMessage
+	err := st.Get("snaps", &stateMap)
+	if err == state.ErrNoState {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, snapst := range stateMap {
+		if err := patch6ReverseFlags(snapst); err != nil {
+			return err
+		}
+	}
+
+	for _, task := range st.Tasks() {
+		var snapsup map[string]*json.RawMessage
+		err := task.Get("snap-setup", &snapsup)
+		if err == state.ErrNoState {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := patch6ReverseFlags(snapsup); err != nil {
+			return err
+		}
+		task.Set("snap-setup", snapsup)
+	}
+
+	st.Set("snaps", stateMap)
+
+	return nil
+}
@@ -20,6 +20,7 @@
 package patch_test
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -207,3 +208,156 @@ func (s *patch6Suite) TestPatch6(c *C) {
 		}
 	}
 }
+
+func (s *patch6Suite) TestPatch6RoundTrip(c *C) {
+	restorer := patch.MockLevel(6)
+	defer restorer()
+
+	r, err := os.Open(dirs.SnapStateFile)
+	c.Assert(err, IsNil)
+	defer r.Close()
+	st, err := state.ReadState(nil, r)
+	c.Assert(err, IsNil)
+
+	snapsups := func() map[string]interface{} {
+		res := make(map[string]interface{})
+		for _, task := range st.Tasks() {
+			snapsup, err := patch.Patch4TaskSnapSetup(task)
+			if err != nil {
+				continue
+			}
+			res[task.ID()] = snapsup
+		}
+		return res
+	}
+
+	st.Lock()
+	stateMapBefore, err := patch.Patch4StateMap(st)
+	c.Assert(err, IsNil)
+	snapsupsBefore := snapsups()
+	st.Unlock()
+	c.Assert(snapsupsBefore, HasLen, 3)
+
+	// go from patch level 5 -> 6 and back
+	c.Assert(patch.Apply(st), IsNil)
+	c.Assert(patch.Downgrade(st, 5), IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+
+	var patchLevel int
+	c.Assert(st.Get("patch-level", &patchLevel), IsNil)
+	c.Check(patchLevel, Equals, 5)
+
+	stateMap, err := patch.Patch4StateMap(st)
+	c.Assert(err, IsNil)
+	c.Check(stateMap, DeepEquals, stateMapBefore)
+	c.Check(snapsups(), DeepEquals, snapsupsBefore)
+}
+
+func (s *patch6Suite) TestPatch6RoundTripJSON(c *C) {
+	restorer := patch.MockLevel(6)
+	defer restorer()
+
+	st, err := state.ReadState(nil, bytes.NewReader([]byte(`{
+	"data": {
+		"patch-level": 5,
+		"snaps": {
+			"a": {
+				"type": "app",
+				"sequence": [{"name": "a", "snap-id": "a-id", "revision": "2"}],
+				"active": true,
+				"current": "2",
+				"channel": "edge",
+				"flags": 5
+			}
+		}
+	},
+	"changes": {"1": {"id": "1", "kind": "revert-snap", "task-ids": ["11"]}},
+	"tasks": {
+		"11": {
+			"id": "11",
+			"change": "1",
+			"kind": "prepare-snap",
+			"data": {"snap-setup": {
+				"channel": "edge",
+				"user-id": 1,
+				"flags": 1073741825,
+				"snap-path": "/some/path",
+				"side-info": {"name": "a", "snap-id": "a-id", "revision": "2"}
+			}}
+		}
+	}
+}`)))
+	c.Assert(err, IsNil)
+	before := patchedData(c, st)
+
+	// go from patch level 5 -> 6 and back
+	c.Assert(patch.Apply(st), IsNil)
+	c.Assert(patch.Downgrade(st, 5), IsNil)
+
+	c.Check(patchedData(c, st), DeepEquals, before)
+}
+
+func (s *patch6Suite) TestPatch6ReverseKeepsOtherKeys(c *C) {
+	restorer := patch.MockLevel(6)
+	defer restorer()
+
+	st, err := state.ReadState(nil, bytes.NewReader([]byte(`{
+	"data": {
+		"patch-level": 6,
+		"snaps": {
+			"a": {
+				"type": "app",
+				"sequence": [{"name": "a", "snap-id": "a-id", "revision": "2", "contact": "mailto:a@example.com"}],
+				"current": "2",
+				"devmode": true,
+				"classic": true,
+				"required": true,
+				"aliases": {"b": {"auto": "b"}},
+				"auto-aliases-disabled": true
+			}
+		}
+	},
+	"changes": {"1": {"id": "1", "kind": "install-snap", "task-ids": ["11"]}},
+	"tasks": {
+		"11": {
+			"id": "11",
+			"change": "1",
+			"kind": "prepare-snap",
+			"data": {"snap-setup": {
+				"base": "core18",
+				"jailmode": true,
+				"ignore-validation": true,
+				"skip-configure": true,
+				"side-info": {"name": "a", "revision": "2", "contact": "mailto:a@example.com"}
+			}}
+		}
+	}
+}`)))
+	c.Assert(err, IsNil)
+
+	c.Assert(patch.Downgrade(st, 5), IsNil)
+
+	c.Check(patchedData(c, st), DeepEquals, map[string]interface{}{
+		"snaps": map[string]interface{}{
+			"a": map[string]interface{}{
+				"type":                  "app",
+				"sequence":              []interface{}{map[string]interface{}{"name": "a", "snap-id": "a-id", "revision": "2", "contact": "mailto:a@example.com"}},
+				"current":               "2",
+				"flags":                 float64(1),
+				"classic":               true,
+				"required":              true,
+				"aliases":               map[string]interface{}{"b": map[string]interface{}{"auto": "b"}},
+				"auto-aliases-disabled": true,
+			},
+		},
+		"task 11": map[string]interface{}{
+			"base":              "core18",
+			"flags":             float64(4),
+			"ignore-validation": true,
+			"skip-configure":    true,
+			"side-info":         map[string]interface{}{"name": "a", "revision": "2", "contact": "mailto:a@example.com"},
+		},
+	})
+}
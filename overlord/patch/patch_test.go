@@ -49,6 +49,10 @@ func (s *patchSuite) TestInit(c *C) {
 	err := st.Get("patch-level", &patchLevel)
 	c.Assert(err, IsNil)
 	c.Check(patchLevel, Equals, 2)
+	var patchSublevel int
+	err = st.Get("patch-sublevel", &patchSublevel)
+	c.Assert(err, IsNil)
+	c.Check(patchSublevel, Equals, 0)
 }
 
 func (s *patchSuite) TestNothingToDo(c *C) {
@@ -176,6 +180,153 @@ func (s *patchSuite) TestError(c *C) {
 	c.Check(n, Equals, 10)
 }
 
+func (s *patchSuite) TestApplySublevels(c *C) {
+	p1 := func(st *state.State) error {
+		var n int
+		st.Get("n", &n)
+		st.Set("n", n+1)
+		return nil
+	}
+	p2 := func(st *state.State) error {
+		var n int
+		st.Get("n", &n)
+		st.Set("n", n*10)
+		return nil
+	}
+	restore := patch.Mock(2, map[int]func(*state.State) error{
+		2: p1,
+	})
+	defer restore()
+	restore = patch.MockSublevel(2, map[int]func(*state.State) error{
+		1: p1,
+		2: p2,
+	})
+	defer restore()
+
+	st := state.New(nil)
+	st.Lock()
+	st.Set("patch-level", 1)
+	st.Set("patch-sublevel", 3)
+	st.Unlock()
+	err := patch.Apply(st)
+	c.Assert(err, IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+
+	var level, sublevel int
+	c.Assert(st.Get("patch-level", &level), IsNil)
+	c.Check(level, Equals, 2)
+	c.Assert(st.Get("patch-sublevel", &sublevel), IsNil)
+	c.Check(sublevel, Equals, 2)
+
+	var n int
+	c.Assert(st.Get("n", &n), IsNil)
+	c.Check(n, Equals, 20)
+}
+
+func (s *patchSuite) TestApplyNewerSublevel(c *C) {
+	restore := patch.Mock(2, nil)
+	defer restore()
+	restore = patch.MockSublevel(1, map[int]func(*state.State) error{
+		1: func(st *state.State) error { return fmt.Errorf("not expected") },
+	})
+	defer restore()
+
+	st := state.New(nil)
+	st.Lock()
+	st.Set("patch-level", 2)
+	st.Set("patch-sublevel", 3)
+	st.Unlock()
+	err := patch.Apply(st)
+	c.Assert(err, IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+	var sublevel int
+	c.Assert(st.Get("patch-sublevel", &sublevel), IsNil)
+	c.Check(sublevel, Equals, 1)
+}
+
+func (s *patchSuite) TestDowngrade(c *C) {
+	r32 := func(st *state.State) error {
+		var n int
+		st.Get("n", &n)
+		st.Set("n", n/10)
+		return nil
+	}
+	r21 := func(st *state.State) error {
+		var n int
+		st.Get("n", &n)
+		st.Set("n", n-1)
+		return nil
+	}
+	restore := patch.MockReverse(map[int]func(*state.State) error{
+		2: r21,
+		3: r32,
+	})
+	defer restore()
+
+	st := state.New(nil)
+	st.Lock()
+	st.Set("patch-level", 3)
+	st.Set("patch-sublevel", 2)
+	st.Set("n", 10)
+	st.Unlock()
+	err := patch.Downgrade(st, 1)
+	c.Assert(err, IsNil)
+
+	st.Lock()
+	defer st.Unlock()
+
+	var level, sublevel int
+	c.Assert(st.Get("patch-level", &level), IsNil)
+	c.Check(level, Equals, 1)
+	c.Assert(st.Get("patch-sublevel", &sublevel), IsNil)
+	c.Check(sublevel, Equals, 0)
+
+	var n int
+	c.Assert(st.Get("n", &n), IsNil)
+	c.Check(n, Equals, 0)
+}
+
+func (s *patchSuite) TestDowngradeNotReversible(c *C) {
+	restore := patch.MockReverse(map[int]func(*state.State) error{
+		3: func(st *state.State) error { return nil },
+	})
+	defer restore()
+
+	st := state.New(nil)
+	st.Lock()
+	st.Set("patch-level", 3)
+	st.Unlock()
+	err := patch.Downgrade(st, 1)
+	c.Assert(err, ErrorMatches, `cannot downgrade: patch level 2 cannot be reverted`)
+
+	st.Lock()
+	defer st.Unlock()
+	var level int
+	c.Assert(st.Get("patch-level", &level), IsNil)
+	c.Check(level, Equals, 2)
+}
+
+func (s *patchSuite) TestDowngradeErrors(c *C) {
+	restore := patch.MockReverse(map[int]func(*state.State) error{
+		2: func(st *state.State) error { return fmt.Errorf("boom") },
+	})
+	defer restore()
+
+	st := state.New(nil)
+	st.Lock()
+	st.Set("patch-level", 2)
+	st.Unlock()
+
+	c.Check(patch.Downgrade(st, 3), ErrorMatches, `cannot downgrade system state at patch level 2 to higher level 3`)
+	c.Check(patch.Downgrade(st, -1), ErrorMatches, `cannot downgrade to invalid patch level -1`)
+	c.Check(patch.Downgrade(st, 1), ErrorMatches, `cannot revert system state from patch level 2 to 1: boom`)
+	c.Check(patch.Downgrade(st, 2), IsNil)
+}
+
 func (s *patchSuite) TestSanity(c *C) {
 	patches := patch.PatchesForTest()
 	levels := make([]int, 0, len(patches))
@@ -190,3 +341,29 @@ func (s *patchSuite) TestSanity(c *C) {
 	// ends at implemented patch level
 	c.Check(levels[len(levels)-1], Equals, patch.Level)
 }
+
+func (s *patchSuite) TestAllReversible(c *C) {
+	reversePatches := patch.ReversePatchesForTest()
+	for level := 1; level <= patch.Level; level++ {
+		c.Check(reversePatches[level], NotNil, Commentf("patch level %d", level))
+	}
+}
+
+// patchedData returns the snaps and the snap-setup of every task in the
+// state as plain JSON values, to check that patches keep them intact.
+func patchedData(c *C, st *state.State) map[string]interface{} {
+	st.Lock()
+	defer st.Unlock()
+
+	data := make(map[string]interface{})
+	var snaps interface{}
+	c.Assert(st.Get("snaps", &snaps), IsNil)
+	data["snaps"] = snaps
+	for _, t := range st.Tasks() {
+		var snapsup interface{}
+		if err := t.Get("snap-setup", &snapsup); err == nil {
+			data["task "+t.ID()] = snapsup
+		}
+	}
+	return data
+}
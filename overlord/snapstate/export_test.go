@@ -96,6 +96,18 @@ func MockErrtrackerReport(mock func(string, string, string, map[string]string) (
 	return func() { errtrackerReport = prev }
 }
 
+func MockRunQueued(mock func(t *state.Task) ([]*state.TaskSet, error)) (restore func()) {
+	old := RunQueued
+	RunQueued = mock
+	return func() { RunQueued = old }
+}
+
+func MockQueueRetryTimeout(d time.Duration) (restore func()) {
+	old := queueRetryTimeout
+	queueRetryTimeout = d
+	return func() { queueRetryTimeout = old }
+}
+
 func MockPrerequisitesRetryTimeout(d time.Duration) (restore func()) {
 	old := prerequisitesRetryTimeout
 	prerequisitesRetryTimeout = d
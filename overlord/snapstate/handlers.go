@@ -334,6 +334,8 @@ func (m *SnapManager) doDownloadSnap(t *state.Task, tomb *tomb.Tomb) error {
 
 	meter := NewTaskProgressAdapterUnlocked(t)
 	targetFn := snapsup.MountFile()
+	span := t.StartSpan("download", fmt.Sprintf("Download snap %q", snapsup.Name()))
+	defer span.Stop()
 	if snapsup.DownloadInfo == nil {
 		var storeInfo *snap.Info
 		// COMPATIBILITY - this task was created from an older version
@@ -381,14 +383,20 @@ func (m *SnapManager) doMountSnap(t *state.Task, _ *tomb.Tomb) error {
 
 	m.backend.CurrentInfo(curInfo)
 
-	if err := checkSnap(t.State(), snapsup.SnapPath, snapsup.SideInfo, curInfo, snapsup.Flags); err != nil {
+	span := t.StartSpan("check-snap", fmt.Sprintf("Check snap %q", snapsup.Name()))
+	err = checkSnap(t.State(), snapsup.SnapPath, snapsup.SideInfo, curInfo, snapsup.Flags)
+	span.Stop()
+	if err != nil {
 		return err
 	}
 
 	pb := NewTaskProgressAdapterUnlocked(t)
 	// TODO Use snapsup.Revision() to obtain the right info to mount
 	//      instead of assuming the candidate is the right one.
-	if err := m.backend.SetupSnap(snapsup.SnapPath, snapsup.SideInfo, pb); err != nil {
+	span = t.StartSpan("setup-snap", fmt.Sprintf("Mount snap %q", snapsup.Name()))
+	err = m.backend.SetupSnap(snapsup.SnapPath, snapsup.SideInfo, pb)
+	span.Stop()
+	if err != nil {
 		return err
 	}
 
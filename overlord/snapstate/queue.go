@@ -0,0 +1,137 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package snapstate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+// RunQueued is called to add the tasks of an operation queued with
+// Queue to the change of its "run-queued" task, once the changes it
+// waits for are ready.
+var RunQueued func(t *state.Task) ([]*state.TaskSet, error)
+
+// timeout for queued operations to check again for conflicting changes
+var queueRetryTimeout = 10 * time.Second
+
+// Queue returns a task set with a "run-queued" task that waits for
+// the given changes to be ready, and then has RunQueued plan the
+// operation on the given snaps. With requeue, the task also waits
+// for other changes affecting the snaps that started meanwhile.
+func Queue(st *state.State, summary string, snapNames []string, after []*state.Change, requeue bool) *state.TaskSet {
+	t := st.NewTask("run-queued", summary)
+	t.Set("snap-names", snapNames)
+	t.Set("requeue", requeue)
+	for _, chg := range after {
+		t.WaitAll(state.NewTaskSet(chg.Tasks()...))
+	}
+	return state.NewTaskSet(t)
+}
+
+// QueuedAfter returns the changes not ready yet that the given change
+// waits for: those its tasks still to run wait for, and the conflicting
+// changes its running "run-queued" tasks check again for.
+func QueuedAfter(chg *state.Change) []*state.Change {
+	st := chg.State()
+	changes := chg.WaitChanges()
+	seen := make(map[string]bool, len(changes))
+	for _, after := range changes {
+		seen[after.ID()] = true
+	}
+	for _, t := range chg.Tasks() {
+		if t.Kind() != "run-queued" || t.Status() != state.DoingStatus {
+			continue
+		}
+		var waitingFor []string
+		if err := t.Get("waiting-for", &waitingFor); err != nil {
+			continue
+		}
+		for _, id := range waitingFor {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if after := st.Change(id); after != nil && !after.Status().Ready() {
+				changes = append(changes, after)
+			}
+		}
+	}
+	return changes
+}
+
+func (m *SnapManager) doRunQueued(t *state.Task, _ *tomb.Tomb) error {
+	st := t.State()
+	st.Lock()
+	defer st.Unlock()
+
+	var snapNames []string
+	if err := t.Get("snap-names", &snapNames); err != nil {
+		return err
+	}
+	var requeue bool
+	if err := t.Get("requeue", &requeue); err != nil && err != state.ErrNoState {
+		return err
+	}
+
+	if requeue {
+		conflicting, err := ConflictingChanges(st, snapNames)
+		if err != nil {
+			return err
+		}
+		if len(conflicting) > 0 {
+			// the task is running already so it cannot be made to
+			// wait for the changes, check again later instead
+			ids := make([]string, len(conflicting))
+			for i, chg := range conflicting {
+				ids[i] = chg.ID()
+			}
+			sort.Strings(ids)
+			var waitingFor []string
+			t.Get("waiting-for", &waitingFor)
+			if strings.Join(ids, ",") != strings.Join(waitingFor, ",") {
+				t.Set("waiting-for", ids)
+				t.Logf("Waiting for conflicting changes %s to finish.", strings.Join(ids, ", "))
+			}
+			return &state.Retry{After: queueRetryTimeout}
+		}
+	}
+
+	if RunQueued == nil {
+		return fmt.Errorf("internal error: cannot run queued operation without RunQueued")
+	}
+	tsets, err := RunQueued(t)
+	if err != nil {
+		return err
+	}
+
+	chg := t.Change()
+	for _, ts := range tsets {
+		ts.WaitFor(t)
+		chg.AddAll(ts)
+	}
+	return nil
+}
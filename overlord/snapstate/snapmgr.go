@@ -329,6 +329,8 @@ func Manager(st *state.State) (*SnapManager, error) {
 
 	// misc
 	runner.AddHandler("switch-snap", m.doSwitchSnap, nil)
+	// queued operations are planned one at a time
+	runner.AddHandler("run-queued", m.doRunQueued, nil, &state.HandlerOptions{MaxConcurrent: 1})
 
 	// control serialisation
 	runner.SetBlocked(m.blockedTask)
@@ -376,6 +378,7 @@ func (m *SnapManager) checkRefreshSchedule() ([]*timeutil.Schedule, error) {
 	}
 	if err != nil {
 		logger.Noticef("cannot use refresh.schedule configuration: %s", err)
+		m.state.Warnf("cannot use refresh.schedule configuration: %s", err)
 		refreshSchedule, err = timeutil.ParseSchedule(defaultRefreshSchedule)
 		if err != nil {
 			panic(fmt.Sprintf("defaultRefreshSchedule cannot be parsed: %s", err))
@@ -426,6 +429,8 @@ func (m *SnapManager) launchAutoRefresh() error {
 	}
 
 	chg := m.state.NewChange("auto-refresh", msg)
+	// let anything the user asked for go first
+	chg.SetPriority(state.LowPriority)
 	for _, ts := range tasksets {
 		chg.AddAll(ts)
 	}
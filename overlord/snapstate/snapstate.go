@@ -355,39 +355,90 @@ func CheckChangeConflictMany(st *state.State, snapNames []string, checkConflictP
 	}
 
 	for _, task := range st.Tasks() {
-		k := task.Kind()
-		chg := task.Change()
-		if snapTopicalTasks[k] && (chg == nil || !chg.Status().Ready()) {
-			if k == "connect" || k == "disconnect" {
-				plugRef, slotRef, err := getPlugAndSlotRefs(task)
-				if err != nil {
-					return fmt.Errorf("internal error: cannot obtain plug/slot data from task: %s", task.Summary())
-				}
-				if (snapMap[plugRef.Snap] || snapMap[slotRef.Snap]) && (checkConflictPredicate == nil || checkConflictPredicate(k)) {
-					var snapName string
-					if snapMap[plugRef.Snap] {
-						snapName = plugRef.Snap
-					} else {
-						snapName = slotRef.Snap
-					}
-					return changeConflictError{snapName}
-				}
-			} else {
-				snapsup, err := TaskSnapSetup(task)
-				if err != nil {
-					return fmt.Errorf("internal error: cannot obtain snap setup from task: %s", task.Summary())
-				}
-				snapName := snapsup.Name()
-				if (snapMap[snapName]) && (checkConflictPredicate == nil || checkConflictPredicate(k)) {
-					return changeConflictError{snapName}
-				}
-			}
+		snapName, err := conflictingSnap(task, snapMap, checkConflictPredicate)
+		if err != nil {
+			return err
+		}
+		if snapName != "" {
+			return changeConflictError{snapName}
 		}
 	}
 
 	return nil
 }
 
+// ConflictingChanges returns the changes in progress that make
+// CheckChangeConflictMany fail for the given snapNames, so that new
+// changes can be queued after them instead.
+func ConflictingChanges(st *state.State, snapNames []string) ([]*state.Change, error) {
+	snapMap := make(map[string]bool, len(snapNames))
+	for _, k := range snapNames {
+		snapMap[k] = true
+	}
+
+	var changes []*state.Change
+	seen := make(map[string]bool)
+	for _, chg := range st.Changes() {
+		if !chg.Status().Ready() && chg.Kind() == "transition-ubuntu-core" {
+			changes = append(changes, chg)
+			seen[chg.ID()] = true
+		}
+	}
+
+	for _, task := range st.Tasks() {
+		chg := task.Change()
+		if chg == nil || seen[chg.ID()] {
+			continue
+		}
+		snapName, err := conflictingSnap(task, snapMap, nil)
+		if err != nil {
+			return nil, err
+		}
+		if snapName != "" {
+			changes = append(changes, chg)
+			seen[chg.ID()] = true
+		}
+	}
+
+	return changes, nil
+}
+
+// conflictingSnap returns which of the snaps in snapMap the given task
+// alters, if it is still in progress, or the empty string.
+func conflictingSnap(task *state.Task, snapMap map[string]bool, checkConflictPredicate func(taskKind string) bool) (string, error) {
+	k := task.Kind()
+	chg := task.Change()
+	if !snapTopicalTasks[k] || (chg != nil && chg.Status().Ready()) {
+		return "", nil
+	}
+
+	var snapName string
+	if k == "connect" || k == "disconnect" {
+		plugRef, slotRef, err := getPlugAndSlotRefs(task)
+		if err != nil {
+			return "", fmt.Errorf("internal error: cannot obtain plug/slot data from task: %s", task.Summary())
+		}
+		switch {
+		case snapMap[plugRef.Snap]:
+			snapName = plugRef.Snap
+		case snapMap[slotRef.Snap]:
+			snapName = slotRef.Snap
+		}
+	} else {
+		snapsup, err := TaskSnapSetup(task)
+		if err != nil {
+			return "", fmt.Errorf("internal error: cannot obtain snap setup from task: %s", task.Summary())
+		}
+		if snapMap[snapsup.Name()] {
+			snapName = snapsup.Name()
+		}
+	}
+	if snapName == "" || (checkConflictPredicate != nil && !checkConflictPredicate(k)) {
+		return "", nil
+	}
+	return snapName, nil
+}
+
 type changeDuringInstallError struct {
 	snapName string
 }
@@ -640,6 +691,7 @@ func UpdateMany(st *state.State, names []string, userID int) ([]string, []*state
 			}
 			// doing "refresh all", log the problems
 			logger.Noticef("cannot refresh some snaps: %v", err)
+			st.Warnf("cannot refresh some snaps: %v", err)
 		}
 	}
 
@@ -27,10 +27,12 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	. "gopkg.in/check.v1"
+	"gopkg.in/tomb.v2"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/interfaces"
@@ -4760,6 +4762,10 @@ func (s *snapmgrTestSuite) TestEnsureRefreshRefusesWeekdaySchedules(c *C) {
 	s.state.Lock()
 
 	c.Check(logbuf.String(), testutil.Contains, `cannot use refresh.schedule configuration: "mon@12:00-14:00" uses weekdays which is currently not supported`)
+
+	warnings := s.state.AllWarnings()
+	c.Assert(warnings, HasLen, 1)
+	c.Check(warnings[0].String(), Equals, `cannot use refresh.schedule configuration: "mon@12:00-14:00" uses weekdays which is currently not supported`)
 }
 
 func (s *snapmgrTestSuite) TestEnsureRefreshesNoUpdate(c *C) {
@@ -6996,6 +7002,210 @@ func (s *snapmgrTestSuite) TestConflictMany(c *C) {
 	}
 }
 
+func (s *snapmgrTestSuite) TestConflictingChanges(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	var changes []*state.Change
+	for _, snapName := range []string{"a-snap", "b-snap"} {
+		snapstate.Set(s.state, snapName, &snapstate.SnapState{
+			Sequence: []*snap.SideInfo{
+				{RealName: snapName, Revision: snap.R(11)},
+			},
+			Current: snap.R(11),
+			Active:  false,
+		})
+
+		ts, err := snapstate.Enable(s.state, snapName)
+		c.Assert(err, IsNil)
+		chg := s.state.NewChange("enable", "...")
+		chg.AddAll(ts)
+		changes = append(changes, chg)
+	}
+
+	for _, t := range []struct {
+		snaps   []string
+		changes []*state.Change
+	}{
+		{nil, nil},
+		{[]string{"c-snap"}, nil},
+		{[]string{"a-snap"}, changes[:1]},
+		{[]string{"b-snap", "c-snap"}, changes[1:]},
+	} {
+		conflicting, err := snapstate.ConflictingChanges(s.state, t.snaps)
+		c.Assert(err, IsNil)
+		c.Check(conflicting, DeepEquals, t.changes, Commentf("%v", t.snaps))
+	}
+
+	conflicting, err := snapstate.ConflictingChanges(s.state, []string{"a-snap", "b-snap"})
+	c.Assert(err, IsNil)
+	c.Check(conflicting, HasLen, 2)
+
+	// ready changes don't conflict
+	changes[0].SetStatus(state.DoneStatus)
+	conflicting, err = snapstate.ConflictingChanges(s.state, []string{"a-snap"})
+	c.Assert(err, IsNil)
+	c.Check(conflicting, HasLen, 0)
+}
+
+func (s *snapmgrTestSuite) testQueue(c *C, after bool) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	si := snap.SideInfo{RealName: "some-snap", Revision: snap.R(7)}
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{&si},
+		Current:  si.Revision,
+		Active:   false,
+	})
+
+	chg1 := s.state.NewChange("enable", "enable a snap")
+	ts, err := snapstate.Enable(s.state, "some-snap")
+	c.Assert(err, IsNil)
+	chg1.AddAll(ts)
+
+	var calls int
+	restore := snapstate.MockRunQueued(func(t *state.Task) ([]*state.TaskSet, error) {
+		calls++
+		// the enabling is over by now
+		c.Check(chg1.Status(), Equals, state.DoneStatus)
+		ts, err := snapstate.Disable(s.state, "some-snap")
+		if err != nil {
+			return nil, err
+		}
+		return []*state.TaskSet{ts}, nil
+	})
+	defer restore()
+
+	restore = snapstate.MockQueueRetryTimeout(time.Millisecond)
+	defer restore()
+
+	var waitFor []*state.Change
+	if after {
+		waitFor = append(waitFor, chg1)
+	}
+	chg2 := s.state.NewChange("disable", "disable a snap")
+	chg2.AddAll(snapstate.Queue(s.state, "wait", []string{"some-snap"}, waitFor, !after))
+
+	s.state.Unlock()
+	defer s.snapmgr.Stop()
+	s.settle(c)
+	s.state.Lock()
+
+	c.Check(calls, Equals, 1)
+	c.Check(chg1.Status(), Equals, state.DoneStatus)
+	c.Check(chg2.Status(), Equals, state.DoneStatus, Commentf("%v", chg2.Err()))
+	c.Check(len(chg2.Tasks()) > 1, Equals, true)
+
+	var snapst snapstate.SnapState
+	c.Assert(snapstate.Get(s.state, "some-snap", &snapst), IsNil)
+	c.Check(snapst.Active, Equals, false)
+}
+
+func (s *snapmgrTestSuite) TestQueueAfterChange(c *C) {
+	s.testQueue(c, true)
+}
+
+func (s *snapmgrTestSuite) TestQueueRequeuesOnConflict(c *C) {
+	s.testQueue(c, false)
+}
+
+func (s *snapmgrTestSuite) TestQueueWaitsForConflictingChange(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	si := snap.SideInfo{RealName: "some-snap", Revision: snap.R(7)}
+	snapstate.Set(s.state, "some-snap", &snapstate.SnapState{
+		Sequence: []*snap.SideInfo{&si},
+		Current:  si.Revision,
+		Active:   false,
+	})
+
+	// hold the enabling until told otherwise
+	hold := true
+	s.snapmgr.AddAdhocTaskHandler("hold", func(t *state.Task, _ *tomb.Tomb) error {
+		t.State().Lock()
+		defer t.State().Unlock()
+		if hold {
+			return &state.Retry{}
+		}
+		return nil
+	}, nil)
+
+	chg1 := s.state.NewChange("enable", "enable a snap")
+	gate := s.state.NewTask("hold", "hold")
+	ts, err := snapstate.Enable(s.state, "some-snap")
+	c.Assert(err, IsNil)
+	ts.WaitFor(gate)
+	chg1.AddTask(gate)
+	chg1.AddAll(ts)
+
+	var calls int
+	restore := snapstate.MockRunQueued(func(t *state.Task) ([]*state.TaskSet, error) {
+		calls++
+		c.Check(chg1.Status(), Equals, state.DoneStatus)
+		return nil, nil
+	})
+	defer restore()
+	restore = snapstate.MockQueueRetryTimeout(time.Millisecond)
+	defer restore()
+
+	chg2 := s.state.NewChange("disable", "disable a snap")
+	queued := snapstate.Queue(s.state, "wait", []string{"some-snap"}, nil, true)
+	chg2.AddAll(queued)
+	t := queued.Tasks()[0]
+
+	for i := 0; i < 3; i++ {
+		s.state.Unlock()
+		s.snapmgr.Ensure()
+		s.snapmgr.Wait()
+		time.Sleep(5 * time.Millisecond)
+		s.state.Lock()
+	}
+
+	// still waiting for the enabling to finish
+	c.Check(calls, Equals, 0)
+	c.Check(t.Status(), Equals, state.DoingStatus)
+	var waitingFor []string
+	c.Assert(t.Get("waiting-for", &waitingFor), IsNil)
+	c.Check(waitingFor, DeepEquals, []string{chg1.ID()})
+	c.Check(strings.Join(t.Log(), "\n"), Matches, `(?s).*Waiting for conflicting changes `+chg1.ID()+` to finish\.`)
+	// the change is still queued while its task runs
+	c.Check(snapstate.QueuedAfter(chg2), DeepEquals, []*state.Change{chg1})
+
+	hold = false
+	s.state.Unlock()
+	defer s.snapmgr.Stop()
+	s.settle(c)
+	s.state.Lock()
+
+	c.Check(calls, Equals, 1)
+	c.Check(chg1.Status(), Equals, state.DoneStatus)
+	c.Check(chg2.Status(), Equals, state.DoneStatus)
+	c.Check(snapstate.QueuedAfter(chg2), HasLen, 0)
+}
+
+func (s *snapmgrTestSuite) TestQueueRunQueuedError(c *C) {
+	s.state.Lock()
+	defer s.state.Unlock()
+
+	restore := snapstate.MockRunQueued(func(t *state.Task) ([]*state.TaskSet, error) {
+		return nil, errors.New("boom")
+	})
+	defer restore()
+
+	chg := s.state.NewChange("disable", "disable a snap")
+	chg.AddAll(snapstate.Queue(s.state, "wait", []string{"some-snap"}, nil, true))
+
+	s.state.Unlock()
+	defer s.snapmgr.Stop()
+	s.settle(c)
+	s.state.Lock()
+
+	c.Check(chg.Status(), Equals, state.ErrorStatus)
+	c.Check(chg.Err(), ErrorMatches, `(?s).*boom.*`)
+}
+
 func (s *snapmgrTestSuite) TestInstallWithoutCoreRunThrough1(c *C) {
 	s.state.Lock()
 	defer s.state.Unlock()
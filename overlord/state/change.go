@@ -104,6 +104,23 @@ func (s Status) String() string {
 	panic(fmt.Sprintf("internal error: unknown task status code: %d", s))
 }
 
+// Priority is the priority class of the tasks of a change.
+type Priority int
+
+// Admitted priority classes for changes.
+const (
+	// LowPriority is for background work, like auto-refreshes, that
+	// should give way to anything else.
+	LowPriority Priority = -1
+
+	// DefaultPriority is the priority of changes, including those
+	// requested by users.
+	DefaultPriority Priority = 0
+
+	// HighPriority is for work that should go ahead of everything else.
+	HighPriority Priority = 1
+)
+
 // Change represents a tracked modification to the system state.
 //
 // The Change provides both the justification for individual tasks
@@ -126,6 +143,8 @@ type Change struct {
 	lanes   int
 	ready   chan struct{}
 
+	priority Priority
+
 	spawnTime time.Time
 	readyTime time.Time
 }
@@ -159,6 +178,8 @@ type marshalledChange struct {
 	TaskIDs []string                    `json:"task-ids,omitempty"`
 	Lanes   int                         `json:"lanes,omitempty"`
 
+	Priority Priority `json:"priority,omitempty"`
+
 	SpawnTime time.Time  `json:"spawn-time"`
 	ReadyTime *time.Time `json:"ready-time,omitempty"`
 }
@@ -180,6 +201,8 @@ func (c *Change) MarshalJSON() ([]byte, error) {
 		TaskIDs: c.taskIDs,
 		Lanes:   c.lanes,
 
+		Priority: c.priority,
+
 		SpawnTime: c.spawnTime,
 		ReadyTime: readyTime,
 	})
@@ -207,6 +230,7 @@ func (c *Change) UnmarshalJSON(data []byte) error {
 	c.data = custData
 	c.taskIDs = unmarshalled.TaskIDs
 	c.lanes = unmarshalled.Lanes
+	c.priority = unmarshalled.Priority
 	c.ready = make(chan struct{})
 	c.spawnTime = unmarshalled.SpawnTime
 	if unmarshalled.ReadyTime != nil {
@@ -237,6 +261,20 @@ func (c *Change) Summary() string {
 	return c.summary
 }
 
+// Priority returns the priority class of the tasks of the change.
+func (c *Change) Priority() Priority {
+	c.state.reading()
+	return c.priority
+}
+
+// SetPriority sets the priority class of the tasks of the change. Among
+// the tasks ready to run, TaskRunner starts those of a higher priority
+// first.
+func (c *Change) SetPriority(p Priority) {
+	c.state.writing()
+	c.priority = p
+}
+
 // Set associates value with key for future consulting by managers.
 // The provided value must properly marshal and unmarshal with encoding/json.
 func (c *Change) Set(key string, value interface{}) {
@@ -305,6 +343,7 @@ func (c *Change) SetStatus(s Status) {
 	if s.Ready() {
 		c.markReady()
 	}
+	c.state.notify(ChangeStatusEvent, c, nil)
 }
 
 func (c *Change) markReady() {
@@ -477,6 +516,30 @@ func (c *Change) Tasks() []*Task {
 	return c.state.tasksIn(c.taskIDs)
 }
 
+// WaitChanges returns the changes that are not ready yet and that
+// tasks of c still to be run wait for.
+func (c *Change) WaitChanges() []*Change {
+	c.state.reading()
+	var changes []*Change
+	seen := make(map[string]bool)
+	for _, tid := range c.taskIDs {
+		t := c.state.tasks[tid]
+		if t.Status() != DoStatus {
+			continue
+		}
+		for _, wt := range c.state.tasksIn(t.waitTasks) {
+			if wt.change == c.id || wt.change == "" || seen[wt.change] {
+				continue
+			}
+			seen[wt.change] = true
+			if chg := c.state.changes[wt.change]; chg != nil && !chg.Status().Ready() {
+				changes = append(changes, chg)
+			}
+		}
+	}
+	return changes
+}
+
 // Abort flags the change for cancellation, whether in progress or not.
 // Cancellation will proceed at the next ensure pass.
 func (c *Change) Abort() {
@@ -575,6 +638,10 @@ func (c *Change) abortTasks(tasks []*Task, abortedLanes map[int]bool, seenTasks
 		}
 
 		for _, halted := range t.HaltTasks() {
+			if halted.change != c.id {
+				// other changes are not aborted with this one
+				continue
+			}
 			if !seenTasks[halted.id] {
 				tasks = append(tasks, halted)
 			}
@@ -20,6 +20,7 @@
 package state_test
 
 import (
+	"bytes"
 	"fmt"
 	"strconv"
 	"strings"
@@ -87,6 +88,27 @@ func (cs *changeSuite) TestGetSet(c *C) {
 	c.Check(v, Equals, 1)
 }
 
+func (cs *changeSuite) TestPriority(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	chg := st.NewChange("auto-refresh", "...")
+	c.Check(chg.Priority(), Equals, state.DefaultPriority)
+
+	chg.SetPriority(state.LowPriority)
+	c.Check(chg.Priority(), Equals, state.LowPriority)
+
+	// the priority is persisted
+	data, err := st.MarshalJSON()
+	c.Assert(err, IsNil)
+	st2, err := state.ReadState(nil, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	st2.Lock()
+	defer st2.Unlock()
+	c.Check(st2.Change(chg.ID()).Priority(), Equals, state.LowPriority)
+}
+
 // TODO Better testing of full change roundtripping via JSON.
 
 func (cs *changeSuite) TestNewTaskAddTaskAndTasks(c *C) {
@@ -323,6 +345,7 @@ func (cs *changeSuite) TestMethodEntrance(c *C) {
 		func() { chg.SetStatus(state.DoStatus) },
 		func() { chg.AddTask(nil) },
 		func() { chg.AddAll(nil) },
+		func() { chg.SetPriority(state.LowPriority) },
 		func() { chg.UnmarshalJSON(nil) },
 	}
 
@@ -335,6 +358,7 @@ func (cs *changeSuite) TestMethodEntrance(c *C) {
 		func() { chg.MarshalJSON() },
 		func() { chg.SpawnTime() },
 		func() { chg.ReadyTime() },
+		func() { chg.Priority() },
 	}
 
 	for i, f := range reads {
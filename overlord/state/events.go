@@ -0,0 +1,71 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state
+
+// EventType identifies what an Event is about.
+type EventType string
+
+const (
+	// ChangeAddedEvent is emitted when a change is created.
+	ChangeAddedEvent EventType = "change-added"
+	// ChangeStatusEvent is emitted when the status of a change is set explicitly.
+	ChangeStatusEvent EventType = "change-status"
+	// TaskStatusEvent is emitted when the status of a task of a change changes.
+	TaskStatusEvent EventType = "task-status"
+	// TaskProgressEvent is emitted when the progress of a task of a change changes.
+	TaskProgressEvent EventType = "task-progress"
+)
+
+// An Event describes a transition of a change or of one of its tasks.
+type Event struct {
+	Type   EventType
+	Change *Change
+	// Task is nil for change events.
+	Task *Task
+}
+
+// AddEventHandler registers f to be called for every change and task
+// event, and returns an id to remove it with RemoveEventHandler.
+// Handlers are called with the state lock held, so they must not block.
+func (s *State) AddEventHandler(f func(ev Event)) int {
+	s.reading()
+	if s.eventHandlers == nil {
+		s.eventHandlers = make(map[int]func(Event))
+	}
+	s.lastEventHandlerId++
+	s.eventHandlers[s.lastEventHandlerId] = f
+	return s.lastEventHandlerId
+}
+
+// RemoveEventHandler removes the event handler with the given id.
+func (s *State) RemoveEventHandler(id int) {
+	s.reading()
+	delete(s.eventHandlers, id)
+}
+
+func (s *State) notify(typ EventType, chg *Change, t *Task) {
+	if chg == nil || len(s.eventHandlers) == 0 {
+		return
+	}
+	ev := Event{Type: typ, Change: chg, Task: t}
+	for _, f := range s.eventHandlers {
+		f(ev)
+	}
+}
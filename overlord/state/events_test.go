@@ -0,0 +1,76 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state_test
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type eventsSuite struct{}
+
+var _ = Suite(&eventsSuite{})
+
+type recordedEvent struct {
+	typ    state.EventType
+	change string
+	task   string
+}
+
+func (es *eventsSuite) TestEvents(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	var events []recordedEvent
+	id := st.AddEventHandler(func(ev state.Event) {
+		rec := recordedEvent{typ: ev.Type, change: ev.Change.ID()}
+		if ev.Task != nil {
+			rec.task = ev.Task.ID()
+		}
+		events = append(events, rec)
+	})
+
+	chg := st.NewChange("install", "...")
+	t := st.NewTask("download", "...")
+	// not part of a change yet
+	t.SetStatus(state.DoingStatus)
+	chg.AddTask(t)
+	t.SetStatus(state.DoingStatus)
+	t.SetStatus(state.DoneStatus)
+	t.SetProgress("downloading", 1, 2)
+	t.SetProgress("downloading", 1, 2)
+	t.SetProgress("downloading", 2, 2)
+	chg.SetStatus(state.ErrorStatus)
+
+	c.Check(events, DeepEquals, []recordedEvent{
+		{state.ChangeAddedEvent, chg.ID(), ""},
+		{state.TaskStatusEvent, chg.ID(), t.ID()},
+		{state.TaskProgressEvent, chg.ID(), t.ID()},
+		{state.TaskProgressEvent, chg.ID(), t.ID()},
+		{state.ChangeStatusEvent, chg.ID(), ""},
+	})
+
+	st.RemoveEventHandler(id)
+	events = nil
+	st.NewChange("install", "...")
+	c.Check(events, HasLen, 0)
+}
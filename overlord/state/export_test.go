@@ -20,6 +20,8 @@
 package state
 
 import (
+	"reflect"
+	"strings"
 	"time"
 )
 
@@ -44,3 +46,27 @@ func MockTaskTimes(t *Task, spawnTime, readyTime time.Time) {
 	t.spawnTime = spawnTime
 	t.readyTime = readyTime
 }
+
+// MockJournalMinCompactSize changes journalMinCompactSize.
+func MockJournalMinCompactSize(size int64) (restore func()) {
+	old := journalMinCompactSize
+	journalMinCompactSize = size
+	return func() {
+		journalMinCompactSize = old
+	}
+}
+
+func jsonKeys(v interface{}) []string {
+	var keys []string
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		keys = append(keys, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+	}
+	return keys
+}
+
+// StateJSONKeys returns the top-level keys of the state as persisted by
+// State and as handled by the journal.
+func StateJSONKeys() (marshalled, journaled []string) {
+	return jsonKeys(marshalledState{}), jsonKeys(journalState{})
+}
@@ -0,0 +1,414 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+)
+
+// journalMinCompactSize is the journal size below which the journal
+// is never compacted into the snapshot.
+var journalMinCompactSize int64 = 1024 * 1024
+
+// like osutil.AtomicWriteFile, skip syncing in tests unless asked not to
+var journalUnsafeIO = len(os.Args) > 0 && strings.HasSuffix(os.Args[0], ".test") && osutil.GetenvBool("SNAPD_UNSAFE_IO", true)
+
+// A Journal persists state checkpoints incrementally. The full state
+// lives in a snapshot file with the same format as a plain
+// checkpoint; each checkpoint then only appends to a write-ahead
+// journal the top-level data entries, changes and tasks that differ
+// from what was persisted before. Once the journal grows bigger than
+// the snapshot it is compacted back into it.
+//
+// Every snapshot written bumps the journal generation, which is
+// recorded in the snapshot and in the journal entries written after
+// it. Entries older than the snapshot, left behind when writing the
+// snapshot was not followed by emptying the journal, are skipped on
+// recovery.
+type Journal struct {
+	snapshotPath string
+	journalPath  string
+
+	f            *os.File
+	size         int64
+	snapshotSize int64
+	generation   int
+
+	persisted *journalState
+}
+
+// journalState is the state as persisted, with every top-level entry
+// kept opaque so that it can be compared with later checkpoints. The
+// warnings are few and kept as a whole. It must have the same fields
+// as marshalledState, plus the generation.
+type journalState struct {
+	Data    map[string]*json.RawMessage `json:"data"`
+	Changes map[string]*json.RawMessage `json:"changes"`
+	Tasks   map[string]*json.RawMessage `json:"tasks"`
+
+	Warnings *json.RawMessage `json:"warnings,omitempty"`
+
+	LastChangeId int `json:"last-change-id"`
+	LastTaskId   int `json:"last-task-id"`
+	LastLaneId   int `json:"last-lane-id"`
+
+	Generation int `json:"journal-generation,omitempty"`
+}
+
+// journalRecord is a single changed entry of a section ("data",
+// "changes" or "tasks"), or the whole of the warnings (section
+// "warnings", with no key); a nil value means the entry was removed.
+type journalRecord struct {
+	Section string           `json:"s"`
+	Key     string           `json:"k"`
+	Value   *json.RawMessage `json:"v,omitempty"`
+}
+
+// journalEntry is the delta of one checkpoint. It is written to the
+// journal as a single line, a truncated last line is the trace of an
+// interrupted checkpoint and is ignored on recovery.
+type journalEntry struct {
+	Records []journalRecord `json:"records,omitempty"`
+
+	LastChangeId int `json:"last-change-id"`
+	LastTaskId   int `json:"last-task-id"`
+	LastLaneId   int `json:"last-lane-id"`
+
+	Generation int `json:"generation,omitempty"`
+}
+
+func (js *journalState) sections() map[string]*map[string]*json.RawMessage {
+	return map[string]*map[string]*json.RawMessage{
+		"data":    &js.Data,
+		"changes": &js.Changes,
+		"tasks":   &js.Tasks,
+	}
+}
+
+func (js *journalState) apply(entry *journalEntry) error {
+	sections := js.sections()
+	for _, rec := range entry.Records {
+		if rec.Section == "warnings" {
+			js.Warnings = rec.Value
+			continue
+		}
+		section := sections[rec.Section]
+		if section == nil {
+			return fmt.Errorf("unknown state section %q", rec.Section)
+		}
+		if rec.Value == nil {
+			delete(*section, rec.Key)
+			continue
+		}
+		if *section == nil {
+			*section = make(map[string]*json.RawMessage)
+		}
+		(*section)[rec.Key] = rec.Value
+	}
+	js.LastChangeId = entry.LastChangeId
+	js.LastTaskId = entry.LastTaskId
+	js.LastLaneId = entry.LastLaneId
+	return nil
+}
+
+// delta returns the journal entry that turns js into other.
+func (js *journalState) delta(other *journalState) *journalEntry {
+	entry := &journalEntry{
+		LastChangeId: other.LastChangeId,
+		LastTaskId:   other.LastTaskId,
+		LastLaneId:   other.LastLaneId,
+	}
+	for _, name := range []string{"data", "changes", "tasks"} {
+		old := *js.sections()[name]
+		cur := *other.sections()[name]
+		for k, v := range cur {
+			if ov, ok := old[k]; ok && rawEqual(ov, v) {
+				continue
+			}
+			entry.Records = append(entry.Records, journalRecord{Section: name, Key: k, Value: v})
+		}
+		for k := range old {
+			if _, ok := cur[k]; !ok {
+				entry.Records = append(entry.Records, journalRecord{Section: name, Key: k})
+			}
+		}
+	}
+	if !rawEqual(js.Warnings, other.Warnings) {
+		entry.Records = append(entry.Records, journalRecord{Section: "warnings", Value: other.Warnings})
+	}
+	return entry
+}
+
+func rawEqual(a, b *json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return bytes.Equal(*a, *b)
+}
+
+// OpenJournal opens, creating it if needed, the journal at journalPath
+// for the snapshot at snapshotPath. Any journaled checkpoints are
+// replayed and compacted into the snapshot, whose recovered content is
+// returned as well; data is nil if there is no state yet.
+func OpenJournal(snapshotPath, journalPath string) (j *Journal, data []byte, err error) {
+	persisted, err := recoverJournal(snapshotPath, journalPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	j = &Journal{
+		snapshotPath: snapshotPath,
+		journalPath:  journalPath,
+	}
+	if persisted != nil {
+		j.generation = persisted.Generation
+		data, err = j.writeSnapshot(persisted)
+		if err != nil {
+			return nil, nil, err
+		}
+		j.persisted = persisted
+	}
+
+	j.f, err = os.OpenFile(journalPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot open state journal: %v", err)
+	}
+	if err := j.truncate(); err != nil {
+		j.f.Close()
+		return nil, nil, err
+	}
+	return j, data, nil
+}
+
+// JournalPath returns the path of the journal kept alongside the state
+// file at statePath when the state is persisted incrementally.
+func JournalPath(statePath string) string {
+	return statePath + ".journal"
+}
+
+// ReadJournaled returns the state persisted in the snapshot at
+// snapshotPath together with the checkpoints in the journal at
+// journalPath, without modifying either. It is meant for inspecting
+// the state while snapd is not running.
+func ReadJournaled(snapshotPath, journalPath string) ([]byte, error) {
+	persisted, err := recoverJournal(snapshotPath, journalPath)
+	if err != nil {
+		return nil, err
+	}
+	if persisted == nil {
+		return nil, fmt.Errorf("cannot read the state file: %v", os.ErrNotExist)
+	}
+	return json.Marshal(persisted)
+}
+
+// recoverJournal reads the snapshot and applies to it every complete
+// journal entry. It returns nil if neither snapshot nor journal exist.
+func recoverJournal(snapshotPath, journalPath string) (*journalState, error) {
+	var persisted *journalState
+
+	snapshot, err := os.Open(snapshotPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot read the state file: %v", err)
+	}
+	if err == nil {
+		defer snapshot.Close()
+		persisted = &journalState{}
+		if err := json.NewDecoder(snapshot).Decode(persisted); err != nil {
+			return nil, err
+		}
+	}
+
+	journal, err := os.Open(journalPath)
+	if os.IsNotExist(err) {
+		return persisted, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the state journal: %v", err)
+	}
+	defer journal.Close()
+
+	generation := 0
+	if persisted != nil {
+		generation = persisted.Generation
+	}
+	stale := 0
+	r := bufio.NewReader(journal)
+	for n := 1; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				logger.Noticef("Ignoring incomplete state journal entry %d.", n)
+			}
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the state journal: %v", err)
+		}
+		var entry journalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("cannot decode state journal entry %d: %v", n, err)
+		}
+		if entry.Generation < generation {
+			// written before the snapshot, which supersedes it
+			stale++
+			continue
+		}
+		if persisted == nil {
+			persisted = &journalState{}
+		}
+		if err := persisted.apply(&entry); err != nil {
+			return nil, fmt.Errorf("cannot apply state journal entry %d: %v", n, err)
+		}
+	}
+	if stale > 0 {
+		logger.Noticef("Ignoring %d state journal entries older than the state file.", stale)
+	}
+	return persisted, nil
+}
+
+// Checkpoint appends to the journal what changed in data, as produced
+// by State, since the previous checkpoint, compacting the journal into
+// the snapshot when it got too big.
+func (j *Journal) Checkpoint(data []byte) error {
+	var cur journalState
+	if err := json.Unmarshal(data, &cur); err != nil {
+		return fmt.Errorf("cannot decode state checkpoint: %v", err)
+	}
+
+	if j.persisted == nil {
+		// nothing to compute a delta against
+		if err := j.compact(&cur); err != nil {
+			return err
+		}
+		j.persisted = &cur
+		return nil
+	}
+
+	entry := j.persisted.delta(&cur)
+	entry.Generation = j.generation
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if j.size+int64(len(line)) > j.compactSize() {
+		if err := j.compact(&cur); err != nil {
+			return err
+		}
+		j.persisted = &cur
+		return nil
+	}
+
+	n, err := j.f.WriteAt(line, j.size)
+	if err != nil {
+		// drop the partial entry so that the next one starts on its own line
+		j.f.Truncate(j.size)
+		return fmt.Errorf("cannot write state journal: %v", err)
+	}
+	if err := j.sync(); err != nil {
+		j.f.Truncate(j.size)
+		return fmt.Errorf("cannot sync state journal: %v", err)
+	}
+	j.size += int64(n)
+	j.persisted = &cur
+	return nil
+}
+
+func (j *Journal) compactSize() int64 {
+	if j.snapshotSize > journalMinCompactSize {
+		return j.snapshotSize
+	}
+	return journalMinCompactSize
+}
+
+// compact replaces the snapshot with cur and empties the journal. If
+// emptying the journal fails, or does not happen at all, its entries
+// are of an older generation than the new snapshot and are skipped
+// on recovery.
+func (j *Journal) compact(cur *journalState) error {
+	if _, err := j.writeSnapshot(cur); err != nil {
+		return err
+	}
+	return j.truncate()
+}
+
+// writeSnapshot writes js as the snapshot of the next generation and
+// returns what was written.
+func (j *Journal) writeSnapshot(js *journalState) ([]byte, error) {
+	js.Generation = j.generation + 1
+	data, err := json.Marshal(js)
+	if err != nil {
+		return nil, err
+	}
+	if err := osutil.AtomicWriteFile(j.snapshotPath, data, 0600, 0); err != nil {
+		return nil, err
+	}
+	j.generation = js.Generation
+	j.snapshotSize = int64(len(data))
+	return data, nil
+}
+
+func (j *Journal) truncate() error {
+	if err := j.f.Truncate(0); err != nil {
+		return fmt.Errorf("cannot truncate state journal: %v", err)
+	}
+	if err := j.sync(); err != nil {
+		return fmt.Errorf("cannot sync state journal: %v", err)
+	}
+	j.size = 0
+	return nil
+}
+
+func (j *Journal) sync() error {
+	if journalUnsafeIO {
+		return nil
+	}
+	return j.f.Sync()
+}
+
+// Close closes the journal, leaving it in place to be recovered by
+// the next OpenJournal.
+func (j *Journal) Close() error {
+	return j.f.Close()
+}
+
+// Remove compacts and then removes the journal, leaving the whole
+// state in the snapshot. It is used to go back to plain checkpoints.
+func (j *Journal) Remove() error {
+	if j.persisted != nil {
+		if _, err := j.writeSnapshot(j.persisted); err != nil {
+			return err
+		}
+	}
+	if err := j.f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(j.journalPath)
+}
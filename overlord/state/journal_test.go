@@ -0,0 +1,474 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type journalSuite struct {
+	snapshotPath string
+	journalPath  string
+}
+
+var _ = Suite(&journalSuite{})
+
+func (js *journalSuite) SetUpTest(c *C) {
+	dir := c.MkDir()
+	js.snapshotPath = filepath.Join(dir, "state.json")
+	js.journalPath = filepath.Join(dir, "state.json.journal")
+}
+
+type journalBackend struct {
+	*state.Journal
+}
+
+func (b journalBackend) EnsureBefore(d time.Duration) {}
+
+func (b journalBackend) RequestRestart(t state.RestartType) {}
+
+func (js *journalSuite) open(c *C) (*state.Journal, *state.State) {
+	j, data, err := state.OpenJournal(js.snapshotPath, js.journalPath)
+	c.Assert(err, IsNil)
+	if data == nil {
+		return j, state.New(journalBackend{j})
+	}
+	st, err := state.ReadState(journalBackend{j}, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	return j, st
+}
+
+func (js *journalSuite) journalLines(c *C) []string {
+	content, err := ioutil.ReadFile(js.journalPath)
+	c.Assert(err, IsNil)
+	if len(content) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+func (js *journalSuite) TestCheckpointAndRecover(c *C) {
+	j, st := js.open(c)
+
+	st.Lock()
+	st.Set("a", 1)
+	st.Set("b", "foo")
+	chg := st.NewChange("install", "...")
+	chg.AddTask(st.NewTask("download", "..."))
+	st.Unlock()
+
+	st.Lock()
+	st.Set("a", 2)
+	st.Set("b", nil)
+	st.Unlock()
+	c.Assert(j.Close(), IsNil)
+
+	j, st = js.open(c)
+	defer j.Close()
+
+	st.Lock()
+	defer st.Unlock()
+	var a int
+	c.Assert(st.Get("a", &a), IsNil)
+	c.Check(a, Equals, 2)
+	var b string
+	c.Check(st.Get("b", &b), Equals, state.ErrNoState)
+	c.Assert(st.Changes(), HasLen, 1)
+	c.Check(st.Changes()[0].Tasks(), HasLen, 1)
+	c.Check(st.NewChange("other", "...").ID(), Equals, "2")
+
+	// recovery compacted the journal into the snapshot
+	c.Check(js.journalLines(c), HasLen, 0)
+	snapshot, err := ioutil.ReadFile(js.snapshotPath)
+	c.Assert(err, IsNil)
+	c.Check(string(snapshot), Matches, `.*"a":2.*`)
+}
+
+func (js *journalSuite) TestCheckpointAppendsOnlyDeltas(c *C) {
+	j, st := js.open(c)
+	defer j.Close()
+
+	st.Lock()
+	st.Set("a", 1)
+	st.Set("b", "foo")
+	st.Unlock()
+
+	// the first checkpoint goes to the snapshot
+	c.Check(js.journalLines(c), HasLen, 0)
+	snapshot, err := ioutil.ReadFile(js.snapshotPath)
+	c.Assert(err, IsNil)
+
+	st.Lock()
+	st.Set("a", 2)
+	st.Unlock()
+
+	st.Lock()
+	st.Set("b", nil)
+	st.Unlock()
+
+	lines := js.journalLines(c)
+	c.Assert(lines, HasLen, 2)
+	c.Check(lines[0], Equals, `{"records":[{"s":"data","k":"a","v":2}],"last-change-id":0,"last-task-id":0,"last-lane-id":0,"generation":1}`)
+	c.Check(lines[1], Equals, `{"records":[{"s":"data","k":"b"}],"last-change-id":0,"last-task-id":0,"last-lane-id":0,"generation":1}`)
+
+	after, err := ioutil.ReadFile(js.snapshotPath)
+	c.Assert(err, IsNil)
+	c.Check(after, DeepEquals, snapshot)
+}
+
+func (js *journalSuite) TestRecoverIgnoresIncompleteEntry(c *C) {
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	j, st := js.open(c)
+	st.Lock()
+	st.Set("a", 1)
+	st.Unlock()
+	st.Lock()
+	st.Set("a", 2)
+	st.Unlock()
+	c.Assert(j.Close(), IsNil)
+
+	f, err := os.OpenFile(js.journalPath, os.O_WRONLY|os.O_APPEND, 0600)
+	c.Assert(err, IsNil)
+	_, err = f.WriteString(`{"records":[{"s":"data","k":"a","v":3`)
+	c.Assert(err, IsNil)
+	f.Close()
+
+	j, st = js.open(c)
+	defer j.Close()
+
+	st.Lock()
+	defer st.Unlock()
+	var a int
+	c.Assert(st.Get("a", &a), IsNil)
+	c.Check(a, Equals, 2)
+	c.Check(logbuf.String(), Matches, `(?s).*Ignoring incomplete state journal entry 2\..*`)
+}
+
+func (js *journalSuite) TestRecoverCorruptedEntry(c *C) {
+	err := ioutil.WriteFile(js.journalPath, []byte("{\"records\":[{\"s\":\"foo\",\"k\":\"a\"}]}\n"), 0600)
+	c.Assert(err, IsNil)
+
+	_, _, err = state.OpenJournal(js.snapshotPath, js.journalPath)
+	c.Check(err, ErrorMatches, `cannot apply state journal entry 1: unknown state section "foo"`)
+
+	err = ioutil.WriteFile(js.journalPath, []byte("garbage\n"), 0600)
+	c.Assert(err, IsNil)
+
+	_, _, err = state.OpenJournal(js.snapshotPath, js.journalPath)
+	c.Check(err, ErrorMatches, `cannot decode state journal entry 1: .*`)
+}
+
+func (js *journalSuite) TestCheckpointCompacts(c *C) {
+	restore := state.MockJournalMinCompactSize(0)
+	defer restore()
+
+	j, st := js.open(c)
+	defer j.Close()
+
+	st.Lock()
+	st.Set("a", strings.Repeat("x", 100))
+	st.Unlock()
+
+	for i := 0; i < 20; i++ {
+		st.Lock()
+		st.Set("b", i)
+		st.Unlock()
+	}
+
+	// the journal never grows past the snapshot
+	snapshot, err := ioutil.ReadFile(js.snapshotPath)
+	c.Assert(err, IsNil)
+	journal, err := ioutil.ReadFile(js.journalPath)
+	c.Assert(err, IsNil)
+	c.Check(len(journal) <= len(snapshot), Equals, true)
+	c.Check(len(js.journalLines(c)) < 20, Equals, true)
+
+	var persisted struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	c.Assert(json.Unmarshal(snapshot, &persisted), IsNil)
+	c.Check(persisted.Data["b"], NotNil)
+}
+
+func (js *journalSuite) TestRecoverSkipsEntriesOlderThanSnapshot(c *C) {
+	logbuf, restore := logger.MockLogger()
+	defer restore()
+
+	j, st := js.open(c)
+
+	st.Lock()
+	st.Set("a", 1)
+	st.Unlock()
+
+	st.Lock()
+	st.Set("a", 2)
+	st.Unlock()
+	c.Assert(js.journalLines(c), HasLen, 1)
+	stale, err := ioutil.ReadFile(js.journalPath)
+	c.Assert(err, IsNil)
+
+	// compact on the next checkpoint
+	restoreSize := state.MockJournalMinCompactSize(0)
+	st.Lock()
+	st.Set("a", strings.Repeat("x", 100))
+	st.Unlock()
+	restoreSize()
+	c.Assert(js.journalLines(c), HasLen, 0)
+	c.Assert(j.Close(), IsNil)
+
+	// simulate a crash after writing the snapshot but before
+	// emptying the journal
+	c.Assert(ioutil.WriteFile(js.journalPath, stale, 0600), IsNil)
+
+	j, st = js.open(c)
+	defer j.Close()
+
+	st.Lock()
+	defer st.Unlock()
+	var a string
+	c.Assert(st.Get("a", &a), IsNil)
+	c.Check(a, Equals, strings.Repeat("x", 100))
+	c.Check(logbuf.String(), Matches, `(?s).*Ignoring 1 state journal entries older than the state file.*`)
+}
+
+func (js *journalSuite) TestMigrateFromAndBackToSnapshot(c *C) {
+	// a plain checkpoint of the whole state
+	st := state.New(nil)
+	st.Lock()
+	st.Set("a", 1)
+	data, err := st.MarshalJSON()
+	st.Unlock()
+	c.Assert(err, IsNil)
+	c.Assert(osutil.AtomicWriteFile(js.snapshotPath, data, 0600, 0), IsNil)
+
+	j, st := js.open(c)
+	st.Lock()
+	var a int
+	c.Assert(st.Get("a", &a), IsNil)
+	c.Check(a, Equals, 1)
+	st.Set("a", 2)
+	st.Unlock()
+	c.Check(js.journalLines(c), HasLen, 1)
+
+	c.Assert(j.Remove(), IsNil)
+	c.Check(osutil.FileExists(js.journalPath), Equals, false)
+
+	snapshot, err := os.Open(js.snapshotPath)
+	c.Assert(err, IsNil)
+	defer snapshot.Close()
+	st, err = state.ReadState(nil, snapshot)
+	c.Assert(err, IsNil)
+	st.Lock()
+	defer st.Unlock()
+	c.Assert(st.Get("a", &a), IsNil)
+	c.Check(a, Equals, 2)
+}
+
+func (js *journalSuite) TestWarningsAreJournaled(c *C) {
+	j, st := js.open(c)
+
+	t0 := time.Date(2017, 11, 1, 10, 0, 0, 0, time.UTC)
+	restore := state.MockTime(t0)
+	defer restore()
+
+	st.Lock()
+	st.Set("a", 1)
+	st.Unlock()
+
+	st.Lock()
+	st.Warnf("hello")
+	st.Unlock()
+
+	st.Lock()
+	st.Warnf("world")
+	st.Unlock()
+
+	lines := js.journalLines(c)
+	c.Assert(lines, HasLen, 2)
+	c.Check(lines[1], Matches, `.*"s":"warnings".*"world".*`)
+	c.Assert(j.Close(), IsNil)
+
+	messages := func(st *state.State) []string {
+		st.Lock()
+		defer st.Unlock()
+		var msgs []string
+		for _, w := range st.AllWarnings() {
+			msgs = append(msgs, w.String())
+		}
+		return msgs
+	}
+
+	// the warnings are replayed from the journal
+	j, st = js.open(c)
+	c.Check(messages(st), DeepEquals, []string{"hello", "world"})
+
+	// and removing them all is journaled as well
+	restore = state.MockTime(t0.Add(state.DefaultExpireAfter + time.Hour))
+	defer restore()
+	st.Lock()
+	st.Prune(time.Hour, time.Hour, 100)
+	st.Unlock()
+	c.Check(messages(st), HasLen, 0)
+	c.Assert(j.Close(), IsNil)
+
+	j, st = js.open(c)
+	c.Check(messages(st), HasLen, 0)
+
+	st.Lock()
+	st.Warnf("again")
+	st.Unlock()
+
+	// going back to plain checkpoints keeps them
+	c.Assert(j.Remove(), IsNil)
+	snapshot, err := os.Open(js.snapshotPath)
+	c.Assert(err, IsNil)
+	defer snapshot.Close()
+	st, err = state.ReadState(nil, snapshot)
+	c.Assert(err, IsNil)
+	c.Check(messages(st), DeepEquals, []string{"again"})
+}
+
+type fileStateBackend struct {
+	path string
+}
+
+func (b fileStateBackend) Checkpoint(data []byte) error {
+	return osutil.AtomicWriteFile(b.path, data, 0600, 0)
+}
+
+func (b fileStateBackend) EnsureBefore(d time.Duration) {}
+
+func (b fileStateBackend) RequestRestart(t state.RestartType) {}
+
+// benchmarkCheckpoint measures checkpointing a change to a single
+// task of a state with many changes, as happens all the time while
+// running tasks. Run with SNAPD_UNSAFE_IO=0 to include the syncs.
+func benchmarkCheckpoint(b *testing.B, backend func(dir string) state.Backend) {
+	dir, err := ioutil.TempDir("", "state-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	st := state.New(backend(dir))
+	st.Lock()
+	var tasks []*state.Task
+	for i := 0; i < 200; i++ {
+		chg := st.NewChange("install", fmt.Sprintf("install snap %d", i))
+		for k := 0; k < 10; k++ {
+			t := st.NewTask("do-something", fmt.Sprintf("task %d of change %d", k, i))
+			t.Set("snap-setup", map[string]interface{}{"name": "foo", "revision": k})
+			chg.AddTask(t)
+			tasks = append(tasks, t)
+		}
+	}
+	st.Unlock()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		st.Lock()
+		tasks[i%len(tasks)].Logf("step %d", i)
+		st.Unlock()
+	}
+}
+
+func BenchmarkCheckpointFile(b *testing.B) {
+	benchmarkCheckpoint(b, func(dir string) state.Backend {
+		return fileStateBackend{path: filepath.Join(dir, "state.json")}
+	})
+}
+
+func BenchmarkCheckpointJournal(b *testing.B) {
+	benchmarkCheckpoint(b, func(dir string) state.Backend {
+		j, _, err := state.OpenJournal(filepath.Join(dir, "state.json"), filepath.Join(dir, "state.json.journal"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		return journalBackend{j}
+	})
+}
+
+func (js *journalSuite) TestJournalStateMatchesState(c *C) {
+	marshalled, journaled := state.StateJSONKeys()
+	// the journal only adds the generation of the snapshot
+	c.Check(journaled, DeepEquals, append(marshalled, "journal-generation"))
+}
+
+func (js *journalSuite) TestReadJournaled(c *C) {
+	j, st := js.open(c)
+	st.Lock()
+	st.Set("a", 1)
+	st.Unlock()
+	st.Lock()
+	st.Set("a", 2)
+	st.NewChange("install", "...")
+	st.Unlock()
+	c.Assert(j.Close(), IsNil)
+
+	snapshot, err := ioutil.ReadFile(js.snapshotPath)
+	c.Assert(err, IsNil)
+	journal, err := ioutil.ReadFile(js.journalPath)
+	c.Assert(err, IsNil)
+	c.Assert(journal, Not(HasLen), 0)
+
+	data, err := state.ReadJournaled(js.snapshotPath, js.journalPath)
+	c.Assert(err, IsNil)
+	st, err = state.ReadState(nil, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	st.Lock()
+	var a int
+	c.Check(st.Get("a", &a), IsNil)
+	c.Check(a, Equals, 2)
+	c.Check(st.Changes(), HasLen, 1)
+	st.Unlock()
+
+	// neither file was touched
+	after, err := ioutil.ReadFile(js.snapshotPath)
+	c.Assert(err, IsNil)
+	c.Check(after, DeepEquals, snapshot)
+	after, err = ioutil.ReadFile(js.journalPath)
+	c.Assert(err, IsNil)
+	c.Check(after, DeepEquals, journal)
+}
+
+func (js *journalSuite) TestReadJournaledNoState(c *C) {
+	_, err := state.ReadJournaled(js.snapshotPath, js.journalPath)
+	c.Check(err, ErrorMatches, "cannot read the state file: file does not exist")
+}
+
+func (js *journalSuite) TestJournalPath(c *C) {
+	c.Check(state.JournalPath("/var/lib/snapd/state.json"), Equals, "/var/lib/snapd/state.json.journal")
+}
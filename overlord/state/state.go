@@ -99,10 +99,15 @@ type State struct {
 	changes map[string]*Change
 	tasks   map[string]*Task
 
+	warnings map[string]*Warning
+
 	modified bool
 
 	cache map[interface{}]interface{}
 
+	eventHandlers      map[int]func(Event)
+	lastEventHandlerId int
+
 	restarting bool
 	restartLck sync.Mutex
 }
@@ -114,6 +119,7 @@ func New(backend Backend) *State {
 		data:     make(customData),
 		changes:  make(map[string]*Change),
 		tasks:    make(map[string]*Task),
+		warnings: make(map[string]*Warning),
 		modified: true,
 		cache:    make(map[interface{}]interface{}),
 	}
@@ -153,6 +159,8 @@ type marshalledState struct {
 	Changes map[string]*Change          `json:"changes"`
 	Tasks   map[string]*Task            `json:"tasks"`
 
+	Warnings []*Warning `json:"warnings,omitempty"`
+
 	LastChangeId int `json:"last-change-id"`
 	LastTaskId   int `json:"last-task-id"`
 	LastLaneId   int `json:"last-lane-id"`
@@ -166,6 +174,8 @@ func (s *State) MarshalJSON() ([]byte, error) {
 		Changes: s.changes,
 		Tasks:   s.tasks,
 
+		Warnings: s.flattenWarnings(),
+
 		LastTaskId:   s.lastTaskId,
 		LastChangeId: s.lastChangeId,
 		LastLaneId:   s.lastLaneId,
@@ -186,6 +196,10 @@ func (s *State) UnmarshalJSON(data []byte) error {
 	s.lastChangeId = unmarshalled.LastChangeId
 	s.lastTaskId = unmarshalled.LastTaskId
 	s.lastLaneId = unmarshalled.LastLaneId
+	s.warnings = make(map[string]*Warning, len(unmarshalled.Warnings))
+	for _, w := range unmarshalled.Warnings {
+		s.warnings[w.message] = w
+	}
 	// backlink state again
 	for _, t := range s.tasks {
 		t.state = s
@@ -197,6 +211,15 @@ func (s *State) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+func (s *State) flattenWarnings() []*Warning {
+	flat := make([]*Warning, 0, len(s.warnings))
+	for _, w := range s.warnings {
+		flat = append(flat, w)
+	}
+	sort.Sort(byLastAdded(flat))
+	return flat
+}
+
 func (s *State) checkpointData() []byte {
 	data, err := json.Marshal(s)
 	if err != nil {
@@ -310,6 +333,7 @@ func (s *State) NewChange(kind, summary string) *Change {
 	id := strconv.Itoa(s.lastChangeId)
 	chg := newChange(s, id, kind, summary)
 	s.changes[id] = chg
+	s.notify(ChangeAddedEvent, chg, nil)
 	return chg
 }
 
@@ -379,9 +403,12 @@ func (s *State) TaskCount() int {
 }
 
 func (s *State) tasksIn(tids []string) []*Task {
-	res := make([]*Task, len(tids))
-	for i, tid := range tids {
-		res[i] = s.tasks[tid]
+	res := make([]*Task, 0, len(tids))
+	for _, tid := range tids {
+		// tasks of other changes might have been pruned already
+		if t := s.tasks[tid]; t != nil {
+			res = append(res, t)
+		}
 	}
 	return res
 }
@@ -391,7 +418,7 @@ func (s *State) tasksIn(tids []string) []*Task {
 // It also removes tasks unlinked to changes after pruneWait. When
 // there are more changes than the limit set via "maxReadyChanges"
 // those changes in ready state will also removed even if they are below
-// the pruneWait duration.
+// the pruneWait duration. Expired warnings are removed as well.
 func (s *State) Prune(pruneWait, abortWait time.Duration, maxReadyChanges int) {
 	now := time.Now()
 	pruneLimit := now.Add(-pruneWait)
@@ -443,6 +470,8 @@ func (s *State) Prune(pruneWait, abortWait time.Duration, maxReadyChanges int) {
 			delete(s.tasks, tid)
 		}
 	}
+
+	s.pruneWarnings(now)
 }
 
 // ReadState returns the state deserialized from r.
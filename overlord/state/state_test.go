@@ -786,6 +786,33 @@ func (ss *stateSuite) TestPrune(c *C) {
 	c.Check(st.TaskCount(), Equals, 3)
 }
 
+func (ss *stateSuite) TestPruneChangeWaitedFor(c *C) {
+	st := state.New(&fakeStateBackend{})
+	st.Lock()
+	defer st.Unlock()
+
+	now := time.Now()
+	pruneWait := 1 * time.Hour
+
+	t1 := st.NewTask("foo", "...")
+	chg1 := st.NewChange("prune", "...")
+	chg1.AddTask(t1)
+	t1.SetStatus(state.DoneStatus)
+	state.MockChangeTimes(chg1, now.Add(-pruneWait), now.Add(-pruneWait))
+
+	t2 := st.NewTask("foo", "...")
+	t2.WaitFor(t1)
+	chg2 := st.NewChange("queued", "...")
+	chg2.AddTask(t2)
+
+	st.Prune(pruneWait, 3*pruneWait, 100)
+
+	c.Assert(st.Change(chg1.ID()), IsNil)
+	c.Assert(st.Task(t1.ID()), IsNil)
+	c.Check(t2.WaitTasks(), HasLen, 0)
+	c.Check(chg2.WaitChanges(), HasLen, 0)
+}
+
 func (ss *stateSuite) TestPruneEmptyChange(c *C) {
 	// Empty changes are a bit special because they start out on Hold
 	// which is a Ready status, but the change itself is not considered Ready
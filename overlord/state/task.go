@@ -56,6 +56,10 @@ type Task struct {
 	readyTime time.Time
 
 	atTime time.Time
+
+	timings []*Span
+	// run is the span of the running handler, if any
+	run *Span
 }
 
 func newTask(state *State, id, kind, summary string) *Task {
@@ -88,6 +92,8 @@ type marshalledTask struct {
 	ReadyTime *time.Time `json:"ready-time,omitempty"`
 
 	AtTime *time.Time `json:"at-time,omitempty"`
+
+	Timings []*Span `json:"timings,omitempty"`
 }
 
 // MarshalJSON makes Task a json.Marshaller
@@ -119,6 +125,8 @@ func (t *Task) MarshalJSON() ([]byte, error) {
 		ReadyTime: readyTime,
 
 		AtTime: atTime,
+
+		Timings: t.timings,
 	})
 }
 
@@ -155,6 +163,7 @@ func (t *Task) UnmarshalJSON(data []byte) error {
 	if unmarshalled.AtTime != nil {
 		t.atTime = *unmarshalled.AtTime
 	}
+	t.timings = unmarshalled.Timings
 	return nil
 }
 
@@ -194,6 +203,9 @@ func (t *Task) SetStatus(new Status) {
 	if chg != nil {
 		chg.taskStatusChanged(t, old, new)
 	}
+	if old != new {
+		t.state.notify(TaskStatusEvent, chg, t)
+	}
 }
 
 // IsClean returns whether the task has been cleaned. See SetClean.
@@ -250,12 +262,16 @@ func (t *Task) SetProgress(label string, done, total int) {
 	} else {
 		t.state.reading()
 	}
+	old := t.progress
 	if total <= 0 || done > total {
 		// Doing math wrong is easy. Be conservative.
 		t.progress = nil
 	} else {
 		t.progress = &progress{Label: label, Done: done, Total: total}
 	}
+	if (old == nil) != (t.progress == nil) || (old != nil && *old != *t.progress) {
+		t.state.notify(TaskProgressEvent, t.Change(), t)
+	}
 }
 
 // SpawnTime returns the time when the change was created.
@@ -361,6 +377,8 @@ func addOnce(set []string, s string) []string {
 }
 
 // WaitFor registers another task as a requirement for t to make progress.
+// The other task can be part of a different change, in which case t only
+// waits for it to be ready, whatever its outcome.
 func (t *Task) WaitFor(another *Task) {
 	t.state.writing()
 	t.waitTasks = addOnce(t.waitTasks, another.id)
@@ -20,12 +20,14 @@
 package state
 
 import (
+	"sort"
 	"sync"
 	"time"
 
 	"gopkg.in/tomb.v2"
 
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/metrics"
 )
 
 // HandlerFunc is the type of function for the handlers
@@ -63,6 +65,16 @@ type TaskRunner struct {
 
 type handlerPair struct {
 	do, undo HandlerFunc
+
+	maxConcurrent int
+}
+
+// HandlerOptions holds optional settings for running the tasks of a
+// kind, to be passed to AddHandler.
+type HandlerOptions struct {
+	// MaxConcurrent is the maximum number of tasks of the kind
+	// running at the same time, 0 means no limit.
+	MaxConcurrent int
 }
 
 // NewTaskRunner creates a new TaskRunner
@@ -77,11 +89,20 @@ func NewTaskRunner(s *State) *TaskRunner {
 
 // AddHandler registers the functions to concurrently call for doing and
 // undoing tasks of the given kind. The undo handler may be nil.
-func (r *TaskRunner) AddHandler(kind string, do, undo HandlerFunc) {
+// Options for the kind, like a limit on how many of its tasks run
+// concurrently, can optionally be given as well.
+func (r *TaskRunner) AddHandler(kind string, do, undo HandlerFunc, opts ...*HandlerOptions) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.handlers[kind] = handlerPair{do, undo}
+	if len(opts) > 1 {
+		panic("internal error: AddHandler takes at most one HandlerOptions")
+	}
+	pair := handlerPair{do: do, undo: undo}
+	if len(opts) == 1 && opts[0] != nil {
+		pair.maxConcurrent = opts[0].MaxConcurrent
+	}
+	r.handlers[kind] = pair
 }
 
 // AddCleanup registers a function to be called after the change completes,
@@ -116,18 +137,21 @@ func (r *TaskRunner) SetBlocked(pred func(t *Task, running []*Task) bool) {
 // run must be called with the state lock in place
 func (r *TaskRunner) run(t *Task) {
 	var handler HandlerFunc
+	var label string
 	switch t.Status() {
 	case DoStatus:
 		t.SetStatus(DoingStatus)
 		fallthrough
 	case DoingStatus:
 		handler = r.handlers[t.Kind()].do
+		label = "do"
 
 	case UndoStatus:
 		t.SetStatus(UndoingStatus)
 		fallthrough
 	case UndoingStatus:
 		handler = r.handlers[t.Kind()].undo
+		label = "undo"
 
 	default:
 		panic("internal error: attempted to run task in status " + t.Status().String())
@@ -137,6 +161,7 @@ func (r *TaskRunner) run(t *Task) {
 	}
 
 	t.At(time.Time{}) // clear schedule
+	t.startRun(label)
 	tomb := &tomb.Tomb{}
 	r.tombs[t.ID()] = tomb
 	tomb.Go(func() error {
@@ -152,6 +177,7 @@ func (r *TaskRunner) run(t *Task) {
 		defer r.state.Unlock()
 
 		delete(r.tombs, t.ID())
+		t.stopRun()
 
 		// some tasks were blocked, now there's chance the
 		// blocked predicate will change its value
@@ -210,6 +236,11 @@ func (r *TaskRunner) run(t *Task) {
 			t.Errorf("%s", err)
 		}
 
+		if t.Status().Ready() && haltsOtherChanges(t) {
+			// tasks of other changes might be able to run now
+			r.state.EnsureBefore(0)
+		}
+
 		return nil
 	})
 }
@@ -280,8 +311,60 @@ func (r *TaskRunner) tryUndo(t *Task) {
 	}
 }
 
+var queuedTasks = metrics.NewGauge("snapd_tasks", "Number of pending tasks of each kind, waiting or running.", "kind", "state")
+
+// recordQueue records how many tasks of each kind handled by the
+// runner are waiting and running. It expects to be called with r.mu
+// and the state lock held.
+func (r *TaskRunner) recordQueue(tasks []*Task) {
+	waiting := make(map[string]int)
+	running := make(map[string]int)
+	for _, t := range tasks {
+		kind := t.Kind()
+		if _, ok := r.handlers[kind]; !ok {
+			continue
+		}
+		if r.tombs[t.ID()] != nil {
+			running[kind]++
+		} else if !t.Status().Ready() {
+			waiting[kind]++
+		}
+	}
+	for kind := range r.handlers {
+		queuedTasks.Set(float64(waiting[kind]), kind, "waiting")
+		queuedTasks.Set(float64(running[kind]), kind, "running")
+	}
+}
+
+// byPriority orders tasks by descending priority of their change, and
+// then by age.
+type byPriority []*Task
+
+func (ts byPriority) Len() int      { return len(ts) }
+func (ts byPriority) Swap(i, j int) { ts[i], ts[j] = ts[j], ts[i] }
+func (ts byPriority) Less(i, j int) bool {
+	pi, pj := taskPriority(ts[i]), taskPriority(ts[j])
+	if pi != pj {
+		return pi > pj
+	}
+	if !ts[i].spawnTime.Equal(ts[j].spawnTime) {
+		return ts[i].spawnTime.Before(ts[j].spawnTime)
+	}
+	// ids are increasing numbers
+	idi, idj := ts[i].id, ts[j].id
+	return len(idi) < len(idj) || (len(idi) == len(idj) && idi < idj)
+}
+
+func taskPriority(t *Task) Priority {
+	if chg := t.Change(); chg != nil {
+		return chg.priority
+	}
+	return DefaultPriority
+}
+
 // Ensure starts new goroutines for all known tasks with no pending
-// dependencies.
+// dependencies, trying the tasks of higher priority changes first and
+// respecting the concurrency limits of their kinds.
 // Note that Ensure will lock the state.
 func (r *TaskRunner) Ensure() {
 	r.mu.Lock()
@@ -298,16 +381,21 @@ func (r *TaskRunner) Ensure() {
 
 	r.someBlocked = false
 	running := make([]*Task, 0, len(r.tombs))
+	runningKinds := make(map[string]int)
 	for tid := range r.tombs {
 		t := r.state.Task(tid)
 		if t != nil {
 			running = append(running, t)
+			runningKinds[t.Kind()]++
 		}
 	}
 
+	tasks := r.state.Tasks()
+	sort.Sort(byPriority(tasks))
+
 	ensureTime := timeNow()
 	nextTaskTime := time.Time{}
-	for _, t := range r.state.Tasks() {
+	for _, t := range tasks {
 		handlers, ok := r.handlers[t.Kind()]
 		if !ok {
 			// Handled by a different runner instance.
@@ -359,6 +447,11 @@ func (r *TaskRunner) Ensure() {
 			continue
 		}
 
+		if handlers.maxConcurrent > 0 && runningKinds[t.Kind()] >= handlers.maxConcurrent {
+			r.someBlocked = true
+			continue
+		}
+
 		if r.blocked != nil && r.blocked(t, running) {
 			r.someBlocked = true
 			continue
@@ -368,8 +461,11 @@ func (r *TaskRunner) Ensure() {
 		r.run(t)
 
 		running = append(running, t)
+		runningKinds[t.Kind()]++
 	}
 
+	r.recordQueue(tasks)
+
 	// schedule next Ensure no later than the next task time
 	if !nextTaskTime.IsZero() {
 		r.state.EnsureBefore(nextTaskTime.Sub(ensureTime))
@@ -381,12 +477,23 @@ func mustWait(t *Task) bool {
 	switch t.Status() {
 	case DoStatus:
 		for _, wt := range t.WaitTasks() {
+			if wt.change != t.change {
+				// tasks of other changes only need to be ready
+				if !wt.Status().Ready() {
+					return true
+				}
+				continue
+			}
 			if wt.Status() != DoneStatus {
 				return true
 			}
 		}
 	case UndoStatus:
 		for _, ht := range t.HaltTasks() {
+			if ht.change != t.change {
+				// undoing doesn't affect other changes
+				continue
+			}
 			if !ht.Status().Ready() {
 				return true
 			}
@@ -395,6 +502,16 @@ func mustWait(t *Task) bool {
 	return false
 }
 
+// haltsOtherChanges returns whether tasks of other changes wait for t.
+func haltsOtherChanges(t *Task) bool {
+	for _, ht := range t.HaltTasks() {
+		if ht.change != t.change {
+			return true
+		}
+	}
+	return false
+}
+
 // wait expects to be called with th r.mu lock held
 func (r *TaskRunner) wait() {
 	for len(r.tombs) > 0 {
@@ -20,6 +20,7 @@
 package state_test
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"strconv"
@@ -30,6 +31,7 @@ import (
 	. "gopkg.in/check.v1"
 	"gopkg.in/tomb.v2"
 
+	"github.com/snapcore/snapd/metrics"
 	"github.com/snapcore/snapd/overlord/state"
 )
 
@@ -621,6 +623,196 @@ func (ts *taskRunnerSuite) TestTaskSerialization(c *C) {
 	c.Check(ensureBeforeTick, HasLen, 0)
 }
 
+func (ts *taskRunnerSuite) TestMaxConcurrent(c *C) {
+	ensureBeforeTick := make(chan bool, 1)
+	sb := &stateBackend{
+		ensureBefore:     time.Hour,
+		ensureBeforeSeen: ensureBeforeTick,
+	}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	started := make(chan string, 3)
+	release := make(chan bool)
+	r.AddHandler("compile", func(t *state.Task, _ *tomb.Tomb) error {
+		started <- t.Summary()
+		<-release
+		return nil
+	}, nil, &state.HandlerOptions{MaxConcurrent: 2})
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	for i := 0; i < 3; i++ {
+		chg.AddTask(st.NewTask("compile", fmt.Sprintf("compile %d", i)))
+	}
+	st.Unlock()
+
+	r.Ensure() // starts only two of them
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-started:
+		case <-time.After(2 * time.Second):
+			c.Fatal("compile wasn't called")
+		}
+	}
+	r.Ensure()
+	c.Check(started, HasLen, 0)
+
+	var buf bytes.Buffer
+	c.Assert(metrics.WriteText(&buf), IsNil)
+	c.Check(buf.String(), Matches, `(?s).*\nsnapd_tasks{kind="compile",state="running"} 2\n.*`)
+	c.Check(buf.String(), Matches, `(?s).*\nsnapd_tasks{kind="compile",state="waiting"} 1\n.*`)
+
+	// finishing one lets the last one go
+	release <- true
+	select {
+	case <-ensureBeforeTick:
+	case <-time.After(2 * time.Second):
+		c.Fatal("EnsureBefore wasn't called")
+	}
+	r.Ensure()
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		c.Fatal("last compile wasn't called")
+	}
+
+	release <- true
+	release <- true
+	r.Wait()
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(chg.Status(), Equals, state.DoneStatus)
+}
+
+func (ts *taskRunnerSuite) TestPriority(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	var mu sync.Mutex
+	var order []string
+	r.AddHandler("foo", func(t *state.Task, _ *tomb.Tomb) error {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, t.Summary())
+		return nil
+	}, nil, &state.HandlerOptions{MaxConcurrent: 1})
+
+	st.Lock()
+	// oldest first at the same priority
+	for _, kind := range []string{"auto-refresh", "install", "urgent", "remove"} {
+		chg := st.NewChange(kind, "...")
+		chg.AddTask(st.NewTask("foo", kind))
+		switch kind {
+		case "auto-refresh":
+			chg.SetPriority(state.LowPriority)
+		case "urgent":
+			chg.SetPriority(state.HighPriority)
+		}
+		c.Check(chg.Priority(), Equals, map[string]state.Priority{
+			"auto-refresh": state.LowPriority,
+			"urgent":       state.HighPriority,
+		}[kind])
+	}
+	st.Unlock()
+
+	for i := 0; i < 4; i++ {
+		r.Ensure()
+		r.Wait()
+	}
+
+	c.Check(order, DeepEquals, []string{"urgent", "install", "remove", "auto-refresh"})
+}
+
+func (ts *taskRunnerSuite) TestWaitForOtherChange(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	var mu sync.Mutex
+	var order []string
+	r.AddHandler("foo", func(t *state.Task, _ *tomb.Tomb) error {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, t.Summary())
+		return nil
+	}, nil)
+	r.AddHandler("fail", func(t *state.Task, _ *tomb.Tomb) error {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, t.Summary())
+		return errors.New("boom")
+	}, nil)
+
+	st.Lock()
+	chg1 := st.NewChange("install", "...")
+	t1 := st.NewTask("foo", "t1")
+	t2 := st.NewTask("fail", "t2")
+	t2.WaitFor(t1)
+	chg1.AddTask(t1)
+	chg1.AddTask(t2)
+
+	chg2 := st.NewChange("remove", "...")
+	t3 := st.NewTask("foo", "t3")
+	t3.WaitFor(t2)
+	chg2.AddTask(t3)
+
+	c.Check(chg2.WaitChanges(), DeepEquals, []*state.Change{chg1})
+	c.Check(chg1.WaitChanges(), HasLen, 0)
+	st.Unlock()
+
+	for i := 0; i < 4; i++ {
+		r.Ensure()
+		r.Wait()
+	}
+
+	st.Lock()
+	defer st.Unlock()
+	// the failure of the first change didn't stop the second one
+	c.Check(order, DeepEquals, []string{"t1", "t2", "t3"})
+	c.Check(chg1.Status(), Equals, state.ErrorStatus)
+	c.Check(chg2.Status(), Equals, state.DoneStatus)
+	c.Check(chg2.WaitChanges(), HasLen, 0)
+}
+
+func (ts *taskRunnerSuite) TestAbortDoesNotCrossChanges(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	r.AddHandler("foo", func(t *state.Task, _ *tomb.Tomb) error { return nil }, nil)
+
+	st.Lock()
+	chg1 := st.NewChange("install", "...")
+	t1 := st.NewTask("foo", "t1")
+	chg1.AddTask(t1)
+
+	chg2 := st.NewChange("remove", "...")
+	t2 := st.NewTask("foo", "t2")
+	t2.WaitFor(t1)
+	chg2.AddTask(t2)
+
+	chg1.Abort()
+	c.Check(t1.Status(), Equals, state.HoldStatus)
+	c.Check(t2.Status(), Equals, state.DoStatus)
+	st.Unlock()
+
+	r.Ensure()
+	r.Wait()
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(chg1.Status(), Equals, state.HoldStatus)
+	c.Check(chg2.Status(), Equals, state.DoneStatus)
+}
+
 func (ts *taskRunnerSuite) TestPrematureChangeReady(c *C) {
 	sb := &stateBackend{}
 	st := state.New(sb)
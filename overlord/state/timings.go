@@ -0,0 +1,119 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state
+
+import (
+	"sync"
+	"time"
+)
+
+// maxTaskTimings is the number of runs of a task whose timings are
+// kept, retried tasks forget about their oldest runs.
+const maxTaskTimings = 20
+
+// A Span is the timing of a step of the work of a task, made possibly
+// of nested spans. The runs of the do and undo handlers of a task are
+// recorded as its top-level spans, labeled "do" and "undo".
+type Span struct {
+	Label    string        `json:"label"`
+	Summary  string        `json:"summary,omitempty"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Spans    []*Span       `json:"spans,omitempty"`
+
+	// mu is shared by all the spans of a run of a handler
+	mu *sync.Mutex
+}
+
+func newSpan(mu *sync.Mutex, label, summary string) *Span {
+	return &Span{
+		Label:   label,
+		Summary: summary,
+		Start:   timeNow(),
+		mu:      mu,
+	}
+}
+
+// StartSpan starts a new span nested into s. It's safe to use from
+// concurrent goroutines.
+func (s *Span) StartSpan(label, summary string) *Span {
+	nested := newSpan(s.mu, label, summary)
+	s.mu.Lock()
+	s.Spans = append(s.Spans, nested)
+	s.mu.Unlock()
+	return nested
+}
+
+// Stop records the duration of the span. Only the first call matters.
+func (s *Span) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Duration == 0 {
+		s.Duration = timeNow().Sub(s.Start)
+	}
+}
+
+// StartSpan starts a new timing span for the running handler of the
+// task, to be stopped by the handler with Span.Stop when the measured
+// step is done. It doesn't require the state lock to be held, which
+// makes it usable from handlers directly. Spans started by a task not
+// run by a TaskRunner are not recorded.
+func (t *Task) StartSpan(label, summary string) *Span {
+	if run := t.run; run != nil {
+		return run.StartSpan(label, summary)
+	}
+	return newSpan(&sync.Mutex{}, label, summary)
+}
+
+// Timings returns the timings of the most recent runs of the handlers
+// of the task.
+func (t *Task) Timings() []*Span {
+	t.state.reading()
+	return append([]*Span(nil), t.timings...)
+}
+
+// startRun starts the top-level span of a run of a handler of the task.
+func (t *Task) startRun(label string) {
+	t.run = newSpan(&sync.Mutex{}, label, "")
+}
+
+// stopRun stops the span of the run of the handler of the task and
+// records it with the task timings.
+func (t *Task) stopRun() {
+	t.state.writing()
+	run := t.run
+	if run == nil {
+		return
+	}
+	t.run = nil
+	stopSpans(run)
+	t.timings = append(t.timings, run)
+	if len(t.timings) > maxTaskTimings {
+		t.timings = t.timings[len(t.timings)-maxTaskTimings:]
+	}
+}
+
+// stopSpans stops s and any nested span a handler forgot about.
+func stopSpans(s *Span) {
+	for _, nested := range s.Spans {
+		stopSpans(nested)
+	}
+	s.Stop()
+}
@@ -0,0 +1,181 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/tomb.v2"
+
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type timingsSuite struct{}
+
+var _ = Suite(&timingsSuite{})
+
+func (ts *timingsSuite) TestTaskRunnerRecordsTimings(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	retried := false
+	r.AddHandler("foo", func(t *state.Task, tb *tomb.Tomb) error {
+		if !retried {
+			retried = true
+			return &state.Retry{}
+		}
+		span := t.StartSpan("download", "Download foo")
+		nested := span.StartSpan("verify", "Verify foo")
+		time.Sleep(time.Millisecond)
+		nested.Stop()
+		span.Stop()
+		// stopped by the runner
+		t.StartSpan("forgotten", "")
+		return errors.New("boom")
+	}, func(t *state.Task, tb *tomb.Tomb) error {
+		return nil
+	})
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t := st.NewTask("foo", "...")
+	chg.AddTask(t)
+	st.Unlock()
+
+	for i := 0; i < 3; i++ {
+		r.Ensure()
+		r.Wait()
+	}
+
+	st.Lock()
+	defer st.Unlock()
+	c.Assert(t.Status(), Equals, state.ErrorStatus)
+
+	timings := t.Timings()
+	c.Assert(timings, HasLen, 2)
+	c.Check(timings[0].Label, Equals, "do")
+	c.Check(timings[0].Spans, HasLen, 0)
+	c.Check(timings[1].Label, Equals, "do")
+	c.Assert(timings[1].Spans, HasLen, 2)
+
+	download := timings[1].Spans[0]
+	c.Check(download.Label, Equals, "download")
+	c.Check(download.Summary, Equals, "Download foo")
+	c.Assert(download.Spans, HasLen, 1)
+	c.Check(download.Spans[0].Label, Equals, "verify")
+	c.Check(download.Spans[0].Duration >= time.Millisecond, Equals, true)
+	c.Check(download.Duration >= download.Spans[0].Duration, Equals, true)
+	c.Check(timings[1].Duration >= download.Duration, Equals, true)
+	c.Check(timings[1].Spans[1].Label, Equals, "forgotten")
+	c.Check(timings[1].Spans[1].Duration > 0, Equals, true)
+
+	// timings are persisted with the task
+	data, err := json.Marshal(st)
+	c.Assert(err, IsNil)
+	st2, err := state.ReadState(nil, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	st2.Lock()
+	defer st2.Unlock()
+	timings2 := st2.Task(t.ID()).Timings()
+	c.Assert(timings2, HasLen, 2)
+	c.Check(timings2[1].Spans[0].Spans[0].Label, Equals, "verify")
+	c.Check(timings2[1].Spans[0].Spans[0].Duration, Equals, download.Spans[0].Duration)
+}
+
+func (ts *timingsSuite) TestTaskRunnerRecordsUndoTimings(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	r.AddHandler("foo", func(t *state.Task, tb *tomb.Tomb) error {
+		return nil
+	}, func(t *state.Task, tb *tomb.Tomb) error {
+		t.StartSpan("cleanup", "").Stop()
+		return nil
+	})
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t := st.NewTask("foo", "...")
+	t.SetStatus(state.UndoStatus)
+	chg.AddTask(t)
+	st.Unlock()
+
+	r.Ensure()
+	r.Wait()
+
+	st.Lock()
+	defer st.Unlock()
+	timings := t.Timings()
+	c.Assert(timings, HasLen, 1)
+	c.Check(timings[0].Label, Equals, "undo")
+	c.Assert(timings[0].Spans, HasLen, 1)
+	c.Check(timings[0].Spans[0].Label, Equals, "cleanup")
+}
+
+func (ts *timingsSuite) TestTimingsKeepRecentRuns(c *C) {
+	sb := &stateBackend{}
+	st := state.New(sb)
+	r := state.NewTaskRunner(st)
+	defer r.Stop()
+
+	runs := 0
+	r.AddHandler("foo", func(t *state.Task, tb *tomb.Tomb) error {
+		runs++
+		t.StartSpan("run", "").Stop()
+		return &state.Retry{}
+	}, nil)
+
+	st.Lock()
+	chg := st.NewChange("install", "...")
+	t := st.NewTask("foo", "...")
+	chg.AddTask(t)
+	st.Unlock()
+
+	for i := 0; i < 25; i++ {
+		r.Ensure()
+		r.Wait()
+	}
+
+	st.Lock()
+	defer st.Unlock()
+	c.Check(runs, Equals, 25)
+	c.Check(t.Timings(), HasLen, 20)
+}
+
+func (ts *timingsSuite) TestStartSpanOutsideTaskRunner(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	t := st.NewTask("foo", "...")
+	span := t.StartSpan("download", "")
+	span.StartSpan("nested", "").Stop()
+	span.Stop()
+
+	c.Check(t.Timings(), HasLen, 0)
+}
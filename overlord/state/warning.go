@@ -0,0 +1,273 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/snapcore/snapd/logger"
+)
+
+var (
+	// DefaultExpireAfter is how long a warning is kept around after
+	// it was last added.
+	DefaultExpireAfter = 28 * 24 * time.Hour
+	// DefaultRepeatAfter is how long after being shown a warning that
+	// keeps being added is shown again.
+	DefaultRepeatAfter = 24 * time.Hour
+)
+
+// Warning is a problem worth bringing to the attention of the user,
+// that would otherwise only end up in the logs.
+//
+// Warnings are identified by their message: adding a warning with
+// the same message as an existing one just updates its last-added
+// time.
+type Warning struct {
+	message     string
+	firstAdded  time.Time
+	lastAdded   time.Time
+	lastShown   time.Time
+	expireAfter time.Duration
+	repeatAfter time.Duration
+}
+
+type marshalledWarning struct {
+	Message     string     `json:"message"`
+	FirstAdded  time.Time  `json:"first-added"`
+	LastAdded   time.Time  `json:"last-added"`
+	LastShown   *time.Time `json:"last-shown,omitempty"`
+	ExpireAfter string     `json:"expire-after,omitempty"`
+	RepeatAfter string     `json:"repeat-after,omitempty"`
+}
+
+// MarshalJSON makes Warning a json.Marshaller
+func (w *Warning) MarshalJSON() ([]byte, error) {
+	m := marshalledWarning{
+		Message:     w.message,
+		FirstAdded:  w.firstAdded,
+		LastAdded:   w.lastAdded,
+		ExpireAfter: w.expireAfter.String(),
+		RepeatAfter: w.repeatAfter.String(),
+	}
+	if !w.lastShown.IsZero() {
+		m.LastShown = &w.lastShown
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON makes Warning a json.Unmarshaller
+func (w *Warning) UnmarshalJSON(data []byte) error {
+	var m marshalledWarning
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	w.message = m.Message
+	w.firstAdded = m.FirstAdded
+	w.lastAdded = m.LastAdded
+	if m.LastShown != nil {
+		w.lastShown = *m.LastShown
+	}
+	w.expireAfter = DefaultExpireAfter
+	if m.ExpireAfter != "" {
+		d, err := time.ParseDuration(m.ExpireAfter)
+		if err != nil {
+			return fmt.Errorf("cannot parse expire-after of warning %q: %v", m.Message, err)
+		}
+		w.expireAfter = d
+	}
+	w.repeatAfter = DefaultRepeatAfter
+	if m.RepeatAfter != "" {
+		d, err := time.ParseDuration(m.RepeatAfter)
+		if err != nil {
+			return fmt.Errorf("cannot parse repeat-after of warning %q: %v", m.Message, err)
+		}
+		w.repeatAfter = d
+	}
+	return nil
+}
+
+// String returns the warning message.
+func (w *Warning) String() string {
+	return w.message
+}
+
+// FirstAdded returns the time the warning was first added.
+func (w *Warning) FirstAdded() time.Time {
+	return w.firstAdded
+}
+
+// LastAdded returns the time the warning was last added.
+func (w *Warning) LastAdded() time.Time {
+	return w.lastAdded
+}
+
+// LastShown returns the time the warning was last acknowledged as
+// shown, or the zero time if it never was.
+func (w *Warning) LastShown() time.Time {
+	return w.lastShown
+}
+
+// IsExpired returns whether the warning was last added long enough
+// before the given time to be forgotten.
+func (w *Warning) IsExpired(now time.Time) bool {
+	return w.lastAdded.Add(w.expireAfter).Before(now)
+}
+
+// IsPending returns whether the warning should be shown to the user at
+// the given time: it was never shown, or it was added again after being
+// shown and the repeat interval passed.
+func (w *Warning) IsPending(now time.Time) bool {
+	if w.IsExpired(now) {
+		return false
+	}
+	if w.lastShown.IsZero() {
+		return true
+	}
+	if !w.lastAdded.After(w.lastShown) {
+		return false
+	}
+	return !now.Before(w.lastShown.Add(w.repeatAfter))
+}
+
+type byLastAdded []*Warning
+
+func (a byLastAdded) Len() int      { return len(a) }
+func (a byLastAdded) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byLastAdded) Less(i, j int) bool {
+	// warnings added at the same time are ordered by when they were
+	// first added, and then by message, so that the order does not
+	// depend on the iteration order of the warnings map
+	if !a[i].lastAdded.Equal(a[j].lastAdded) {
+		return a[i].lastAdded.Before(a[j].lastAdded)
+	}
+	if !a[i].firstAdded.Equal(a[j].firstAdded) {
+		return a[i].firstAdded.Before(a[j].firstAdded)
+	}
+	return a[i].message < a[j].message
+}
+
+// Warnf records a warning with the message built from format and args.
+// If a warning with the same message already exists only its last-added
+// time is updated.
+func (s *State) Warnf(format string, args ...interface{}) {
+	var msg string
+	if len(args) > 0 {
+		msg = fmt.Sprintf(format, args...)
+	} else {
+		msg = format
+	}
+	if msg == "" {
+		logger.Panicf("internal error: cannot add a warning with no message")
+	}
+	s.writing()
+	now := timeNow().UTC()
+	w := s.warnings[msg]
+	if w == nil || w.IsExpired(now) {
+		w = &Warning{
+			message:     msg,
+			firstAdded:  now,
+			expireAfter: DefaultExpireAfter,
+			repeatAfter: DefaultRepeatAfter,
+		}
+		s.warnings[msg] = w
+	}
+	w.lastAdded = now
+}
+
+// AllWarnings returns all the warnings that have not expired, sorted
+// by the time they were last added.
+func (s *State) AllWarnings() []*Warning {
+	s.reading()
+	now := timeNow()
+	all := make([]*Warning, 0, len(s.warnings))
+	for _, w := range s.warnings {
+		if w.IsExpired(now) {
+			continue
+		}
+		all = append(all, w)
+	}
+	sort.Sort(byLastAdded(all))
+	return all
+}
+
+// PendingWarnings returns the warnings that should be shown to the
+// user, sorted by the time they were last added, together with the
+// time of the check, to be passed to OkayWarnings once they were shown.
+func (s *State) PendingWarnings() ([]*Warning, time.Time) {
+	s.reading()
+	now := timeNow().UTC()
+	var pending []*Warning
+	for _, w := range s.warnings {
+		if w.IsPending(now) {
+			pending = append(pending, w)
+		}
+	}
+	sort.Sort(byLastAdded(pending))
+	return pending, now
+}
+
+// WarningsSummary returns the number of pending warnings and the
+// time the most recent of them was last added.
+func (s *State) WarningsSummary() (int, time.Time) {
+	s.reading()
+	now := timeNow()
+	var last time.Time
+	n := 0
+	for _, w := range s.warnings {
+		if !w.IsPending(now) {
+			continue
+		}
+		n++
+		if w.lastAdded.After(last) {
+			last = w.lastAdded
+		}
+	}
+	return n, last
+}
+
+// OkayWarnings marks as shown at the given time the warnings that were
+// added no later than that, and returns how many were marked.
+func (s *State) OkayWarnings(t time.Time) int {
+	t = t.UTC()
+	s.writing()
+	n := 0
+	for _, w := range s.warnings {
+		if w.lastAdded.After(t) || !w.lastShown.Before(t) {
+			continue
+		}
+		w.lastShown = t
+		n++
+	}
+	return n
+}
+
+// pruneWarnings removes the warnings that expired.
+func (s *State) pruneWarnings(now time.Time) {
+	for msg, w := range s.warnings {
+		if w.IsExpired(now) {
+			s.writing()
+			delete(s.warnings, msg)
+		}
+	}
+}
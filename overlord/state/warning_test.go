@@ -0,0 +1,174 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package state_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/overlord/state"
+)
+
+type warningSuite struct{}
+
+var _ = Suite(&warningSuite{})
+
+func (warningSuite) TestWarnfDeduplicates(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	t0 := time.Date(2017, 11, 1, 10, 0, 0, 0, time.UTC)
+	restore := state.MockTime(t0)
+	st.Warnf("hello %s", "world")
+	restore()
+
+	t1 := t0.Add(time.Hour)
+	restore = state.MockTime(t1)
+	defer restore()
+	st.Warnf("hello world")
+	st.Warnf("something else")
+
+	all := st.AllWarnings()
+	c.Assert(all, HasLen, 2)
+	c.Check(all[0].String(), Equals, "hello world")
+	c.Check(all[0].FirstAdded(), DeepEquals, t0)
+	c.Check(all[0].LastAdded(), DeepEquals, t1)
+	c.Check(all[0].LastShown().IsZero(), Equals, true)
+	c.Check(all[1].String(), Equals, "something else")
+}
+
+func (warningSuite) TestWarningsOrderIsStable(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	t0 := time.Date(2017, 11, 1, 10, 0, 0, 0, time.UTC)
+	restore := state.MockTime(t0)
+	st.Warnf("old")
+	restore()
+	restore = state.MockTime(t0.Add(time.Hour))
+	defer restore()
+	for _, msg := range []string{"d", "b", "old", "c", "a"} {
+		st.Warnf(msg)
+	}
+
+	// warnings added at the same time come in the same order every time
+	for i := 0; i < 20; i++ {
+		all := st.AllWarnings()
+		messages := make([]string, len(all))
+		for i, w := range all {
+			messages[i] = w.String()
+		}
+		c.Assert(messages, DeepEquals, []string{"old", "a", "b", "c", "d"})
+	}
+}
+
+func (warningSuite) TestPendingAndOkay(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	t0 := time.Date(2017, 11, 1, 10, 0, 0, 0, time.UTC)
+	restore := state.MockTime(t0)
+	st.Warnf("one")
+	st.Warnf("two")
+
+	pending, t := st.PendingWarnings()
+	c.Check(pending, HasLen, 2)
+	c.Check(t, DeepEquals, t0)
+	n, last := st.WarningsSummary()
+	c.Check(n, Equals, 2)
+	c.Check(last, DeepEquals, t0)
+	restore()
+
+	// a warning added after the listing is not acknowledged
+	restore = state.MockTime(t0.Add(time.Minute))
+	st.Warnf("three")
+	c.Check(st.OkayWarnings(t), Equals, 2)
+	pending, _ = st.PendingWarnings()
+	c.Assert(pending, HasLen, 1)
+	c.Check(pending[0].String(), Equals, "three")
+	restore()
+
+	// adding a shown warning again only shows it after the repeat interval
+	restore = state.MockTime(t0.Add(time.Hour))
+	st.Warnf("one")
+	n, _ = st.WarningsSummary()
+	c.Check(n, Equals, 1)
+	restore()
+
+	restore = state.MockTime(t0.Add(state.DefaultRepeatAfter))
+	defer restore()
+	n, last = st.WarningsSummary()
+	c.Check(n, Equals, 2)
+	c.Check(last, DeepEquals, t0.Add(time.Hour))
+}
+
+func (warningSuite) TestExpiredWarningsArePruned(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	defer st.Unlock()
+
+	restore := state.MockTime(time.Now().Add(-state.DefaultExpireAfter - time.Hour))
+	st.Warnf("old")
+	restore()
+	st.Warnf("new")
+
+	all := st.AllWarnings()
+	c.Assert(all, HasLen, 1)
+	c.Check(all[0].String(), Equals, "new")
+
+	st.Prune(time.Hour, time.Hour, 100)
+
+	data, err := json.Marshal(st)
+	c.Assert(err, IsNil)
+	c.Check(bytes.Contains(data, []byte(`"old"`)), Equals, false)
+	c.Check(bytes.Contains(data, []byte(`"new"`)), Equals, true)
+}
+
+func (warningSuite) TestWarningsRoundTrip(c *C) {
+	st := state.New(nil)
+	st.Lock()
+	t0 := time.Date(2017, 11, 1, 10, 0, 0, 0, time.UTC)
+	restore := state.MockTime(t0)
+	defer restore()
+	st.Warnf("hello")
+	st.OkayWarnings(t0)
+	data, err := json.Marshal(st)
+	st.Unlock()
+	c.Assert(err, IsNil)
+
+	st2, err := state.ReadState(nil, bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	st2.Lock()
+	defer st2.Unlock()
+
+	all := st2.AllWarnings()
+	c.Assert(all, HasLen, 1)
+	c.Check(all[0].String(), Equals, "hello")
+	c.Check(all[0].FirstAdded(), DeepEquals, t0)
+	c.Check(all[0].LastShown(), DeepEquals, t0)
+	pending, _ := st2.PendingWarnings()
+	c.Check(pending, HasLen, 0)
+}
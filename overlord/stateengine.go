@@ -21,9 +21,12 @@ package overlord
 
 import (
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/metrics"
 
 	"github.com/snapcore/snapd/overlord/state"
 )
@@ -69,6 +72,18 @@ func (se *StateEngine) State() *state.State {
 	return se.state
 }
 
+var (
+	ensureCount    = metrics.NewCounter("snapd_ensure_total", "Number of ensure passes of each state manager.", "manager")
+	ensureErrors   = metrics.NewCounter("snapd_ensure_errors_total", "Number of ensure passes of each state manager that failed.", "manager")
+	ensureDuration = metrics.NewSummary("snapd_ensure_duration_seconds", "Time spent in the ensure passes of each state manager.", "manager")
+)
+
+// managerName returns the name of the manager for the metrics,
+// e.g. "snapstate.SnapManager".
+func managerName(m StateManager) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", m), "*")
+}
+
 type ensureError struct {
 	errs []error
 }
@@ -93,9 +108,14 @@ func (se *StateEngine) Ensure() error {
 	}
 	var errs []error
 	for _, m := range se.managers {
+		name := managerName(m)
+		start := time.Now()
 		err := m.Ensure()
+		ensureDuration.Observe(time.Since(start).Seconds(), name)
+		ensureCount.Inc(name)
 		if err != nil {
 			logger.Noticef("state ensure error: %v", err)
+			ensureErrors.Inc(name)
 			errs = append(errs, err)
 		}
 	}
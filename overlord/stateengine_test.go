@@ -20,10 +20,12 @@
 package overlord_test
 
 import (
+	"bytes"
 	"errors"
 
 	. "gopkg.in/check.v1"
 
+	"github.com/snapcore/snapd/metrics"
 	"github.com/snapcore/snapd/overlord"
 	"github.com/snapcore/snapd/overlord/state"
 )
@@ -101,6 +103,22 @@ func (ses *stateEngineSuite) TestEnsureError(c *C) {
 	c.Check(calls, DeepEquals, []string{"ensure:mgr1", "ensure:mgr2"})
 }
 
+func (ses *stateEngineSuite) TestEnsureMetrics(c *C) {
+	s := state.New(nil)
+	se := overlord.NewStateEngine(s)
+
+	calls := []string{}
+	se.AddManager(&fakeManager{name: "mgr1", calls: &calls, ensureError: errors.New("boom")})
+
+	c.Check(se.Ensure(), NotNil)
+
+	var buf bytes.Buffer
+	c.Assert(metrics.WriteText(&buf), IsNil)
+	c.Check(buf.String(), Matches, `(?s).*\nsnapd_ensure_total{manager="overlord_test.fakeManager"} [1-9][0-9]*\n.*`)
+	c.Check(buf.String(), Matches, `(?s).*\nsnapd_ensure_errors_total{manager="overlord_test.fakeManager"} [1-9][0-9]*\n.*`)
+	c.Check(buf.String(), Matches, `(?s).*\nsnapd_ensure_duration_seconds_count{manager="overlord_test.fakeManager"} [1-9][0-9]*\n.*`)
+}
+
 func (ses *stateEngineSuite) TestStop(c *C) {
 	s := state.New(nil)
 	se := overlord.NewStateEngine(s)
@@ -77,6 +77,8 @@ type StoreService interface {
 	SuggestedCurrency() string
 	Buy(options *store.BuyOptions, user *auth.UserState) (*store.BuyResult, error)
 	ReadyToBuy(*auth.UserState) error
+
+	ConnectivityCheck() (map[string]bool, error)
 }
 
 // SetupStore configures the system's initial store.
@@ -58,6 +58,10 @@ func (*fakeAuthContext) DeviceSessionRequestParams(nonce string) (*auth.DeviceSe
 	panic("fakeAuthContext DeviceSessionRequestParams is not implemented")
 }
 
+func (*fakeAuthContext) ProxyStoreParams(defaultURL *url.URL) (string, *url.URL, error) {
+	panic("fakeAuthContext ProxyStoreParams is not implemented")
+}
+
 type storeStateSuite struct{}
 
 var _ = Suite(&storeStateSuite{})
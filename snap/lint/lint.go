@@ -0,0 +1,313 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+// Package lint looks for common packaging problems in snaps, before
+// they are uploaded to the store or installed.
+package lint
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/snapcore/snapd/asserts"
+	"github.com/snapcore/snapd/interfaces/builtin"
+	"github.com/snapcore/snapd/interfaces/policy"
+	"github.com/snapcore/snapd/osutil"
+	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/pack"
+	"github.com/snapcore/snapd/spdx"
+)
+
+const (
+	// MaxIconSize is the largest icon file size, in bytes, that is
+	// not reported.
+	MaxIconSize = 256 * 1024
+	// MaxIconDimension is the largest width or height, in pixels, of
+	// a png icon that is not reported.
+	MaxIconDimension = 512
+)
+
+// Problem is a packaging problem found in a snap.
+type Problem struct {
+	// Check is the name of the check that found the problem.
+	Check   string
+	Message string
+}
+
+func (p *Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.Check, p.Message)
+}
+
+type linter struct {
+	dir      string
+	info     *snap.Info
+	problems []*Problem
+}
+
+func (l *linter) addf(check, format string, args ...interface{}) {
+	l.problems = append(l.problems, &Problem{Check: check, Message: fmt.Sprintf(format, args...)})
+}
+
+// Snap looks for problems in the snap at path, which is either a snap
+// file or a directory with the content of a snap. An error is returned
+// only if the snap cannot be examined at all, like when its
+// meta/snap.yaml cannot be read.
+func Snap(path string) ([]*Problem, error) {
+	dir := path
+	if !osutil.IsDirectory(path) {
+		snapf, err := snap.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		tmpdir, err := ioutil.TempDir("", "snap-lint-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmpdir)
+		dir = filepath.Join(tmpdir, "snap")
+		if err := snapf.Unpack("*", dir); err != nil {
+			return nil, err
+		}
+	}
+
+	yamlData, err := ioutil.ReadFile(filepath.Join(dir, "meta", "snap.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	info, err := snap.InfoFromSnapYaml(yamlData)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &linter{dir: dir, info: info}
+	l.checkSnapYaml()
+	l.checkLicense()
+	l.checkApps()
+	l.checkHooks()
+	l.checkInterfaces()
+	l.checkDesktopFiles()
+	l.checkIcon()
+	return l.problems, nil
+}
+
+// checkSnapYaml reports what snap.Validate finds, leaving the license
+// to checkLicense.
+func (l *linter) checkSnapYaml() {
+	info := *l.info
+	info.License = ""
+	if err := snap.Validate(&info); err != nil {
+		l.addf("snap.yaml", "%v", err)
+	}
+}
+
+func (l *linter) checkLicense() {
+	if l.info.License == "" {
+		return
+	}
+	if err := spdx.ValidateLicense(l.info.License); err != nil {
+		l.addf("license", "invalid SPDX license expression %q: %v", l.info.License, err)
+	}
+}
+
+func (l *linter) checkApps() {
+	for _, name := range sortedApps(l.info) {
+		app := l.info.Apps[name]
+		for _, command := range []string{app.Command, app.StopCommand, app.ReloadCommand, app.PostStopCommand} {
+			fields := strings.Fields(command)
+			if len(fields) == 0 {
+				continue
+			}
+			if err := pack.CheckExecutable(l.dir, fields[0]); err != nil {
+				l.addf("apps", "app %q: %v", name, err)
+			}
+		}
+	}
+}
+
+// checkHooks checks both the hooks declared in snap.yaml and the
+// implicit ones found in meta/hooks.
+func (l *linter) checkHooks() {
+	hooks := make(map[string]bool)
+	for name := range l.info.Hooks {
+		hooks[name] = true
+	}
+	if fis, err := ioutil.ReadDir(filepath.Join(l.dir, "meta", "hooks")); err == nil {
+		for _, fi := range fis {
+			if snap.IsHookSupported(fi.Name()) {
+				hooks[fi.Name()] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(hooks))
+	for name := range hooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := pack.CheckExecutable(l.dir, filepath.Join("meta", "hooks", name)); err != nil {
+			l.addf("hooks", "hook %q: %v", name, err)
+		}
+	}
+}
+
+func knownInterfaces() map[string]bool {
+	known := make(map[string]bool)
+	for _, iface := range builtin.Interfaces() {
+		known[iface.Name()] = true
+	}
+	return known
+}
+
+// checkInterfaces reports plugs and slots of unknown interfaces, and
+// the ones the base declaration does not allow to be installed, which
+// the store will hold for manual review.
+func (l *linter) checkInterfaces() {
+	known := knownInterfaces()
+	baseDecl := asserts.BuiltinBaseDeclaration()
+
+	plugNames := make([]string, 0, len(l.info.Plugs))
+	for name := range l.info.Plugs {
+		plugNames = append(plugNames, name)
+	}
+	sort.Strings(plugNames)
+	for _, name := range plugNames {
+		plug := l.info.Plugs[name]
+		if !known[plug.Interface] {
+			l.addf("interfaces", "plug %q uses unknown interface %q", name, plug.Interface)
+			continue
+		}
+		// check each plug on its own to report all of them
+		only := *l.info
+		only.Plugs = map[string]*snap.PlugInfo{name: plug}
+		only.Slots = nil
+		ic := policy.InstallCandidate{Snap: &only, BaseDeclaration: baseDecl}
+		if err := ic.Check(); err != nil {
+			l.addf("base-declaration", "plug %q will need manual review: %v", name, err)
+		}
+	}
+
+	slotNames := make([]string, 0, len(l.info.Slots))
+	for name := range l.info.Slots {
+		slotNames = append(slotNames, name)
+	}
+	sort.Strings(slotNames)
+	for _, name := range slotNames {
+		slot := l.info.Slots[name]
+		if !known[slot.Interface] {
+			l.addf("interfaces", "slot %q uses unknown interface %q", name, slot.Interface)
+			continue
+		}
+		only := *l.info
+		only.Plugs = nil
+		only.Slots = map[string]*snap.SlotInfo{name: slot}
+		ic := policy.InstallCandidate{Snap: &only, BaseDeclaration: baseDecl}
+		if err := ic.Check(); err != nil {
+			l.addf("base-declaration", "slot %q will need manual review: %v", name, err)
+		}
+	}
+}
+
+// checkDesktopFiles reports the Exec lines of desktop files that do not
+// run one of the apps of the snap, which are dropped when the desktop
+// files are installed (see wrappers/desktop.go).
+func (l *linter) checkDesktopFiles() {
+	desktopFiles, err := filepath.Glob(filepath.Join(l.dir, "meta", "gui", "*.desktop"))
+	if err != nil {
+		return
+	}
+	for _, desktopFile := range desktopFiles {
+		content, err := ioutil.ReadFile(desktopFile)
+		if err != nil {
+			l.addf("desktop", "cannot read %s: %v", filepath.Base(desktopFile), err)
+			continue
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		for i := 1; scanner.Scan(); i++ {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "Exec=") {
+				continue
+			}
+			cmd := strings.TrimPrefix(line, "Exec=")
+			if !l.isAppCommand(cmd) {
+				l.addf("desktop", "%s:%d: invalid exec command: %q", filepath.Base(desktopFile), i, cmd)
+			}
+		}
+	}
+}
+
+// isAppCommand returns whether cmd runs one of the apps of the snap,
+// optionally with arguments.
+func (l *linter) isAppCommand(cmd string) bool {
+	for _, app := range l.info.Apps {
+		validCmd := snap.JoinSnapApp(l.info.Name(), app.Name)
+		if cmd == validCmd || strings.HasPrefix(cmd, validCmd+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *linter) checkIcon() {
+	for _, name := range []string{"icon.png", "icon.svg"} {
+		relpath := filepath.Join("meta", "gui", name)
+		f, err := os.Open(filepath.Join(l.dir, relpath))
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+
+		st, err := f.Stat()
+		if err != nil {
+			l.addf("icon", "cannot stat %s: %v", relpath, err)
+			continue
+		}
+		if st.Size() > MaxIconSize {
+			l.addf("icon", "%s is %d bytes, more than %d", relpath, st.Size(), MaxIconSize)
+		}
+		if name != "icon.png" {
+			continue
+		}
+		config, _, err := image.DecodeConfig(f)
+		if err != nil {
+			l.addf("icon", "cannot decode %s: %v", relpath, err)
+			continue
+		}
+		if config.Width > MaxIconDimension || config.Height > MaxIconDimension {
+			l.addf("icon", "%s is %dx%d pixels, more than %dx%d", relpath, config.Width, config.Height, MaxIconDimension, MaxIconDimension)
+		}
+	}
+}
+
+func sortedApps(info *snap.Info) []string {
+	names := make([]string, 0, len(info.Apps))
+	for name := range info.Apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
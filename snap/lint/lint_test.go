@@ -0,0 +1,208 @@
+// -*- Mode: Go; indent-tabs-mode: t -*-
+
+/*
+ * Copyright (C) 2017 Canonical Ltd
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License version 3 as
+ * published by the Free Software Foundation.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ *
+ */
+
+package lint_test
+
+import (
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/snapcore/snapd/snap/lint"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type lintSuite struct{}
+
+var _ = Suite(&lintSuite{})
+
+func makeSnapDir(c *C, snapYaml string, files map[string]string) string {
+	snapDir := c.MkDir()
+	c.Assert(os.MkdirAll(filepath.Join(snapDir, "meta"), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(snapDir, "meta", "snap.yaml"), []byte(snapYaml), 0644), IsNil)
+	for name, content := range files {
+		path := filepath.Join(snapDir, name)
+		c.Assert(os.MkdirAll(filepath.Dir(path), 0755), IsNil)
+		c.Assert(ioutil.WriteFile(path, []byte(content), 0755), IsNil)
+	}
+	return snapDir
+}
+
+func messages(problems []*lint.Problem) []string {
+	msgs := make([]string, len(problems))
+	for i, p := range problems {
+		msgs[i] = p.String()
+	}
+	return msgs
+}
+
+func (s *lintSuite) TestClean(c *C) {
+	snapDir := makeSnapDir(c, `name: hello
+version: 1.0
+license: GPL-3.0 OR MIT
+apps:
+ hello:
+  command: bin/hello
+  plugs: [network]
+`, map[string]string{
+		"bin/hello":              "#!/bin/sh\n",
+		"meta/hooks/configure":   "#!/bin/sh\n",
+		"meta/gui/hello.desktop": "[Desktop Entry]\nName=Hello\nExec=hello %U\n",
+	})
+
+	problems, err := lint.Snap(snapDir)
+	c.Assert(err, IsNil)
+	c.Check(problems, HasLen, 0)
+}
+
+func (s *lintSuite) TestNoSnapYaml(c *C) {
+	_, err := lint.Snap(c.MkDir())
+	c.Assert(err, ErrorMatches, `open .*/meta/snap.yaml: no such file or directory`)
+}
+
+func (s *lintSuite) TestSnapYamlAndLicense(c *C) {
+	snapDir := makeSnapDir(c, `name: hello
+version: 1.0
+license: GPL-3.0 AND foo
+epoch: "*"
+`, nil)
+
+	problems, err := lint.Snap(snapDir)
+	c.Assert(err, IsNil)
+	c.Check(messages(problems), DeepEquals, []string{
+		`snap.yaml: invalid snap epoch: "*"`,
+		`license: invalid SPDX license expression "GPL-3.0 AND foo": unknown license: foo`,
+	})
+}
+
+func (s *lintSuite) TestExecutables(c *C) {
+	snapDir := makeSnapDir(c, `name: hello
+version: 1.0
+apps:
+ hello:
+  command: bin/hello --with-args
+  stop-command: bin/stop
+ world:
+  command: bin/world
+hooks:
+ install:
+`, map[string]string{
+		"bin/hello":            "#!/bin/sh\n",
+		"bin/world":            "#!/bin/sh\n",
+		"meta/hooks/configure": "#!/bin/sh\n",
+	})
+	c.Assert(os.Chmod(filepath.Join(snapDir, "bin", "world"), 0644), IsNil)
+	c.Assert(os.Chmod(filepath.Join(snapDir, "meta", "hooks", "configure"), 0644), IsNil)
+
+	problems, err := lint.Snap(snapDir)
+	c.Assert(err, IsNil)
+	c.Check(messages(problems), DeepEquals, []string{
+		`apps: app "hello": bin/stop does not exist`,
+		`apps: app "world": bin/world is not executable`,
+		`hooks: hook "configure": meta/hooks/configure is not executable`,
+		`hooks: hook "install": meta/hooks/install does not exist`,
+	})
+}
+
+func (s *lintSuite) TestInterfaces(c *C) {
+	snapDir := makeSnapDir(c, `name: hello
+version: 1.0
+plugs:
+ foo:
+  interface: no-such-interface
+ snapd-control:
+ network:
+slots:
+ bar:
+  interface: other-unknown
+ docker-support:
+`, nil)
+
+	problems, err := lint.Snap(snapDir)
+	c.Assert(err, IsNil)
+	c.Check(messages(problems), DeepEquals, []string{
+		`interfaces: plug "foo" uses unknown interface "no-such-interface"`,
+		`base-declaration: plug "snapd-control" will need manual review: installation not allowed by "snapd-control" plug rule of interface "snapd-control"`,
+		`interfaces: slot "bar" uses unknown interface "other-unknown"`,
+		`base-declaration: slot "docker-support" will need manual review: installation not allowed by "docker-support" slot rule of interface "docker-support"`,
+	})
+}
+
+func (s *lintSuite) TestDesktopFiles(c *C) {
+	snapDir := makeSnapDir(c, `name: hello
+version: 1.0
+apps:
+ hello:
+  command: bin/hello
+ world:
+  command: bin/hello
+`, map[string]string{
+		"bin/hello":              "#!/bin/sh\n",
+		"meta/gui/hello.desktop": "[Desktop Entry]\nName=Hello\nExec=hello.world\n",
+		"meta/gui/other.desktop": "[Desktop Entry]\nName=Other\nExec=/usr/bin/hello %U\nExec=hello.worldwide\n",
+	})
+
+	problems, err := lint.Snap(snapDir)
+	c.Assert(err, IsNil)
+	c.Check(messages(problems), DeepEquals, []string{
+		`desktop: other.desktop:3: invalid exec command: "/usr/bin/hello %U"`,
+		`desktop: other.desktop:4: invalid exec command: "hello.worldwide"`,
+	})
+}
+
+func writePNG(c *C, path string, width, height int) {
+	f, err := os.Create(path)
+	c.Assert(err, IsNil)
+	defer f.Close()
+	c.Assert(png.Encode(f, image.NewGray(image.Rect(0, 0, width, height))), IsNil)
+}
+
+func (s *lintSuite) TestIcons(c *C) {
+	snapDir := makeSnapDir(c, "name: hello\nversion: 1.0\n", nil)
+	c.Assert(os.MkdirAll(filepath.Join(snapDir, "meta", "gui"), 0755), IsNil)
+
+	writePNG(c, filepath.Join(snapDir, "meta", "gui", "icon.png"), 256, 256)
+	problems, err := lint.Snap(snapDir)
+	c.Assert(err, IsNil)
+	c.Check(problems, HasLen, 0)
+
+	writePNG(c, filepath.Join(snapDir, "meta", "gui", "icon.png"), 1024, 512)
+	svg := make([]byte, lint.MaxIconSize+1)
+	c.Assert(ioutil.WriteFile(filepath.Join(snapDir, "meta", "gui", "icon.svg"), svg, 0644), IsNil)
+	problems, err = lint.Snap(snapDir)
+	c.Assert(err, IsNil)
+	c.Check(messages(problems), DeepEquals, []string{
+		`icon: meta/gui/icon.png is 1024x512 pixels, more than 512x512`,
+		`icon: meta/gui/icon.svg is 262145 bytes, more than 262144`,
+	})
+
+	c.Assert(ioutil.WriteFile(filepath.Join(snapDir, "meta", "gui", "icon.png"), []byte("not a png"), 0644), IsNil)
+	c.Assert(os.Remove(filepath.Join(snapDir, "meta", "gui", "icon.svg")), IsNil)
+	problems, err = lint.Snap(snapDir)
+	c.Assert(err, IsNil)
+	c.Check(messages(problems), DeepEquals, []string{
+		`icon: cannot decode meta/gui/icon.png: image: unknown format`,
+	})
+}
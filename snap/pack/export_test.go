@@ -17,10 +17,11 @@
  *
  */
 
-package snaptest
+package pack
 
 var (
 	CopyToBuildDir       = copyToBuildDir
 	ShouldExcludeDynamic = shouldExcludeDynamic
 	DebArchitecture      = debArchitecture
+	Lchtimes             = lchtimes
 )
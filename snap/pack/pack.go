@@ -17,9 +17,8 @@
  *
  */
 
-package snaptest
-
-// TODO: replace this using some subset from snapcraft or simplify further!
+// Package pack builds snap files out of directory trees.
+package pack
 
 import (
 	"bufio"
@@ -30,9 +29,12 @@ import (
 	"regexp"
 	"strings"
 	"syscall"
+	"time"
+	"unsafe"
 
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/snapdir"
 	"github.com/snapcore/snapd/snap/squashfs"
 )
 
@@ -140,8 +142,8 @@ func debArchitecture(info *snap.Info) string {
 	}
 }
 
-func copyToBuildDir(sourceDir, buildDir string) error {
-	sourceDir, err := filepath.Abs(sourceDir)
+func copyToBuildDir(sourceDir, buildDir string) (err error) {
+	sourceDir, err = filepath.Abs(sourceDir)
 	if err != nil {
 		return err
 	}
@@ -156,6 +158,19 @@ func copyToBuildDir(sourceDir, buildDir string) error {
 	oldUmask := syscall.Umask(0)
 	defer syscall.Umask(oldUmask)
 
+	// modification times are carried over so that building the same
+	// tree twice gives the same snap; directories get theirs once
+	// everything was copied into them, deepest first
+	var dirs []string
+	var dirTimes []time.Time
+	defer func() {
+		for i := len(dirs) - 1; i >= 0; i-- {
+			if err == nil {
+				err = os.Chtimes(dirs[i], dirTimes[i], dirTimes[i])
+			}
+		}
+	}()
+
 	return filepath.Walk(sourceDir, func(path string, info os.FileInfo, errin error) (err error) {
 		if errin != nil {
 			return errin
@@ -176,6 +191,8 @@ func copyToBuildDir(sourceDir, buildDir string) error {
 			if err := os.Mkdir(dest, info.Mode()); err != nil {
 				return err
 			}
+			dirs = append(dirs, dest)
+			dirTimes = append(dirTimes, info.ModTime())
 			// ensure that permissions are preserved
 			uid := int(info.Sys().(*syscall.Stat_t).Uid)
 			gid := int(info.Sys().(*syscall.Stat_t).Gid)
@@ -192,7 +209,10 @@ func copyToBuildDir(sourceDir, buildDir string) error {
 			if err != nil {
 				return err
 			}
-			return os.Symlink(target, dest)
+			if err := os.Symlink(target, dest); err != nil {
+				return err
+			}
+			return lchtimes(dest, info.ModTime())
 		}
 
 		// fail if its unsupported
@@ -206,23 +226,86 @@ func copyToBuildDir(sourceDir, buildDir string) error {
 			return nil
 		}
 		// sigh. ok, copy it is.
-		return osutil.CopyFile(path, dest, osutil.CopyFlagDefault)
+		return osutil.CopyFile(path, dest, osutil.CopyFlagPreserveAll)
 	})
 }
 
-func prepare(sourceDir, targetDir, buildDir string) (snapName string, err error) {
-	// ensure we have valid content
-	yaml, err := ioutil.ReadFile(filepath.Join(sourceDir, "meta", "snap.yaml"))
+// lchtimes sets the modification time of path without following it if
+// it is a symlink, which os.Chtimes can't do.
+func lchtimes(path string, mtime time.Time) error {
+	p, err := syscall.BytePtrFromString(path)
 	if err != nil {
-		return "", err
+		return err
+	}
+	ts := []syscall.Timespec{syscall.NsecToTimespec(mtime.UnixNano()), syscall.NsecToTimespec(mtime.UnixNano())}
+	atFdCwd := -100
+	const atSymlinkNofollow = 0x100
+	_, _, errno := syscall.Syscall6(syscall.SYS_UTIMENSAT, uintptr(atFdCwd), uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&ts[0])), atSymlinkNofollow, 0, 0)
+	if errno != 0 {
+		return &os.PathError{Op: "lchtimes", Path: path, Err: errno}
 	}
+	return nil
+}
 
-	info, err := snap.InfoFromSnapYaml(yaml)
+// CheckSnap validates the snap in sourceDir, including that the
+// commands of its apps and its hooks are executable files, and returns
+// its information.
+func CheckSnap(sourceDir string) (*snap.Info, error) {
+	info, err := snap.ReadInfoFromSnapFile(snapdir.New(sourceDir), nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	if err := checkExecutables(info, sourceDir); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
 
-	err = snap.Validate(info)
+// checkExecutables verifies that the commands of the apps and the hooks
+// of the snap are executable files in sourceDir.
+func checkExecutables(info *snap.Info, sourceDir string) error {
+	for _, app := range info.Apps {
+		for _, command := range []string{app.Command, app.StopCommand, app.ReloadCommand, app.PostStopCommand} {
+			fields := strings.Fields(command)
+			if len(fields) == 0 {
+				continue
+			}
+			if err := CheckExecutable(sourceDir, fields[0]); err != nil {
+				return fmt.Errorf("snap is unusable due to bad app %q: %v", app.Name, err)
+			}
+		}
+	}
+	for _, hook := range info.Hooks {
+		if err := CheckExecutable(sourceDir, filepath.Join("meta", "hooks", hook.Name)); err != nil {
+			return fmt.Errorf("snap is unusable due to bad hook %q: %v", hook.Name, err)
+		}
+	}
+	return nil
+}
+
+// CheckExecutable verifies that relpath names an executable regular file
+// in sourceDir.
+func CheckExecutable(sourceDir, relpath string) error {
+	path := filepath.Join(sourceDir, relpath)
+	st, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s does not exist", relpath)
+		}
+		return err
+	}
+	if !st.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", relpath)
+	}
+	if st.Mode().Perm()&0111 == 0 {
+		return fmt.Errorf("%s is not executable", relpath)
+	}
+	return nil
+}
+
+func prepare(sourceDir, targetDir, buildDir string) (snapName string, err error) {
+	// ensure we have valid content
+	info, err := snap.ReadInfoFromSnapFile(snapdir.New(sourceDir), nil)
 	if err != nil {
 		return "", err
 	}
@@ -246,9 +329,10 @@ func prepare(sourceDir, targetDir, buildDir string) (snapName string, err error)
 	return snapName, nil
 }
 
-// BuildSquashfsSnap the given sourceDirectory and return the generated
-// snap file
-func BuildSquashfsSnap(sourceDir, targetDir string) (string, error) {
+// Snap builds the snap in sourceDir into targetDir (or the current
+// directory if empty) and returns the path of the generated snap
+// file, named after the name, version and architecture of the snap.
+func Snap(sourceDir, targetDir string) (string, error) {
 	// create build dir
 	buildDir, err := ioutil.TempDir("", "snappy-build-")
 	if err != nil {
@@ -17,7 +17,7 @@
  *
  */
 
-package snaptest_test
+package pack_test
 
 import (
 	"fmt"
@@ -28,15 +28,19 @@ import (
 	"regexp"
 	"strings"
 	"syscall"
+	"testing"
+	"time"
 
 	"github.com/snapcore/snapd/dirs"
 	"github.com/snapcore/snapd/snap"
-	"github.com/snapcore/snapd/snap/snaptest"
+	"github.com/snapcore/snapd/snap/pack"
 	"github.com/snapcore/snapd/testutil"
 
 	. "gopkg.in/check.v1"
 )
 
+func Test(t *testing.T) { TestingT(t) }
+
 type BuildTestSuite struct {
 	testutil.BaseTest
 }
@@ -103,7 +107,7 @@ printf "hello world"
 func (s *BuildTestSuite) TestBuildNoManifestFails(c *C) {
 	sourceDir := makeExampleSnapSourceDir(c, "")
 	c.Assert(os.Remove(filepath.Join(sourceDir, "meta", "snap.yaml")), IsNil)
-	_, err := snaptest.BuildSquashfsSnap(sourceDir, "")
+	_, err := pack.Snap(sourceDir, "")
 	c.Assert(err, NotNil) // XXX maybe make the error more explicit
 }
 
@@ -111,7 +115,7 @@ func (s *BuildTestSuite) TestCopyCopies(c *C) {
 	sourceDir := makeExampleSnapSourceDir(c, "name: hello")
 	// actually this'll be on /tmp so it'll be a link
 	target := c.MkDir()
-	c.Assert(snaptest.CopyToBuildDir(sourceDir, target), IsNil)
+	c.Assert(pack.CopyToBuildDir(sourceDir, target), IsNil)
 	out, err := exec.Command("diff", "-qrN", sourceDir, target).Output()
 	c.Check(err, IsNil)
 	c.Check(out, DeepEquals, []byte{})
@@ -129,7 +133,7 @@ func (s *BuildTestSuite) TestCopyActuallyCopies(c *C) {
 	}
 	c.Assert(err, IsNil)
 
-	c.Assert(snaptest.CopyToBuildDir(sourceDir, target), IsNil)
+	c.Assert(pack.CopyToBuildDir(sourceDir, target), IsNil)
 	out, err := exec.Command("diff", "-qrN", sourceDir, target).Output()
 	c.Check(err, IsNil)
 	c.Check(out, DeepEquals, []byte{})
@@ -140,7 +144,7 @@ func (s *BuildTestSuite) TestCopyExcludesBackups(c *C) {
 	target := c.MkDir()
 	// add a backup file
 	c.Assert(ioutil.WriteFile(filepath.Join(sourceDir, "foo~"), []byte("hi"), 0755), IsNil)
-	c.Assert(snaptest.CopyToBuildDir(sourceDir, target), IsNil)
+	c.Assert(pack.CopyToBuildDir(sourceDir, target), IsNil)
 	cmd := exec.Command("diff", "-qr", sourceDir, target)
 	cmd.Env = append(cmd.Env, "LANG=C")
 	out, err := cmd.Output()
@@ -155,7 +159,7 @@ func (s *BuildTestSuite) TestCopyExcludesTopLevelDEBIAN(c *C) {
 	c.Assert(os.MkdirAll(filepath.Join(sourceDir, "DEBIAN", "foo"), 0755), IsNil)
 	// and a non-toplevel DEBIAN
 	c.Assert(os.MkdirAll(filepath.Join(sourceDir, "bar", "DEBIAN", "baz"), 0755), IsNil)
-	c.Assert(snaptest.CopyToBuildDir(sourceDir, target), IsNil)
+	c.Assert(pack.CopyToBuildDir(sourceDir, target), IsNil)
 	cmd := exec.Command("diff", "-qr", sourceDir, target)
 	cmd.Env = append(cmd.Env, "LANG=C")
 	out, err := cmd.Output()
@@ -171,7 +175,7 @@ func (s *BuildTestSuite) TestCopyExcludesWholeDirs(c *C) {
 	// add a file inside a skipped dir
 	c.Assert(os.Mkdir(filepath.Join(sourceDir, ".bzr"), 0755), IsNil)
 	c.Assert(ioutil.WriteFile(filepath.Join(sourceDir, ".bzr", "foo"), []byte("hi"), 0755), IsNil)
-	c.Assert(snaptest.CopyToBuildDir(sourceDir, target), IsNil)
+	c.Assert(pack.CopyToBuildDir(sourceDir, target), IsNil)
 	out, _ := exec.Command("find", sourceDir).Output()
 	c.Check(string(out), Not(Equals), "")
 	cmd := exec.Command("diff", "-qr", sourceDir, target)
@@ -181,32 +185,49 @@ func (s *BuildTestSuite) TestCopyExcludesWholeDirs(c *C) {
 	c.Check(string(out), Matches, `(?m)Only in \S+: \.bzr`)
 }
 
+func (s *BuildTestSuite) TestCopyPreservesTimes(c *C) {
+	sourceDir := makeExampleSnapSourceDir(c, "name: hello")
+	then := time.Date(2017, 11, 1, 10, 0, 0, 0, time.UTC)
+	for _, p := range []string{"bin/hello-world", "bin", "meta", ""} {
+		c.Assert(os.Chtimes(filepath.Join(sourceDir, p), then, then), IsNil)
+	}
+	c.Assert(pack.Lchtimes(filepath.Join(sourceDir, "symlink"), then), IsNil)
+
+	target := filepath.Join(c.MkDir(), "build")
+	c.Assert(pack.CopyToBuildDir(sourceDir, target), IsNil)
+	for _, p := range []string{"bin/hello-world", "bin", "meta", "symlink", ""} {
+		st, err := os.Lstat(filepath.Join(target, p))
+		c.Assert(err, IsNil)
+		c.Check(st.ModTime().Equal(then), Equals, true, Commentf("%q: %v", p, st.ModTime()))
+	}
+}
+
 func (s *BuildTestSuite) TestExcludeDynamicFalseIfNoSnapignore(c *C) {
 	basedir := c.MkDir()
-	c.Check(snaptest.ShouldExcludeDynamic(basedir, "foo"), Equals, false)
+	c.Check(pack.ShouldExcludeDynamic(basedir, "foo"), Equals, false)
 }
 
 func (s *BuildTestSuite) TestExcludeDynamicWorksIfSnapignore(c *C) {
 	basedir := c.MkDir()
 	c.Assert(ioutil.WriteFile(filepath.Join(basedir, ".snapignore"), []byte("foo\nb.r\n"), 0644), IsNil)
-	c.Check(snaptest.ShouldExcludeDynamic(basedir, "foo"), Equals, true)
-	c.Check(snaptest.ShouldExcludeDynamic(basedir, "bar"), Equals, true)
-	c.Check(snaptest.ShouldExcludeDynamic(basedir, "bzr"), Equals, true)
-	c.Check(snaptest.ShouldExcludeDynamic(basedir, "baz"), Equals, false)
+	c.Check(pack.ShouldExcludeDynamic(basedir, "foo"), Equals, true)
+	c.Check(pack.ShouldExcludeDynamic(basedir, "bar"), Equals, true)
+	c.Check(pack.ShouldExcludeDynamic(basedir, "bzr"), Equals, true)
+	c.Check(pack.ShouldExcludeDynamic(basedir, "baz"), Equals, false)
 }
 
 func (s *BuildTestSuite) TestExcludeDynamicWeirdRegexps(c *C) {
 	basedir := c.MkDir()
 	c.Assert(ioutil.WriteFile(filepath.Join(basedir, ".snapignore"), []byte("*hello\n"), 0644), IsNil)
 	// note "*hello" is not a valid regexp, so will be taken literally (not globbed!)
-	c.Check(snaptest.ShouldExcludeDynamic(basedir, "ahello"), Equals, false)
-	c.Check(snaptest.ShouldExcludeDynamic(basedir, "*hello"), Equals, true)
+	c.Check(pack.ShouldExcludeDynamic(basedir, "ahello"), Equals, false)
+	c.Check(pack.ShouldExcludeDynamic(basedir, "*hello"), Equals, true)
 }
 
 func (s *BuildTestSuite) TestDebArchitecture(c *C) {
-	c.Check(snaptest.DebArchitecture(&snap.Info{Architectures: []string{"foo"}}), Equals, "foo")
-	c.Check(snaptest.DebArchitecture(&snap.Info{Architectures: []string{"foo", "bar"}}), Equals, "multi")
-	c.Check(snaptest.DebArchitecture(&snap.Info{Architectures: nil}), Equals, "unknown")
+	c.Check(pack.DebArchitecture(&snap.Info{Architectures: []string{"foo"}}), Equals, "foo")
+	c.Check(pack.DebArchitecture(&snap.Info{Architectures: []string{"foo", "bar"}}), Equals, "multi")
+	c.Check(pack.DebArchitecture(&snap.Info{Architectures: nil}), Equals, "unknown")
 }
 
 func (s *BuildTestSuite) TestBuildFailsForUnknownType(c *C) {
@@ -216,10 +237,85 @@ version: 1.0.1
 	err := syscall.Mkfifo(filepath.Join(sourceDir, "fifo"), 0644)
 	c.Assert(err, IsNil)
 
-	_, err = snaptest.BuildSquashfsSnap(sourceDir, "")
+	_, err = pack.Snap(sourceDir, "")
 	c.Assert(err, ErrorMatches, "cannot handle type of file .*")
 }
 
+func (s *BuildTestSuite) TestCheckSnap(c *C) {
+	sourceDir := makeExampleSnapSourceDir(c, `name: hello
+version: 1.0.1
+apps:
+ hello:
+  command: bin/hello-world --greet
+`)
+	info, err := pack.CheckSnap(sourceDir)
+	c.Assert(err, IsNil)
+	c.Check(info.Name(), Equals, "hello")
+}
+
+func (s *BuildTestSuite) TestCheckSnapInvalidYaml(c *C) {
+	sourceDir := makeExampleSnapSourceDir(c, `name: hello
+version: 1.0.1
+apps:
+ hello:
+  command: bin/hello-world
+  daemon: sometimes
+`)
+	_, err := pack.CheckSnap(sourceDir)
+	c.Assert(err, ErrorMatches, `"daemon" field contains invalid value "sometimes"`)
+}
+
+func (s *BuildTestSuite) TestCheckSnapBadApps(c *C) {
+	for _, t := range []struct {
+		command string
+		err     string
+	}{
+		{"bin/missing", `snap is unusable due to bad app "hello": bin/missing does not exist`},
+		{"bin", `snap is unusable due to bad app "hello": bin is not a regular file`},
+		{"file-with-perm", `snap is unusable due to bad app "hello": file-with-perm is not executable`},
+	} {
+		sourceDir := makeExampleSnapSourceDir(c, fmt.Sprintf(`name: hello
+version: 1.0.1
+apps:
+ hello:
+  command: %s
+`, t.command))
+		_, err := pack.CheckSnap(sourceDir)
+		c.Check(err, ErrorMatches, t.err)
+	}
+}
+
+func (s *BuildTestSuite) TestCheckSnapBadHook(c *C) {
+	sourceDir := makeExampleSnapSourceDir(c, `name: hello
+version: 1.0.1
+`)
+	hooksDir := filepath.Join(sourceDir, "meta", "hooks")
+	c.Assert(os.MkdirAll(hooksDir, 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(hooksDir, "configure"), nil, 0644), IsNil)
+
+	_, err := pack.CheckSnap(sourceDir)
+	c.Assert(err, ErrorMatches, `snap is unusable due to bad hook "configure": meta/hooks/configure is not executable`)
+}
+
+func (s *BuildTestSuite) TestBuildIsReproducible(c *C) {
+	sourceDir := makeExampleSnapSourceDir(c, `name: hello
+version: 1.0.1
+`)
+
+	first, err := pack.Snap(sourceDir, filepath.Join(c.MkDir(), "first"))
+	c.Assert(err, IsNil)
+	// let the clock move on
+	time.Sleep(1100 * time.Millisecond)
+	second, err := pack.Snap(sourceDir, filepath.Join(c.MkDir(), "second"))
+	c.Assert(err, IsNil)
+
+	firstData, err := ioutil.ReadFile(first)
+	c.Assert(err, IsNil)
+	secondData, err := ioutil.ReadFile(second)
+	c.Assert(err, IsNil)
+	c.Check(secondData, DeepEquals, firstData)
+}
+
 func (s *BuildTestSuite) TestBuildSquashfsSimple(c *C) {
 	sourceDir := makeExampleSnapSourceDir(c, `name: hello
 version: 1.0.1
@@ -229,7 +325,7 @@ integration:
   apparmor-profile: meta/hello.apparmor
 `)
 
-	resultSnap, err := snaptest.BuildSquashfsSnap(sourceDir, "")
+	resultSnap, err := pack.Snap(sourceDir, "")
 	c.Assert(err, IsNil)
 
 	// check that there is result
@@ -261,7 +357,7 @@ integration:
 
 	outputDir := filepath.Join(c.MkDir(), "output")
 	snapOutput := filepath.Join(outputDir, "hello_1.0.1_multi.snap")
-	resultSnap, err := snaptest.BuildSquashfsSnap(sourceDir, outputDir)
+	resultSnap, err := pack.Snap(sourceDir, outputDir)
 	c.Assert(err, IsNil)
 
 	// check that there is result
@@ -29,6 +29,7 @@ import (
 
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/snap"
+	"github.com/snapcore/snapd/snap/pack"
 )
 
 // MockSnap puts a snap.yaml file on disk so to mock an installed snap, based on the provided arguments.
@@ -142,3 +143,9 @@ func MakeTestSnapWithFiles(c *check.C, snapYamlContent string, files [][]string)
 	}
 	return filepath.Join(snapSource, snapFilePath)
 }
+
+// BuildSquashfsSnap builds the snap in the given source directory and
+// returns the generated snap file.
+func BuildSquashfsSnap(sourceDir, targetDir string) (string, error) {
+	return pack.Snap(sourceDir, targetDir)
+}
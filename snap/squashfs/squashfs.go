@@ -20,6 +20,7 @@
 package squashfs
 
 import (
+	"encoding/binary"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -27,6 +28,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"time"
 
 	"github.com/snapcore/snapd/osutil"
 )
@@ -142,19 +144,69 @@ func (s *Snap) ListDir(dirPath string) ([]string, error) {
 }
 
 // Build builds the snap.
+//
+// The result only depends on the content of buildDir, modification
+// times included, so building the same tree twice gives identical
+// files.
 func (s *Snap) Build(buildDir string) error {
 	fullSnapPath, err := filepath.Abs(s.path)
 	if err != nil {
 		return err
 	}
 
-	return osutil.ChDir(buildDir, func() error {
+	err = osutil.ChDir(buildDir, func() error {
 		return exec.Command(
 			"mksquashfs",
 			".", fullSnapPath,
 			"-noappend",
 			"-comp", "xz",
 			"-no-xattrs",
+			// fragments are compressed in parallel and can
+			// end up in any order
+			"-no-fragments",
 		).Run()
 	})
+	if err != nil {
+		return err
+	}
+
+	// mksquashfs records the time of the build in the superblock,
+	// replace it with the time of the most recent content
+	mtime, err := latestModTime(buildDir)
+	if err != nil {
+		return err
+	}
+	return setBuildTime(fullSnapPath, mtime)
+}
+
+// offset of the modification time in the squashfs superblock
+const superblockTimeOffset = 8
+
+func setBuildTime(snapPath string, t time.Time) error {
+	f, err := os.OpenFile(snapPath, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], uint32(t.Unix()))
+	if _, err := f.WriteAt(buf[:], superblockTimeOffset); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func latestModTime(dir string) (time.Time, error) {
+	var latest time.Time
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest, err
 }
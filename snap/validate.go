@@ -22,7 +22,9 @@ package snap
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/snapcore/snapd/spdx"
@@ -137,12 +139,7 @@ func Validate(info *Info) error {
 		return err
 	}
 
-	for _, layout := range info.Layout {
-		if err := ValidateLayout(layout); err != nil {
-			return err
-		}
-	}
-	return nil
+	return ValidateLayoutAll(info)
 }
 
 func plugsSlotsUniqueNames(info *Info) error {
@@ -222,13 +219,49 @@ func ValidatePathVariables(path string) error {
 	return nil
 }
 
+// layoutReservedDirs lists the directories that layouts cannot be
+// placed in, nor point into, as snapd or the system rely on them.
+var layoutReservedDirs = []string{
+	"/proc", "/sys", "/dev", "/run", "/boot", "/etc", "/var/lib/snapd",
+	"/var/snap", "/snap", "/tmp/.snap", "/lib/modules",
+}
+
+// validateLayoutPath checks that a path used by a layout is absolute
+// once snap variables are expanded, clean and not reserved.
+func validateLayoutPath(path string) error {
+	if err := ValidatePathVariables(path); err != nil {
+		return err
+	}
+	// the known variables all expand to absolute paths
+	if !filepath.IsAbs(path) && !strings.HasPrefix(path, "$") {
+		return fmt.Errorf("%q is not an absolute path", path)
+	}
+	for _, elem := range strings.Split(path, "/") {
+		if elem == ".." {
+			return fmt.Errorf("%q refers to a parent directory", path)
+		}
+	}
+	if filepath.Clean(path) != path {
+		return fmt.Errorf("%q is not a clean path", path)
+	}
+	if path == "/" {
+		return fmt.Errorf("%q is reserved", path)
+	}
+	for _, dir := range layoutReservedDirs {
+		if path == dir || strings.HasPrefix(path, dir+"/") {
+			return fmt.Errorf("%q is in the reserved directory %q", path, dir)
+		}
+	}
+	return nil
+}
+
 // ValidateLayout ensures that the given layout contains only valid subset of constructs.
 func ValidateLayout(li *Layout) error {
 	// The path is used to identify the layout below so validate it first.
 	if li.Path == "" {
 		return fmt.Errorf("cannot accept layout with empty path")
 	} else {
-		if err := ValidatePathVariables(li.Path); err != nil {
+		if err := validateLayoutPath(li.Path); err != nil {
 			return fmt.Errorf("cannot accept layout of %q: %s", li.Path, err)
 		}
 	}
@@ -242,7 +275,7 @@ func ValidateLayout(li *Layout) error {
 		return fmt.Errorf("cannot accept conflicting layout for %q", li.Path)
 	}
 	if li.Bind != "" {
-		if err := ValidatePathVariables(li.Bind); err != nil {
+		if err := validateLayoutPath(li.Bind); err != nil {
 			return fmt.Errorf("cannot accept layout of %q: %s", li.Path, err)
 		}
 	}
@@ -251,7 +284,7 @@ func ValidateLayout(li *Layout) error {
 		return fmt.Errorf("cannot accept filesystem %q for %q", li.Type, li.Path)
 	}
 	if li.Symlink != "" {
-		if err := ValidatePathVariables(li.Symlink); err != nil {
+		if err := validateLayoutPath(li.Symlink); err != nil {
 			return fmt.Errorf("cannot accept layout of %q: %s", li.Path, err)
 		}
 	}
@@ -269,3 +302,83 @@ func ValidateLayout(li *Layout) error {
 	}
 	return nil
 }
+
+// interfaceMountDirs lists, by interface, the directories that get
+// mounted over when plugs of the interface are connected (see
+// interfaces/builtin). The content and system-files interfaces mount
+// over the directories given by the attributes of their plugs instead.
+var interfaceMountDirs = map[string][]string{
+	"desktop": {"/usr/share/fonts", "/usr/local/share/fonts", "/var/cache/fontconfig"},
+}
+
+// plugMountDirs returns the clean directories that get mounted over
+// when the given plug is connected.
+func plugMountDirs(info *Info, plug *PlugInfo) []string {
+	switch plug.Interface {
+	case "content":
+		target, ok := plug.Attrs["target"].(string)
+		if !ok {
+			return nil
+		}
+		// the content interface assumes $SNAP for relative targets
+		if !strings.HasPrefix(target, "$") && !filepath.IsAbs(target) {
+			target = "$SNAP/" + target
+		}
+		return []string{filepath.Clean(info.ExpandSnapVariables(target))}
+	case "system-files":
+		var dirs []string
+		for _, which := range []string{"read", "write"} {
+			list, _ := plug.Attrs[which].([]interface{})
+			for _, item := range list {
+				if path, ok := item.(string); ok {
+					dirs = append(dirs, filepath.Clean(path))
+				}
+			}
+		}
+		return dirs
+	}
+	return interfaceMountDirs[plug.Interface]
+}
+
+// pathsOverlap returns whether one of the given clean paths is the same
+// as, or contains, the other.
+func pathsOverlap(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+// ValidateLayoutAll validates all the layout elements of the snap, and
+// that they do not overlap with directories that interfaces mount over
+// when the plugs of the snap are connected.
+func ValidateLayoutAll(info *Info) error {
+	paths := make([]string, 0, len(info.Layout))
+	for path := range info.Layout {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := ValidateLayout(info.Layout[path]); err != nil {
+			return err
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	plugNames := make([]string, 0, len(info.Plugs))
+	for name := range info.Plugs {
+		plugNames = append(plugNames, name)
+	}
+	sort.Strings(plugNames)
+
+	for _, path := range paths {
+		mountPoint := filepath.Clean(info.ExpandSnapVariables(path))
+		for _, name := range plugNames {
+			for _, dir := range plugMountDirs(info, info.Plugs[name]) {
+				if pathsOverlap(mountPoint, dir) {
+					return fmt.Errorf("cannot accept layout of %q: it overlaps with %q used by plug %q", path, dir, name)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -374,12 +374,44 @@ func (s *ValidateSuite) TestValidateLayout(c *C) {
 		ErrorMatches, `cannot accept layout of "/foo": reference to unknown variable "\$BAR"`)
 	c.Check(ValidateLayout(&Layout{Path: "/foo", Symlink: "$BAR"}),
 		ErrorMatches, `cannot accept layout of "/foo": reference to unknown variable "\$BAR"`)
+	for _, t := range []struct {
+		path, err string
+	}{
+		{"foo", `"foo" is not an absolute path`},
+		{"foo/$SNAP", `"foo/\$SNAP" is not an absolute path`},
+		{"/foo/../bar", `"/foo/../bar" refers to a parent directory`},
+		{"$SNAP/..", `"\$SNAP/.." refers to a parent directory`},
+		{"/foo/", `"/foo/" is not a clean path`},
+		{"/foo//bar", `"/foo//bar" is not a clean path`},
+		{"/foo/./bar", `"/foo/./bar" is not a clean path`},
+		{"/", `"/" is reserved`},
+		{"/proc", `"/proc" is in the reserved directory "/proc"`},
+		{"/sys/foo", `"/sys/foo" is in the reserved directory "/sys"`},
+		{"/dev/foo", `"/dev/foo" is in the reserved directory "/dev"`},
+		{"/run/foo", `"/run/foo" is in the reserved directory "/run"`},
+		{"/boot", `"/boot" is in the reserved directory "/boot"`},
+		{"/etc/foo.conf", `"/etc/foo.conf" is in the reserved directory "/etc"`},
+		{"/var/lib/snapd/foo", `"/var/lib/snapd/foo" is in the reserved directory "/var/lib/snapd"`},
+		{"/var/snap/foo", `"/var/snap/foo" is in the reserved directory "/var/snap"`},
+		{"/snap/foo/current", `"/snap/foo/current" is in the reserved directory "/snap"`},
+		{"/tmp/.snap/foo", `"/tmp/.snap/foo" is in the reserved directory "/tmp/.snap"`},
+		{"/lib/modules", `"/lib/modules" is in the reserved directory "/lib/modules"`},
+	} {
+		c.Check(ValidateLayout(&Layout{Path: t.path, Type: "tmpfs"}),
+			ErrorMatches, `cannot accept layout of .*: `+t.err, Commentf(t.path))
+		c.Check(ValidateLayout(&Layout{Path: "/foo", Bind: t.path}),
+			ErrorMatches, fmt.Sprintf(`cannot accept layout of "/foo": %s`, t.err), Commentf(t.path))
+		c.Check(ValidateLayout(&Layout{Path: "/foo", Symlink: t.path}),
+			ErrorMatches, fmt.Sprintf(`cannot accept layout of "/foo": %s`, t.err), Commentf(t.path))
+	}
 	// Several valid layouts.
 	c.Check(ValidateLayout(&Layout{Path: "/tmp", Type: "tmpfs"}), IsNil)
 	c.Check(ValidateLayout(&Layout{Path: "/usr", Bind: "$SNAP/usr"}), IsNil)
 	c.Check(ValidateLayout(&Layout{Path: "/var", Bind: "$SNAP_DATA/var"}), IsNil)
 	c.Check(ValidateLayout(&Layout{Path: "/var", Bind: "$SNAP_COMMON/var"}), IsNil)
-	c.Check(ValidateLayout(&Layout{Path: "/etc/foo.conf", Symlink: "$SNAP_DATA/etc/foo.conf"}), IsNil)
+	c.Check(ValidateLayout(&Layout{Path: "/usr/share/foo.conf", Symlink: "$SNAP_DATA/etc/foo.conf"}), IsNil)
+	c.Check(ValidateLayout(&Layout{Path: "/tmp/foo", Type: "tmpfs"}), IsNil)
+	c.Check(ValidateLayout(&Layout{Path: "/lib/foo", Bind: "$SNAP/lib/foo"}), IsNil)
 	c.Check(ValidateLayout(&Layout{Path: "/a/b", Type: "tmpfs", User: "nobody"}), IsNil)
 	c.Check(ValidateLayout(&Layout{Path: "/a/b", Type: "tmpfs", User: "root"}), IsNil)
 	c.Check(ValidateLayout(&Layout{Path: "/a/b", Type: "tmpfs", Group: "nobody"}), IsNil)
@@ -390,3 +422,59 @@ func (s *ValidateSuite) TestValidateLayout(c *C) {
 	c.Check(ValidateLayout(&Layout{Path: "/var", Symlink: "$SNAP_COMMON/var"}), IsNil)
 	c.Check(ValidateLayout(&Layout{Path: "$SNAP/data", Symlink: "$SNAP_DATA"}), IsNil)
 }
+
+func (s *ValidateSuite) TestValidateLayoutAll(c *C) {
+	info, err := InfoFromSnapYaml([]byte(`name: foo
+version: 1.0
+plugs:
+ desktop:
+ content:
+  target: $SNAP_DATA/content
+ relative:
+  interface: content
+  target: import
+ system-files:
+  read: [/opt/foo/data]
+  write: [/srv/foo/]
+layout:
+ /usr/share/foo:
+  bind: $SNAP/usr/share/foo
+ $SNAP_DATA/other:
+  type: tmpfs
+`))
+	c.Assert(err, IsNil)
+	c.Check(ValidateLayoutAll(info), IsNil)
+
+	// layouts are validated
+	info.Layout["/opt/bar"] = &Layout{Snap: info, Path: "/opt/bar", Type: "ext4"}
+	c.Check(ValidateLayoutAll(info), ErrorMatches, `cannot accept filesystem "ext4" for "/opt/bar"`)
+	delete(info.Layout, "/opt/bar")
+
+	// layouts cannot be used under, at or above directories that
+	// interfaces mount over
+	for _, t := range []struct {
+		path, dir, plug string
+	}{
+		{"/usr/share/fonts/foo", "/usr/share/fonts", "desktop"},
+		{"/var/cache/fontconfig", "/var/cache/fontconfig", "desktop"},
+		{"/opt/foo", "/opt/foo/data", "system-files"},
+		{"/srv/foo/bar", "/srv/foo", "system-files"},
+		{"$SNAP_DATA/content/foo", "/var/snap/foo/x1/content", "content"},
+		{"$SNAP_DATA", "/var/snap/foo/x1/content", "content"},
+		{"$SNAP/import/sub", "/snap/foo/x1/import", "relative"},
+	} {
+		info.Revision = R("x1")
+		info.Layout[t.path] = &Layout{Snap: info, Path: t.path, Type: "tmpfs"}
+		err := ValidateLayoutAll(info)
+		c.Assert(err, NotNil, Commentf("%s", t.path))
+		c.Check(err.Error(), Equals, fmt.Sprintf(`cannot accept layout of %q: it overlaps with %q used by plug %q`, t.path, t.dir, t.plug))
+		delete(info.Layout, t.path)
+	}
+
+	// the same snap without the plugs is fine
+	info.Plugs = nil
+	info.Layout["/usr/share/fonts/foo"] = &Layout{Snap: info, Path: "/usr/share/fonts/foo", Type: "tmpfs"}
+	info.Layout["/opt/foo"] = &Layout{Snap: info, Path: "/opt/foo", Type: "tmpfs"}
+	info.Layout["$SNAP_DATA/content/foo"] = &Layout{Snap: info, Path: "$SNAP_DATA/content/foo", Type: "tmpfs"}
+	c.Check(ValidateLayoutAll(info), IsNil)
+}
@@ -45,6 +45,7 @@ import (
 	"github.com/snapcore/snapd/httputil"
 	"github.com/snapcore/snapd/i18n"
 	"github.com/snapcore/snapd/logger"
+	"github.com/snapcore/snapd/metrics"
 	"github.com/snapcore/snapd/osutil"
 	"github.com/snapcore/snapd/overlord/auth"
 	"github.com/snapcore/snapd/progress"
@@ -200,21 +201,7 @@ func (cfg *Config) SetBaseURL(u *url.URL) error {
 
 // Store represents the ubuntu snap store
 type Store struct {
-	searchURI      *url.URL
-	detailsURI     *url.URL
-	bulkURI        *url.URL
-	assertionsURI  *url.URL
-	ordersURI      *url.URL
-	buyURI         *url.URL
-	customersMeURI *url.URL
-	sectionsURI    *url.URL
-	commandsURI    *url.URL
-
-	// Device auth endpoints.
-	// - deviceNonceURI points to endpoint to get a nonce
-	// - deviceSessionURI points to endpoint to get a device session
-	deviceNonceURI   *url.URL
-	deviceSessionURI *url.URL
+	cfg *Config
 
 	architecture string
 	series       string
@@ -430,6 +417,7 @@ func New(cfg *Config, authContext auth.AuthContext) *Store {
 	}
 
 	store := &Store{
+		cfg:             cfg,
 		series:          series,
 		architecture:    architecture,
 		noCDN:           osutil.GetenvBool("SNAPPY_STORE_NO_CDN"),
@@ -439,36 +427,72 @@ func New(cfg *Config, authContext auth.AuthContext) *Store {
 		deltaFormat:     deltaFormat,
 
 		client: httputil.NewHTTPClient(&httputil.ClientOpts{
-			Timeout:    10 * time.Second,
-			MayLogBody: true,
+			Timeout:          10 * time.Second,
+			MayLogBody:       true,
+			ExtraSSLCertsDir: dirs.SnapdStoreSSLCertsDir,
 		}),
 	}
 
-	// see https://wiki.ubuntu.com/AppStore/Interfaces/ClickPackageIndex
-	// XXX: These are all required in real system but optional makes it
-	// convenient for tests.
-	// XXX: Repeating "api/" here is cumbersome, but the next generation
-	// of store APIs will probably drop that prefix (since it now
-	// duplicates the hostname), and we may want to switch to v2 APIs
-	// one at a time; so it's better to consider that as part of
-	// individual endpoint paths.
-	if cfg.StoreBaseURL != nil {
-		store.searchURI = endpointURL(cfg.StoreBaseURL, "api/v1/snaps/search", nil)
-		store.detailsURI = endpointURL(cfg.StoreBaseURL, "api/v1/snaps/details", nil)
-		store.bulkURI = endpointURL(cfg.StoreBaseURL, "api/v1/snaps/metadata", nil)
-		store.ordersURI = endpointURL(cfg.StoreBaseURL, "api/v1/snaps/purchases/orders", nil)
-		store.buyURI = endpointURL(cfg.StoreBaseURL, "api/v1/snaps/purchases/buy", nil)
-		store.customersMeURI = endpointURL(cfg.StoreBaseURL, "api/v1/snaps/purchases/customers/me", nil)
-		store.sectionsURI = endpointURL(cfg.StoreBaseURL, "api/v1/snaps/sections", nil)
-		store.commandsURI = endpointURL(cfg.StoreBaseURL, "api/v1/snaps/names", nil)
-		store.deviceNonceURI = endpointURL(cfg.StoreBaseURL, "api/v1/snaps/auth/nonces", nil)
-		store.deviceSessionURI = endpointURL(cfg.StoreBaseURL, "api/v1/snaps/auth/sessions", nil)
-	}
-	if cfg.AssertionsBaseURL != nil {
-		store.assertionsURI = endpointURL(cfg.AssertionsBaseURL, "assertions", nil)
+	return store
+}
+
+// see https://wiki.ubuntu.com/AppStore/Interfaces/ClickPackageIndex
+// XXX: Repeating "api/" here is cumbersome, but the next generation
+// of store APIs will probably drop that prefix (since it now
+// duplicates the hostname), and we may want to switch to v2 APIs
+// one at a time; so it's better to consider that as part of
+// individual endpoint paths.
+const (
+	searchEndpPath      = "api/v1/snaps/search"
+	detailsEndpPath     = "api/v1/snaps/details"
+	bulkEndpPath        = "api/v1/snaps/metadata"
+	ordersEndpPath      = "api/v1/snaps/purchases/orders"
+	buyEndpPath         = "api/v1/snaps/purchases/buy"
+	customersMeEndpPath = "api/v1/snaps/purchases/customers/me"
+	sectionsEndpPath    = "api/v1/snaps/sections"
+	commandsEndpPath    = "api/v1/snaps/names"
+	// Device auth endpoints.
+	// - deviceNonceEndpPath is the endpoint to get a nonce
+	// - deviceSessionEndpPath is the endpoint to get a device session
+	deviceNonceEndpPath   = "api/v1/snaps/auth/nonces"
+	deviceSessionEndpPath = "api/v1/snaps/auth/sessions"
+
+	assertionsPath = "api/v1/snaps/assertions"
+)
+
+// baseURL returns the given default base URL, or the URL of the
+// proxy store if the device is configured to use one.
+func (s *Store) baseURL(defaultURL *url.URL) *url.URL {
+	u := defaultURL
+	if s.authContext != nil {
+		var err error
+		_, u, err = s.authContext.ProxyStoreParams(defaultURL)
+		if err != nil {
+			logger.Debugf("cannot get proxy store parameters from state: %v", err)
+		}
 	}
+	if u != nil {
+		return u
+	}
+	return defaultURL
+}
 
-	return store
+// endpointURL returns the URL of the given store API endpoint.
+func (s *Store) endpointURL(p string, query url.Values) *url.URL {
+	return endpointURL(s.baseURL(s.cfg.StoreBaseURL), p, query)
+}
+
+// assertionsEndpointURL returns the URL of the given assertions
+// endpoint. The assertions service can be overridden separately from
+// the store API, but a proxy store serves both.
+func (s *Store) assertionsEndpointURL(p string, query url.Values) *url.URL {
+	if proxyURL := s.baseURL(nil); proxyURL != nil {
+		return endpointURL(proxyURL, path.Join(assertionsPath, p), query)
+	}
+	if s.cfg.AssertionsBaseURL != nil {
+		return endpointURL(s.cfg.AssertionsBaseURL, path.Join("assertions", p), query)
+	}
+	return endpointURL(s.cfg.StoreBaseURL, path.Join(assertionsPath, p), query)
 }
 
 func (s *Store) defaultSnapQuery() url.Values {
@@ -605,7 +629,7 @@ func (s *Store) refreshDeviceSession(device *auth.DeviceState) error {
 		return fmt.Errorf("internal error: no authContext")
 	}
 
-	nonce, err := requestStoreDeviceNonce(s.deviceNonceURI.String())
+	nonce, err := requestStoreDeviceNonce(s.endpointURL(deviceNonceEndpPath, nil).String())
 	if err != nil {
 		return err
 	}
@@ -615,7 +639,7 @@ func (s *Store) refreshDeviceSession(device *auth.DeviceState) error {
 		return err
 	}
 
-	session, err := requestDeviceSession(s.deviceSessionURI.String(), devSessReqParams, device.SessionMacaroon)
+	session, err := requestDeviceSession(s.endpointURL(deviceSessionEndpPath, nil).String(), devSessReqParams, device.SessionMacaroon)
 	if err != nil {
 		return err
 	}
@@ -736,6 +760,21 @@ func (s *Store) retryRequestDecodeJSON(ctx context.Context, reqOptions *requestO
 	}, defaultRetryStrategy)
 }
 
+var (
+	requestDuration = metrics.NewSummary("snapd_store_request_duration_seconds", "Time taken by requests to the store, until the response headers are received.", "method")
+	requestCount    = metrics.NewCounter("snapd_store_requests_total", "Number of requests to the store, by response status code.", "method", "code")
+	requestErrors   = metrics.NewCounter("snapd_store_request_errors_total", "Number of requests to the store that failed or got a server error.", "method")
+	downloadBytes   = metrics.NewCounter("snapd_store_download_bytes_total", "Number of bytes downloaded from the store.")
+)
+
+// downloadCounter counts the bytes written to it as downloaded.
+type downloadCounter struct{}
+
+func (downloadCounter) Write(p []byte) (int, error) {
+	downloadBytes.Add(float64(len(p)))
+	return len(p), nil
+}
+
 // doRequest does an authenticated request to the store handling a potential macaroon refresh required if needed
 func (s *Store) doRequest(ctx context.Context, client *http.Client, reqOptions *requestOptions, user *auth.UserState) (*http.Response, error) {
 	req, err := s.newRequest(reqOptions, user)
@@ -744,14 +783,21 @@ func (s *Store) doRequest(ctx context.Context, client *http.Client, reqOptions *
 	}
 
 	var resp *http.Response
+	start := time.Now()
 	if ctx != nil {
 		resp, err = ctxhttp.Do(ctx, client, req)
 	} else {
 		resp, err = client.Do(req)
 	}
+	requestDuration.Observe(time.Since(start).Seconds(), reqOptions.Method)
 	if err != nil {
+		requestErrors.Inc(reqOptions.Method)
 		return nil, err
 	}
+	requestCount.Inc(reqOptions.Method, strconv.Itoa(resp.StatusCode))
+	if resp.StatusCode >= 500 {
+		requestErrors.Inc(reqOptions.Method)
+	}
 
 	wwwAuth := resp.Header.Get("WWW-Authenticate")
 	if resp.StatusCode == 401 {
@@ -917,7 +963,7 @@ func (s *Store) decorateOrders(snaps []*snap.Info, user *auth.UserState) error {
 
 	reqOptions := &requestOptions{
 		Method: "GET",
-		URL:    s.ordersURI,
+		URL:    s.endpointURL(ordersEndpPath, nil),
 		Accept: jsonContentType,
 	}
 	var result ordersResult
@@ -989,7 +1035,7 @@ func (s *Store) SnapInfo(snapSpec SnapSpec, user *auth.UserState) (*snap.Info, e
 	}
 	query.Set("channel", channel)
 
-	u := endpointURL(s.detailsURI, snapSpec.Name, query)
+	u := s.endpointURL(path.Join(detailsEndpPath, snapSpec.Name), query)
 	reqOptions := &requestOptions{
 		Method: "GET",
 		URL:    u,
@@ -1081,7 +1127,7 @@ func (s *Store) Find(search *Search, user *auth.UserState) ([]*snap.Info, error)
 		q.Set("confinement", "strict")
 	}
 
-	u := endpointURL(s.searchURI, "", q)
+	u := s.endpointURL(searchEndpPath, q)
 	reqOptions := &requestOptions{
 		Method: "GET",
 		URL:    u,
@@ -1121,7 +1167,7 @@ func (s *Store) Find(search *Search, user *auth.UserState) ([]*snap.Info, error)
 func (s *Store) Sections(user *auth.UserState) ([]string, error) {
 	reqOptions := &requestOptions{
 		Method: "GET",
-		URL:    s.sectionsURI,
+		URL:    s.endpointURL(sectionsEndpPath, nil),
 		Accept: halJsonContentType,
 	}
 
@@ -1147,22 +1193,80 @@ func (s *Store) Sections(user *auth.UserState) ([]string, error) {
 	return sectionNames, nil
 }
 
+// ConnectivityCheck checks whether the store API, the assertions
+// service and the download servers can be reached, going through the
+// proxy store if one is configured. It returns the reachability of
+// each host that was tried.
+func (s *Store) ConnectivityCheck() (status map[string]bool, err error) {
+	status = make(map[string]bool)
+
+	check := func(reqOptions *requestOptions) *http.Response {
+		resp, err := s.doRequest(context.TODO(), s.client, reqOptions, nil)
+		if err != nil {
+			logger.Debugf("cannot reach %s: %v", reqOptions.URL.Host, err)
+			status[reqOptions.URL.Host] = false
+			return nil
+		}
+		// any HTTP response means the host is reachable
+		status[reqOptions.URL.Host] = true
+		return resp
+	}
+
+	// "core" is the one snap that is sure to exist in any store
+	q := url.Values{}
+	q.Set("fields", "anon_download_url")
+	q.Set("channel", "stable")
+	resp := check(&requestOptions{
+		Method: "GET",
+		URL:    s.endpointURL(path.Join(detailsEndpPath, "core"), q),
+		Accept: halJsonContentType,
+	})
+	if resp != nil {
+		var remote snapDetails
+		if resp.StatusCode == 200 {
+			err = json.NewDecoder(resp.Body).Decode(&remote)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode details for snap \"core\": %v", err)
+		}
+		if remote.AnonDownloadURL != "" {
+			u, err := url.Parse(remote.AnonDownloadURL)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse download URL: %v", err)
+			}
+			if resp := check(&requestOptions{Method: "HEAD", URL: u}); resp != nil {
+				resp.Body.Close()
+			}
+		}
+	}
+
+	resp = check(&requestOptions{
+		Method: "HEAD",
+		URL:    s.assertionsEndpointURL("", nil),
+		Accept: asserts.MediaType,
+	})
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	return status, nil
+}
+
 // WriteCatalogs queries the "commands" endpoint and writes the
 // command names into the given io.Writer.
 func (s *Store) WriteCatalogs(names io.Writer) error {
-	u := *s.commandsURI
-
-	q := u.Query()
+	q := url.Values{}
 	if release.OnClassic {
 		q.Set("confinement", "strict,classic")
 	} else {
 		q.Set("confinement", "strict")
 	}
 
-	u.RawQuery = q.Encode()
+	u := s.endpointURL(commandsEndpPath, q)
 	reqOptions := &requestOptions{
 		Method: "GET",
-		URL:    &u,
+		URL:    u,
 		Accept: halJsonContentType,
 	}
 
@@ -1256,7 +1360,7 @@ func (s *Store) refreshForCandidates(currentSnaps []*currentSnapJSON, user *auth
 
 	reqOptions := &requestOptions{
 		Method:      "POST",
-		URL:         s.bulkURI,
+		URL:         s.endpointURL(bulkEndpPath, nil),
 		Accept:      halJsonContentType,
 		ContentType: jsonContentType,
 		Data:        jsonData,
@@ -1527,7 +1631,7 @@ var download = func(ctx context.Context, name, sha3_384, downloadURL string, use
 			pbar = &progress.NullProgress{}
 		}
 		pbar.Start(name, float64(resp.ContentLength))
-		mw := io.MultiWriter(w, h, pbar)
+		mw := io.MultiWriter(w, h, pbar, downloadCounter{})
 		_, finalErr = io.Copy(mw, resp.Body)
 		pbar.Finished()
 		if finalErr != nil {
@@ -1682,7 +1786,7 @@ type assertionSvcError struct {
 func (s *Store) Assertion(assertType *asserts.AssertionType, primaryKey []string, user *auth.UserState) (asserts.Assertion, error) {
 	v := url.Values{}
 	v.Set("max-format", strconv.Itoa(assertType.MaxSupportedFormat()))
-	u := endpointURL(s.assertionsURI, path.Join(assertType.Name, path.Join(primaryKey...)), v)
+	u := s.assertionsEndpointURL(path.Join(assertType.Name, path.Join(primaryKey...)), v)
 
 	reqOptions := &requestOptions{
 		Method: "GET",
@@ -1823,7 +1927,7 @@ func (s *Store) Buy(options *BuyOptions, user *auth.UserState) (*BuyResult, erro
 
 	reqOptions := &requestOptions{
 		Method:      "POST",
-		URL:         s.buyURI,
+		URL:         s.endpointURL(buyEndpPath, nil),
 		Accept:      jsonContentType,
 		ContentType: jsonContentType,
 		Data:        jsonData,
@@ -1887,7 +1991,7 @@ func (s *Store) ReadyToBuy(user *auth.UserState) error {
 
 	reqOptions := &requestOptions{
 		Method: "GET",
-		URL:    s.customersMeURI,
+		URL:    s.endpointURL(customersMeEndpPath, nil),
 		Accept: jsonContentType,
 	}
 
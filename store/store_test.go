@@ -217,6 +217,9 @@ type testAuthContext struct {
 	user   *auth.UserState
 
 	storeID string
+
+	proxyStoreID  string
+	proxyStoreURL *url.URL
 }
 
 func (ac *testAuthContext) Device() (*auth.DeviceState, error) {
@@ -272,6 +275,13 @@ func (ac *testAuthContext) DeviceSessionRequestParams(nonce string) (*auth.Devic
 	}, nil
 }
 
+func (ac *testAuthContext) ProxyStoreParams(defaultURL *url.URL) (string, *url.URL, error) {
+	if ac.proxyStoreID != "" {
+		return ac.proxyStoreID, ac.proxyStoreURL, nil
+	}
+	return "", defaultURL, nil
+}
+
 func makeTestMacaroon() (*macaroon.Macaroon, error) {
 	m, err := macaroon.New([]byte("secret"), "some-id", "location")
 	if err != nil {
@@ -1643,6 +1653,39 @@ func (t *remoteRepoTestSuite) TestDoRequestSetsExtraHeaders(c *C) {
 	c.Check(string(responseData), Equals, "response-data")
 }
 
+func (t *remoteRepoTestSuite) TestDoRequestMetrics(c *C) {
+	status := 200
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	c.Assert(mockServer, NotNil)
+	defer mockServer.Close()
+
+	repo := New(&Config{}, nil)
+	c.Assert(repo, NotNil)
+	endpoint, _ := url.Parse(mockServer.URL)
+	reqOptions := &requestOptions{Method: "GET", URL: endpoint}
+
+	okCount := requestCount.Value("GET", "200")
+	errCount := requestErrors.Value("GET")
+	observed := requestDuration.Count("GET")
+
+	response, err := repo.doRequest(context.TODO(), repo.client, reqOptions, nil)
+	c.Assert(err, IsNil)
+	response.Body.Close()
+	c.Check(requestCount.Value("GET", "200"), Equals, okCount+1)
+	c.Check(requestErrors.Value("GET"), Equals, errCount)
+	c.Check(requestDuration.Count("GET"), Equals, observed+1)
+
+	status = 500
+	failCount := requestCount.Value("GET", "500")
+	response, err = repo.doRequest(context.TODO(), repo.client, reqOptions, nil)
+	c.Assert(err, IsNil)
+	response.Body.Close()
+	c.Check(requestCount.Value("GET", "500"), Equals, failCount+1)
+	c.Check(requestErrors.Value("GET"), Equals, errCount+1)
+}
+
 func (t *remoteRepoTestSuite) TestLoginUser(c *C) {
 	macaroon, err := makeTestMacaroon()
 	c.Assert(err, IsNil)
@@ -2543,6 +2586,61 @@ func (t *remoteRepoTestSuite) TestUbuntuStoreSectionsQuery(c *C) {
 	c.Check(sections, DeepEquals, []string{"featured", "database"})
 }
 
+func (t *remoteRepoTestSuite) TestConnectivityCheck(c *C) {
+	var seen []string
+	var mockServerURL string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case detailsPath("core"):
+			c.Check(r.URL.Query().Get("fields"), Equals, "anon_download_url")
+			w.Header().Set("Content-Type", "application/hal+json")
+			fmt.Fprintf(w, `{"anon_download_url": "%s/download/core.snap"}`, mockServerURL)
+		case "/download/core.snap":
+			w.WriteHeader(200)
+		case "/api/v1/snaps/assertions":
+			w.WriteHeader(404)
+		default:
+			c.Fatalf("unexpected request to %q", r.URL.Path)
+		}
+	}))
+	c.Assert(mockServer, NotNil)
+	defer mockServer.Close()
+	mockServerURL = mockServer.URL
+
+	serverURL, _ := url.Parse(mockServer.URL)
+	cfg := Config{
+		StoreBaseURL: serverURL,
+	}
+	repo := New(&cfg, nil)
+	c.Assert(repo, NotNil)
+
+	status, err := repo.ConnectivityCheck()
+	c.Assert(err, IsNil)
+	c.Check(status, DeepEquals, map[string]bool{
+		serverURL.Host: true,
+	})
+	c.Check(seen, DeepEquals, []string{
+		"GET " + detailsPath("core"),
+		"HEAD /download/core.snap",
+		"HEAD /api/v1/snaps/assertions",
+	})
+}
+
+func (t *remoteRepoTestSuite) TestConnectivityCheckUnreachable(c *C) {
+	serverURL, _ := url.Parse("http://127.0.0.1:0/")
+	cfg := Config{
+		StoreBaseURL: serverURL,
+	}
+	repo := New(&cfg, nil)
+
+	status, err := repo.ConnectivityCheck()
+	c.Assert(err, IsNil)
+	c.Check(status, DeepEquals, map[string]bool{
+		"127.0.0.1:0": false,
+	})
+}
+
 const mockNamesJSON = `
 {
   "_embedded": {
@@ -3980,11 +4078,36 @@ func (t *remoteRepoTestSuite) TestNew(c *C) {
 	aStore := New(nil, nil)
 	// check for fields
 	c.Check(aStore.detailFields, DeepEquals, detailFields)
-	c.Check(aStore.searchURI.Query(), DeepEquals, url.Values{})
-	c.Check(aStore.detailsURI.Query(), DeepEquals, url.Values{})
-	c.Check(aStore.bulkURI.Query(), DeepEquals, url.Values{})
-	c.Check(aStore.sectionsURI.Query(), DeepEquals, url.Values{})
-	c.Check(aStore.assertionsURI.Query(), DeepEquals, url.Values{})
+	c.Check(aStore.endpointURL(searchEndpPath, nil).Query(), DeepEquals, url.Values{})
+	c.Check(aStore.endpointURL(detailsEndpPath, nil).Query(), DeepEquals, url.Values{})
+	c.Check(aStore.endpointURL(bulkEndpPath, nil).Query(), DeepEquals, url.Values{})
+	c.Check(aStore.endpointURL(sectionsEndpPath, nil).Query(), DeepEquals, url.Values{})
+	c.Check(aStore.assertionsEndpointURL("", nil).Query(), DeepEquals, url.Values{})
+}
+
+func (t *remoteRepoTestSuite) TestEndpointURLs(c *C) {
+	aStore := New(nil, nil)
+	c.Check(aStore.endpointURL(searchEndpPath, nil).String(), Equals, "https://api.snapcraft.io/api/v1/snaps/search")
+	c.Check(aStore.assertionsEndpointURL("snap-revision/sha3", nil).String(), Equals, "https://api.snapcraft.io/api/v1/snaps/assertions/snap-revision/sha3")
+}
+
+func (t *remoteRepoTestSuite) TestEndpointURLsProxyStore(c *C) {
+	proxyURL, err := url.Parse("http://proxy.internal/prefix/")
+	c.Assert(err, IsNil)
+	authContext := &testAuthContext{c: c, proxyStoreID: "foo", proxyStoreURL: proxyURL}
+
+	cfg := DefaultConfig()
+	sasURL, err := url.Parse("http://sas.example.com/v1/")
+	c.Assert(err, IsNil)
+	cfg.AssertionsBaseURL = sasURL
+	aStore := New(cfg, authContext)
+	c.Check(aStore.endpointURL(searchEndpPath, nil).String(), Equals, "http://proxy.internal/prefix/api/v1/snaps/search")
+	c.Check(aStore.assertionsEndpointURL("snap-revision/sha3", nil).String(), Equals, "http://proxy.internal/prefix/api/v1/snaps/assertions/snap-revision/sha3")
+
+	// without a proxy store the separately configured assertions URL is used
+	aStore = New(cfg, &testAuthContext{c: c})
+	c.Check(aStore.endpointURL(searchEndpPath, nil).String(), Equals, "https://api.snapcraft.io/api/v1/snaps/search")
+	c.Check(aStore.assertionsEndpointURL("snap-revision/sha3", nil).String(), Equals, "http://sas.example.com/v1/assertions/snap-revision/sha3")
 }
 
 var testAssertion = `type: snap-declaration
@@ -84,3 +84,7 @@ func (Store) Assertion(*asserts.AssertionType, []string, *auth.UserState) (asser
 func (Store) WriteCatalogs(io.Writer) error {
 	panic("fakeStore.WriteCatalogs not expected")
 }
+
+func (Store) ConnectivityCheck() (map[string]bool, error) {
+	panic("ConnectivityCheck not expected")
+}